@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	abConfigA string
+	abConfigB string
+	abRounds  int
+)
+
+var abCmd = &cobra.Command{
+	Use:   "ab",
+	Short: "Compare two configurations in alternating runs",
+	Long: `Run two load test configurations back-to-back in alternating rounds and
+report whether the differences between them are statistically significant.
+
+Each round runs both configurations once; the order flips every round so
+neither side always runs first on a warmer (or more tired) target. Configs
+use the --config format, and each run lasts that config's duration.
+
+Example:
+  g0 ab --config-a old-build.yaml --config-b new-build.yaml --alternate 5`,
+	RunE: runAB,
+}
+
+func init() {
+	rootCmd.AddCommand(abCmd)
+
+	abCmd.Flags().StringVar(&abConfigA, "config-a", "", "Config file for variant A (required)")
+	abCmd.Flags().StringVar(&abConfigB, "config-b", "", "Config file for variant B (required)")
+	abCmd.Flags().IntVar(&abRounds, "alternate", 1, "Number of rounds; each round runs A and B once")
+	abCmd.MarkFlagRequired("config-a")
+	abCmd.MarkFlagRequired("config-b")
+}
+
+// abVariant is one side of an A/B comparison
+type abVariant struct {
+	name      string
+	path      string
+	plan      *runPlan
+	summaries []*runner.Summary
+}
+
+func runAB(cmd *cobra.Command, args []string) error {
+	if abRounds < 1 {
+		return fmt.Errorf("--alternate must be at least 1")
+	}
+
+	// Validate both configs before sending any requests
+	a := &abVariant{name: "A", path: abConfigA}
+	b := &abVariant{name: "B", path: abConfigB}
+	for _, v := range []*abVariant{a, b} {
+		plan, err := prepareConfigPlan(v.path)
+		if err != nil {
+			return fmt.Errorf("config %s: %w", v.name, err)
+		}
+		v.plan = plan
+	}
+	cmd.SilenceUsage = true
+
+	out := printer.Default()
+	out.PrintLogo()
+	fmt.Printf("A/B Test: %d rounds, about %s in total\n", abRounds, time.Duration(abRounds)*(a.plan.duration+b.plan.duration))
+	fmt.Printf("  A: %s (%d URLs, %d workers, %s)\n", a.path, len(a.plan.urls), a.plan.workers, a.plan.duration)
+	fmt.Printf("  B: %s (%d URLs, %d workers, %s)\n", b.path, len(b.plan.urls), b.plan.workers, b.plan.duration)
+	fmt.Println()
+
+	// Ctrl+C or SIGTERM stops after the current run; completed rounds are still compared
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	completed := 0
+	for round := 1; round <= abRounds; round++ {
+		order := []*abVariant{a, b}
+		if round%2 == 0 {
+			order = []*abVariant{b, a}
+		}
+		for _, v := range order {
+			summary, err := runVariant(ctx, out, v.plan)
+			if err != nil {
+				return withExitCode(ExitAborted, fmt.Errorf("round %d, variant %s: %w", round, v.name, err))
+			}
+			if summary.Aborted {
+				break
+			}
+			v.summaries = append(v.summaries, summary)
+			out.PrintABRound(round, abRounds, v.name, summary)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		completed++
+	}
+
+	// Only compare complete rounds so both sides have the same number of runs
+	if completed == 0 {
+		return withExitCode(ExitAborted, fmt.Errorf("A/B test aborted before the first round completed"))
+	}
+	comparisons := runner.CompareRuns(a.summaries[:completed], b.summaries[:completed])
+	out.PrintComparison(a.path, b.path, completed, comparisons)
+
+	if completed < abRounds {
+		return withExitCode(ExitAborted, fmt.Errorf("A/B test aborted after %d of %d rounds", completed, abRounds))
+	}
+	return nil
+}
+
+// prepareConfigPlan validates a config file as g0 run --config would
+func prepareConfigPlan(path string) (*runPlan, error) {
+	// A fresh flag set resets every run flag to its default, so the
+	// other config doesn't leak values into this one
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	addRunFlags(flags)
+	configFile = path
+	profileName = ""
+	return prepareRun(flags)
+}
+
+// runVariant runs one load test, showing the progress line while it runs
+func runVariant(ctx context.Context, out *printer.Printer, plan *runPlan) (*runner.Summary, error) {
+	statsChan := make(chan *runner.Stats, 1)
+	resultChan := make(chan *runner.RunResult, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		result, err := runner.RunWithContext(ctx, plan.config, statsChan)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- result
+	}()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	start := time.Now()
+	var stats *runner.Stats
+	for {
+		select {
+		case s := <-statsChan:
+			stats = s
+		case <-ticker.C:
+			if stats != nil && time.Since(start) < plan.duration {
+				progress := stats.GetProgressStats()
+				out.PrintProgress(time.Since(start), plan.duration, &progress, 0)
+			}
+		case err := <-errChan:
+			out.ClearProgress()
+			return nil, err
+		case result := <-resultChan:
+			out.ClearProgress()
+			return result.Summary, nil
+		}
+	}
+}
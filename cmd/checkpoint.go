@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/calummacc/g0/internal/config"
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// checkpointVersion is the format of checkpoint files
+const checkpointVersion = 1
+
+// checkpointFlags are left out of the flags saved in a checkpoint: config files
+// were already applied, and a resumed run starts right away
+var checkpointFlags = []string{"resume", "config", "profile", "start-at", "start-after", "proc-child"}
+
+// checkpoint is the state of a run saved periodically with --checkpoint, so
+// --resume can continue the run after the generator crashed or was stopped
+type checkpoint struct {
+	Version    int                `json:"version"`
+	RunID      string             `json:"run_id"`
+	SavedAt    string             `json:"saved_at"`
+	DurationMs int64              `json:"duration_ms"` // Duration of the whole run
+	ElapsedMs  int64              `json:"elapsed_ms"`  // Test time covered by Result
+	Flags      config.File        `json:"flags"`       // Flags of the run, applied again on resume
+	Result     printer.JSONOutput `json:"result"`      // Results up to the checkpoint, all parts combined
+}
+
+// loadCheckpoint reads a checkpoint written by checkpointSaver
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	// Keep numbers in flags as written, so large integers don't turn into 1e+06
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var c checkpoint
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %s has unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// checkpointSaver writes the progress of a run to its checkpoint file
+type checkpointSaver struct {
+	path     string
+	interval time.Duration
+	flags    config.File
+	runID    string
+	duration time.Duration // Duration of the whole run, over all parts
+	resumed  *checkpoint   // Checkpoint the run continues (nil = a new run)
+
+	// Result description
+	urls    []string
+	workers int
+	headers map[string]string
+
+	failed bool // A save failed and was reported
+}
+
+// combine returns the results of the whole run so far: those of the resumed
+// checkpoint followed by the current part's
+func (s *checkpointSaver) combine(part printer.JSONOutput) printer.JSONOutput {
+	if s.resumed == nil {
+		return part
+	}
+	return printer.AppendResults([]printer.JSONOutput{s.resumed.Result, part})
+}
+
+// save writes the results of the current part so far to the checkpoint file,
+// replacing it atomically so a crash while saving keeps the previous checkpoint
+func (s *checkpointSaver) save(summary *runner.Summary) error {
+	part := printer.BuildResultJSON(summary, s.urls, s.workers, summary.Duration, method, s.headers)
+	c := checkpoint{
+		Version:    checkpointVersion,
+		RunID:      s.runID,
+		SavedAt:    time.Now().Format(time.RFC3339),
+		DurationMs: s.duration.Milliseconds(),
+		ElapsedMs:  summary.Duration.Milliseconds(),
+		Flags:      s.flags,
+		Result:     s.combine(part),
+	}
+	if s.resumed != nil {
+		c.ElapsedMs += s.resumed.ElapsedMs
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create checkpoint directory: %w", err)
+		}
+	}
+	// The flags may carry credentials, like profiles
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
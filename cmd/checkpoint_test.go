@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// TestCheckpointDuringRun saves checkpoints while results keep arriving; run
+// with -race, it catches summaries sharing maps with the live statistics
+func TestCheckpointDuringRun(t *testing.T) {
+	stats := runner.NewStats(clock.System())
+	saver := &checkpointSaver{path: filepath.Join(t.TempDir(), "checkpoint.json"), urls: []string{"http://example.com/"}, workers: 1}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// New status codes, error classes and encodings keep the maps growing
+			result := runner.Result{Latency: time.Duration(i%100) * time.Millisecond, StatusCode: 200 + i%300, ContentEncoding: fmt.Sprintf("enc%d", i%50)}
+			if i%7 == 0 {
+				result.StatusCode = 0
+				result.Error = errors.New("connection refused")
+				result.ErrorClass = fmt.Sprintf("class%d", i%40)
+			}
+			stats.AddResult(result)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		summary := stats.SummarySoFar()
+		if err := saver.save(&summary); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if _, err := loadCheckpoint(saver.path); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertOutput  string
+	convertInPlace bool
+	convertCheck   bool
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <result.json>...",
+	Short: "Upgrade JSON result files to the current schema version",
+	Long: fmt.Sprintf(`Upgrade JSON result files written by older versions of g0 to the current
+schema version (%d), so tools reading the results only need to handle one format.
+Files that are already current are left unchanged.
+
+Example:
+  g0 convert results/old.json > results/old-v%d.json
+  g0 convert --in-place results/*.json
+  g0 convert --check results/*.json`, printer.SchemaVersion, printer.SchemaVersion),
+	Args: cobra.MinimumNArgs(1),
+	RunE: runConvert,
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVarP(&convertOutput, "output", "o", "", "Write the upgraded result to this file (single input only; default: stdout)")
+	convertCmd.Flags().BoolVar(&convertInPlace, "in-place", false, "Rewrite each file with the upgraded result")
+	convertCmd.Flags().BoolVar(&convertCheck, "check", false, "Only print the schema version of each file")
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	if convertInPlace && convertOutput != "" {
+		return fmt.Errorf("--in-place and --output cannot be combined")
+	}
+	if len(args) > 1 && !convertInPlace && !convertCheck {
+		return fmt.Errorf("use --in-place to convert several files")
+	}
+	cmd.SilenceUsage = true
+
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read result: %w", err)
+		}
+
+		if convertCheck {
+			version, err := printer.ResultVersion(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			state := "current"
+			if version < printer.SchemaVersion {
+				state = "outdated"
+			}
+			fmt.Printf("%s: version %d (%s)\n", path, version, state)
+			continue
+		}
+
+		upgraded, err := printer.UpgradeResult(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		switch {
+		case convertInPlace:
+			if string(upgraded) == string(data) {
+				continue
+			}
+			if err := os.WriteFile(path, upgraded, 0644); err != nil {
+				return fmt.Errorf("failed to write result: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Upgraded %s\n", path)
+		case convertOutput != "":
+			if err := os.WriteFile(convertOutput, upgraded, 0644); err != nil {
+				return fmt.Errorf("failed to write result: %w", err)
+			}
+		default:
+			os.Stdout.Write(upgraded)
+			fmt.Println()
+		}
+	}
+	return nil
+}
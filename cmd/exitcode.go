@@ -0,0 +1,38 @@
+package cmd
+
+import "errors"
+
+// Process exit codes, so g0 can be used directly as a CI step
+const (
+	ExitOK               = 0 // Test completed and all thresholds passed
+	ExitThresholdsFailed = 1 // Test completed but one or more thresholds failed
+	ExitAborted          = 2 // Test was interrupted or could not complete
+	ExitConfigError      = 3 // Invalid flags or configuration
+)
+
+// exitError carries the process exit code for an error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode attaches an exit code to an error
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code for an error returned by a command
+// Errors without an explicit code come from flag parsing or validation
+func exitCode(err error) int {
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return ExitConfigError
+}
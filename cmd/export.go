@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/calummacc/g0/internal/grafana"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutput  string
+	exportTitle   string
+	exportUID     string
+	exportRefresh string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export integrations for other tools",
+}
+
+var exportDashboardCmd = &cobra.Command{
+	Use:   "grafana-dashboard",
+	Short: "Print a Grafana dashboard for the metrics served with --prometheus-listen",
+	Long: `Print a Grafana dashboard (JSON) showing requests per second by status class,
+error rate, latency percentiles, throughput and workers for runs started with
+--prometheus-listen. Add the generators as Prometheus scrape targets and import
+the dashboard in Grafana (Dashboards > New > Import).
+
+Example:
+  g0 run --config loadtest.yaml --prometheus-listen :9464
+  g0 export grafana-dashboard -o g0-dashboard.json`,
+	Args: cobra.NoArgs,
+	RunE: runExportDashboard,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportDashboardCmd)
+
+	exportDashboardCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the dashboard to this file instead of stdout")
+	exportDashboardCmd.Flags().StringVar(&exportTitle, "title", "g0 load test", "Dashboard title")
+	exportDashboardCmd.Flags().StringVar(&exportUID, "uid", "g0-load-test", "Dashboard UID; re-importing with the same UID replaces the dashboard")
+	exportDashboardCmd.Flags().StringVar(&exportRefresh, "refresh", "5s", "Dashboard auto-refresh interval")
+}
+
+func runExportDashboard(cmd *cobra.Command, args []string) error {
+	dashboard, err := grafana.Dashboard(grafana.DashboardOptions{
+		Title:   exportTitle,
+		UID:     exportUID,
+		Refresh: exportRefresh,
+	})
+	if err != nil {
+		return err
+	}
+	dashboard = append(dashboard, '\n')
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(dashboard)
+		return err
+	}
+	if err := os.WriteFile(exportOutput, dashboard, 0644); err != nil {
+		return fmt.Errorf("failed to write dashboard: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Dashboard written to: %s\n", exportOutput)
+	return nil
+}
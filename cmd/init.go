@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calummacc/g0/internal/config"
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+// defaultConfigFile is the file written by `g0 init` when no path is given
+const defaultConfigFile = "g0.yaml"
+
+var initCmd = &cobra.Command{
+	Use:   "init [file]",
+	Short: "Create a config file interactively",
+	Long: `Ask for the target, load model, duration and thresholds and write them to
+a config file (default: g0.yaml) that can be run with g0 run --config <file>.
+Optionally runs a short smoke test with the new config.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runInit,
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
+
+// prompter asks questions on stdout and reads answers from a reader
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints a question and returns the answer, or def if the answer is empty
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", fmt.Errorf("input ended before setup was complete")
+		}
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// askValid asks until validate accepts the answer
+func (p *prompter) askValid(question, def string, validate func(string) error) (string, error) {
+	for {
+		answer, err := p.ask(question, def)
+		if err != nil {
+			return "", err
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.out, "  %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// confirm asks a yes/no question
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := p.askValid(question+" ("+hint+")", "", func(s string) error {
+		switch strings.ToLower(s) {
+		case "", "y", "yes", "n", "no":
+			return nil
+		}
+		return fmt.Errorf("please answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return def, nil
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	path := defaultConfigFile
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	fmt.Println("This will create a g0 config file. Press Enter to accept the [default].")
+	fmt.Println()
+
+	if _, err := os.Stat(path); err == nil {
+		overwrite, err := p.confirm(fmt.Sprintf("%s already exists. Overwrite?", path), false)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			return nil
+		}
+	}
+
+	file := config.File{}
+
+	target, err := p.askValid("Target URL", "", validateTargetURL)
+	if err != nil {
+		return err
+	}
+	file["url"] = []string{target}
+
+	m, err := p.ask("HTTP method", "GET")
+	if err != nil {
+		return err
+	}
+	if m = strings.ToUpper(m); m != "GET" {
+		file["method"] = m
+	}
+
+	// Load model: a fixed number of workers, or a target request rate
+	model, err := p.askValid("Load model: (c)oncurrent workers or fixed (r)ate", "c", func(s string) error {
+		switch strings.ToLower(s) {
+		case "c", "concurrency", "r", "rate":
+			return nil
+		}
+		return fmt.Errorf("please answer c or r")
+	})
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.ToLower(model), "r") {
+		rps, err := p.askValid("Requests per second", "100", validatePositiveInt)
+		if err != nil {
+			return err
+		}
+		file["max-rps"], _ = strconv.Atoi(rps)
+	}
+	workers, err := p.askValid("Concurrent workers", "10", validatePositiveInt)
+	if err != nil {
+		return err
+	}
+	file["concurrency"], _ = strconv.Atoi(workers)
+
+	d, err := p.askValid("Test duration", "30s", func(s string) error {
+		d, err := parseHumanDuration(s)
+		if err == nil && d <= 0 {
+			return fmt.Errorf("duration must be greater than 0")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	file["duration"] = d
+
+	timeout, err := p.askValid("Per-request timeout (empty for the 30s default)", "", validateOptionalDuration)
+	if err != nil {
+		return err
+	}
+	if timeout != "" {
+		file["request-timeout"] = timeout
+	}
+
+	// Thresholds make the config usable as a CI check
+	var ts []string
+	p95, err := p.askValid("Fail if p95 latency exceeds (e.g., 300ms, empty to skip)", "", validateOptionalDuration)
+	if err != nil {
+		return err
+	}
+	if p95 != "" {
+		ts = append(ts, "p95<"+p95)
+	}
+	errRate, err := p.askValid("Fail if error rate exceeds % (empty to skip)", "", func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := runner.ParseThreshold("error_rate<" + strings.TrimSuffix(s, "%") + "%")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if errRate != "" {
+		ts = append(ts, "error_rate<"+strings.TrimSuffix(errRate, "%")+"%")
+	}
+	if len(ts) > 0 {
+		file["threshold"] = ts
+	}
+
+	if err := file.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("\nWrote %s. Run it with:\n  g0 run --config %s\n\n", path, path)
+
+	smoke, err := p.confirm("Run a 5 second smoke test with one worker now?", false)
+	if err != nil || !smoke {
+		return err
+	}
+	return runSmokeTest(path)
+}
+
+// runSmokeTest runs the config with a single worker for a few seconds
+// Thresholds in the config are evaluated, so a failing smoke test exits non-zero
+func runSmokeTest(path string) error {
+	flags := runCmd.Flags()
+	for name, value := range map[string]string{"config": path, "concurrency": "1", "duration": "5s"} {
+		if err := flags.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return runLoadTest(runCmd, nil)
+}
+
+// validateTargetURL accepts absolute http(s) URLs
+func validateTargetURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("enter a full URL such as https://api.example.com/health")
+	}
+	return nil
+}
+
+// validatePositiveInt accepts integers greater than 0
+func validatePositiveInt(s string) error {
+	if n, err := strconv.Atoi(s); err != nil || n <= 0 {
+		return fmt.Errorf("enter a number greater than 0")
+	}
+	return nil
+}
+
+// validateOptionalDuration accepts an empty answer or a positive duration
+func validateOptionalDuration(s string) error {
+	if s == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(s); err != nil || d <= 0 {
+		return fmt.Errorf("invalid duration (e.g., 300ms, 2s)")
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/calummacc/g0/internal/config"
+	"github.com/calummacc/g0/internal/k8s"
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	k8sConfig    string
+	k8sReplicas  int
+	k8sImage     string
+	k8sName      string
+	k8sNamespace string
+	k8sOutput    string
+	k8sSplitRPS  bool
+	k8sJob       string
+)
+
+// k8sFileKeys are the run flags that name local files; they are copied into the
+// ConfigMap so the pods can read them
+var k8sFileKeys = []string{"targets", "data", "body-file", "slo", "response-schema"}
+
+// k8sNamePattern matches names that are valid for Jobs and their pods
+var k8sNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,50}[a-z0-9])?$`)
+
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Run load tests as Kubernetes Jobs",
+	Long: `Generate Kubernetes manifests that run g0 on several pods at once and
+collect the results afterwards.`,
+}
+
+var k8sGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Print ConfigMap and Job manifests for a config file",
+	Long: `Print a ConfigMap with the config (and the targets, data, body and SLO files
+it references) and an indexed Job that runs g0 run on --replicas pods in parallel.
+Each pod prints its report and then its JSON result, which g0 k8s collect reads
+from the pod logs.
+
+Example:
+  g0 k8s generate --config run.yaml --replicas 5 --image registry.example.com/g0:latest | kubectl apply -f -
+  g0 k8s collect --job g0-loadtest`,
+	Args: cobra.NoArgs,
+	RunE: runK8sGenerate,
+}
+
+var k8sCollectCmd = &cobra.Command{
+	Use:   "collect [result.json...]",
+	Short: "Merge the results of a g0 Job (or of local result files)",
+	Long: `Read the JSON results from the logs of every pod of a Job created with
+g0 k8s generate (using kubectl), or from local result files, and merge them
+into one result.
+
+Request counts, bytes and RPS are summed and the average latency is weighted by
+requests. Percentiles cannot be merged exactly; the worst replica's value is
+reported as an upper bound.
+
+Example:
+  g0 k8s collect --job g0-loadtest --namespace loadtest -o merged.json
+  g0 k8s collect results/replica-*.json`,
+	RunE: runK8sCollect,
+}
+
+func init() {
+	rootCmd.AddCommand(k8sCmd)
+	k8sCmd.AddCommand(k8sGenerateCmd, k8sCollectCmd)
+
+	k8sGenerateCmd.Flags().StringVar(&k8sConfig, "config", "", "Config file for g0 run (required)")
+	k8sGenerateCmd.Flags().IntVar(&k8sReplicas, "replicas", 1, "Number of pods running the test in parallel")
+	k8sGenerateCmd.Flags().StringVar(&k8sImage, "image", "", "Container image with g0 and sh (required)")
+	k8sGenerateCmd.Flags().StringVar(&k8sName, "name", "g0-loadtest", "Name of the Job (the ConfigMap gets a -config suffix)")
+	k8sGenerateCmd.Flags().StringVar(&k8sNamespace, "namespace", "", "Namespace of the generated objects (default: kubectl's current namespace)")
+	k8sGenerateCmd.Flags().StringVarP(&k8sOutput, "output", "o", "", "Write the manifests to this file instead of stdout")
+	k8sGenerateCmd.Flags().BoolVar(&k8sSplitRPS, "split-rps", true, "Divide the config's max-rps between the replicas so the total rate is unchanged")
+	k8sGenerateCmd.MarkFlagRequired("config")
+	k8sGenerateCmd.MarkFlagRequired("image")
+
+	k8sCollectCmd.Flags().StringVar(&k8sJob, "job", "", "Name of the Job whose pod logs are collected")
+	k8sCollectCmd.Flags().StringVar(&k8sNamespace, "namespace", "", "Namespace of the Job")
+	k8sCollectCmd.Flags().StringVarP(&k8sOutput, "output", "o", "", "Output file for the merged JSON result (default: results/g0-result-<run ID>.json)")
+}
+
+func runK8sGenerate(cmd *cobra.Command, args []string) error {
+	if k8sReplicas < 1 {
+		return fmt.Errorf("--replicas must be at least 1")
+	}
+	if !k8sNamePattern.MatchString(k8sName) {
+		return fmt.Errorf("invalid --name %q (lowercase letters, digits and '-', at most 52 characters)", k8sName)
+	}
+
+	// Run the same checks as g0 run, so a broken config fails here rather than in every pod
+	plan, err := prepareConfigPlan(k8sConfig)
+	if err != nil {
+		return err
+	}
+	file, err := config.Load(k8sConfig)
+	if err != nil {
+		return err
+	}
+
+	// Ship referenced files in the ConfigMap and point the config at the mounted copies
+	files := make(map[string]string)
+	for _, key := range k8sFileKeys {
+		path, ok := file[key].(string)
+		if !ok || path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s file: %w", key, err)
+		}
+		name := key + filepath.Ext(path)
+		files[name] = string(content)
+		file[key] = k8s.ConfigMountPath + "/" + name
+	}
+
+	// Pods have no stdin; ship the body read from it in the config instead
+	if file["body"] == "-" {
+		file["body"] = plan.config.Body
+	}
+
+	// Each pod writes its own result and pod files are lost, so output settings from the config don't apply
+	delete(file, "json")
+	delete(file, "output")
+	delete(file, "record")
+	delete(file, "record-format")
+	delete(file, "record-max-size")
+	delete(file, "checkpoint")
+	delete(file, "checkpoint-interval")
+	delete(file, "resume")
+
+	// All pods report the same run, so their results and metrics correlate
+	delete(file, "run-id")
+	extraArgs := []string{"--run-id", plan.config.RunID}
+	fmt.Fprintf(os.Stderr, "Run ID: %s\n", plan.config.RunID)
+	if k8sSplitRPS && plan.config.MaxRPS > 0 && k8sReplicas > 1 {
+		perReplica := (plan.config.MaxRPS + k8sReplicas - 1) / k8sReplicas
+		extraArgs = append(extraArgs, "--max-rps", strconv.Itoa(perReplica))
+		fmt.Fprintf(os.Stderr, "Splitting max-rps %d between %d replicas (%d each)\n", plan.config.MaxRPS, k8sReplicas, perReplica)
+	}
+	if plan.config.Data != nil && plan.config.Data.Mode() == runner.FeedUnique && k8sReplicas > 1 {
+		// Kubernetes substitutes the pod's completion index, so each pod gets its own rows
+		extraArgs = append(extraArgs, "--data-shard", fmt.Sprintf("$(JOB_COMPLETION_INDEX)/%d", k8sReplicas))
+		fmt.Fprintf(os.Stderr, "Splitting %d unique data rows between %d replicas\n", plan.config.Data.Len(), k8sReplicas)
+	}
+
+	runConfig, err := file.Marshal()
+	if err != nil {
+		return err
+	}
+	files[k8s.ConfigKey] = string(runConfig)
+
+	cmd.SilenceUsage = true
+	manifests, err := k8s.Manifests(k8s.JobOptions{
+		Name:      k8sName,
+		Namespace: k8sNamespace,
+		Image:     k8sImage,
+		Replicas:  k8sReplicas,
+		Files:     files,
+		Args:      extraArgs,
+	})
+	if err != nil {
+		return err
+	}
+
+	if k8sOutput == "" {
+		_, err = os.Stdout.Write(manifests)
+		return err
+	}
+	if err := os.WriteFile(k8sOutput, manifests, 0644); err != nil {
+		return fmt.Errorf("failed to write manifests: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Manifests written to: %s\n", k8sOutput)
+	return nil
+}
+
+func runK8sCollect(cmd *cobra.Command, args []string) error {
+	if (k8sJob == "") == (len(args) == 0) {
+		return fmt.Errorf("give either --job or result files")
+	}
+	cmd.SilenceUsage = true
+
+	var raw [][]byte
+	if k8sJob != "" {
+		logs, err := jobPodLogs(k8sJob, k8sNamespace)
+		if err != nil {
+			return err
+		}
+		for pod, log := range logs {
+			result, err := k8s.ExtractResult(log)
+			if err != nil {
+				return fmt.Errorf("pod %s: %w", pod, err)
+			}
+			raw = append(raw, result)
+		}
+	} else {
+		for _, path := range args {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read result: %w", err)
+			}
+			raw = append(raw, data)
+		}
+	}
+
+	results := make([]printer.JSONOutput, 0, len(raw))
+	for i, data := range raw {
+		result, err := printer.LoadResultJSON(data)
+		if err != nil {
+			return fmt.Errorf("result %d: %w", i+1, err)
+		}
+		results = append(results, result)
+	}
+
+	merged := printer.MergeResults(results)
+	printer.Default().PrintMergedResults(merged)
+	filePath, err := printer.SaveResultJSON(merged, k8sOutput, printer.FormatJSON)
+	if err != nil {
+		return withExitCode(ExitAborted, err)
+	}
+	fmt.Fprintf(os.Stderr, "\nMerged results saved to: %s\n", filePath)
+
+	if merged.Aborted {
+		return withExitCode(ExitAborted, fmt.Errorf("at least one replica was aborted"))
+	}
+	if !merged.Passed {
+		return withExitCode(ExitThresholdsFailed, fmt.Errorf("at least one replica failed its thresholds"))
+	}
+	return nil
+}
+
+// jobPodLogs returns the logs of every pod of a Job, keyed by pod name
+func jobPodLogs(job, namespace string) (map[string][]byte, error) {
+	kubectl := func(args ...string) ([]byte, error) {
+		if namespace != "" {
+			args = append([]string{"--namespace", namespace}, args...)
+		}
+		var stderr bytes.Buffer
+		c := exec.Command("kubectl", args...)
+		c.Stderr = &stderr
+		out, err := c.Output()
+		if err != nil {
+			return nil, fmt.Errorf("kubectl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+
+	out, err := kubectl("get", "pods", "--selector", "job-name="+job, "--output", "jsonpath={.items[*].metadata.name}")
+	if err != nil {
+		return nil, err
+	}
+	pods := strings.Fields(string(out))
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods found for job %s", job)
+	}
+
+	logs := make(map[string][]byte, len(pods))
+	for _, pod := range pods {
+		if logs[pod], err = kubectl("logs", pod); err != nil {
+			return nil, err
+		}
+	}
+	return logs, nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	modelsConfig string
+	modelsRate   int
+)
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "Compare the closed (worker) model with a constant arrival rate",
+	Long: `Run a load test configuration twice, first with the closed model (each
+worker sends its next request when the previous one completes) and then
+with the open model (requests start at a constant arrival rate however
+long earlier ones take), and compare the latencies.
+
+When the target slows down, a closed-model worker stops sending until its
+response arrives, so the requests that would have queued never happen and
+their latency is missing from the statistics (coordinated omission). The
+open model counts the time each request waited for a free worker.
+
+The open run uses --rate, or the closed run's throughput by default, so
+both runs carry the same load. Configs use the --config format.
+
+Example:
+  g0 models --config api.yaml
+  g0 models --config api.yaml --rate 800`,
+	RunE: runModels,
+}
+
+func init() {
+	rootCmd.AddCommand(modelsCmd)
+
+	modelsCmd.Flags().StringVar(&modelsConfig, "config", "", "Config file of the load test (required)")
+	modelsCmd.Flags().IntVar(&modelsRate, "rate", 0, "Arrival rate of the open-model run in requests per second (default: the closed run's RPS)")
+	modelsCmd.MarkFlagRequired("config")
+}
+
+func runModels(cmd *cobra.Command, args []string) error {
+	if modelsRate < 0 {
+		return fmt.Errorf("--rate must be greater than or equal to 0")
+	}
+	plan, err := prepareConfigPlan(modelsConfig)
+	if err != nil {
+		return err
+	}
+	switch {
+	case plan.config.ArrivalRate > 0:
+		return fmt.Errorf("the config sets arrival-rate; g0 models runs it with and without one")
+	case plan.config.RPSTrace != nil:
+		return fmt.Errorf("the config sets rps-trace; g0 models runs it with and without an arrival rate")
+	case plan.config.Users > 0:
+		return fmt.Errorf("the config sets users, which can't be combined with an arrival rate")
+	}
+	cmd.SilenceUsage = true
+
+	out := printer.Default()
+	out.PrintLogo()
+	fmt.Printf("Closed vs. Open Model: 2 runs of %s, about %s in total\n", plan.duration, 2*plan.duration)
+	fmt.Printf("  Config: %s (%d URLs, %d workers)\n", modelsConfig, len(plan.urls), plan.workers)
+	fmt.Println()
+
+	// Ctrl+C or SIGTERM stops the comparison; it needs both runs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	closed, err := runVariant(ctx, out, plan)
+	if err != nil {
+		return withExitCode(ExitAborted, fmt.Errorf("closed model: %w", err))
+	}
+	if closed.Aborted {
+		return withExitCode(ExitAborted, fmt.Errorf("comparison aborted during the closed-model run"))
+	}
+	out.PrintModelRun("Closed", closed)
+
+	rate := modelsRate
+	if rate == 0 {
+		rate = int(math.Round(closed.RPS))
+	}
+	if rate < 1 {
+		return withExitCode(ExitAborted, fmt.Errorf("the closed-model run sent less than 1 request/s; set --rate"))
+	}
+
+	// Same target and workers; the arrival rate replaces any rate limit
+	openPlan := *plan
+	openPlan.config.MaxRPS = 0
+	openPlan.config.ArrivalRate = rate
+	open, err := runVariant(ctx, out, &openPlan)
+	if err != nil {
+		return withExitCode(ExitAborted, fmt.Errorf("open model: %w", err))
+	}
+	if open.Aborted {
+		return withExitCode(ExitAborted, fmt.Errorf("comparison aborted during the open-model run"))
+	}
+	out.PrintModelRun("Open", open)
+
+	out.PrintModelComparison(closed, open, rate)
+	return nil
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/calummacc/g0/internal/chart"
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	plotOut    string
+	plotFormat string
+	plotWidth  int
+	plotHeight int
+)
+
+// plotHistogramBins is the number of bars in the latency distribution chart
+const plotHistogramBins = 50
+
+var plotCmd = &cobra.Command{
+	Use:   "plot <result.json>...",
+	Short: "Render charts of JSON results to PNG or SVG files",
+	Long: `Render charts of JSON results for slide decks and wiki pages: the latency
+distribution, latency percentiles over time, RPS over time and the error rate
+over time. Each result gets its own set of files named after it, e.g.
+charts/run-latency.png.
+
+Charts over time need results written by g0 run with this version or later;
+merged results (g0 k8s collect) only have the latency distribution.
+
+Example:
+  g0 plot results/g0-result-20240102-140000.json --out charts/
+  g0 plot --format svg results/*.json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPlot,
+}
+
+func init() {
+	rootCmd.AddCommand(plotCmd)
+
+	plotCmd.Flags().StringVar(&plotOut, "out", "charts", "Directory the chart files are written to")
+	plotCmd.Flags().StringVar(&plotFormat, "format", chart.FormatPNG, "Image format: png or svg")
+	plotCmd.Flags().IntVar(&plotWidth, "width", chart.DefaultWidth, "Image width in pixels")
+	plotCmd.Flags().IntVar(&plotHeight, "height", chart.DefaultHeight, "Image height in pixels")
+}
+
+func runPlot(cmd *cobra.Command, args []string) error {
+	if plotFormat != chart.FormatPNG && plotFormat != chart.FormatSVG {
+		return fmt.Errorf("invalid --format %q (available: %s, %s)", plotFormat, chart.FormatPNG, chart.FormatSVG)
+	}
+	if plotWidth < 200 || plotHeight < 150 {
+		return fmt.Errorf("--width must be at least 200 and --height at least 150")
+	}
+	cmd.SilenceUsage = true
+
+	if err := os.MkdirAll(plotOut, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read result: %w", err)
+		}
+		result, err := printer.LoadResultJSON(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		charts := resultCharts(result)
+		if len(charts) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: no latency histogram or timeline to plot (written by an older g0?)\n", path)
+			continue
+		}
+		for _, kind := range []string{"latency", "latency-over-time", "rps", "errors"} {
+			c, ok := charts[kind]
+			if !ok {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := chart.Render(&buf, c, plotFormat, plotWidth, plotHeight); err != nil {
+				return err
+			}
+			file := filepath.Join(plotOut, name+"-"+kind+"."+plotFormat)
+			if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+				return fmt.Errorf("failed to write chart: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Chart saved to: %s\n", file)
+		}
+	}
+	return nil
+}
+
+// resultCharts builds the charts a result has data for, keyed by file suffix
+func resultCharts(result printer.JSONOutput) map[string]chart.Chart {
+	charts := make(map[string]chart.Chart)
+	seconds := func(v float64) string { return fmt.Sprintf("%gs", v) }
+
+	if c, ok := latencyDistributionChart(result.Metrics); ok {
+		charts["latency"] = c
+	}
+
+	timeline := result.Metrics.Timeline
+	if len(timeline) < 2 {
+		return charts
+	}
+	offsets := make([]float64, len(timeline))
+	rps := make([]float64, len(timeline))
+	errorRate := make([]float64, len(timeline))
+	p50 := make([]float64, len(timeline))
+	p95 := make([]float64, len(timeline))
+	p99 := make([]float64, len(timeline))
+	max := make([]float64, len(timeline))
+	for i, p := range timeline {
+		offsets[i] = float64(p.OffsetMs) / 1000
+		rps[i] = p.RPS
+		if p.Requests > 0 {
+			errorRate[i] = float64(p.Failed) / float64(p.Requests) * 100
+		}
+		p50[i], p95[i], p99[i], max[i] = p.P50Ms, p.P95Ms, p.P99Ms, p.MaxMs
+	}
+
+	charts["latency-over-time"] = chart.Chart{
+		Title:   "Latency over time",
+		XLabel:  "Time since start",
+		YLabel:  "ms",
+		FormatX: seconds,
+		Series: []chart.Series{
+			{Name: "p50", X: offsets, Y: p50},
+			{Name: "p95", X: offsets, Y: p95},
+			{Name: "p99", X: offsets, Y: p99},
+			{Name: "max", X: offsets, Y: max},
+		},
+	}
+	charts["rps"] = chart.Chart{
+		Title:   "Requests per second",
+		XLabel:  "Time since start",
+		YLabel:  "req/s",
+		FormatX: seconds,
+		Series:  []chart.Series{{Name: "RPS", X: offsets, Y: rps}},
+	}
+	charts["errors"] = chart.Chart{
+		Title:   "Error rate",
+		XLabel:  "Time since start",
+		YLabel:  "% failed",
+		FormatX: seconds,
+		Series:  []chart.Series{{Name: "Error rate", X: offsets, Y: errorRate}},
+	}
+	return charts
+}
+
+// latencyDistributionChart re-bins the geometric latency histogram into equal-width
+// bars up to p99, so the shape of the bulk isn't squashed by the slowest requests
+func latencyDistributionChart(m printer.JSONMetrics) (chart.Chart, bool) {
+	if len(m.LatencyHistogram) == 0 {
+		return chart.Chart{}, false
+	}
+
+	limit := m.Latency.P99.Ms
+	if limit <= 0 {
+		limit = m.LatencyHistogram[len(m.LatencyHistogram)-1].LessThanMs
+	}
+	binWidth := limit / plotHistogramBins
+	counts := make([]float64, plotHistogramBins)
+	var above int64
+	lower := 0.0
+	for _, b := range m.LatencyHistogram {
+		mid := (lower + b.LessThanMs) / 2
+		lower = b.LessThanMs
+		if mid > limit {
+			above += b.Count
+			continue
+		}
+		bin := int(math.Min(mid/binWidth, plotHistogramBins-1))
+		counts[bin] += float64(b.Count)
+	}
+
+	xs := make([]float64, plotHistogramBins)
+	for i := range xs {
+		xs[i] = (float64(i) + 0.5) * binWidth
+	}
+	title := "Latency distribution (up to p99)"
+	if above > 0 {
+		title = fmt.Sprintf("Latency distribution (up to p99; %d slower requests not shown)", above)
+	}
+	return chart.Chart{
+		Title:    title,
+		XLabel:   "Latency (ms)",
+		YLabel:   "Requests",
+		Series:   []chart.Series{{Name: "Requests", X: xs, Y: counts}},
+		Bars:     true,
+		BarWidth: binWidth,
+	}, true
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	probeURL     string
+	probeMethod  string
+	probeBody    string
+	probeHeaders []string
+	probeIdle    time.Duration
+	probeCount   int
+	probeWarm    int
+	probeTimeout time.Duration
+	probeOutput  string
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Measure cold starts by sending single requests after idle periods",
+	Long: `Measure scale-from-zero cold starts of serverless and autoscaled targets.
+
+Before each probe g0 sends nothing for --idle so the platform can scale the
+target down, then sends one request on a new connection (the cold request)
+followed by --warm-requests requests as a warm baseline. Cold and warm
+latencies are reported separately. The idle period also precedes the first
+probe, since the target may still be warm when probing starts.
+
+Example:
+  g0 probe --url https://fn.example.com/api --idle 10m --probes 6
+  g0 probe --url https://fn.example.com/api --idle 15m --probes 4 -o results/coldstart.json`,
+	Args: cobra.NoArgs,
+	RunE: runProbe,
+}
+
+func init() {
+	rootCmd.AddCommand(probeCmd)
+
+	probeCmd.Flags().StringVarP(&probeURL, "url", "u", "", "Target URL (required)")
+	probeCmd.Flags().StringVarP(&probeMethod, "method", "m", "GET", "HTTP method")
+	probeCmd.Flags().StringVarP(&probeBody, "body", "b", "", "Request body")
+	probeCmd.Flags().StringArrayVarP(&probeHeaders, "headers", "H", []string{}, "HTTP headers (can be specified multiple times)")
+	probeCmd.Flags().DurationVar(&probeIdle, "idle", 10*time.Minute, "Idle time before each probe")
+	probeCmd.Flags().IntVar(&probeCount, "probes", 6, "Number of probes")
+	probeCmd.Flags().IntVar(&probeWarm, "warm-requests", 5, "Requests sent right after each probe as the warm baseline")
+	probeCmd.Flags().DurationVar(&probeTimeout, "request-timeout", 2*time.Minute, "Per-request timeout")
+	probeCmd.Flags().StringVarP(&probeOutput, "output", "o", "", "Also write the results as JSON to this file")
+	probeCmd.MarkFlagRequired("url")
+}
+
+func runProbe(cmd *cobra.Command, args []string) error {
+	switch {
+	case probeIdle <= 0:
+		return fmt.Errorf("idle must be greater than 0")
+	case probeCount < 1:
+		return fmt.Errorf("probes must be at least 1")
+	case probeWarm < 0:
+		return fmt.Errorf("warm-requests must be greater than or equal to 0")
+	case probeTimeout <= 0:
+		return fmt.Errorf("request-timeout must be greater than 0")
+	}
+	headerMap := make(map[string]string)
+	for _, h := range probeHeaders {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid header format: %s (expected 'Key: Value')", h)
+		}
+		headerMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	cmd.SilenceUsage = true
+
+	config := runner.ProbeConfig{
+		URL:          probeURL,
+		Method:       probeMethod,
+		Body:         probeBody,
+		Headers:      headerMap,
+		Idle:         probeIdle,
+		Probes:       probeCount,
+		WarmRequests: probeWarm,
+		Timeout:      probeTimeout,
+	}
+
+	out := printer.Default()
+	out.PrintLogo()
+	out.PrintProbeStart(config)
+
+	// Ctrl+C or SIGTERM stops probing; completed probes are still reported
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	next := 1
+	summary := runner.RunProbes(ctx, config,
+		func(at time.Time) { out.PrintProbeWait(next, config.Probes, at) },
+		func(p runner.Probe) {
+			out.PrintProbe(p, config.Probes)
+			next = p.Number + 1
+		})
+	out.PrintProbeSummary(summary)
+
+	if probeOutput != "" {
+		if err := printer.WriteProbeJSON(summary, probeOutput); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "\nJSON results saved to: %s\n", probeOutput)
+	}
+
+	if summary.Aborted {
+		return withExitCode(ExitAborted, fmt.Errorf("probing interrupted after %d of %d probes", len(summary.Probes), config.Probes))
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// procStopDelay is how long a generator process may take to write its partial
+// result after being interrupted before it is killed
+const procStopDelay = 10 * time.Second
+
+// splitShare returns the share of total that generator process index of count gets;
+// the remainder goes to the first processes
+func splitShare(total, index, count int) int {
+	share := total / count
+	if index < total%count {
+		share++
+	}
+	return share
+}
+
+// procArgs returns the arguments of generator process index of count: the
+// command line of this process with the per-process values appended (the last
+// value of a flag wins)
+func procArgs(plan *runPlan, index, count int, resultFile string) []string {
+	args := append([]string{}, os.Args[1:]...)
+	args = append(args,
+		"--procs", "1",
+		"--proc-child",
+		"--concurrency", strconv.Itoa(splitShare(plan.config.Concurrency, index, count)),
+		"--json", "--output", resultFile, "--output-format", printer.FormatJSON,
+		"--report-file=",
+		"--run-id", plan.config.RunID,
+	)
+	if plan.config.MaxRPS > 0 {
+		args = append(args, "--max-rps", strconv.Itoa(splitShare(plan.config.MaxRPS, index, count)))
+	}
+	if plan.config.ArrivalRate > 0 {
+		args = append(args, "--arrival-rate", strconv.Itoa(splitShare(plan.config.ArrivalRate, index, count)))
+	}
+	if t := plan.config.RPSTrace; t != nil {
+		// Each process replays the trace at its share of the rate
+		args = append(args, "--rps-trace-scale", strconv.FormatFloat(t.Scale/float64(count), 'g', -1, 64))
+	}
+	if plan.config.MaxMemory > 0 {
+		// The budget is for the machine, so the processes share it
+		args = append(args, "--max-memory", strconv.FormatInt(plan.config.MaxMemory/int64(count), 10))
+	}
+	// Processes appending to one file could interleave their lines, and each
+	// process creating the ID list would overwrite the others'
+	if log := plan.config.ErrorLog; log != "" {
+		args = append(args, "--error-log", procFile(log, index))
+	}
+	if file := plan.config.Created.File(); file != "" {
+		args = append(args, "--cleanup-file", procFile(file, index))
+	}
+	if seed := plan.config.Seed; seed != 0 {
+		// Processes with one seed would send the same randomized values; moving
+		// away from 0 keeps every seed explicit, so the run stays reproducible
+		if seed > 0 {
+			seed += int64(index)
+		} else {
+			seed -= int64(index)
+		}
+		args = append(args, "--seed", strconv.FormatInt(seed, 10))
+	}
+	if plan.config.Users > 0 {
+		args = append(args, "--users", strconv.Itoa(splitShare(plan.config.Users, index, count)))
+	}
+	if plan.config.Data != nil && plan.config.Data.Mode() == runner.FeedUnique {
+		// Each process sends its own rows, as each pod of g0 k8s does
+		args = append(args, "--data-shard", fmt.Sprintf("%d/%d", index, count))
+	}
+	return args
+}
+
+// procFile returns the file of process index for a file every process writes,
+// e.g. errors.ndjson becomes errors-2.ndjson
+func procFile(path string, index int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), index, ext)
+}
+
+// runProcs runs the load test in count child g0 processes, splitting the
+// concurrency and rate between them, and writes their merged results to report
+// Separate processes sidestep the limits of a single Go runtime (GC pauses, one
+// netpoller) on machines with many cores
+func runProcs(ctx context.Context, plan *runPlan, count int, report io.Writer) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return withExitCode(ExitAborted, fmt.Errorf("failed to locate the g0 executable: %w", err))
+	}
+	dir, err := os.MkdirTemp("", "g0-procs-")
+	if err != nil {
+		return withExitCode(ExitAborted, fmt.Errorf("failed to create a directory for the process results: %w", err))
+	}
+	defer os.RemoveAll(dir)
+
+	out := printer.New(report, os.Stderr)
+	out.RawNumbers = rawNumbers
+	out.PrintLogo()
+	out.PrintTestStart(plan.urls, plan.config.Concurrency, plan.duration, plan.config.RunID)
+	fmt.Fprintf(report, "Running %d generator processes...\n", count)
+
+	// Each process prints its own report; only its errors are kept
+	outputs := make([]bytes.Buffer, count)
+	errs := make([]error, count)
+	files := make([]string, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		files[i] = filepath.Join(dir, fmt.Sprintf("proc-%d.json", i))
+		proc := exec.CommandContext(ctx, executable, procArgs(plan, i, count, files[i])...)
+		proc.Stderr = &outputs[i]
+		if body == "-" {
+			// Stdin was read once by this process; hand each process its copy
+			proc.Stdin = strings.NewReader(plan.config.Body)
+		}
+		// Interrupt rather than kill, so the process still writes its partial result
+		proc.Cancel = func() error { return proc.Process.Signal(os.Interrupt) }
+		proc.WaitDelay = plan.config.Grace + procStopDelay
+		if err := proc.Start(); err != nil {
+			return withExitCode(ExitAborted, fmt.Errorf("failed to start generator process: %w", err))
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = proc.Wait()
+		}(i)
+	}
+	wg.Wait()
+
+	results := make([]printer.JSONOutput, 0, count)
+	for i, file := range files {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			var result printer.JSONOutput
+			if result, err = printer.LoadResultJSON(data); err == nil {
+				results = append(results, result)
+				continue
+			}
+		}
+		if errs[i] != nil {
+			err = errs[i]
+		}
+		return withExitCode(ExitAborted, fmt.Errorf("generator process %d failed: %w\n%s", i, err, strings.TrimSpace(outputs[i].String())))
+	}
+
+	// Check the thresholds on the merged result; the processes only saw their share
+	merged := printer.MergeResults(results)
+	plan.redaction.Apply(&merged)
+	failedThresholds := printer.EvaluateMergedThresholds(&merged, plan.thresholds)
+	fmt.Fprintln(report)
+	out.PrintMergedResults(merged)
+
+	if plan.saveFormat != "" {
+		filePath, err := printer.SaveResultJSON(merged, outputFile, plan.saveFormat)
+		if err != nil {
+			return withExitCode(ExitAborted, fmt.Errorf("failed to save results: %w", err))
+		}
+		fmt.Fprintf(os.Stderr, "\nResults saved to: %s\n", filePath)
+	}
+
+	if merged.Aborted {
+		return withExitCode(ExitAborted, fmt.Errorf("load test aborted before the configured duration"))
+	}
+	if failedThresholds > 0 {
+		return withExitCode(ExitThresholdsFailed, fmt.Errorf("%d of %d thresholds failed", failedThresholds, len(plan.thresholds)))
+	}
+	if !merged.Passed {
+		return withExitCode(ExitThresholdsFailed, fmt.Errorf("at least one generator process missed its SLOs"))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// lastValue returns the value of the last occurrence of flag in args, which
+// is the one that wins
+func lastValue(args []string, flag string) string {
+	value := ""
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			value = args[i+1]
+		}
+	}
+	return value
+}
+
+func TestProcArgsPerProcessValues(t *testing.T) {
+	created, err := runner.NewResourceTracker("header:Location", "", "created.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := &runPlan{config: runner.Config{Concurrency: 4, Seed: 42, Created: created, ErrorLog: "errors.ndjson"}}
+
+	for index, want := range []struct{ seed, cleanup, errorLog string }{
+		{"42", "created-0.txt", "errors-0.ndjson"},
+		{"43", "created-1.txt", "errors-1.ndjson"},
+	} {
+		args := procArgs(plan, index, 2, "result.json")
+		if got := lastValue(args, "--seed"); got != want.seed {
+			t.Errorf("process %d: seed %q, want %q", index, got, want.seed)
+		}
+		if got := lastValue(args, "--cleanup-file"); got != want.cleanup {
+			t.Errorf("process %d: cleanup file %q, want %q", index, got, want.cleanup)
+		}
+		if got := lastValue(args, "--error-log"); got != want.errorLog {
+			t.Errorf("process %d: error log %q, want %q", index, got, want.errorLog)
+		}
+	}
+
+	// A negative seed moves away from 0 too, which would pick a random seed
+	plan.config.Seed = -1
+	if got := lastValue(procArgs(plan, 1, 2, "result.json"), "--seed"); got != "-2" {
+		t.Errorf("seed %q, want -2", got)
+	}
+
+	// Without an explicit seed each process picks its own
+	plan.config.Seed = 0
+	if args := procArgs(plan, 1, 2, "result.json"); slices.Contains(args, "--seed") {
+		t.Errorf("seed %q added without --seed", lastValue(args, "--seed"))
+	}
+}
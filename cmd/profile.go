@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/calummacc/g0/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage saved flag profiles",
+	Long: `Profiles store frequently used run flags (URLs, headers, thresholds, ...)
+under the user config directory (e.g., ~/.config/g0/profiles) so they can be
+reused with g0 run --profile <name>.`,
+}
+
+var profileSaveCmd = &cobra.Command{
+	Use:   "save <name> [run flags]",
+	Short: "Save run flags as a named profile",
+	Long: `Save the given run flags as a named profile. Only flags that are set
+explicitly are stored; an existing profile with the same name is replaced.
+
+Example:
+  g0 profile save staging --url https://staging.example.com/api --c 50 -H "Authorization: Bearer $TOKEN" --threshold 'p95<300ms'
+  g0 run --profile staging --d 1m`,
+	Args: cobra.ExactArgs(1),
+	RunE: saveProfile,
+}
+
+var profileListCmd = &cobra.Command{
+	Use: "list",
+	// Failures here are not usage errors
+	SilenceUsage: true,
+	Short:        "List saved profiles",
+	Args:         cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := config.ListProfiles()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Println("No profiles saved (use `g0 profile save <name> [run flags]`)")
+			return nil
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	},
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:          "show <name>",
+	SilenceUsage: true,
+	Short:        "Print a saved profile",
+	Args:         cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.ProfilePath(args[0])
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("profile %q not found: %w", args[0], err)
+		}
+		fmt.Printf("# %s\n%s", path, data)
+		return nil
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:          "delete <name>",
+	SilenceUsage: true,
+	Short:        "Delete a saved profile",
+	Args:         cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.ProfilePath(args[0])
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to delete profile %q: %w", args[0], err)
+		}
+		fmt.Printf("Deleted profile %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileSaveCmd, profileListCmd, profileShowCmd, profileDeleteCmd)
+
+	addRunFlags(profileSaveCmd.Flags())
+}
+
+func saveProfile(cmd *cobra.Command, args []string) error {
+	path, err := config.ProfilePath(args[0])
+	if err != nil {
+		return err
+	}
+
+	// A --config file given here is folded into the profile
+	if err := applyConfigFiles(cmd.Flags()); err != nil {
+		return err
+	}
+	profile := config.FromFlags(cmd.Flags(), "config")
+	if len(profile) == 0 {
+		return fmt.Errorf("no flags given to save in profile %q", args[0])
+	}
+	cmd.SilenceUsage = true
+
+	if err := profile.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("Saved profile %s to %s (%d flags)\n", args[0], path, len(profile))
+	return nil
+}
+
+// applyConfigFiles fills in flags from --config and --profile
+// Precedence is: explicit flags, then the config file, then the profile
+func applyConfigFiles(flags *pflag.FlagSet) error {
+	if configFile != "" {
+		file, err := config.Load(configFile)
+		if err != nil {
+			return err
+		}
+		if err := file.Apply(flags); err != nil {
+			return fmt.Errorf("%s: %w", configFile, err)
+		}
+	}
+
+	if profileName != "" {
+		path, err := config.ProfilePath(profileName)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("profile %q not found (see `g0 profile list`)", profileName)
+		}
+		file, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := file.Apply(flags); err != nil {
+			return fmt.Errorf("profile %s: %w", profileName, err)
+		}
+	}
+	return nil
+}
@@ -12,17 +12,19 @@ var rootCmd = &cobra.Command{
 	Short: "g0 - A minimal high-performance HTTP load tester",
 	Long: `g0 is a fast, lightweight CLI tool that sends concurrent HTTP requests
 and measures load-testing metrics. It's designed to be simple yet powerful.`,
+	// Errors are printed once by Execute, which also picks the exit code
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
+// The exit code reflects the outcome (see ExitOK, ExitThresholdsFailed, ExitAborted, ExitConfigError).
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
 	// Here you can define flags and configuration settings
 }
-
@@ -1,28 +1,170 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
+	"github.com/calummacc/g0/internal/config"
+	"github.com/calummacc/g0/internal/httpclient"
 	"github.com/calummacc/g0/internal/printer"
 	"github.com/calummacc/g0/internal/runner"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	urls        []string
-	concurrency int
-	duration    string
-	method      string
-	body        string
-	headers     []string
-	jsonOutput  bool
-	outputFile  string
-	maxRPS      int
+	urls         []string
+	targetsFile  string
+	rawRequest   string
+	urlStrategy  string
+	hashKey      string
+	targetDown   []string
+	chaos        []string
+	concurrency  int
+	duration     string
+	method       string
+	body         string
+	headers      []string
+	jsonOutput   bool
+	outputFile   string
+	outputFormat string
+	reportFile   string
+	reportTmpl   string
+	rawNumbers   bool
+	redactHdrs   []string
+	omitHeaders  bool
+	maxRPS       int
+	arrivalRate  int
+	rpsTrace     string
+	traceScale   float64
+	traceSpeed   float64
+	cacheBust    bool
+	conditional  bool
+	acceptEnc    string
+	compressBody string
+	bodyFile     string
+	bodySize     string
+	bodyRate     string
+	expectCont   bool
+	http10       bool
+	expectWait   time.Duration
+	clientBW     string
+	readDelay    time.Duration
+	maxBodyBytes string
+	maxResponse  string
+	skipBody     bool
+	reqTimeout   time.Duration
+	timeoutCands []time.Duration
+	dnsServer    string
+	grace        time.Duration
+	progressInt  time.Duration
+	thresholds   []string
+	maxErrorRate float64
+	configFile   string
+	profileName  string
+	seed         int64
+	dataFile     string
+	dataMode     string
+	dataShard    string
+	workerHeader bool
+	runID        string
+	runIDHeader  bool
+	idemKey      string
+	trackCreated string
+	cleanupURL   string
+	cleanupFile  string
+	authURL      string
+	authMethod   string
+	authBody     string
+	authHeaders  []string
+	authField    string
+	authInterval time.Duration
+	authPercent  float64
+	httpAuth     string
+	httpAuthUser string
+	httpAuthPass string
+	httpAuthTab  string
+	httpAuthCC   string
+	httpAuthConf string
+	httpAuthSPN  string
+	certDir      string
+	certMode     string
+	noResumption bool
+	needStaple   bool
+	sniFile      string
+	proxyProto   string
+	proxySource  string
+	traceProp    string
+	traceLink    string
+	spoofHeader  string
+	spoofMode    string
+	spoofCIDR    string
+	canaryURL    string
+	canaryPct    float64
+	mirrorURL    string
+	mirrorMode   string
+	healthURL    string
+	healthInt    time.Duration
+	healthPause  bool
+	metricsURL   string
+	metricsInt   time.Duration
+	sloFile      string
+	schemaFile   string
+	schemaSample float64
+	bodySHA256   []string
+	countBodies  bool
+	rangeSize    string
+	rangePct     float64
+	captureHdrs  []string
+	startAt      string
+	startAfter   time.Duration
+	ntpServer    string
+	recordFile   string
+	recordFormat string
+	errorLogFile string
+	errorLogMax  string
+	noErrorLog   bool
+	recordMax    string
+	rotateEvery  time.Duration
+	rotateKeep   int
+	rotateGzip   bool
+	checkpointTo string
+	checkpointIn time.Duration
+	resumeFile   string
+	maxMemory    string
+	promListen   string
+	warnErrRate  float64
+	warnBell     bool
+	coldRequests int
+	churnRate    int
+	users        int
+	thinkTime    time.Duration
+	cpus         int
+	cpuAffinity  string
+	shardWorkers bool
+	procs        int
+	procChild    bool
+	audit        bool
+	auditHeader  string
 )
 
+// minAlarmRequests is the fewest requests in the error rate window that can trigger
+// --warn-error-rate, so a single early failure doesn't raise an alarm
+const minAlarmRequests = 10
+
+// maxChurnRate caps --churn-rate at what a ticker can pace reliably
+const maxChurnRate = 10000
+
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run a load test",
@@ -30,75 +172,1132 @@ var runCmd = &cobra.Command{
 
 Example:
   g0 run --url https://api.example.com --c 100 --d 10s
-  g0 run --url https://api.example.com --c 50 --d 30s --method POST --body '{"key":"value"}' --headers "Content-Type: application/json"`,
+  g0 run --url https://api.example.com --c 50 --d 30s --method POST --body '{"key":"value"}' --headers "Content-Type: application/json"
+  g0 run --targets targets.json --c 50 --d 30s
+  g0 run --profile staging --d 1m
+  g0 run --config loadtest.yaml`,
 	RunE: runLoadTest,
 }
 
 func init() {
 	rootCmd.AddCommand(runCmd)
 
-	runCmd.Flags().StringArrayVarP(&urls, "url", "u", []string{}, "Target URL(s) - can be specified multiple times (required)")
-	runCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 10, "Number of concurrent workers")
-	runCmd.Flags().StringVarP(&duration, "duration", "d", "10s", "Test duration (e.g., 10s, 1m, 30s)")
-	runCmd.Flags().StringVarP(&method, "method", "m", "GET", "HTTP method")
-	runCmd.Flags().StringVarP(&body, "body", "b", "", "Request body")
-	runCmd.Flags().StringArrayVarP(&headers, "headers", "H", []string{}, "HTTP headers (can be specified multiple times)")
-	runCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output results in JSON format")
-	runCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path for JSON results (default: results/g0-result-YYYYMMDD-HHMMSS.json)")
-	runCmd.Flags().IntVarP(&maxRPS, "max-rps", "r", 0, "Maximum requests per second (0 = no limit)")
-
-	runCmd.MarkFlagRequired("url")
+	addRunFlags(runCmd.Flags())
+	runCmd.Flags().StringVar(&profileName, "profile", "", "Load flags from a saved profile (see g0 profile save)")
 }
 
-func runLoadTest(cmd *cobra.Command, args []string) error {
+// addRunFlags registers the load test flags on a flag set
+// They are shared by `g0 run` and `g0 profile save`
+func addRunFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVarP(&urls, "url", "u", []string{}, "Target URL(s) - can be specified multiple times (required unless --targets is set)")
+	flags.StringVar(&targetsFile, "targets", "", "JSON file with targets, each optionally overriding method, headers and body")
+	flags.StringVar(&rawRequest, "raw-request", "", "Replay the literal HTTP/1.x request in this file (e.g., exported from Burp or ZAP) byte for byte, with {{...}} templates rendered, to the scheme and address of the one --url")
+	flags.StringVar(&urlStrategy, "url-strategy", string(runner.StrategyRoundRobin), "How workers pick the target of each request: round-robin, sticky (each worker keeps one URL for the whole run), random (reproducible with --seed) or hash (by the --hash-key data column)")
+	flags.StringVar(&hashKey, "hash-key", "", "Data column whose value picks the target with --url-strategy hash, so the same key always reaches the same URL")
+	flags.StringArrayVar(&targetDown, "target-down", []string{}, "Take a target out of rotation during the run, as URL@FROM or URL@FROM-TO, e.g. https://b.example.com@1m-1m30s (can be specified multiple times)")
+	flags.IntVarP(&concurrency, "concurrency", "c", 10, "Number of concurrent workers")
+	flags.IntVar(&users, "users", 0, "Simulate this many virtual users, each pausing --think-time between its requests, multiplexed over the -c workers (0 = each worker sends back to back)")
+	flags.DurationVar(&thinkTime, "think-time", time.Second, "Pause of each --users virtual user between its requests")
+	flags.StringVarP(&duration, "duration", "d", "10s", "Test duration (e.g., 30s, 5m, 1h30m, 2d, or 90 for seconds)")
+	flags.StringVarP(&method, "method", "m", "GET", "HTTP method")
+	flags.StringVarP(&body, "body", "b", "", "Request body (- reads it from stdin)")
+	flags.StringArrayVarP(&headers, "headers", "H", []string{}, "HTTP headers (can be specified multiple times)")
+	flags.BoolVarP(&jsonOutput, "json", "j", false, "Output results in JSON format")
+	flags.StringVarP(&outputFile, "output", "o", "", "Output file path for JSON results (default: results/g0-result-<run ID>.json)")
+	flags.StringVar(&outputFormat, "output-format", printer.FormatJSON, "Format of the saved results: json or yaml (yaml saves the results without --json)")
+	flags.StringArrayVar(&redactHdrs, "redact-header", nil, "Replace the value of this header with [REDACTED] in saved results, besides Authorization, Cookie, X-Api-Key and other credential headers (can be specified multiple times)")
+	flags.BoolVar(&omitHeaders, "omit-headers", false, "Leave the request headers out of saved results entirely")
+	flags.StringVar(&reportFile, "report-file", "", "Write the text report to this file instead of stdout (the progress line stays on stderr)")
+	flags.StringVar(&reportTmpl, "report-template", "", "Produce the text report with this Go text/template file, executed with the run's summary")
+	flags.BoolVar(&rawNumbers, "raw-numbers", false, "Print counts and rates as plain digits, without digit grouping (1,234,567) or compact forms (1.2M)")
+	flags.IntVarP(&maxRPS, "max-rps", "r", 0, "Maximum requests per second (0 = no limit)")
+	flags.IntVar(&arrivalRate, "arrival-rate", 0, "Start this many requests per second however long earlier ones take (open model); latency includes waiting for a free worker")
+	flags.StringVar(&rpsTrace, "rps-trace", "", "Replay the traffic shape of a CSV of timestamp,rps rows (e.g., exported from production metrics) as the arrival rate (open model; -d defaults to the trace's length)")
+	flags.Float64Var(&traceScale, "rps-trace-scale", 1, "Multiply the rates of --rps-trace by this factor (e.g., 0.1 for a staging environment a tenth the size)")
+	flags.Float64Var(&traceSpeed, "rps-trace-speed", 1, "Play --rps-trace this many times faster (e.g., 24 replays a day in an hour)")
+	flags.StringArrayVar(&chaos, "chaos", []string{}, "Burst or pause the load during the run: burst@AT:DURATION:FACTOR (e.g., burst@2m:10s:3x, needs --max-rps, --arrival-rate or --rps-trace) or pause@AT:DURATION (e.g., pause@4m:5s; can be specified multiple times)")
+	flags.BoolVar(&cacheBust, "cache-bust", false, "Append a unique query parameter to every request to bypass caches")
+	flags.StringVar(&acceptEnc, "accept-encoding", "", "Request compressed responses (comma-separated: gzip, br, deflate) and report compression metrics")
+	flags.StringVar(&compressBody, "compress-body", "", "Compress request bodies and set Content-Encoding (gzip, br, deflate)")
+	flags.StringVar(&bodyFile, "body-file", "", "Stream the request body from a file using chunked transfer encoding")
+	flags.StringVar(&bodySize, "body-size", "", "Stream a generated request body of this size using chunked transfer encoding (e.g., 100MB)")
+	flags.StringVar(&bodyRate, "body-rate", "", "Upload rate for streamed bodies (e.g., 10Mbps, 1MB/s)")
+	flags.BoolVar(&http10, "http1.0", false, "Send requests as HTTP/1.0, one connection per request (targets can force a protocol with \"protocol\": \"1.0\", \"1.1\" or \"2\")")
+	flags.BoolVar(&expectCont, "expect-continue", false, "Send request bodies with Expect: 100-continue and report how long the server takes to ask for them")
+	flags.DurationVar(&expectWait, "expect-continue-timeout", time.Second, "How long to wait for 100 Continue before sending the body anyway")
+	flags.StringVar(&maxBodyBytes, "max-body-bytes", "", "Stop reading each response body after this many bytes (e.g., 1MB)")
+	flags.StringVar(&maxResponse, "max-response-bytes", "", "Abort any response whose body exceeds this size on the wire or decoded (e.g., 50MB) and count it as response_too_large")
+	flags.StringVar(&clientBW, "client-bandwidth", "", "Read response bodies at most this fast per request, like a slow mobile client (e.g., 256kbps, 1Mbps)")
+	flags.DurationVar(&readDelay, "read-delay", 0, "Pause this long before each 4 KiB read of a response body, like a client slow to consume data")
+	flags.BoolVar(&skipBody, "skip-body", false, "Discard response bodies unread to save bandwidth (latency covers headers only)")
+	flags.DurationVar(&reqTimeout, "request-timeout", 0, "Per-request deadline, counted as a timeout error when exceeded (default: 30s client timeout)")
+	flags.DurationSliceVar(&timeoutCands, "timeout-candidates", nil, "Client timeouts to report the share of requests slower than, to pick one from the measured latencies (comma-separated; default 100ms,250ms,500ms,1s,2s,5s,10s,30s)")
+	flags.StringVar(&dnsServer, "dns-server", "", "Resolve host names through this DNS server instead of /etc/resolv.conf, e.g. 1.1.1.1:53 (port defaults to 53)")
+	flags.DurationVar(&grace, "grace", 0, "Let requests in flight at the end of the test finish and be recorded for up to this long")
+	flags.DurationVar(&progressInt, "progress-interval", 500*time.Millisecond, "How often the progress line is refreshed")
+	flags.StringArrayVar(&thresholds, "threshold", []string{}, "Pass/fail condition, e.g. 'p95<300ms', 'error_rate<1%', 'rps>=500' (can be specified multiple times)")
+	flags.Float64Var(&maxErrorRate, "exit-on-error-rate", 0, "Fail (exit code 1) if the error rate exceeds this percentage")
+	flags.BoolVar(&conditional, "conditional", false, "Replay ETag/Last-Modified from first responses as If-None-Match/If-Modified-Since")
+	flags.Int64Var(&seed, "seed", 0, "Seed for randomized behavior such as template randInt/randString (default: random, recorded in the report)")
+	flags.StringVar(&dataFile, "data", "", "CSV file whose columns are available in templates as {{.column}} (first line names the columns)")
+	flags.StringVar(&dataMode, "data-mode", string(runner.FeedSequential), "How data rows are assigned: sequential, worker (one sticky row per worker), random or unique (each row at most once)")
+	flags.StringVar(&dataShard, "data-shard", "", "Use only this generator's share of the data rows, as INDEX/COUNT (e.g., 0/4 for the first of four generators)")
+	flags.BoolVar(&workerHeader, "worker-header", false, "Send an X-G0-Worker header with the worker ID on every request")
+	flags.StringVar(&runID, "run-id", "", "ID of the run in its results, metrics and requests (default: start time and a random suffix, e.g. 20240315-143000-9f2c4a)")
+	flags.BoolVar(&runIDHeader, "run-id-header", false, "Send an X-G0-Run-ID header with the run ID on every request, to find the run's requests in server logs")
+	flags.StringVar(&idemKey, "idempotency-key", "", "Send an Idempotency-Key header with every request: auto (a unique key per request)")
+	flags.StringVar(&trackCreated, "track-created", "", "Record the ID of the resource created by each 2xx response, from header:NAME (e.g., header:Location) or json:PATH (e.g., json:data.id)")
+	flags.StringVar(&cleanupURL, "cleanup-url", "", "After the test, send DELETE to this URL for each tracked ID, e.g. 'https://api.example.com/users/{id}'")
+	flags.StringVar(&cleanupFile, "cleanup-file", "", "Write the tracked IDs to this file, one per line")
+	flags.StringVar(&authURL, "auth-url", "", "Token endpoint to authenticate against; every request carries the token as a bearer token, and a share of the workers re-authenticate periodically")
+	flags.StringVar(&authMethod, "auth-method", "POST", "HTTP method of the --auth-url requests")
+	flags.StringVar(&authBody, "auth-body", "", "Body of the --auth-url requests, e.g. 'grant_type=client_credentials&client_id=...'")
+	flags.StringArrayVar(&authHeaders, "auth-header", []string{}, "Header of the --auth-url requests, e.g. 'Content-Type: application/x-www-form-urlencoded' (can be specified multiple times)")
+	flags.StringVar(&authField, "auth-token-field", runner.DefaultAuthTokenField, "Field of the --auth-url JSON response holding the token (dot-separated path, e.g. data.token)")
+	flags.DurationVar(&authInterval, "auth-refresh-interval", time.Minute, "How often a refreshing worker re-authenticates against --auth-url")
+	flags.Float64Var(&authPercent, "auth-refresh-percent", 10, "Percentage of the workers that re-authenticate; the others keep using the cached token")
+	flags.StringVar(&httpAuth, "http-auth", "", "Answer the target's authentication challenges: digest, ntlm (with --http-auth-user) or negotiate (Kerberos)")
+	flags.StringVar(&httpAuthUser, "http-auth-user", "", "User name for --http-auth; for NTLM as DOMAIN\\user or user@domain, for a Kerberos keytab as user or user@REALM")
+	flags.StringVar(&httpAuthPass, "http-auth-password", "", "Password for --http-auth (default: $G0_HTTP_AUTH_PASSWORD)")
+	flags.StringVar(&httpAuthTab, "http-auth-keytab", "", "Keytab of --http-auth-user for --http-auth negotiate (default: use the ticket cache)")
+	flags.StringVar(&httpAuthCC, "http-auth-ccache", "", "Kerberos ticket cache for --http-auth negotiate (default: $KRB5CCNAME or /tmp/krb5cc_<uid>)")
+	flags.StringVar(&httpAuthConf, "krb5-config", "", "Kerberos configuration for --http-auth negotiate (default: $KRB5_CONFIG or /etc/krb5.conf)")
+	flags.StringVar(&httpAuthSPN, "http-auth-spn", "", "Service principal to request tickets for with --http-auth negotiate (default: HTTP/<host>)")
+	flags.StringVar(&certDir, "client-cert-dir", "", "Directory of client certificates for mutual TLS (NAME.crt or NAME.pem, key in the same file or NAME.key); each worker or request presents a different one")
+	flags.StringVar(&certMode, "client-cert-mode", runner.ClientCertPerWorker, "Client certificate strategy: worker (fixed per worker) or request (rotated per request)")
+	flags.StringVar(&sniFile, "sni-list", "", "File of server names, one per line; each request presents the next one in SNI and the Host header, against the address of the URL")
+	flags.BoolVar(&noResumption, "no-tls-resumption", false, "Make every new connection do a full TLS handshake instead of resuming an earlier session")
+	flags.BoolVar(&needStaple, "require-ocsp-staple", false, "Fail the requests of connections whose server staples no OCSP response to its certificate")
+	flags.StringVar(&traceProp, "trace-propagation", "", "Send trace context headers with every request: w3c, b3 or w3c,b3 (tagged with the run ID in tracestate)")
+	flags.StringVar(&traceLink, "trace-link", "", "URL template for linking reported traces, e.g. 'https://tracing.example.com/trace/{trace_id}'")
+	flags.StringVar(&spoofHeader, "spoof-client-ip-header", "", "Send a synthetic client address in this header (e.g., X-Forwarded-For)")
+	flags.StringVar(&spoofMode, "spoof-client-ip", runner.ClientIPRandom, "Client address strategy: random (per request) or worker (fixed per worker)")
+	flags.StringVar(&spoofCIDR, "spoof-client-ip-cidr", "", "Draw client addresses from this network, e.g. 203.0.113.0/24 (default: public IPv4 space)")
+	flags.StringVar(&proxyProto, "proxy-protocol", "", "Prepend a PROXY protocol header (v1 or v2) to each new connection, as a load balancer would")
+	flags.StringVar(&proxySource, "proxy-protocol-source", runner.ProxySourceRandom, "Client address in the PROXY protocol header: random (public IPv4 per connection), local (the real one), an address, or a network to draw from (e.g., 203.0.113.0/24)")
+	flags.StringVar(&canaryURL, "canary-url", "", "Send a share of the requests to this base URL (same path and query) and compare it with the baseline, e.g. https://canary.example.com")
+	flags.Float64Var(&canaryPct, "canary-percent", 5, "Percentage of requests sent to --canary-url")
+	flags.StringVar(&mirrorURL, "mirror", "", "Duplicate every request to this base URL (same path and query), e.g. http://shadow.example.com")
+	flags.StringVar(&mirrorMode, "mirror-mode", runner.MirrorMeasure, "How mirrored responses are handled: measure (compare with the target) or forget (discard unread)")
+	flags.StringVar(&healthURL, "health-url", "", "Health endpoint checked before the test starts and polled during it; downtime is reported")
+	flags.DurationVar(&healthInt, "health-interval", time.Second, "How often --health-url is checked during the test")
+	flags.BoolVar(&healthPause, "health-pause", false, "Pause load while --health-url is failing")
+	flags.StringVar(&metricsURL, "metrics-url", "", "Prometheus endpoint on the target (e.g., node_exporter) to scrape for CPU and memory usage")
+	flags.DurationVar(&metricsInt, "metrics-interval", 5*time.Second, "How often --metrics-url is scraped")
+	flags.StringVar(&schemaFile, "response-schema", "", "JSON Schema file that successful response bodies are validated against; violations are counted separately")
+	flags.Float64Var(&schemaSample, "schema-sample", 100, "Percentage of requests whose response is validated against --response-schema")
+	flags.StringArrayVar(&bodySHA256, "expect-body-sha256", []string{}, "Fail successful responses whose body doesn't have this SHA-256 (hex, as printed by sha256sum; can be specified multiple times to allow several bodies)")
+	flags.BoolVar(&countBodies, "body-cardinality", false, "Hash successful response bodies and report how many distinct ones each target returned (reveals a cache serving one response to everyone)")
+	flags.StringVar(&rangeSize, "range-size", "", "Send requests for a random byte range of this size (e.g., 1MB) and verify the 206 responses")
+	flags.Float64Var(&rangePct, "range-percent", 100, "Percentage of requests sent as range requests with --range-size; the rest fetch the full object")
+	flags.StringVar(&sloFile, "slo", "", "YAML file with SLOs (e.g., 99% of requests within 300ms); reports error-budget burn and fails the run if an SLO is missed")
+	flags.StringArrayVar(&captureHdrs, "capture-header", []string{}, "Record this response header (e.g., X-Cache, Server) and report its value distribution (can be specified multiple times)")
+	flags.StringVar(&startAt, "start-at", "", "Wait until this time before starting, e.g. 14:00:00 (local time today) or 2024-01-02T14:00:00Z, so several generators start together")
+	flags.DurationVar(&startAfter, "start-after", 0, "Wait this long before starting (e.g., 30s)")
+	flags.StringVar(&ntpServer, "ntp-server", runner.DefaultNTPServer, "NTP server used to check the local clock before a scheduled start (empty = skip the check)")
+	flags.Float64Var(&warnErrRate, "warn-error-rate", 0, "Print a warning as soon as the error rate over the last 10s exceeds this percentage (0 = off)")
+	flags.BoolVar(&warnBell, "warn-bell", false, "Ring the terminal bell with --warn-error-rate warnings")
+	flags.IntVar(&coldRequests, "cold-requests", 0, "Report the first N requests of each worker (cold caches, connection setup, server warmup) separately from the steady state (0 = off)")
+	flags.IntVar(&cpus, "cpus", 0, "Number of CPUs g0 uses (GOMAXPROCS; 0 = all, or all of --cpu-affinity)")
+	flags.StringVar(&cpuAffinity, "cpu-affinity", "", "Pin g0 to these CPUs, e.g. 0-3,8 (Linux only), keeping it off cores used by the target or other processes")
+	flags.BoolVar(&shardWorkers, "shard-workers", false, "Split the workers into one shard per CPU, each with its own connection pool and results collector, to cut contention at extreme request rates")
+	flags.IntVar(&procs, "procs", 1, "Run the test in this many g0 processes, splitting the concurrency, --max-rps and --arrival-rate between them, and merge their results")
+	flags.BoolVar(&procChild, "proc-child", false, "Run as one of the --procs processes (set by g0)")
+	flags.MarkHidden("proc-child")
+	flags.BoolVar(&audit, "audit", false, "Cross-check the request counts of the rate limiter, workers, stats collector and record file and print a reconciliation table")
+	flags.StringVar(&auditHeader, "audit-counter-header", "", "Response header carrying the target's running request count, compared with g0's counts by --audit (e.g., X-Request-Count)")
+	flags.IntVar(&churnRate, "churn-rate", 0, "Open and close this many extra connections per second to the first target alongside the load, to stress connection handling on proxies and load balancers (0 = off)")
+	flags.StringVar(&promListen, "prometheus-listen", "", "Serve live run metrics for Prometheus on this address during the test (e.g., :9464); see g0 export grafana-dashboard")
+	flags.StringVar(&recordFile, "record", "", "Write one record per request (timestamp, method, URL, status, latency, bytes, error) to this file")
+	flags.StringVar(&recordFormat, "record-format", "", "Format of --record: jsonl or parquet (default: parquet for .parquet files, otherwise jsonl)")
+	flags.StringVar(&errorLogFile, "error-log", "", "Write every failed request (timestamp, URL, error class, message, latency) to this NDJSON file, created on the first failure (default: results/g0-errors-<run ID>.ndjson)")
+	flags.StringVar(&errorLogMax, "error-log-max-size", "100MB", "Size at which the error log is moved to <file>.1 and a new one started (0 = no limit)")
+	flags.BoolVar(&noErrorLog, "no-error-log", false, "Don't write failed requests to an error log")
+	flags.StringVar(&recordMax, "record-max-size", "0", "Size at which the --record file is moved to <file>.1 and a new one started (e.g., 1GB; 0 = no limit)")
+	flags.DurationVar(&rotateEvery, "rotate-interval", 0, "Also rotate the --record file and error log when they are this old (e.g., 1h; 0 = only by size)")
+	flags.IntVar(&rotateKeep, "rotate-keep", runner.DefaultRotateKeep, "Rotated segments of the --record file and error log to keep; older ones are deleted (0 = keep all)")
+	flags.BoolVar(&rotateGzip, "rotate-gzip", false, "Compress rotated segments of the --record file and error log to <file>.N.gz")
+	flags.StringVar(&checkpointTo, "checkpoint", "", "Save the run's flags and results so far to this file every --checkpoint-interval, so --resume can continue it after a crash")
+	flags.DurationVar(&checkpointIn, "checkpoint-interval", time.Minute, "How often --checkpoint saves the run")
+	flags.StringVar(&resumeFile, "resume", "", "Continue the run saved in this --checkpoint file for the rest of its duration, with its flags (flags given here win), and report the whole run")
+	flags.StringVar(&maxMemory, "max-memory", "", "Memory budget of g0 (e.g., 2GB): nearing it, the per-request record is stopped and latency samples are thinned rather than g0 being OOM-killed (default: no budget)")
+	flags.StringVar(&configFile, "config", "", "Load flags from a YAML config file (keys are flag names)")
+}
+
+// runPlan is a validated load test configuration
+type runPlan struct {
+	config     runner.Config
+	urls       []string // All target URLs, for display and reporting
+	workers    int      // Workers in total, including those dedicated to targets
+	headers    map[string]string
+	duration   time.Duration
+	thresholds []runner.Threshold
+	startAt    time.Time          // Wall-clock start (zero = start immediately)
+	cpus       int                // GOMAXPROCS for the run (0 = unchanged)
+	affinity   []int              // CPUs the process is pinned to (nil = unchanged)
+	template   *template.Template // Layout of the text report (nil = built-in report)
+	saveFormat string             // Format the results are saved in ("" = not saved)
+	redaction  printer.Redaction  // Headers kept out of saved results
+	checkpoint *checkpointSaver   // Saves the run for --resume (nil = no checkpoints)
+	resumed    *checkpoint        // Checkpoint the run continues (nil = a new run)
+}
+
+// prepareRun applies config files and validates the run flags without sending any requests
+func prepareRun(flags *pflag.FlagSet) (*runPlan, error) {
+	// A resumed run takes the flags of its checkpoint; explicit flags win
+	var resumed *checkpoint
+	durationGiven := flags.Changed("duration")
+	if resumeFile != "" {
+		var err error
+		if resumed, err = loadCheckpoint(resumeFile); err != nil {
+			return nil, err
+		}
+		if err := resumed.Flags.Apply(flags); err != nil {
+			return nil, fmt.Errorf("checkpoint %s: %w", resumeFile, err)
+		}
+	}
+
+	// Fill in flags from the config file and profile; explicit flags win
+	if err := applyConfigFiles(flags); err != nil {
+		return nil, err
+	}
+
 	// Parse duration
-	testDuration, err := time.ParseDuration(duration)
+	testDuration, err := parseHumanDuration(duration)
 	if err != nil {
-		return fmt.Errorf("invalid duration format: %w", err)
+		return nil, err
+	}
+	if testDuration <= 0 {
+		return nil, fmt.Errorf("duration must be greater than 0")
+	}
+
+	// Load the RPS trace; without an explicit duration the run plays all of it
+	var shape *runner.RPSTrace
+	if rpsTrace != "" {
+		if shape, err = runner.LoadRPSTrace(rpsTrace, traceScale, traceSpeed); err != nil {
+			return nil, err
+		}
+		if !flags.Changed("duration") {
+			testDuration = shape.Length()
+		}
+	} else if flags.Changed("rps-trace-scale") || flags.Changed("rps-trace-speed") {
+		return nil, fmt.Errorf("--rps-trace-scale and --rps-trace-speed require --rps-trace")
+	}
+
+	// Load per-target overrides if a targets file was given
+	var targets []runner.Target
+	if targetsFile != "" {
+		targets, err = runner.LoadTargets(targetsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Replay a literal request to the address of the one URL
+	if rawRequest != "" {
+		if len(urls) != 1 || targetsFile != "" {
+			return nil, fmt.Errorf("--raw-request requires exactly one --url (the scheme and address to send it to) and no --targets")
+		}
+		// The file's request goes out unchanged, so flags that add headers, rewrite
+		// the URL or check the response against a request of their own don't apply
+		for _, name := range []string{
+			"method", "body", "headers", "body-file", "body-size", "compress-body", "expect-continue", "http1.0", "cache-bust", "conditional", "http-auth",
+			"worker-header", "run-id-header", "idempotency-key", "trace-propagation", "spoof-client-ip-header", "accept-encoding", "auth-url", "sni-list", "range-size",
+		} {
+			if flags.Changed(name) {
+				return nil, fmt.Errorf("--%s cannot be combined with --raw-request, which sends the file's request as written", name)
+			}
+		}
+		raw, err := runner.LoadRawRequest(rawRequest, urls[0])
+		if err != nil {
+			return nil, err
+		}
+		urls, targets, method = nil, []runner.Target{raw}, raw.Method
 	}
 
 	// Validate URLs
-	if len(urls) == 0 {
-		return fmt.Errorf("at least one URL is required (use --url or -u)")
+	if len(urls) == 0 && len(targets) == 0 {
+		return nil, fmt.Errorf("at least one URL is required (use --url, -u or --targets)")
+	}
+	allURLs := append(append([]string{}, urls...), runner.TargetURLs(targets)...)
+	for _, u := range allURLs {
+		if err := runner.ValidateURL(u); err != nil {
+			return nil, err
+		}
+	}
+
+	// Workers either take the targets in turn or each keep one of them
+	strategy, err := runner.ParseURLStrategy(strings.ToLower(urlStrategy))
+	if err != nil {
+		return nil, err
+	}
+	shared := len(urls)
+	for _, t := range targets {
+		if t.Concurrency == 0 {
+			shared++
+		}
+	}
+	if strategy == runner.StrategySticky && concurrency < shared {
+		fmt.Fprintf(os.Stderr, "Warning: %d workers are pinned to %d URLs with --url-strategy sticky; %d URLs get no requests\n", concurrency, shared, shared-concurrency)
+	}
+
+	// Load template variables and check templates before any request is sent
+	var data *runner.DataFeeder
+	if dataFile != "" {
+		if data, err = runner.LoadCSV(dataFile, runner.FeedMode(strings.ToLower(dataMode))); err != nil {
+			return nil, err
+		}
+		if dataShard != "" {
+			index, count, err := parseShard(dataShard)
+			if err != nil {
+				return nil, err
+			}
+			if err := data.Shard(index, count); err != nil {
+				return nil, err
+			}
+		}
+	} else if dataShard != "" {
+		return nil, fmt.Errorf("--data-shard requires --data")
+	}
+	// Parse target outages and check that each pool keeps a target to send to
+	var outages []runner.TargetOutage
+	for _, s := range targetDown {
+		outage, err := runner.ParseTargetOutage(s)
+		if err != nil {
+			return nil, err
+		}
+		if outage.From >= testDuration {
+			return nil, fmt.Errorf("target outage %q starts after the test ends (%s)", s, testDuration)
+		}
+		outages = append(outages, outage)
+	}
+	if err := runner.ValidateTargetOutages(outages, urls, targets); err != nil {
+		return nil, err
+	}
+
+	// The hash strategy routes each request by a column of its data row
+	switch {
+	case strategy == runner.StrategyHash && hashKey == "":
+		return nil, fmt.Errorf("--url-strategy hash requires --hash-key")
+	case hashKey != "" && strategy != runner.StrategyHash:
+		return nil, fmt.Errorf("--hash-key requires --url-strategy hash")
+	case hashKey != "" && data == nil:
+		return nil, fmt.Errorf("--hash-key requires --data")
+	case hashKey != "" && !data.HasColumn(hashKey):
+		return nil, fmt.Errorf("--hash-key %q is not a column of %s (columns: %s)", hashKey, dataFile, strings.Join(data.Columns(), ", "))
+	}
+	// --body - reads the payload from a pipe, e.g. python gen.py | g0 run --body - ...
+	requestBody, err := readBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTemplates(requestBody, headers, targets, data, 0); err != nil {
+		return nil, err
 	}
 
 	// Validate concurrency
 	if concurrency <= 0 {
-		return fmt.Errorf("concurrency must be greater than 0")
+		return nil, fmt.Errorf("concurrency must be greater than 0")
+	}
+	if users < 0 {
+		return nil, fmt.Errorf("users must be greater than or equal to 0")
+	}
+	if users > 0 && runner.PinnedTargets(targets) {
+		return nil, fmt.Errorf("--users cannot be combined with targets that set their own concurrency")
+	}
+	if users > 0 && users < concurrency {
+		return nil, fmt.Errorf("users (%d) must be at least the concurrency (%d); -c sets the number of workers the users share", users, concurrency)
+	}
+	if flags.Changed("think-time") && users == 0 {
+		return nil, fmt.Errorf("--think-time requires --users")
+	}
+	if thinkTime < 0 {
+		return nil, fmt.Errorf("think-time must be greater than or equal to 0")
 	}
 
 	// Parse headers
-	headerMap := make(map[string]string)
-	for _, h := range headers {
-		parts := strings.SplitN(h, ":", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid header format: %s (expected 'Key: Value')", h)
+	headerMap, err := parseHeaders(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate requested encodings
+	var encodings []string
+	for _, e := range strings.Split(acceptEnc, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if !isSupportedEncoding(e) {
+			return nil, fmt.Errorf("unsupported encoding %q (supported: %s)", e, strings.Join(httpclient.SupportedEncodings, ", "))
+		}
+		encodings = append(encodings, e)
+	}
+
+	// Validate request body compression
+	compressBody = strings.ToLower(strings.TrimSpace(compressBody))
+	if compressBody != "" && !isSupportedEncoding(compressBody) {
+		return nil, fmt.Errorf("unsupported body compression %q (supported: %s)", compressBody, strings.Join(httpclient.SupportedEncodings, ", "))
+	}
+
+	// Configure streamed request bodies
+	var bodySource httpclient.BodySource
+	switch {
+	case bodyFile != "" && bodySize != "":
+		return nil, fmt.Errorf("--body-file and --body-size cannot be used together")
+	case bodyFile != "":
+		if _, err := os.Stat(bodyFile); err != nil {
+			return nil, fmt.Errorf("invalid body file: %w", err)
+		}
+		bodySource = httpclient.FileBody{Path: bodyFile}
+	case bodySize != "":
+		size, err := parseByteSize(bodySize)
+		if err != nil {
+			return nil, err
+		}
+		bodySource = httpclient.GeneratedBody{Size: size}
+	}
+	if bodySource != nil && (requestBody != "" || compressBody != "") {
+		return nil, fmt.Errorf("--body and --compress-body cannot be combined with a streamed body")
+	}
+	var uploadRate int64
+	if bodyRate != "" {
+		if bodySource == nil {
+			return nil, fmt.Errorf("--body-rate requires --body-file or --body-size")
+		}
+		if uploadRate, err = parseBitrate(bodyRate); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate Expect: 100-continue
+	var expectContinue time.Duration
+	if expectCont {
+		if expectWait <= 0 {
+			return nil, fmt.Errorf("--expect-continue-timeout must be positive")
+		}
+		expectContinue = expectWait
+	} else if flags.Changed("expect-continue-timeout") {
+		return nil, fmt.Errorf("--expect-continue-timeout requires --expect-continue")
+	}
+
+	var protocol string
+	if http10 {
+		if bodySource != nil || expectCont {
+			return nil, fmt.Errorf("--http1.0 cannot be combined with --body-file, --body-size or --expect-continue")
+		}
+		protocol = httpclient.ProtocolHTTP10
+	}
+
+	// Parse response body read limit
+	var bodyLimit int64
+	if maxBodyBytes != "" {
+		if bodyLimit, err = parseByteSize(maxBodyBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	if skipBody && (bodyLimit > 0 || acceptEnc != "") {
+		return nil, fmt.Errorf("--skip-body cannot be combined with --max-body-bytes or --accept-encoding")
+	}
+
+	// Parse the response size limit, which guards the generator against huge
+	// responses and decompression bombs
+	var responseLimit int64
+	if maxResponse != "" {
+		if responseLimit, err = parseByteSize(maxResponse); err != nil {
+			return nil, err
+		}
+		if skipBody {
+			return nil, fmt.Errorf("--skip-body cannot be combined with --max-response-bytes")
 		}
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		headerMap[key] = value
 	}
 
-	// Print logo
-	printer.PrintLogo()
+	// Parse slow client simulation
+	var readRate int64
+	if clientBW != "" {
+		if readRate, err = parseBitrate(clientBW); err != nil {
+			return nil, err
+		}
+	}
+	if readDelay < 0 {
+		return nil, fmt.Errorf("read-delay must be greater than or equal to 0")
+	}
+	if skipBody && (readRate > 0 || readDelay > 0) {
+		return nil, fmt.Errorf("--skip-body cannot be combined with --client-bandwidth or --read-delay")
+	}
+
+	// Track created resources for cleanup
+	var created *runner.ResourceTracker
+	if trackCreated != "" {
+		if created, err = runner.NewResourceTracker(trackCreated, cleanupURL, cleanupFile); err != nil {
+			return nil, err
+		}
+		if skipBody && strings.HasPrefix(trackCreated, "json:") {
+			return nil, fmt.Errorf("--skip-body cannot be combined with --track-created json:...")
+		}
+	} else if cleanupURL != "" || cleanupFile != "" {
+		return nil, fmt.Errorf("--cleanup-url and --cleanup-file require --track-created")
+	}
+
+	// Authenticate against a token endpoint, refreshing from a share of the workers
+	var auth *runner.AuthRefresh
+	if authURL != "" {
+		authHeaderMap, err := parseHeaders(authHeaders)
+		if err != nil {
+			return nil, err
+		}
+		if auth, err = runner.NewAuthRefresh(authURL, authMethod, authBody, authHeaderMap, authField, authInterval, authPercent); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, name := range []string{"auth-method", "auth-body", "auth-header", "auth-token-field", "auth-refresh-interval", "auth-refresh-percent"} {
+			if flags.Changed(name) {
+				return nil, fmt.Errorf("--%s requires --auth-url", name)
+			}
+		}
+	}
+
+	// Answer Digest, NTLM or Negotiate challenges of legacy and intranet services
+	var challengeAuth httpclient.Authenticator
+	if httpAuth != "" {
+		if authURL != "" {
+			return nil, fmt.Errorf("--http-auth cannot be combined with --auth-url")
+		}
+		creds := httpclient.Credentials{
+			Username:   httpAuthUser,
+			Password:   httpAuthPass,
+			Keytab:     httpAuthTab,
+			CCache:     httpAuthCC,
+			Krb5Config: httpAuthConf,
+			SPN:        httpAuthSPN,
+		}
+		if !flags.Changed("http-auth-password") {
+			creds.Password = os.Getenv("G0_HTTP_AUTH_PASSWORD")
+		}
+		kerberos := strings.EqualFold(httpAuth, httpclient.AuthNegotiate)
+		for _, name := range []string{"http-auth-keytab", "http-auth-ccache", "krb5-config", "http-auth-spn"} {
+			if flags.Changed(name) && !kerberos {
+				return nil, fmt.Errorf("--%s requires --http-auth %s", name, httpclient.AuthNegotiate)
+			}
+		}
+		if httpAuthTab != "" && httpAuthCC != "" {
+			return nil, fmt.Errorf("--http-auth-keytab and --http-auth-ccache cannot be used together")
+		}
+		if challengeAuth, err = httpclient.NewAuthenticator(httpAuth, creds); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, name := range []string{"http-auth-user", "http-auth-password", "http-auth-keytab", "http-auth-ccache", "krb5-config", "http-auth-spn"} {
+			if flags.Changed(name) {
+				return nil, fmt.Errorf("--%s requires --http-auth", name)
+			}
+		}
+	}
+
+	// Present a pool of client certificates for mutual TLS
+	var clientCerts *runner.ClientCertPool
+	if certDir != "" {
+		if clientCerts, err = runner.NewClientCertPool(certDir, certMode); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("client-cert-mode") {
+		return nil, fmt.Errorf("--client-cert-mode requires --client-cert-dir")
+	}
+
+	// Present the server names of a list against the target's address
+	var sni *runner.SNIList
+	if sniFile != "" {
+		if sni, err = runner.LoadSNIList(sniFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if reqTimeout < 0 {
+		return nil, fmt.Errorf("request-timeout must be greater than or equal to 0")
+	}
+	for _, t := range timeoutCands {
+		if t <= 0 {
+			return nil, fmt.Errorf("timeout candidates must be greater than 0")
+		}
+	}
+	var resolver string
+	if dnsServer != "" {
+		if resolver, err = httpclient.ParseDNSServer(dnsServer); err != nil {
+			return nil, err
+		}
+	}
+	if grace < 0 {
+		return nil, fmt.Errorf("grace must be greater than or equal to 0")
+	}
+	if progressInt <= 0 {
+		return nil, fmt.Errorf("progress-interval must be greater than 0")
+	}
+
+	// Set up trace context propagation
+	var trace *runner.TracePropagation
+	if traceProp != "" {
+		if trace, err = runner.NewTracePropagation(traceProp); err != nil {
+			return nil, err
+		}
+		trace.LinkTemplate = traceLink
+	} else if traceLink != "" {
+		return nil, fmt.Errorf("--trace-link requires --trace-propagation")
+	}
+
+	// Idempotency keys are generated; a fixed key would make the target replay one response
+	if idemKey != "" && idemKey != "auto" {
+		return nil, fmt.Errorf("invalid idempotency key mode %q (supported: auto)", idemKey)
+	}
+
+	// Set up synthetic client addresses
+	var clientIP *runner.ClientIPSpoofer
+	if spoofHeader != "" {
+		if clientIP, err = runner.NewClientIPSpoofer(spoofHeader, spoofMode, spoofCIDR); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("spoof-client-ip") || spoofCIDR != "" {
+		return nil, fmt.Errorf("--spoof-client-ip and --spoof-client-ip-cidr require --spoof-client-ip-header")
+	}
+
+	// Claim client addresses in a PROXY protocol header
+	var proxyProtocol *httpclient.ProxyProtocol
+	if proxyProto != "" {
+		if proxyProtocol, err = runner.NewProxyProtocol(proxyProto, proxySource); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("proxy-protocol-source") {
+		return nil, fmt.Errorf("--proxy-protocol-source requires --proxy-protocol")
+	}
+
+	// Set up the canary target
+	var canary *runner.Canary
+	if canaryURL != "" {
+		if canary, err = runner.NewCanary(canaryURL, canaryPct); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("canary-percent") {
+		return nil, fmt.Errorf("--canary-percent requires --canary-url")
+	}
+
+	// Set up the mirror target
+	var mirror *runner.Mirror
+	if mirrorURL != "" {
+		if canary != nil {
+			return nil, fmt.Errorf("--mirror cannot be combined with --canary-url")
+		}
+		if mirror, err = runner.NewMirror(mirrorURL, mirrorMode); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("mirror-mode") {
+		return nil, fmt.Errorf("--mirror-mode requires --mirror")
+	}
+
+	// Configure health checks
+	var healthCheck *runner.HealthCheck
+	if healthURL != "" {
+		if err := runner.ValidateURL(healthURL); err != nil {
+			return nil, fmt.Errorf("invalid health URL: %w", err)
+		}
+		if healthInt <= 0 {
+			return nil, fmt.Errorf("health-interval must be greater than 0")
+		}
+		healthCheck = &runner.HealthCheck{URL: healthURL, Interval: healthInt, Pause: healthPause}
+	} else if healthPause {
+		return nil, fmt.Errorf("--health-pause requires --health-url")
+	}
+
+	// Validate target metrics scraping
+	if metricsURL != "" {
+		if err := runner.ValidateURL(metricsURL); err != nil {
+			return nil, fmt.Errorf("invalid metrics URL: %w", err)
+		}
+		if metricsInt <= 0 {
+			return nil, fmt.Errorf("metrics-interval must be greater than 0")
+		}
+	}
+
+	// Load SLO definitions
+	var slos []runner.SLO
+	if sloFile != "" {
+		if slos, err = runner.LoadSLOs(sloFile); err != nil {
+			return nil, err
+		}
+	}
 
-	// Print test configuration
-	printer.PrintTestStart(urls, concurrency, testDuration)
+	// Load the response schema
+	var schema *runner.SchemaCheck
+	if schemaFile != "" {
+		if skipBody {
+			return nil, fmt.Errorf("--skip-body cannot be combined with --response-schema")
+		}
+		if schema, err = runner.LoadSchemaCheck(schemaFile, schemaSample); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("schema-sample") {
+		return nil, fmt.Errorf("--schema-sample requires --response-schema")
+	}
+
+	// Parse the expected response body hashes
+	var bodyHashes runner.BodyHashes
+	if len(bodySHA256) > 0 {
+		if skipBody || bodyLimit > 0 {
+			return nil, fmt.Errorf("--expect-body-sha256 cannot be combined with --skip-body or --max-body-bytes")
+		}
+		if bodyHashes, err = runner.ParseBodyHashes(bodySHA256); err != nil {
+			return nil, fmt.Errorf("invalid --expect-body-sha256: %w", err)
+		}
+	}
+
+	if countBodies && skipBody {
+		return nil, fmt.Errorf("--body-cardinality cannot be combined with --skip-body")
+	}
+
+	// Set up byte-range requests
+	var ranges *runner.RangeRequests
+	if rangeSize != "" {
+		if len(bodyHashes) > 0 {
+			return nil, fmt.Errorf("--range-size cannot be combined with --expect-body-sha256")
+		}
+		size, err := parseByteSize(rangeSize)
+		if err != nil {
+			return nil, err
+		}
+		if ranges, err = runner.NewRangeRequests(size, rangePct); err != nil {
+			return nil, err
+		}
+	} else if flags.Changed("range-percent") {
+		return nil, fmt.Errorf("--range-percent requires --range-size")
+	}
+
+	captureHeaders, err := runner.ParseCaptureHeaders(captureHdrs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --capture-header: %w", err)
+	}
+
+	// Validate per-request recording
+	switch {
+	case recordFormat != "" && recordFile == "":
+		return nil, fmt.Errorf("--record-format requires --record")
+	case recordFormat != "" && recordFormat != runner.RecordJSONL && recordFormat != runner.RecordParquet:
+		return nil, fmt.Errorf("invalid record format %q (available: %s, %s)", recordFormat, runner.RecordJSONL, runner.RecordParquet)
+	}
+
+	// Resolve the scheduled start
+	var scheduledStart time.Time
+	switch {
+	case startAt != "" && startAfter > 0:
+		return nil, fmt.Errorf("--start-at and --start-after cannot be combined")
+	case startAt != "":
+		if scheduledStart, err = runner.ParseStartAt(startAt, time.Now()); err != nil {
+			return nil, err
+		}
+	case startAfter < 0:
+		return nil, fmt.Errorf("--start-after must not be negative")
+	case startAfter > 0:
+		scheduledStart = time.Now().Add(startAfter)
+	}
+
+	// Parse thresholds; --exit-on-error-rate is shorthand for an error_rate threshold
+	var parsedThresholds []runner.Threshold
+	for _, expr := range thresholds {
+		t, err := runner.ParseThreshold(expr)
+		if err != nil {
+			return nil, err
+		}
+		parsedThresholds = append(parsedThresholds, t)
+	}
+	if flags.Changed("exit-on-error-rate") {
+		if maxErrorRate < 0 || maxErrorRate > 100 {
+			return nil, fmt.Errorf("exit-on-error-rate must be between 0 and 100")
+		}
+		t, _ := runner.ParseThreshold(fmt.Sprintf("error_rate<=%g%%", maxErrorRate))
+		parsedThresholds = append(parsedThresholds, t)
+	}
+
+	if warnErrRate < 0 || warnErrRate > 100 {
+		return nil, fmt.Errorf("warn-error-rate must be between 0 and 100")
+	}
+	if warnBell && warnErrRate == 0 {
+		return nil, fmt.Errorf("--warn-bell requires --warn-error-rate")
+	}
+
+	if coldRequests < 0 {
+		return nil, fmt.Errorf("cold-requests must be greater than or equal to 0")
+	}
+
+	if churnRate < 0 || churnRate > maxChurnRate {
+		return nil, fmt.Errorf("churn-rate must be between 0 and %d", maxChurnRate)
+	}
+
+	// Validate CPU tuning; it is applied when the run starts
+	var affinity []int
+	if cpuAffinity != "" {
+		if affinity, err = runner.ParseCPUList(cpuAffinity); err != nil {
+			return nil, err
+		}
+	}
+	if cpus < 0 {
+		return nil, fmt.Errorf("cpus must be greater than or equal to 0")
+	}
+	if affinity != nil && cpus > len(affinity) {
+		return nil, fmt.Errorf("--cpus (%d) exceeds the %d CPUs of --cpu-affinity", cpus, len(affinity))
+	}
+	maxProcs := cpus
+	if maxProcs == 0 && affinity != nil {
+		maxProcs = len(affinity)
+	}
 
 	// Validate max RPS if specified
 	if maxRPS < 0 {
-		return fmt.Errorf("max-rps must be greater than or equal to 0")
+		return nil, fmt.Errorf("max-rps must be greater than or equal to 0")
+	}
+
+	// Validate the arrival rate; it replaces the rate limit, and users bring their own pacing
+	switch {
+	case arrivalRate < 0:
+		return nil, fmt.Errorf("arrival-rate must be greater than or equal to 0")
+	case arrivalRate > 0 && maxRPS > 0:
+		return nil, fmt.Errorf("--arrival-rate cannot be combined with --max-rps")
+	case arrivalRate > 0 && users > 0:
+		return nil, fmt.Errorf("--arrival-rate cannot be combined with --users")
+	case shape != nil && (maxRPS > 0 || arrivalRate > 0):
+		return nil, fmt.Errorf("--rps-trace sets the arrival rate and cannot be combined with --max-rps or --arrival-rate")
+	case shape != nil && users > 0:
+		return nil, fmt.Errorf("--rps-trace cannot be combined with --users")
+	}
+
+	// Parse chaos events; bursts multiply the rate, so one must be set
+	var chaosEvents []runner.ChaosEvent
+	for _, s := range chaos {
+		event, err := runner.ParseChaosEvent(s)
+		if err != nil {
+			return nil, err
+		}
+		if event.At >= testDuration {
+			return nil, fmt.Errorf("chaos event %q starts after the test ends (%s)", s, testDuration)
+		}
+		chaosEvents = append(chaosEvents, event)
+	}
+	if err := runner.ValidateChaos(chaosEvents, maxRPS > 0 || arrivalRate > 0 || shape != nil); err != nil {
+		return nil, err
+	}
+
+	// Validate generator processes; each one needs a worker and a share of the rate
+	if procs < 1 {
+		return nil, fmt.Errorf("procs must be at least 1")
+	}
+	if procs > 1 {
+		switch {
+		case concurrency < procs:
+			return nil, fmt.Errorf("--procs (%d) exceeds the concurrency (%d)", procs, concurrency)
+		case runner.PinnedTargets(targets):
+			return nil, fmt.Errorf("--procs cannot be combined with targets that set their own concurrency")
+		case maxRPS > 0 && maxRPS < procs:
+			return nil, fmt.Errorf("--procs (%d) exceeds --max-rps (%d)", procs, maxRPS)
+		case arrivalRate > 0 && arrivalRate < procs:
+			return nil, fmt.Errorf("--procs (%d) exceeds --arrival-rate (%d)", procs, arrivalRate)
+		case recordFile != "":
+			return nil, fmt.Errorf("--procs cannot be combined with --record")
+		case promListen != "":
+			return nil, fmt.Errorf("--procs cannot be combined with --prometheus-listen")
+		case data != nil && data.Mode() == runner.FeedUnique && dataShard != "":
+			return nil, fmt.Errorf("--procs cannot be combined with --data-shard")
+		case reportTmpl != "":
+			return nil, fmt.Errorf("--procs cannot be combined with --report-template")
+		case checkpointTo != "" || resumed != nil:
+			return nil, fmt.Errorf("--procs cannot be combined with --checkpoint or --resume")
+		}
+	}
+	if procChild {
+		// The parent process checks the thresholds on the merged result
+		parsedThresholds = nil
+	}
+
+	if auditHeader != "" && !audit {
+		return nil, fmt.Errorf("--audit-counter-header requires --audit")
+	}
+
+	// The run ID names files and goes into headers and metric labels
+	id := runID
+	if id == "" {
+		id = runner.NewRunID()
+	} else if strings.Trim(id, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-") != "" {
+		return nil, fmt.Errorf("--run-id may only contain letters, digits, '.', '_' and '-'")
+	}
+	if trace != nil {
+		// Traces are tagged with the same ID as the rest of the run's artifacts
+		trace.RunID = id
+	}
+
+	// Failed requests go to an error log named after the run unless it is turned off
+	errorLog := errorLogFile
+	if noErrorLog {
+		if errorLog != "" {
+			return nil, fmt.Errorf("--error-log cannot be combined with --no-error-log")
+		}
+	} else if errorLog == "" {
+		errorLog = filepath.Join("results", fmt.Sprintf("g0-errors-%s.ndjson", id))
+	}
+	errorLogMaxBytes, err := parseByteSize(errorLogMax)
+	if err != nil {
+		return nil, fmt.Errorf("--error-log-max-size: %w", err)
+	}
+	recordMaxBytes, err := parseByteSize(recordMax)
+	if err != nil {
+		return nil, fmt.Errorf("--record-max-size: %w", err)
+	}
+	switch {
+	case rotateEvery < 0:
+		return nil, fmt.Errorf("--rotate-interval must not be negative")
+	case rotateKeep < 0:
+		return nil, fmt.Errorf("--rotate-keep must not be negative")
+	case recordMaxBytes > 0 && recordFile == "":
+		return nil, fmt.Errorf("--record-max-size requires --record")
+	}
+	rotation := runner.Rotation{Interval: rotateEvery, Keep: rotateKeep, Gzip: rotateGzip}
+	recordRotation, errorLogRotation := rotation, rotation
+	recordRotation.MaxBytes, errorLogRotation.MaxBytes = recordMaxBytes, errorLogMaxBytes
+
+	var memoryBudget int64
+	if maxMemory != "" {
+		if memoryBudget, err = parseByteSize(maxMemory); err != nil {
+			return nil, fmt.Errorf("--max-memory: %w", err)
+		}
+		if memoryBudget <= 0 {
+			return nil, fmt.Errorf("--max-memory must be greater than 0")
+		}
+	}
+
+	// A resumed run covers what its checkpoint didn't, of the checkpoint's duration
+	// unless -d changes it; schedules such as traces and chaos events start over
+	fullDuration := testDuration
+	if resumed != nil {
+		if !durationGiven {
+			fullDuration = time.Duration(resumed.DurationMs) * time.Millisecond
+		}
+		testDuration = fullDuration - time.Duration(resumed.ElapsedMs)*time.Millisecond
+		if testDuration <= 0 {
+			return nil, fmt.Errorf("checkpoint %s covers the whole run (%s); there is nothing left to resume", resumeFile, fullDuration)
+		}
+	}
+	var saver *checkpointSaver
+	if checkpointTo != "" {
+		if checkpointIn <= 0 {
+			return nil, fmt.Errorf("--checkpoint-interval must be greater than 0")
+		}
+		// The checkpoint keeps the run ID, so the resumed run's artifacts match
+		saved := config.FromFlags(flags, checkpointFlags...)
+		saved["run-id"] = id
+		saver = &checkpointSaver{
+			path:     checkpointTo,
+			interval: checkpointIn,
+			flags:    saved,
+			runID:    id,
+			duration: fullDuration,
+			resumed:  resumed,
+			urls:     allURLs,
+			workers:  runner.TotalWorkers(len(urls), targets, concurrency),
+			headers:  headerMap,
+		}
+	} else if flags.Changed("checkpoint-interval") {
+		return nil, fmt.Errorf("--checkpoint-interval requires --checkpoint")
+	}
+
+	// YAML results are saved as --json saves JSON ones
+	var saveFormat string
+	switch {
+	case outputFormat != printer.FormatJSON && outputFormat != printer.FormatYAML:
+		return nil, fmt.Errorf("unsupported output format %q (supported: %s)", outputFormat, strings.Join(printer.ResultFormats, ", "))
+	case jsonOutput || outputFormat == printer.FormatYAML:
+		saveFormat = outputFormat
 	}
 
-	// Create and run the load test
-	config := runner.Config{
+	var reportTemplate *template.Template
+	if reportTmpl != "" {
+		if reportTemplate, err = printer.ParseReportTemplate(reportTmpl); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &runPlan{
+		urls:       allURLs,
+		workers:    runner.TotalWorkers(len(urls), targets, concurrency),
+		headers:    headerMap,
+		duration:   testDuration,
+		thresholds: parsedThresholds,
+		template:   reportTemplate,
+		saveFormat: saveFormat,
+		redaction:  printer.Redaction{Headers: redactHdrs, OmitHeaders: omitHeaders},
+		startAt:    scheduledStart,
+		cpus:       maxProcs,
+		affinity:   affinity,
+		checkpoint: saver,
+		resumed:    resumed,
+	}
+	plan.config = runner.Config{
 		URLs:        urls,
+		Targets:     targets,
+		URLStrategy: strategy,
+		HashKey:     hashKey,
 		Concurrency: concurrency,
 		Duration:    testDuration,
 		Method:      method,
-		Body:        body,
+		Body:        requestBody,
 		Headers:     headerMap,
+		Protocol:    protocol,
 		MaxRPS:      maxRPS,
+		ArrivalRate: arrivalRate,
+		RPSTrace:    shape,
+		CacheBust:   cacheBust,
+		Conditional: conditional,
+
+		TargetOutages: outages,
+		Chaos:         chaosEvents,
+
+		AcceptEncoding: strings.Join(encodings, ", "),
+		CompressBody:   compressBody,
+
+		BodySource: bodySource,
+		BodyRate:   uploadRate,
+
+		ExpectContinue: expectContinue,
+
+		MaxBodyBytes: bodyLimit,
+		SkipBody:     skipBody,
+
+		MaxResponseBytes: responseLimit,
+
+		ReadRate:  readRate,
+		ReadDelay: readDelay,
+
+		RequestTimeout: reqTimeout,
+		DNSServer:      resolver,
+		Grace:          grace,
+
+		TimeoutCandidates: timeoutCands,
+
+		Seed:         seed,
+		Data:         data,
+		WorkerHeader: workerHeader,
+		RunID:        id,
+		RunIDHeader:  runIDHeader,
+
+		IdempotencyKey: idemKey == "auto",
+		Created:        created,
+		Auth:           auth,
+		HTTPAuth:       challengeAuth,
+		ClientCerts:    clientCerts,
+
+		DisableTLSResumption: noResumption,
+		RequireOCSPStaple:    needStaple,
+		SNI:                  sni,
+		ProxyProtocol:        proxyProtocol,
+
+		Trace:    trace,
+		ClientIP: clientIP,
+		Canary:   canary,
+		Mirror:   mirror,
+
+		HealthCheck: healthCheck,
+
+		MetricsURL:      metricsURL,
+		MetricsInterval: metricsInt,
+
+		SLOs: slos,
+
+		Schema:           schema,
+		ExpectBodySHA256: bodyHashes,
+		BodyCardinality:  countBodies,
+		Ranges:           ranges,
+
+		CaptureHeaders: captureHeaders,
+
+		RecordFile:     recordFile,
+		RecordFormat:   recordFormat,
+		RecordRotation: recordRotation,
+
+		ErrorLog:         errorLog,
+		ErrorLogRotation: errorLogRotation,
+
+		MaxMemory: memoryBudget,
+
+		PrometheusListen: promListen,
+
+		ColdRequests: coldRequests,
+		ChurnRate:    churnRate,
+
+		Users:     users,
+		ThinkTime: thinkTime,
+
+		ShardWorkers: shardWorkers,
+
+		Audit:              audit,
+		AuditCounterHeader: auditHeader,
+	}
+
+	return plan, nil
+}
+
+func runLoadTest(cmd *cobra.Command, args []string) error {
+	plan, err := prepareRun(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	testDuration := plan.duration
+
+	// Configuration is valid from here on; don't print usage for runtime failures
+	cmd.SilenceUsage = true
+
+	report, closeReport, err := openReport(reportFile)
+	if err != nil {
+		return err
+	}
+	defer closeReport()
+	out := printer.New(report, os.Stderr)
+	out.RawNumbers = rawNumbers
+
+	// Hand the test to child processes and merge their results
+	if procs > 1 {
+		procsCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return runProcs(procsCtx, plan, procs, report)
+	}
+
+	// Limit the CPUs g0 runs on before any worker starts
+	if plan.affinity != nil {
+		if err := runner.SetCPUAffinity(plan.affinity); err != nil {
+			return err
+		}
+	}
+	if plan.cpus > 0 {
+		runtime.GOMAXPROCS(plan.cpus)
+	}
+
+	// Print logo and test configuration; a report template lays out the whole
+	// report, so they go to stderr instead
+	banner := out
+	if plan.template != nil {
+		banner = printer.New(os.Stderr, os.Stderr)
+	}
+	banner.PrintLogo()
+	banner.PrintTestStart(plan.urls, plan.workers, testDuration, plan.config.RunID)
+
+	// Ctrl+C or SIGTERM stops the test early; partial results are still reported
+	interruptCtx, stopInterrupt := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopInterrupt()
+
+	// Hold the start until the scheduled time so separately launched generators begin together
+	if !plan.startAt.IsZero() {
+		schedule := &runner.StartSchedule{At: plan.startAt, NTPServer: ntpServer}
+		schedule.CheckClock(2 * time.Second)
+		for _, warning := range schedule.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		if !waitForStart(interruptCtx, out, plan.startAt) {
+			return withExitCode(ExitAborted, fmt.Errorf("interrupted while waiting for the scheduled start"))
+		}
+		plan.config.Schedule = schedule
 	}
 
 	// Channel to receive test result
@@ -111,10 +1310,12 @@ func runLoadTest(cmd *cobra.Command, args []string) error {
 	testCompleted := make(chan struct{}) // Signal when test is actually done
 	startTime := time.Now()
 	var stats *runner.Stats
+	alarm := errorRateAlarm{out: out, limit: warnErrRate, bell: warnBell}
+	shed := 0 // Memory events already printed
 
 	// Start the test in a goroutine
 	go func() {
-		result, err := runner.RunWithStatsAndChannel(config, statsChan)
+		result, err := runner.RunWithContext(interruptCtx, plan.config, statsChan)
 		if err != nil {
 			errChan <- err
 			return
@@ -132,14 +1333,33 @@ func runLoadTest(cmd *cobra.Command, args []string) error {
 			// Stats not available yet, continue anyway (shouldn't happen normally)
 		}
 
-		ticker := time.NewTicker(100 * time.Millisecond) // Update every 100ms
+		ticker := time.NewTicker(progressInt)
 		defer ticker.Stop()
 
+		// Checkpoints are saved alongside the progress updates
+		var checkpoints <-chan time.Time
+		if plan.checkpoint != nil {
+			checkpointTicker := time.NewTicker(plan.checkpoint.interval)
+			defer checkpointTicker.Stop()
+			checkpoints = checkpointTicker.C
+		}
+
 		for {
 			select {
 			case s := <-statsChan:
 				// Stats instance is now available (if not received earlier)
 				stats = s
+			case <-checkpoints:
+				if stats == nil {
+					continue
+				}
+				summary := stats.SummarySoFar()
+				if err := plan.checkpoint.save(&summary); err != nil && !plan.checkpoint.failed {
+					// Report the first failure only; later saves may still succeed
+					plan.checkpoint.failed = true
+					out.ClearProgress()
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+				}
 			case <-ticker.C:
 				// Check if test completed first - if so, stop immediately
 				select {
@@ -155,11 +1375,16 @@ func runLoadTest(cmd *cobra.Command, args []string) error {
 					if elapsed < testDuration {
 						if stats != nil {
 							progressStats := stats.GetProgressStats()
-							printer.PrintProgress(elapsed, testDuration, &progressStats, 0)
+							alarm.check(elapsed, &progressStats)
+							for _, event := range progressStats.MemoryEvents[shed:] {
+								out.PrintMemoryShed(event, plan.config.MaxMemory)
+							}
+							shed = len(progressStats.MemoryEvents)
+							out.PrintProgress(elapsed, testDuration, &progressStats, 0)
 						} else {
 							// Stats not available yet, show basic progress with zero stats
 							zeroStats := runner.ProgressStats{}
-							printer.PrintProgress(elapsed, testDuration, &zeroStats, 0)
+							out.PrintProgress(elapsed, testDuration, &zeroStats, 0)
 						}
 					}
 					// If elapsed >= testDuration, don't update anymore - let main goroutine handle it
@@ -180,8 +1405,8 @@ func runLoadTest(cmd *cobra.Command, args []string) error {
 	case err := <-errChan:
 		close(progressDone)
 		time.Sleep(50 * time.Millisecond)
-		printer.ClearProgress()
-		return fmt.Errorf("load test failed: %w", err)
+		out.ClearProgress()
+		return withExitCode(ExitAborted, fmt.Errorf("load test failed: %w", err))
 	case result = <-resultChan:
 		// Test completed - signal to stop progress updates immediately
 		// Close testCompleted first to signal completion
@@ -190,7 +1415,7 @@ func runLoadTest(cmd *cobra.Command, args []string) error {
 		close(progressDone)
 		// Wait longer to ensure all ticker events are processed and goroutine has stopped
 		time.Sleep(250 * time.Millisecond)
-		
+
 		// Show final "Generating report..." message once
 		if stats != nil {
 			progressStats := stats.GetProgressStats()
@@ -198,26 +1423,227 @@ func runLoadTest(cmd *cobra.Command, args []string) error {
 			if testDuration > 0 {
 				rps = float64(progressStats.TotalRequests) / testDuration.Seconds()
 			}
-			printer.PrintGeneratingReport(&progressStats, rps)
+			out.PrintGeneratingReport(&progressStats, rps)
 			time.Sleep(300 * time.Millisecond) // Show message briefly
 		}
-		
+
 		// Clear progress line
-		printer.ClearProgress()
-		fmt.Println() // Add a newline after clearing progress
+		out.ClearProgress()
+		if plan.template == nil {
+			fmt.Fprintln(report) // Add a newline after clearing progress
+		}
+	}
+
+	// An interrupted run can be resumed from its last state; a finished one is done
+	if c := plan.checkpoint; c != nil {
+		if interruptCtx.Err() != nil {
+			if err := c.save(result.Summary); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Checkpoint saved; continue the run with: g0 run --resume %s\n", c.path)
+			}
+		} else if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove checkpoint: %s\n", err)
+		}
+	}
+
+	// Evaluate pass/fail thresholds before printing so they appear in every report
+	failedThresholds := runner.EvaluateThresholds(result.Summary, plan.thresholds)
+
+	// Print results in text format, falling back to the built-in report if the template fails
+	printed := false
+	if plan.template != nil {
+		data := printer.ReportData{Summary: result.Summary, URLs: plan.urls, Concurrency: plan.workers, Method: method, Headers: plan.headers}
+		if err := out.PrintReportTemplate(plan.template, data); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s; printing the built-in report\n", err)
+		} else {
+			printed = true
+		}
+	}
+	if !printed {
+		out.PrintResults(result.Summary)
+	}
+
+	// A resumed run is reported as a whole too, its thresholds checked on all parts
+	var combined *printer.JSONOutput
+	if plan.resumed != nil {
+		part := printer.BuildResultJSON(result.Summary, plan.urls, plan.workers, testDuration, method, plan.headers)
+		whole := printer.AppendResults([]printer.JSONOutput{plan.resumed.Result, part})
+		plan.redaction.Apply(&whole)
+		failedThresholds = printer.EvaluateMergedThresholds(&whole, plan.thresholds)
+		fmt.Fprintln(report)
+		out.PrintMergedResults(whole)
+		combined = &whole
 	}
 
-	// Print results in text format
-	printer.PrintResults(result.Summary)
-	
 	// If JSON output is enabled, also save to file
-	if jsonOutput {
-		filePath, err := printer.PrintResultsJSON(result.Summary, urls, concurrency, testDuration, method, headerMap, outputFile)
+	if plan.saveFormat != "" {
+		var output printer.JSONOutput
+		if combined != nil {
+			output = *combined
+		} else {
+			output = printer.BuildResultJSON(result.Summary, plan.urls, plan.workers, testDuration, method, plan.headers)
+			plan.redaction.Apply(&output)
+		}
+		filePath, err := printer.SaveResultJSON(output, outputFile, plan.saveFormat)
 		if err != nil {
-			return fmt.Errorf("failed to save JSON output: %w", err)
+			return withExitCode(ExitAborted, fmt.Errorf("failed to save results: %w", err))
 		}
 		fmt.Fprintf(os.Stderr, "\nResults saved to: %s\n", filePath)
 	}
 
+	if result.Summary.Aborted {
+		return withExitCode(ExitAborted, fmt.Errorf("load test aborted before the configured duration"))
+	}
+	if failedThresholds > 0 {
+		return withExitCode(ExitThresholdsFailed, fmt.Errorf("%d of %d thresholds failed", failedThresholds, len(plan.thresholds)))
+	}
+	if failed := result.Summary.FailedSLOs(); failed > 0 {
+		return withExitCode(ExitThresholdsFailed, fmt.Errorf("%d of %d SLOs missed", failed, len(result.Summary.SLOs)))
+	}
+
 	return nil
 }
+
+// errorRateAlarm warns when the rolling error rate crosses --warn-error-rate
+// It only prints on transitions, so a failing target raises one warning
+type errorRateAlarm struct {
+	out    *printer.Printer // Prints the warnings
+	limit  float64          // Percent; 0 = off
+	bell   bool
+	firing bool
+}
+
+// check compares the rolling error rate with the limit and reports changes
+func (a *errorRateAlarm) check(elapsed time.Duration, p *runner.ProgressStats) {
+	if a.limit == 0 || p.RecentRequests < minAlarmRequests {
+		return
+	}
+	above := p.RecentErrorRate > a.limit
+	switch {
+	case above && !a.firing:
+		a.out.PrintErrorRateWarning(elapsed, p.RecentErrorRate, a.limit, a.bell)
+	case !above && a.firing:
+		a.out.PrintErrorRateRecovered(elapsed, p.RecentErrorRate, a.limit)
+	}
+	a.firing = above
+}
+
+// waitForStart blocks until the start time, showing a countdown
+// It returns false if ctx is cancelled first
+func waitForStart(ctx context.Context, out *printer.Printer, at time.Time) bool {
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	ticker := time.NewTicker(progressInt)
+	defer ticker.Stop()
+
+	out.PrintWaitingForStart(at, time.Until(at))
+	for {
+		select {
+		case <-ctx.Done():
+			out.ClearProgress()
+			return false
+		case <-timer.C:
+			out.ClearProgress()
+			return true
+		case <-ticker.C:
+			out.PrintWaitingForStart(at, time.Until(at))
+		}
+	}
+}
+
+// openReport opens the writer of the text report: the --report-file file, or stdout
+// The returned function closes it
+func openReport(path string) (io.Writer, func(), error) {
+	if path == "" {
+		return os.Stdout, func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, withExitCode(ExitAborted, fmt.Errorf("failed to create report file: %w", err))
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// isSupportedEncoding reports whether the content coding can be encoded and decoded
+func isSupportedEncoding(encoding string) bool {
+	for _, e := range httpclient.SupportedEncodings {
+		if e == encoding {
+			return true
+		}
+	}
+	return false
+}
+
+// stdinBody is standard input once read for --body -, so every run of the
+// process (e.g., both configs of g0 ab) sends the same payload
+var stdinBody *string
+
+// readBody returns the request body given with --body, reading it from
+// standard input when it is "-"
+func readBody(value string) (string, error) {
+	if value != "-" {
+		return value, nil
+	}
+	if stdinBody == nil {
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return "", fmt.Errorf("--body - reads the body from stdin, but stdin is a terminal; pipe the body in")
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the request body from stdin: %w", err)
+		}
+		s := string(data)
+		stdinBody = &s
+	}
+	return *stdinBody, nil
+}
+
+// parseHeaders parses "Key: Value" headers
+func parseHeaders(list []string) (map[string]string, error) {
+	headers := make(map[string]string, len(list))
+	for _, h := range list {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid header format: %s (expected 'Key: Value')", h)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// validateTemplates checks every templated URL, header and body before the run starts
+// size is the value of {{size}} (the payload size of a g0 sweep stage)
+func validateTemplates(body string, headers []string, targets []runner.Target, data *runner.DataFeeder, size int64) error {
+	values := append([]string{body}, urls...)
+	values = append(values, headers...)
+	for _, t := range targets {
+		values = append(values, t.URL, t.Body)
+		for _, v := range t.Headers {
+			values = append(values, v)
+		}
+	}
+	for _, v := range values {
+		if err := runner.ValidateTemplate(v, data, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseShard parses a data shard given as INDEX/COUNT
+func parseShard(s string) (index, count int, err error) {
+	i, c, ok := strings.Cut(s, "/")
+	if ok {
+		index, err = strconv.Atoi(strings.TrimSpace(i))
+		if err == nil {
+			count, err = strconv.Atoi(strings.TrimSpace(c))
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("invalid data shard %q (expected INDEX/COUNT, e.g. 0/4)", s)
+	}
+	return index, count, nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestRawRequestRejectsRequestFlags(t *testing.T) {
+	raw := filepath.Join(t.TempDir(), "request.txt")
+	if err := os.WriteFile(raw, []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, flag := range []string{
+		"--worker-header",
+		"--run-id-header",
+		"--idempotency-key=auto",
+		"--trace-propagation=w3c",
+		"--spoof-client-ip-header=X-Forwarded-For",
+		"--accept-encoding=gzip",
+		"--auth-url=http://example.com/token",
+		"--sni-list=names.txt",
+		"--range-size=1MB",
+	} {
+		t.Run(flag, func(t *testing.T) {
+			// A fresh flag set resets every run flag to its default
+			flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+			addRunFlags(flags)
+			configFile, profileName = "", ""
+			if err := flags.Parse([]string{"--url=http://example.com/", "--raw-request=" + raw, flag}); err != nil {
+				t.Fatal(err)
+			}
+			name := strings.SplitN(flag, "=", 2)[0]
+			if _, err := prepareRun(flags); err == nil || !strings.Contains(err.Error(), name+" cannot be combined with --raw-request") {
+				t.Errorf("error %v, want %s rejected", err, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/calummacc/g0/internal/config"
+	"github.com/calummacc/g0/internal/httpclient"
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	serverListen string
+	serverToken  string
+)
+
+// maxAPIRuns is the number of runs the API server remembers; the oldest finished
+// runs are dropped first
+const maxAPIRuns = 100
+
+// apiProgressInterval is how often progress events are streamed
+const apiProgressInterval = 500 * time.Millisecond
+
+// apiConfigKeys are the config keys accepted by POST /runs: run flags that
+// neither name files on the server host (targets, data, certificates, report
+// templates, ...) nor change the server process (CPUs, child processes,
+// listeners)
+var apiConfigKeys = []string{
+	"url", "url-strategy", "hash-key", "target-down", "concurrency", "users", "think-time", "duration",
+	"method", "body", "headers", "output-format", "redact-header", "omit-headers", "raw-numbers",
+	"max-rps", "arrival-rate", "rps-trace-scale", "rps-trace-speed", "chaos",
+	"cache-bust", "conditional", "accept-encoding", "compress-body", "body-size", "body-rate", "http1.0",
+	"expect-continue", "expect-continue-timeout", "max-body-bytes", "max-response-bytes",
+	"client-bandwidth", "read-delay", "skip-body", "request-timeout", "timeout-candidates", "dns-server",
+	"grace", "progress-interval", "threshold", "exit-on-error-rate", "seed", "data-mode", "data-shard",
+	"worker-header", "run-id-header", "idempotency-key", "track-created", "cleanup-url",
+	"auth-url", "auth-method", "auth-body", "auth-header", "auth-token-field", "auth-refresh-interval", "auth-refresh-percent",
+	"http-auth", "http-auth-user", "http-auth-password",
+	"client-cert-mode", "no-tls-resumption", "require-ocsp-staple", "trace-propagation", "trace-link",
+	"spoof-client-ip-header", "spoof-client-ip", "spoof-client-ip-cidr", "proxy-protocol", "proxy-protocol-source",
+	"canary-url", "canary-percent", "mirror", "mirror-mode", "health-url", "health-interval", "health-pause",
+	"metrics-url", "metrics-interval", "schema-sample", "expect-body-sha256", "body-cardinality",
+	"range-size", "range-percent", "capture-header", "start-at", "start-after", "ntp-server",
+	"warn-error-rate", "warn-bell", "cold-requests", "shard-workers", "audit", "audit-counter-header",
+	"churn-rate", "max-memory",
+}
+
+// apiDroppedKeys configure the files a run writes; runs started through the
+// API write none, so these are dropped rather than rejected
+var apiDroppedKeys = []string{
+	"json", "output", "record", "record-format", "record-max-size",
+	"error-log", "error-log-max-size", "no-error-log",
+	"rotate-interval", "rotate-keep", "rotate-gzip",
+	"checkpoint", "checkpoint-interval", "resume", "run-id",
+}
+
+// Run states reported by the API
+const (
+	runRunning   = "running"
+	runCompleted = "completed"
+	runStopped   = "stopped"
+	runFailed    = "failed"
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Serve a REST API to start, stop and monitor load tests",
+	Long: `Serve a REST API so load tests can be started, stopped and monitored from
+other tools. Run configs are JSON objects with the same keys as config files
+(g0 run flag names). One test runs at a time.
+
+  POST /runs                 Start a run (body: config), returns the run
+  GET  /runs                 List runs
+  GET  /runs/{id}            Run status, with the JSON result once finished
+  GET  /runs/{id}/progress   Live progress as server-sent events
+  POST /runs/{id}/stop       Stop a run early (partial results are kept)
+
+Example:
+  g0 server --listen :8081 --token "$G0_TOKEN"
+  curl -H "Authorization: Bearer $G0_TOKEN" -d '{"url": ["https://api.example.com"], "concurrency": 50, "duration": "1m"}' localhost:8081/runs`,
+	Args: cobra.NoArgs,
+	RunE: runServer,
+}
+
+func init() {
+	rootCmd.AddCommand(serverCmd)
+
+	serverCmd.Flags().StringVar(&serverListen, "listen", "localhost:8081", "Address to listen on (e.g., :8081 for all interfaces)")
+	serverCmd.Flags().StringVar(&serverToken, "token", os.Getenv("G0_SERVER_TOKEN"), "Require this bearer token on every request (default: $G0_SERVER_TOKEN)")
+}
+
+func runServer(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api := &apiServer{runs: make(map[string]*apiRun), token: serverToken}
+	srv := &http.Server{Addr: serverListen, Handler: api}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		api.stopAll()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if serverToken == "" {
+		fmt.Fprintln(os.Stderr, "Warning: no --token set; anyone who can reach the server can start load tests")
+	}
+	fmt.Fprintf(os.Stderr, "g0 API listening on %s\n", serverListen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return withExitCode(ExitAborted, err)
+	}
+	return nil
+}
+
+// apiServer serves the run API
+type apiServer struct {
+	mu     sync.Mutex
+	runs   map[string]*apiRun
+	order  []string // Run IDs, oldest first
+	active *apiRun
+	token  string
+}
+
+// apiRun is a load test started through the API
+type apiRun struct {
+	id      string
+	plan    *runPlan
+	created time.Time
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu      sync.Mutex
+	status  string
+	started time.Time
+	stats   *runner.Stats
+	result  *printer.JSONOutput
+	err     string
+}
+
+// apiRunStatus is the JSON representation of a run
+type apiRunStatus struct {
+	ID        string              `json:"id"`
+	Status    string              `json:"status"`
+	CreatedAt string              `json:"created_at"`
+	URLs      []string            `json:"urls"`
+	Duration  string              `json:"duration"`
+	Error     string              `json:"error,omitempty"`
+	Progress  *apiProgress        `json:"progress,omitempty"`
+	Result    *printer.JSONOutput `json:"result,omitempty"`
+}
+
+// apiProgress is a snapshot of a running test
+type apiProgress struct {
+	ElapsedMs       int64   `json:"elapsed_ms"`
+	TotalRequests   int64   `json:"total_requests"`
+	SuccessRequests int64   `json:"success_requests"`
+	FailedRequests  int64   `json:"failed_requests"`
+	RPS             float64 `json:"rps"`
+	RecentP50Ms     float64 `json:"recent_p50_ms"`
+	RecentP95Ms     float64 `json:"recent_p95_ms"`
+	RecentP99Ms     float64 `json:"recent_p99_ms"`
+	TargetDown      bool    `json:"target_down,omitempty"`
+}
+
+// apiError is the body of error responses
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, apiError{"missing or invalid bearer token"})
+			return
+		}
+	}
+
+	// Routes: /runs, /runs/{id}, /runs/{id}/progress, /runs/{id}/stop
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "runs" || len(parts) > 3 {
+		writeJSON(w, http.StatusNotFound, apiError{"not found"})
+		return
+	}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.listRuns(w)
+		case http.MethodPost:
+			s.startRun(w, r)
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, apiError{"use GET or POST"})
+		}
+		return
+	}
+
+	s.mu.Lock()
+	run := s.runs[parts[1]]
+	s.mu.Unlock()
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, apiError{"run not found"})
+		return
+	}
+
+	action := ""
+	if len(parts) == 3 {
+		action = parts[2]
+	}
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, run.statusJSON())
+	case action == "progress" && r.Method == http.MethodGet:
+		run.streamProgress(w, r)
+	case action == "stop" && r.Method == http.MethodPost:
+		run.cancel()
+		writeJSON(w, http.StatusAccepted, run.statusJSON())
+	default:
+		writeJSON(w, http.StatusNotFound, apiError{"not found"})
+	}
+}
+
+// startRun validates the posted config and starts the test in the background
+func (s *apiServer) startRun(w http.ResponseWriter, r *http.Request) {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.UseNumber()
+	file := config.File{}
+	if err := dec.Decode(&file); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{fmt.Sprintf("invalid config: %v", err)})
+		return
+	}
+
+	// Results are returned by the API, failed requests are in them, and the
+	// run ID (which names files) is generated
+	for _, key := range apiDroppedKeys {
+		delete(file, key)
+	}
+	for _, key := range file.Keys() {
+		if !slices.Contains(apiConfigKeys, key) {
+			writeJSON(w, http.StatusBadRequest, apiError{fmt.Sprintf("config key %q is not accepted by the API (keys naming files on the server or changing the server process are not)", key)})
+			return
+		}
+	}
+	file["no-error-log"] = true
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil {
+		writeJSON(w, http.StatusConflict, apiError{fmt.Sprintf("run %s is still running", s.active.id)})
+		return
+	}
+
+	// prepareRun works on the package-level flag variables, which s.mu also guards
+	plan, err := prepareConfigFile(file)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &apiRun{
+		id:      newRunID(),
+		plan:    plan,
+		created: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		status:  runRunning,
+	}
+	s.runs[run.id] = run
+	s.order = append(s.order, run.id)
+	s.active = run
+	s.evictRuns()
+
+	go func() {
+		run.execute(ctx)
+		s.mu.Lock()
+		s.active = nil
+		s.mu.Unlock()
+	}()
+
+	writeJSON(w, http.StatusCreated, run.statusJSON())
+}
+
+// listRuns returns the known runs, newest first
+func (s *apiServer) listRuns(w http.ResponseWriter) {
+	s.mu.Lock()
+	runs := make([]apiRunStatus, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		status := s.runs[s.order[i]].statusJSON()
+		status.Result = nil
+		runs = append(runs, status)
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// evictRuns drops the oldest finished runs beyond maxAPIRuns; s.mu must be held
+func (s *apiServer) evictRuns() {
+	for len(s.order) > maxAPIRuns {
+		oldest := s.order[0]
+		if s.runs[oldest] == s.active {
+			return
+		}
+		delete(s.runs, oldest)
+		s.order = s.order[1:]
+	}
+}
+
+// stopAll cancels the active run, e.g. when the server shuts down
+func (s *apiServer) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil {
+		s.active.cancel()
+	}
+}
+
+// execute runs the test and stores its result
+func (run *apiRun) execute(ctx context.Context) {
+	defer close(run.done)
+	defer run.cancel()
+
+	statsChan := make(chan *runner.Stats, 1)
+	go func() {
+		if stats, ok := <-statsChan; ok {
+			run.mu.Lock()
+			run.stats = stats
+			run.mu.Unlock()
+		}
+	}()
+
+	run.mu.Lock()
+	run.started = time.Now()
+	run.mu.Unlock()
+
+	plan := run.plan
+	result, err := runner.RunWithContext(ctx, plan.config, statsChan)
+	close(statsChan)
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if err != nil {
+		run.status = runFailed
+		run.err = err.Error()
+		return
+	}
+	runner.EvaluateThresholds(result.Summary, plan.thresholds)
+	output := printer.BuildResultJSON(result.Summary, plan.urls, plan.workers, plan.duration, plan.config.Method, plan.headers)
+	plan.redaction.Apply(&output)
+	run.result = &output
+	run.status = runCompleted
+	if result.Summary.Aborted {
+		run.status = runStopped
+	}
+}
+
+// statusJSON returns the run's current state
+func (run *apiRun) statusJSON() apiRunStatus {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	status := apiRunStatus{
+		ID:        run.id,
+		Status:    run.status,
+		CreatedAt: run.created.Format(time.RFC3339),
+		URLs:      run.plan.urls,
+		Duration:  run.plan.duration.String(),
+		Error:     run.err,
+		Result:    run.result,
+	}
+	if run.status == runRunning {
+		status.Progress = run.progressLocked()
+	}
+	return status
+}
+
+// progressLocked returns a progress snapshot (nil before the test started); run.mu must be held
+func (run *apiRun) progressLocked() *apiProgress {
+	if run.stats == nil {
+		return nil
+	}
+	p := run.stats.GetProgressStats()
+	elapsed := time.Since(run.started)
+	progress := &apiProgress{
+		ElapsedMs:       elapsed.Milliseconds(),
+		TotalRequests:   p.TotalRequests,
+		SuccessRequests: p.SuccessRequests,
+		FailedRequests:  p.FailedRequests,
+		RecentP50Ms:     float64(p.RecentP50.Nanoseconds()) / 1e6,
+		RecentP95Ms:     float64(p.RecentP95.Nanoseconds()) / 1e6,
+		RecentP99Ms:     float64(p.RecentP99.Nanoseconds()) / 1e6,
+		TargetDown:      p.TargetDown,
+	}
+	if elapsed > 0 {
+		progress.RPS = float64(p.TotalRequests) / elapsed.Seconds()
+	}
+	return progress
+}
+
+// streamProgress sends progress snapshots as server-sent events until the run
+// finishes, then a final "done" event with the run status
+func (run *apiRun) streamProgress(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, apiError{"streaming is not supported"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(apiProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-run.done:
+			status := run.statusJSON()
+			status.Result = nil
+			writeEvent(w, "done", status)
+			flusher.Flush()
+			return
+		case <-ticker.C:
+			run.mu.Lock()
+			progress := run.progressLocked()
+			run.mu.Unlock()
+			if progress != nil {
+				writeEvent(w, "progress", progress)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// prepareConfigFile validates a config as g0 run --config would
+func prepareConfigFile(file config.File) (*runPlan, error) {
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	addRunFlags(flags)
+	configFile = ""
+	profileName = ""
+	if err := file.Apply(flags); err != nil {
+		return nil, err
+	}
+	if body == "-" {
+		return nil, fmt.Errorf("body \"-\" reads stdin, which runs started through the API don't have")
+	}
+	// Challenges are answered with the caller's credentials, never the server's:
+	// neither its $G0_HTTP_AUTH_PASSWORD nor its Kerberos tickets
+	if httpAuth != "" {
+		if strings.EqualFold(httpAuth, httpclient.AuthNegotiate) {
+			return nil, fmt.Errorf("http-auth %s authenticates with the server's Kerberos tickets, which runs started through the API can't use", httpclient.AuthNegotiate)
+		}
+		if !flags.Changed("http-auth-password") {
+			return nil, fmt.Errorf("http-auth requires http-auth-password in runs started through the API, which don't read $G0_HTTP_AUTH_PASSWORD")
+		}
+	}
+	return prepareRun(flags)
+}
+
+// newRunID returns a random run identifier
+func newRunID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeEvent writes v as a server-sent event
+func writeEvent(w http.ResponseWriter, event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calummacc/g0/internal/config"
+)
+
+func TestPrepareConfigFileHTTPAuthCredentials(t *testing.T) {
+	t.Setenv("G0_HTTP_AUTH_PASSWORD", "server-secret")
+	base := func(extra config.File) config.File {
+		file := config.File{"url": []interface{}{"http://example.com/"}, "duration": "1s"}
+		for key, value := range extra {
+			file[key] = value
+		}
+		return file
+	}
+
+	// The server's password and Kerberos tickets never answer challenges for API callers
+	for name, file := range map[string]config.File{
+		"password from the server's environment": base(config.File{"http-auth": "digest", "http-auth-user": "alice"}),
+		"negotiate":                              base(config.File{"http-auth": "negotiate", "http-auth-password": "x"}),
+	} {
+		if _, err := prepareConfigFile(file); err == nil || !strings.Contains(err.Error(), "API") {
+			t.Errorf("%s: error %v, want it rejected", name, err)
+		}
+	}
+
+	plan, err := prepareConfigFile(base(config.File{"http-auth": "digest", "http-auth-user": "alice", "http-auth-password": "caller-secret"}))
+	if err != nil || plan == nil {
+		t.Fatalf("caller's own credentials: %v", err)
+	}
+}
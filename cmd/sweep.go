@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/calummacc/g0/internal/printer"
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sweepConfig string
+	sweepSizes  []string
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Measure latency and throughput across payload sizes",
+	Long: `Run a load test configuration once per payload size and report latency and
+throughput as a function of size.
+
+Templates in the config see the size of the current stage in bytes as
+{{size}}: use it in the body to vary the request size, or in the URL or a
+header to ask the target for responses of that size. Each stage lasts the
+config's duration.
+
+Example:
+  g0 sweep --config upload.yaml --sizes 1KB,10KB,100KB,1MB
+  (with body: '{{randString size}}' in upload.yaml)`,
+	RunE: runSweep,
+}
+
+func init() {
+	rootCmd.AddCommand(sweepCmd)
+
+	sweepCmd.Flags().StringVar(&sweepConfig, "config", "", "Config file of the load test (required)")
+	sweepCmd.Flags().StringSliceVar(&sweepSizes, "sizes", nil, "Payload sizes of the stages, in order (e.g., 1KB,10KB,100KB,1MB; required)")
+	sweepCmd.MarkFlagRequired("config")
+	sweepCmd.MarkFlagRequired("sizes")
+}
+
+func runSweep(cmd *cobra.Command, args []string) error {
+	plan, err := prepareConfigPlan(sweepConfig)
+	if err != nil {
+		return err
+	}
+
+	// Check every stage's templates before sending any requests
+	stages := make([]runner.SweepStage, len(sweepSizes))
+	for i, s := range sweepSizes {
+		size, err := parseByteSize(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		if err := validateTemplates(plan.config.Body, headers, plan.config.Targets, plan.config.Data, size); err != nil {
+			return fmt.Errorf("size %s: %w", s, err)
+		}
+		stages[i] = runner.SweepStage{Label: strings.TrimSpace(s), Size: size}
+	}
+	if !usesSizeTemplate(plan) {
+		fmt.Fprintln(os.Stderr, "Warning: no URL, header or body of the config uses {{size}}, so every stage sends the same requests")
+	}
+	cmd.SilenceUsage = true
+
+	out := printer.Default()
+	out.PrintLogo()
+	fmt.Printf("Payload Size Sweep: %d stages of %s, about %s in total\n", len(stages), plan.duration, time.Duration(len(stages))*plan.duration)
+	fmt.Printf("  Config: %s (%d URLs, %d workers)\n", sweepConfig, len(plan.urls), plan.workers)
+	fmt.Println()
+
+	// Ctrl+C or SIGTERM stops after the current stage; completed stages are still reported
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	completed := 0
+	for i := range stages {
+		stagePlan := *plan
+		stagePlan.config.PayloadSize = stages[i].Size
+		summary, err := runVariant(ctx, out, &stagePlan)
+		if err != nil {
+			return withExitCode(ExitAborted, fmt.Errorf("stage %d (size %s): %w", i+1, stages[i].Label, err))
+		}
+		if summary.Aborted {
+			break
+		}
+		stages[i].Summary = summary
+		out.PrintSweepStage(i+1, len(stages), stages[i])
+		completed++
+	}
+
+	if completed == 0 {
+		return withExitCode(ExitAborted, fmt.Errorf("sweep aborted before the first stage completed"))
+	}
+	out.PrintSweep(stages[:completed])
+
+	if completed < len(stages) {
+		return withExitCode(ExitAborted, fmt.Errorf("sweep aborted after %d of %d stages", completed, len(stages)))
+	}
+	return nil
+}
+
+// usesSizeTemplate reports whether any templated URL, header or body of the plan
+// calls {{size}}
+func usesSizeTemplate(plan *runPlan) bool {
+	values := append([]string{plan.config.Body}, plan.urls...)
+	for _, v := range plan.config.Headers {
+		values = append(values, v)
+	}
+	for _, t := range plan.config.Targets {
+		values = append(values, t.URL, t.Body)
+		for _, v := range t.Headers {
+			values = append(values, v)
+		}
+	}
+	for _, v := range values {
+		if strings.Contains(v, "{{") && strings.Contains(v, "size") {
+			return true
+		}
+	}
+	return false
+}
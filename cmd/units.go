@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unitSuffix is a unit suffix and the multiplier it applies
+type unitSuffix struct {
+	suffix     string
+	multiplier float64
+}
+
+// byteUnits maps size suffixes to their multiplier (decimal and binary)
+var byteUnits = []unitSuffix{
+	// Longest suffixes first so "KiB" is not matched as "B"
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9}, {"tb", 1e12},
+	{"k", 1e3}, {"m", 1e6}, {"g", 1e9}, {"t", 1e12},
+	{"b", 1},
+}
+
+// parseByteSize parses a size such as "512", "64KB", "1.5MiB" or "2GB" into bytes
+func parseByteSize(s string) (int64, error) {
+	value, err := parseWithUnits(s, byteUnits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q (e.g., 512KB, 10MB, 1GiB)", s)
+	}
+	return int64(value), nil
+}
+
+// bitrateUnits maps bitrate suffixes to bytes per second
+var bitrateUnits = []unitSuffix{
+	{"kib/s", 1 << 10}, {"mib/s", 1 << 20}, {"gib/s", 1 << 30},
+	{"kb/s", 1e3}, {"mb/s", 1e6}, {"gb/s", 1e9}, {"b/s", 1},
+	{"kbps", 1e3 / 8}, {"mbps", 1e6 / 8}, {"gbps", 1e9 / 8}, {"bps", 1.0 / 8},
+}
+
+// parseBitrate parses a rate such as "256kbps", "10Mbps" (bits per second) or
+// "1MB/s" (bytes per second) and returns bytes per second
+func parseBitrate(s string) (int64, error) {
+	value, err := parseWithUnits(s, bitrateUnits)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("invalid rate %q (e.g., 256kbps, 10Mbps, 1MB/s)", s)
+	}
+	return int64(value), nil
+}
+
+// parseWithUnits parses a non-negative number followed by one of the given suffixes
+// A bare number uses a multiplier of 1; values that don't fit an int64 are rejected
+func parseWithUnits(s string, units []unitSuffix) (float64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	multiplier := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			multiplier = u.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || !finite(value) || value < 0 {
+		return 0, fmt.Errorf("invalid value")
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which already overflows
+	if value*multiplier >= math.MaxInt64 {
+		return 0, fmt.Errorf("value out of range")
+	}
+	return value * multiplier, nil
+}
+
+// parseHumanDuration parses a test duration such as "30s", "1h30m", "2d", "1d12h"
+// or a bare number of seconds ("90")
+func parseHumanDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid duration %q (e.g., 30s, 5m, 1h30m, 2d, or 90 for seconds)", s)
+
+	// A bare number is seconds
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if !finite(seconds) || seconds < 0 || seconds > maxDurationSeconds {
+			return 0, invalid
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	// time.ParseDuration has no day unit, so handle a leading "<n>d" here
+	var total time.Duration
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		days, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil || !finite(days) || days < 0 || days*24*3600 > maxDurationSeconds {
+			return 0, invalid
+		}
+		total = time.Duration(days * 24 * float64(time.Hour))
+		s = s[i+1:]
+		if s == "" {
+			return total, nil
+		}
+	}
+
+	// The days and the rest must stay within the limit together
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 || d > maxDurationSeconds*time.Second-total {
+		return 0, invalid
+	}
+	return total + d, nil
+}
+
+// finite reports whether v is neither NaN nor infinite (ParseFloat accepts
+// "NaN" and "Inf")
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// maxDurationSeconds keeps parsed durations well inside time.Duration's range
+const maxDurationSeconds = 100 * 365 * 24 * 3600
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHumanDuration(t *testing.T) {
+	valid := map[string]time.Duration{
+		"30s":    30 * time.Second,
+		"90":     90 * time.Second,
+		"1.5":    1500 * time.Millisecond,
+		"1h30m":  90 * time.Minute,
+		"2d":     48 * time.Hour,
+		"1d12h":  36 * time.Hour,
+		" 5m ":   5 * time.Minute,
+		"36500d": maxDurationSeconds * time.Second,
+	}
+	for s, want := range valid {
+		if got, err := parseHumanDuration(s); err != nil || got != want {
+			t.Errorf("parseHumanDuration(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{
+		"", "abc", "-5", "-1s", "-1d",
+		"NaN", "nan", "Inf", "+Inf", "-Inf", "infinity", "NaNd", "Infd", "1e300",
+		"36501d", "36500d1s", "1d2562047h", // Over the limit, alone or with the days
+	} {
+		if got, err := parseHumanDuration(s); err == nil {
+			t.Errorf("parseHumanDuration(%q) = %v, want an error", s, got)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	valid := map[string]int64{"512": 512, "64KB": 64000, "1.5MiB": 1572864, "2gb": 2e9, "9e18": 9e18, "8000000TB": 8e18}
+	for s, want := range valid {
+		if got, err := parseByteSize(s); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "-1KB", "NaN", "InfMB", "tenMB", "1e19", "9223372036854775807", "10000000TB", "1e300GB"} {
+		if got, err := parseByteSize(s); err == nil {
+			t.Errorf("parseByteSize(%q) = %d, want an error", s, got)
+		}
+	}
+}
+
+func TestParseBitrate(t *testing.T) {
+	valid := map[string]int64{"256kbps": 32000, "10Mbps": 1250000, "1MB/s": 1e6, "1KiB/s": 1024}
+	for s, want := range valid {
+		if got, err := parseBitrate(s); err != nil || got != want {
+			t.Errorf("parseBitrate(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "1bps", "NaNMbps", "1e19B/s", "1e10GB/s"} {
+		if got, err := parseBitrate(s); err == nil {
+			t.Errorf("parseBitrate(%q) = %d, want an error", s, got)
+		}
+	}
+}
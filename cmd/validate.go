@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/calummacc/g0/internal/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <file>...",
+	Short: "Check config, targets and data files without running a test",
+	Long: `Check config files (.yaml, .yml), targets files (.json) and data files (.csv)
+for errors without sending any requests. Config files get the same checks as
+g0 run: unknown keys, invalid values, URLs and URL patterns, thresholds, the
+targets and data files they reference, and templates (including variables
+missing from the data file).
+
+Exits with code 3 if any file is invalid, so it can run in pre-commit hooks and CI.
+
+Example:
+  g0 validate g0.yaml
+  g0 validate loadtests/*.yaml targets.json`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	invalid := 0
+	for _, path := range args {
+		summary, err := validateFile(path)
+		if err != nil {
+			invalid++
+			// Config errors may already name the file
+			fmt.Printf("[FAIL] %s: %s\n", path, strings.TrimPrefix(err.Error(), path+": "))
+			continue
+		}
+		fmt.Printf("[OK]   %s: %s\n", path, summary)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files invalid", invalid, len(args))
+	}
+	return nil
+}
+
+// validateFile checks a single file and returns a short description of its contents
+func validateFile(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return validateTargetsFile(path)
+	case ".yaml", ".yml":
+		return validateConfigFile(path)
+	case ".csv":
+		return validateDataFile(path)
+	default:
+		return "", fmt.Errorf("unknown file type (expected .yaml, .yml, .json or .csv)")
+	}
+}
+
+// validateTargetsFile checks a JSON targets file
+func validateTargetsFile(path string) (string, error) {
+	targets, err := runner.LoadTargets(path)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		return "", fmt.Errorf("no targets defined")
+	}
+	for _, t := range targets {
+		if err := runner.ValidateURL(t.URL); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("targets file, %d targets", len(targets)), nil
+}
+
+// validateConfigFile runs the g0 run checks against a config file
+func validateConfigFile(path string) (string, error) {
+	// A fresh flag set resets every run flag to its default, so files
+	// validated earlier don't leak values into this one
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	addRunFlags(flags)
+	configFile = path
+
+	plan, err := prepareRun(flags)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("config, %d URLs, %d workers, %s, %d thresholds",
+		len(plan.urls), plan.workers, plan.duration, len(plan.thresholds)), nil
+}
+
+// validateDataFile checks a CSV data file
+func validateDataFile(path string) (string, error) {
+	data, err := runner.LoadCSV(path, runner.FeedSequential)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data file, %d rows, columns: %s", data.Len(), strings.Join(data.Columns(), ", ")), nil
+}
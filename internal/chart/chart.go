@@ -0,0 +1,221 @@
+// Package chart renders simple line and bar charts to PNG and SVG
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// Default image size in pixels
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 450
+)
+
+// Plot area margins in pixels
+const (
+	marginLeft   = 70
+	marginRight  = 24
+	marginTop    = 48
+	marginBottom = 48
+)
+
+// Palette holds the series colors, used in order
+var Palette = []color.RGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+}
+
+var (
+	colorBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	colorText       = color.RGBA{R: 0x22, G: 0x22, B: 0x22, A: 0xff}
+	colorAxis       = color.RGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff}
+	colorGrid       = color.RGBA{R: 0xe4, G: 0xe4, B: 0xe4, A: 0xff}
+)
+
+// Series is one line (or the bars) of a chart
+type Series struct {
+	Name string
+	X    []float64
+	Y    []float64
+}
+
+// Chart describes what to draw; the Y axis always starts at zero
+type Chart struct {
+	Title    string
+	XLabel   string
+	YLabel   string
+	Series   []Series
+	Bars     bool    // Draw the first series as bars of width BarWidth instead of lines
+	BarWidth float64 // Bar width in X units
+
+	// FormatX and FormatY format tick labels (default: up to two decimals)
+	FormatX func(float64) string
+	FormatY func(float64) string
+}
+
+// Formats supported by Render
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// anchor aligns text horizontally at its position
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas is a drawing surface in pixel coordinates with the origin at the top left
+type canvas interface {
+	rect(x, y, w, h float64, c color.RGBA)
+	line(x1, y1, x2, y2, width float64, c color.RGBA)
+	polyline(xs, ys []float64, width float64, c color.RGBA)
+	text(x, y float64, s string, a anchor, c color.RGBA)
+	writeTo(w io.Writer) error
+}
+
+// Render draws the chart in the given format (png or svg) to w
+func Render(w io.Writer, c Chart, format string, width, height int) error {
+	var cv canvas
+	switch format {
+	case FormatPNG:
+		cv = newPNGCanvas(width, height)
+	case FormatSVG:
+		cv = newSVGCanvas(width, height)
+	default:
+		return fmt.Errorf("unsupported chart format %q (available: %s, %s)", format, FormatPNG, FormatSVG)
+	}
+	c.draw(cv, float64(width), float64(height))
+	return cv.writeTo(w)
+}
+
+// draw lays out the title, axes, grid, series and legend
+func (c Chart) draw(cv canvas, width, height float64) {
+	formatX, formatY := c.FormatX, c.FormatY
+	if formatX == nil {
+		formatX = formatNumber
+	}
+	if formatY == nil {
+		formatY = formatNumber
+	}
+
+	// Data ranges; bars extend half their width past the first and last X
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	yMax := 0.0
+	for _, s := range c.Series {
+		for i, x := range s.X {
+			xMin = math.Min(xMin, x)
+			xMax = math.Max(xMax, x)
+			yMax = math.Max(yMax, s.Y[i])
+		}
+	}
+	if math.IsInf(xMin, 0) {
+		xMin, xMax = 0, 1
+	}
+	if c.Bars {
+		xMin -= c.BarWidth / 2
+		xMax += c.BarWidth / 2
+	}
+	if xMax <= xMin {
+		xMax = xMin + 1
+	}
+	yTicks := niceTicks(0, yMax, 5)
+	yMax = yTicks[len(yTicks)-1]
+
+	left, top := float64(marginLeft), float64(marginTop)
+	plotW, plotH := width-marginLeft-marginRight, height-marginTop-marginBottom
+	px := func(x float64) float64 { return left + (x-xMin)/(xMax-xMin)*plotW }
+	py := func(y float64) float64 { return top + plotH - y/yMax*plotH }
+
+	cv.rect(0, 0, width, height, colorBackground)
+	cv.text(width/2, 22, c.Title, anchorMiddle, colorText)
+	cv.text(left, top-10, c.YLabel, anchorStart, colorText)
+	cv.text(left+plotW/2, height-10, c.XLabel, anchorMiddle, colorText)
+
+	for _, y := range yTicks {
+		cv.line(left, py(y), left+plotW, py(y), 1, colorGrid)
+		cv.text(left-6, py(y)+4, formatY(y), anchorEnd, colorText)
+	}
+	for _, x := range niceTicks(xMin, xMax, 8) {
+		if x < xMin || x > xMax {
+			continue
+		}
+		cv.line(px(x), top+plotH, px(x), top+plotH+4, 1, colorAxis)
+		cv.text(px(x), top+plotH+18, formatX(x), anchorMiddle, colorText)
+	}
+
+	for i, s := range c.Series {
+		col := Palette[i%len(Palette)]
+		if c.Bars && i == 0 {
+			for j, x := range s.X {
+				x0, x1 := px(x-c.BarWidth/2), px(x+c.BarWidth/2)
+				cv.rect(x0, py(s.Y[j]), math.Max(x1-x0-1, 1), top+plotH-py(s.Y[j]), col)
+			}
+			continue
+		}
+		xs := make([]float64, len(s.X))
+		ys := make([]float64, len(s.Y))
+		for j := range s.X {
+			xs[j], ys[j] = px(s.X[j]), py(s.Y[j])
+		}
+		cv.polyline(xs, ys, 2, col)
+	}
+
+	cv.line(left, top+plotH, left+plotW, top+plotH, 1, colorAxis)
+	cv.line(left, top, left, top+plotH, 1, colorAxis)
+
+	// Legend in the top right corner, only needed with several series
+	if len(c.Series) > 1 {
+		y := top + 14
+		for i, s := range c.Series {
+			x := left + plotW - 10
+			cv.text(x-24, y+4, s.Name, anchorEnd, colorText)
+			cv.line(x-18, y, x, y, 3, Palette[i%len(Palette)])
+			y += 16
+		}
+	}
+}
+
+// niceTicks returns about n evenly spaced round values; the first is at or
+// below min and the last at or above max
+func niceTicks(min, max float64, n int) []float64 {
+	if max <= min {
+		max = min + 1
+	}
+	raw := (max - min) / float64(n)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		step = m * magnitude
+		if step >= raw {
+			break
+		}
+	}
+
+	var ticks []float64
+	for i := math.Floor(min / step); ; i++ {
+		ticks = append(ticks, i*step)
+		if i*step >= max-step*1e-9 {
+			return ticks
+		}
+	}
+}
+
+// formatNumber formats a tick value without trailing zeros
+func formatNumber(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,143 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		n        int
+		want     []float64
+	}{
+		{0, 100, 5, []float64{0, 20, 40, 60, 80, 100}},
+		{0, 97, 5, []float64{0, 20, 40, 60, 80, 100}},
+		{0, 1, 5, []float64{0, 0.2, 0.4, 0.6000000000000001, 0.8, 1}},
+		{0, 12, 5, []float64{0, 2.5, 5, 7.5, 10, 12.5}},
+		{0, 42, 8, []float64{0, 10, 20, 30, 40, 50}},
+		{13, 87, 5, []float64{0, 20, 40, 60, 80, 100}},
+		{1000, 1090, 4, []float64{1000, 1025, 1050, 1075, 1100}},
+		{-0.5, 9.5, 8, []float64{-2, 0, 2, 4, 6, 8, 10}},
+		{0, 0, 5, []float64{0, 0.2, 0.4, 0.6000000000000001, 0.8, 1}}, // An empty range spans one unit
+		{0, 3500, 5, []float64{0, 1000, 2000, 3000, 4000}},
+	}
+	for _, tt := range tests {
+		got := niceTicks(tt.min, tt.max, tt.n)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("niceTicks(%v, %v, %d) = %v, want %v", tt.min, tt.max, tt.n, got, tt.want)
+			continue
+		}
+		if got[0] > tt.min || got[len(got)-1] < tt.max {
+			t.Errorf("niceTicks(%v, %v, %d) = %v does not cover the range", tt.min, tt.max, tt.n, got)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	for v, want := range map[float64]string{0: "0", 2.5: "2.5", 100: "100", 0.6000000000000001: "0.6", 1.234: "1.23", -0.001: "0"} {
+		if got := formatNumber(v); got != want {
+			t.Errorf("formatNumber(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+// testChart has two series, so it draws a legend
+var testChart = Chart{
+	Title:  "Latency <p99>",
+	XLabel: "Time (s)",
+	YLabel: "ms",
+	Series: []Series{
+		{Name: "p50", X: []float64{0, 1, 2}, Y: []float64{10, 12, 11}},
+		{Name: "p99", X: []float64{0, 1, 2}, Y: []float64{40, 55, 48}},
+	},
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites it under -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (run go test -update to accept it)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, testChart, FormatSVG, 200, 150); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGolden(t, "chart.svg", buf.Bytes())
+
+	// The document is well-formed XML
+	dec := xml.NewDecoder(&buf)
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err != io.EOF {
+				t.Errorf("invalid SVG: %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestRenderBarsSVG(t *testing.T) {
+	c := Chart{Bars: true, BarWidth: 10, Series: []Series{{Name: "count", X: []float64{5, 15}, Y: []float64{3, 1}}}}
+	var buf bytes.Buffer
+	if err := Render(&buf, c, FormatSVG, 200, 150); err != nil {
+		t.Fatal(err)
+	}
+	// Two bars spanning the X axis, the first 3 of 3 units high, the second 1
+	for _, bar := range []string{
+		`<rect x="70.0" y="48.0" width="52.0" height="54.0" fill="#1f77b4"/>`,
+		`<rect x="123.0" y="84.0" width="52.0" height="18.0" fill="#1f77b4"/>`,
+	} {
+		if !strings.Contains(buf.String(), bar) {
+			t.Errorf("SVG lacks %s:\n%s", bar, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "<polyline") {
+		t.Errorf("bar chart draws a line")
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, testChart, FormatPNG, 320, 200); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 320 || size.Y != 200 {
+		t.Errorf("PNG is %v, want 320x200", size)
+	}
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	if err := Render(&bytes.Buffer{}, testChart, "gif", 200, 150); err == nil {
+		t.Errorf("gif accepted")
+	}
+}
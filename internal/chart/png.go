@@ -0,0 +1,72 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// pngCanvas draws into an RGBA image using the built-in 7x13 bitmap font
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	return &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64, col color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(c.img, r, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// line steps along the longer axis and stamps a square of the line width at each step
+func (c *pngCanvas) line(x1, y1, x2, y2, width float64, col color.RGBA) {
+	steps := math.Max(math.Abs(x2-x1), math.Abs(y2-y1))
+	if steps < 1 {
+		steps = 1
+	}
+	half := (width - 1) / 2
+	for i := 0.0; i <= steps; i++ {
+		x := x1 + (x2-x1)*i/steps
+		y := y1 + (y2-y1)*i/steps
+		for dx := -half; dx <= half; dx++ {
+			for dy := -half; dy <= half; dy++ {
+				c.img.SetRGBA(int(math.Round(x+dx)), int(math.Round(y+dy)), col)
+			}
+		}
+	}
+}
+
+func (c *pngCanvas) polyline(xs, ys []float64, width float64, col color.RGBA) {
+	if len(xs) == 1 {
+		c.line(xs[0], ys[0], xs[0], ys[0], width+1, col)
+	}
+	for i := 1; i < len(xs); i++ {
+		c.line(xs[i-1], ys[i-1], xs[i], ys[i], width, col)
+	}
+}
+
+func (c *pngCanvas) text(x, y float64, s string, a anchor, col color.RGBA) {
+	d := &font.Drawer{Dst: c.img, Src: image.NewUniform(col), Face: basicfont.Face7x13}
+	width := d.MeasureString(s).Round()
+	switch a {
+	case anchorMiddle:
+		x -= float64(width) / 2
+	case anchorEnd:
+		x -= float64(width)
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	d.DrawString(s)
+}
+
+func (c *pngCanvas) writeTo(w io.Writer) error {
+	return png.Encode(w, c.img)
+}
@@ -0,0 +1,94 @@
+// Package clock provides the time source load tests measure with
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock reads the current time
+// Readings are monotonic: a reading is never before an earlier one, so the
+// durations between them are never negative and never include a step of the
+// wall clock (e.g., NTP correcting it during a long run)
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// systemClock reads the time from the monotonic clock of the OS
+type systemClock struct {
+	base time.Time
+}
+
+// system is shared so every reading of the process is on the same timeline
+var system = &systemClock{base: time.Now()}
+
+// System returns the real clock
+// Its readings are taken on the monotonic clock and placed on the wall-clock
+// timeline of the moment the process started, so a wall-clock step during the
+// run moves neither the latencies nor the timestamps of the results
+func System() Clock {
+	return system
+}
+
+// Now returns the current time
+func (c *systemClock) Now() time.Time {
+	return c.base.Add(time.Since(c.base))
+}
+
+// Since returns the time elapsed since t, never less than zero
+func (c *systemClock) Since(t time.Time) time.Duration {
+	return nonNegative(c.Now().Sub(t))
+}
+
+// Or returns c, or the real clock if c is nil
+func Or(c Clock) Clock {
+	if c == nil {
+		return system
+	}
+	return c
+}
+
+// Manual is a clock that only moves when told to, so code measuring time can be
+// checked with exact durations
+type Manual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManual creates a clock reading start until it is advanced
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+// Now returns the current time
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Since returns the time elapsed since t, never less than zero
+func (m *Manual) Since(t time.Time) time.Duration {
+	return nonNegative(m.Now().Sub(t))
+}
+
+// Advance moves the clock forward by d
+// The clock never goes back, so a negative d is ignored
+func (m *Manual) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
+
+// nonNegative clamps a duration at zero; a time from another source (e.g., read
+// back from a file) may lie after the current reading
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
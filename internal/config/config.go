@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// File holds `g0 run` flag values keyed by flag name, as stored in YAML
+// config files and profiles
+//
+// Example:
+//
+//	url:
+//	  - https://staging.example.com/api
+//	concurrency: 50
+//	duration: 1m
+//	headers:
+//	  - "Authorization: Bearer token"
+//	threshold:
+//	  - p95<300ms
+type File map[string]interface{}
+
+// Load reads a YAML config file
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file := File{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Marshal encodes the config as YAML
+func (f File) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}(f)); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Save writes the config to a YAML file, creating parent directories as needed
+func (f File) Save(path string) error {
+	data, err := f.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Apply sets every flag from the config that was not given on the command line,
+// so explicit flags always win over config values
+func (f File) Apply(flags *pflag.FlagSet) error {
+	for _, name := range f.Keys() {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown config key %q (keys are `g0 run` flag names)", name)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		values, err := stringValues(f[name])
+		if err != nil {
+			return fmt.Errorf("invalid value for %q: %w", name, err)
+		}
+		for _, v := range values {
+			if err := flags.Set(name, v); err != nil {
+				return fmt.Errorf("invalid value for %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Keys returns the config keys in sorted order
+func (f File) Keys() []string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FromFlags builds a config from the flags that were explicitly set
+// Flags listed in exclude (e.g., "profile") are skipped
+func FromFlags(flags *pflag.FlagSet, exclude ...string) File {
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+
+	file := File{}
+	flags.Visit(func(flag *pflag.Flag) {
+		if skip[flag.Name] {
+			return
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			file[flag.Name] = slice.GetSlice()
+			return
+		}
+
+		// Keep numbers and booleans typed so the YAML stays readable
+		raw := flag.Value.String()
+		switch flag.Value.Type() {
+		case "int", "int64":
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				file[flag.Name] = n
+				return
+			}
+		case "float64":
+			if n, err := strconv.ParseFloat(raw, 64); err == nil {
+				file[flag.Name] = n
+				return
+			}
+		case "bool":
+			if b, err := strconv.ParseBool(raw); err == nil {
+				file[flag.Name] = b
+				return
+			}
+		}
+		file[flag.Name] = raw
+	})
+	return file
+}
+
+// stringValues converts a YAML value (scalar or list) into flag values
+func stringValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, fmt.Errorf("nested lists and maps are not supported")
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	case map[string]interface{}:
+		return nil, fmt.Errorf("maps are not supported")
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// profileNamePattern restricts profile names to safe file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ProfileDir returns the directory profiles are stored in (e.g., ~/.config/g0/profiles)
+func ProfileDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "g0", "profiles"), nil
+}
+
+// ProfilePath returns the file path of a named profile
+func ProfilePath(name string) (string, error) {
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	dir, err := ProfileDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".yaml"), nil
+}
+
+// ListProfiles returns the names of all saved profiles
+func ListProfiles() ([]string, error) {
+	dir, err := ProfileDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, filepath.Base(m[:len(m)-len(".yaml")]))
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,140 @@
+// Package grafana generates Grafana dashboards for the metrics g0 serves to Prometheus
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// DashboardOptions configures the generated dashboard
+type DashboardOptions struct {
+	Title   string
+	UID     string // Stable dashboard UID so re-imports replace the dashboard ("" = Grafana assigns one)
+	Refresh string // Auto-refresh interval, e.g. 5s
+}
+
+// Grid layout: Grafana dashboards are 24 units wide
+const (
+	gridWidth   = 24
+	panelHeight = 8
+)
+
+// datasource refers to the Prometheus data source chosen in the dashboard variable
+var datasource = map[string]string{"type": "prometheus", "uid": "${datasource}"}
+
+// instanceFilter restricts queries to the generators selected in the instance variable
+const instanceFilter = `instance=~"$instance"`
+
+// panel is one dashboard panel with its queries
+type panel struct {
+	title   string
+	unit    string
+	width   int
+	queries []query
+}
+
+// query is a PromQL expression with its legend
+type query struct {
+	expr   string
+	legend string
+}
+
+// Dashboard returns the dashboard JSON, ready for Grafana's dashboard import
+func Dashboard(opts DashboardOptions) ([]byte, error) {
+	rate := func(metric string) string {
+		return fmt.Sprintf("rate(%s{%s}[$__rate_interval])", metric, instanceFilter)
+	}
+	quantile := func(q float64) query {
+		return query{
+			expr:   fmt.Sprintf("histogram_quantile(%g, sum by (le) (%s))", q, rate(runner.MetricDuration+"_bucket")),
+			legend: fmt.Sprintf("p%g", q*100),
+		}
+	}
+
+	panels := []panel{
+		{title: "Requests per second", unit: "reqps", width: 12, queries: []query{
+			{expr: fmt.Sprintf("sum by (status_class) (%s)", rate(runner.MetricRequests)), legend: "{{status_class}}"},
+		}},
+		{title: "Error rate", unit: "percent", width: 12, queries: []query{
+			{expr: fmt.Sprintf("100 * sum(%s) / sum(%s)", rate(runner.MetricFailedRequests), rate(runner.MetricRequests)), legend: "failed"},
+		}},
+		{title: "Latency", unit: "s", width: 12, queries: []query{
+			quantile(0.5), quantile(0.95), quantile(0.99),
+			{expr: fmt.Sprintf("sum(%s) / sum(%s)", rate(runner.MetricDuration+"_sum"), rate(runner.MetricDuration+"_count")), legend: "avg"},
+		}},
+		{title: "Throughput", unit: "Bps", width: 12, queries: []query{
+			{expr: fmt.Sprintf("sum(%s)", rate(runner.MetricBytesReceived)), legend: "received"},
+			{expr: fmt.Sprintf("sum(%s)", rate(runner.MetricBytesSent)), legend: "sent"},
+		}},
+		{title: "Workers", unit: "short", width: 24, queries: []query{
+			{expr: fmt.Sprintf("sum(%s{%s})", runner.MetricWorkers, instanceFilter), legend: "workers"},
+		}},
+	}
+
+	var out []map[string]interface{}
+	x, y := 0, 0
+	for i, p := range panels {
+		if x+p.width > gridWidth {
+			x, y = 0, y+panelHeight
+		}
+		targets := make([]map[string]interface{}, len(p.queries))
+		for j, q := range p.queries {
+			targets[j] = map[string]interface{}{
+				"datasource":   datasource,
+				"expr":         q.expr,
+				"legendFormat": q.legend,
+				"refId":        string(rune('A' + j)),
+			}
+		}
+		out = append(out, map[string]interface{}{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": x, "y": y, "w": p.width, "h": panelHeight},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]interface{}{"unit": p.unit},
+				"overrides": []interface{}{},
+			},
+			"targets": targets,
+		})
+		x += p.width
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         opts.Title,
+		"tags":          []string{"g0", "load-testing"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"refresh":       opts.Refresh,
+		"time":          map[string]string{"from": "now-15m", "to": "now"},
+		"panels":        out,
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				map[string]interface{}{
+					"name":       "instance",
+					"label":      "Generator",
+					"type":       "query",
+					"datasource": datasource,
+					"query":      fmt.Sprintf("label_values(%s, instance)", runner.MetricWorkers),
+					"refresh":    2, // Reload the generators when the time range changes
+					"multi":      true,
+					"includeAll": true,
+					"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+				},
+			},
+		},
+	}
+	if opts.UID != "" {
+		dashboard["uid"] = opts.UID
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
@@ -0,0 +1,340 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Authentication schemes answered by an Authenticator (NewAuthenticator)
+const (
+	AuthDigest    = "digest"
+	AuthNTLM      = "ntlm"
+	AuthNegotiate = "negotiate" // SPNEGO with Kerberos
+)
+
+// maxAuthLegs bounds the challenges answered for one request, so a server that
+// keeps rejecting the credentials doesn't loop; an NTLM handshake takes two,
+// and may be retried once
+const maxAuthLegs = 4
+
+// Authenticator answers the authentication challenges (401 with WWW-Authenticate)
+// of one scheme; the client resends the request with each answer
+// Implementations are shared by all workers and must be safe for concurrent use
+type Authenticator interface {
+	// Scheme returns the scheme as named in WWW-Authenticate, e.g. "Digest"
+	Scheme() string
+
+	// Authorize returns an Authorization header to send before any challenge,
+	// e.g. from an earlier challenge of the same server ("" = send without)
+	Authorize(req *http.Request) string
+
+	// Respond returns the Authorization header answering a challenge of the
+	// scheme, the leg-th for this request (from 0); "" gives up and returns the 401
+	Respond(req *http.Request, challenge Challenge, leg int) (string, error)
+
+	// PerConnection reports whether the scheme authenticates connections rather
+	// than requests (NTLM, Negotiate); new connections then answer the challenges as they
+	// are dialed, before any request is sent on them
+	PerConnection() bool
+}
+
+// Credentials authenticate against a server; which fields apply depends on the scheme
+type Credentials struct {
+	// Username and Password of Digest and NTLM; an NTLM user may name its
+	// domain as DOMAIN\user or user@domain
+	// With a keytab, Username is the Kerberos principal (user or user@REALM)
+	Username string
+	Password string
+
+	// Negotiate takes its Kerberos tickets from Keytab, or else from the ticket
+	// cache at CCache ("" = $KRB5CCNAME or /tmp/krb5cc_<uid>)
+	Keytab string
+	CCache string
+	// Krb5Config is the Kerberos configuration ("" = $KRB5_CONFIG or /etc/krb5.conf)
+	Krb5Config string
+	// SPN is the service principal tickets are requested for ("" = HTTP/<host>)
+	SPN string
+}
+
+// NewAuthenticator returns the authenticator of scheme (AuthDigest, ...) for
+// the credentials
+func NewAuthenticator(scheme string, creds Credentials) (Authenticator, error) {
+	switch strings.ToLower(scheme) {
+	case AuthDigest, AuthNTLM:
+		if creds.Username == "" {
+			return nil, fmt.Errorf("%s authentication needs a user name", scheme)
+		}
+		if strings.EqualFold(scheme, AuthDigest) {
+			return newDigestAuth(creds.Username, creds.Password), nil
+		}
+		return newNTLMAuth(creds.Username, creds.Password), nil
+	case AuthNegotiate:
+		return newNegotiateAuth(creds)
+	default:
+		return nil, fmt.Errorf("unsupported authentication scheme %q (expected %s, %s or %s)", scheme, AuthDigest, AuthNTLM, AuthNegotiate)
+	}
+}
+
+// Challenge is one challenge of a WWW-Authenticate header
+type Challenge struct {
+	Scheme string
+	Token  string            // token68 value, e.g. the base64 NTLM message ("" = none)
+	Params map[string]string // auth-params, names in lower case
+}
+
+// parseChallenges parses the challenges of WWW-Authenticate header values, e.g.
+// `Digest realm="x", nonce="y", NTLM`
+func parseChallenges(values []string) []Challenge {
+	var challenges []Challenge
+	for _, value := range values {
+		s := value
+		for {
+			s = strings.TrimLeft(s, " \t,")
+			if s == "" {
+				break
+			}
+			scheme := s
+			if i := strings.IndexAny(s, " \t,"); i >= 0 {
+				scheme = s[:i]
+			}
+			s = s[len(scheme):]
+			c := Challenge{Scheme: scheme, Params: make(map[string]string)}
+			c.Token, c.Params, s = parseAuthParams(s)
+			challenges = append(challenges, c)
+		}
+	}
+	return challenges
+}
+
+// parseAuthParams parses the token68 or the auth-params following a scheme up
+// to the next challenge and returns the rest of the header
+func parseAuthParams(s string) (string, map[string]string, string) {
+	params := make(map[string]string)
+	s = strings.TrimLeft(s, " \t")
+	// A token68 is a single value not followed by '=' and a parameter value
+	end := strings.IndexAny(s, " \t,")
+	if end < 0 {
+		end = len(s)
+	}
+	if word := s[:end]; word != "" && !strings.Contains(strings.TrimRight(word, "="), "=") {
+		rest := strings.TrimLeft(s[end:], " \t")
+		if rest == "" || rest[0] == ',' {
+			return word, params, rest
+		}
+	}
+
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		sep := strings.IndexAny(s, " \t,")
+		if eq <= 0 || (sep >= 0 && sep < eq) {
+			// Not a parameter: the next challenge starts here
+			return "", params, s
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value, s = b.String(), s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexAny(s, " \t,")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		params[name] = value
+	}
+}
+
+// authTransport answers the authentication challenges of an Authenticator,
+// resending the request until it is authenticated or the authenticator gives up
+type authTransport struct {
+	base http.RoundTripper
+	auth Authenticator
+}
+
+// authChallengesKey is the context key of the challenges answered for a request
+type authChallengesKey struct{}
+
+// authRequestKey is the context key of the request a connection is dialed for
+type authRequestKey struct{}
+
+// RoundTrip sends req, answering challenges of the authenticator's scheme; the
+// number of challenges answered is added to the counter in the request context
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authorization := t.auth.Authorize(req)
+	if t.auth.PerConnection() {
+		req = req.WithContext(context.WithValue(req.Context(), authRequestKey{}, req))
+	}
+	for leg := 0; ; leg++ {
+		attempt := req
+		if leg > 0 || authorization != "" {
+			attempt = req.Clone(req.Context())
+			if leg > 0 && req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attempt.Body = body
+			}
+			attempt.Header.Set("Authorization", authorization)
+		}
+		resp, err := t.base.RoundTrip(attempt)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || leg == maxAuthLegs {
+			return resp, err
+		}
+		// A streamed body can't be sent again
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+		challenge, ok := findChallenge(resp.Header.Values("WWW-Authenticate"), t.auth.Scheme())
+		if !ok {
+			return resp, nil
+		}
+		next, err := t.auth.Respond(req, challenge, leg)
+		if err != nil || next == "" {
+			return resp, err
+		}
+
+		// Drain the rejected response so its connection carries the next leg
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		authorization = next
+		countChallenge(req.Context())
+	}
+}
+
+// countChallenge adds an answered challenge to the request's counter
+func countChallenge(ctx context.Context) {
+	if challenges, ok := ctx.Value(authChallengesKey{}).(*int64); ok {
+		atomic.AddInt64(challenges, 1)
+	}
+}
+
+// authDialer authenticates new connections for a per-connection scheme before
+// the transport gets them
+// Handshaking through the transport would let it hand the connection to
+// another request between the legs, which breaks NTLM under concurrency
+type authDialer struct {
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	tls  *tls.Config // Base TLS configuration of https connections (nil = defaults)
+	auth Authenticator
+}
+
+// DialContext dials a plain connection and authenticates it
+func (d *authDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.handshake(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// DialTLSContext dials a TLS connection, limited to HTTP/1.1, and authenticates it
+func (d *authDialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{}
+	if d.tls != nil {
+		config = d.tls.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	config.NextProtos = []string{"http/1.1"}
+	tlsConn, err := clientHandshake(ctx, conn, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.handshake(ctx, tlsConn); err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// handshake answers the challenges of the request the connection is dialed for
+// with bodiless copies of it, until a response is not a challenge
+// A connection left unauthenticated is still handed out; its first request
+// then answers the challenges itself
+func (d *authDialer) handshake(ctx context.Context, conn net.Conn) error {
+	req, ok := ctx.Value(authRequestKey{}).(*http.Request)
+	if !ok {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	reader := bufio.NewReader(conn)
+	challenge := Challenge{Scheme: d.auth.Scheme()}
+	for leg := 0; leg < maxAuthLegs; leg++ {
+		authorization, err := d.auth.Respond(req, challenge, leg)
+		if err != nil || authorization == "" {
+			return err
+		}
+		probe := &http.Request{
+			Method:     req.Method,
+			URL:        req.URL,
+			Host:       req.Host,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Authorization": {authorization}},
+		}
+		if err := probe.Write(conn); err != nil {
+			return err
+		}
+		resp, err := http.ReadResponse(reader, probe)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		countChallenge(ctx)
+		if resp.Close {
+			return fmt.Errorf("server closed the connection during %s authentication", d.auth.Scheme())
+		}
+		if resp.StatusCode != http.StatusUnauthorized {
+			return nil
+		}
+		if challenge, ok = findChallenge(resp.Header.Values("WWW-Authenticate"), d.auth.Scheme()); !ok {
+			return nil
+		}
+	}
+	return nil
+}
+
+// findChallenge returns the challenge of scheme among WWW-Authenticate values
+func findChallenge(values []string, scheme string) (Challenge, bool) {
+	for _, c := range parseChallenges(values) {
+		if strings.EqualFold(c.Scheme, scheme) {
+			return c, true
+		}
+	}
+	return Challenge{}, false
+}
@@ -0,0 +1,212 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/calummacc/g0/internal/clock"
+)
+
+// SupportedEncodings lists the content codings that can be requested and decoded
+var SupportedEncodings = []string{"gzip", "br", "deflate"}
+
+// ErrResponseTooLarge fails a response whose body, on the wire or decoded, is
+// larger than Request.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// bodyInfo describes how a response body was transferred and decoded
+type bodyInfo struct {
+	bytesRead       int64         // Body bytes received on the wire
+	decodedBytes    int64         // Body bytes after decompression (equals bytesRead if not compressed)
+	contentEncoding string        // Content-Encoding of the response ("" if identity)
+	decompressTime  time.Duration // Time spent decompressing
+	downloadTime    time.Duration // Time spent receiving the body
+	truncated       bool          // Reading stopped at the byte limit
+}
+
+// skipBodyDrainLimit is the largest known-length body that is still drained when
+// bodies are skipped; draining it is cheaper than losing the keep-alive connection
+const skipBodyDrainLimit = 4 * 1024
+
+// skipBody discards the response body without measuring it
+// Small bodies of known length are drained so the connection stays reusable;
+// anything else is abandoned, which closes HTTP/1.1 connections (HTTP/2 only resets the stream)
+func skipBody(resp *http.Response) bodyInfo {
+	if resp.ContentLength >= 0 && resp.ContentLength <= skipBodyDrainLimit {
+		io.Copy(io.Discard, resp.Body)
+	}
+	return bodyInfo{contentEncoding: strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))}
+}
+
+// readBody reads the response body so the connection can be reused
+// If maxBytes is positive, reading stops after that many bytes (the connection is
+// then closed instead of reused)
+// If decompress is set and the response is compressed, the body is buffered and
+// decoded separately so network time and decompression time can be measured apart
+// If maxResponse is positive, a body larger than that on the wire or decoded
+// fails with ErrResponseTooLarge as soon as the excess arrives, so a huge
+// response or a decompression bomb isn't read to the end
+// If sink is not nil, it receives the (decoded) body
+// Reading and decoding are timed with clock
+func readBody(clock clock.Clock, resp *http.Response, decompress bool, maxBytes, maxResponse int64, sink io.Writer) (bodyInfo, error) {
+	info := bodyInfo{contentEncoding: strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))}
+
+	if sink == nil {
+		sink = io.Discard
+	}
+
+	// A body announced as too large isn't read at all
+	if maxResponse > 0 && resp.ContentLength > maxResponse && (maxBytes <= 0 || maxBytes >= maxResponse) {
+		return info, fmt.Errorf("%w: Content-Length %d exceeds %d bytes", ErrResponseTooLarge, resp.ContentLength, maxResponse)
+	}
+
+	var body io.Reader = resp.Body
+	if maxBytes > 0 {
+		// Read one extra byte to detect truncation
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	if maxResponse > 0 {
+		body = &cappedReader{r: body, limit: maxResponse}
+	}
+
+	start := clock.Now()
+	if !decompress || info.contentEncoding == "" || info.contentEncoding == "identity" {
+		w := sink
+		if maxBytes > 0 && w != io.Discard {
+			// The extra byte read to detect truncation is not part of the body
+			w = &limitedWriter{w: w, n: maxBytes}
+		}
+		n, err := io.Copy(w, body)
+		info.downloadTime = clock.Since(start)
+		if maxBytes > 0 && n > maxBytes {
+			n = maxBytes
+			info.truncated = true
+		}
+		info.bytesRead = n
+		info.decodedBytes = n
+		return info, err
+	}
+
+	raw, err := io.ReadAll(body)
+	info.downloadTime = clock.Since(start)
+	if maxBytes > 0 && int64(len(raw)) > maxBytes {
+		raw = raw[:maxBytes]
+		info.truncated = true
+	}
+	info.bytesRead = int64(len(raw))
+	if err != nil {
+		return info, err
+	}
+	if info.truncated {
+		// A partial compressed stream can't be decoded reliably
+		info.decodedBytes = info.bytesRead
+		return info, nil
+	}
+
+	start = clock.Now()
+	decoder, err := newDecoder(info.contentEncoding, bytes.NewReader(raw))
+	if err != nil {
+		return info, err
+	}
+	if maxResponse > 0 {
+		decoder = &cappedReader{r: decoder, limit: maxResponse}
+	}
+	n, err := io.Copy(sink, decoder)
+	info.decompressTime = clock.Since(start)
+	info.decodedBytes = n
+	if errors.Is(err, ErrResponseTooLarge) {
+		return info, err
+	}
+	if err != nil {
+		return info, fmt.Errorf("failed to decode %s response body: %w", info.contentEncoding, err)
+	}
+	return info, nil
+}
+
+// limitedWriter passes on at most n bytes to w and discards the rest
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	if len(p) > 0 {
+		if _, err := l.w.Write(p); err != nil {
+			return 0, err
+		}
+		l.n -= int64(len(p))
+	}
+	return written, nil
+}
+
+// cappedReader fails with ErrResponseTooLarge once more than limit bytes were read
+type cappedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	// Read at most one byte past the limit
+	if rest := c.limit - c.n + 1; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.n > c.limit {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, c.limit)
+	}
+	return n, err
+}
+
+// EncodeBody compresses a request body with the given content coding
+func EncodeBody(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newDecoder returns a reader that decodes the given content coding
+func newDecoder(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "br":
+		return brotli.NewReader(r), nil
+	case "deflate":
+		// HTTP "deflate" is the zlib format (RFC 9110)
+		return zlib.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
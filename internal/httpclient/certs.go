@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ClientCert is a client certificate presented for mutual TLS
+type ClientCert struct {
+	Name string // File name the certificate was loaded from, without its extension
+	Cert tls.Certificate
+}
+
+// LoadClientCerts loads the client certificates in dir: each NAME.crt or
+// NAME.pem with its private key in the same file or in NAME.key, in name order
+func LoadClientCerts(dir string) ([]ClientCert, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate directory: %w", err)
+	}
+	var certs []ClientCert
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".crt" && ext != ".pem") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ext)
+		certPEM, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keyPEM := certPEM
+		if !bytes.Contains(certPEM, []byte("PRIVATE KEY-----")) {
+			if keyPEM, err = os.ReadFile(filepath.Join(dir, name+".key")); err != nil {
+				return nil, fmt.Errorf("no private key for client certificate %s: %w", entry.Name(), err)
+			}
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate %s: %w", entry.Name(), err)
+		}
+		certs = append(certs, ClientCert{Name: name, Cert: cert})
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no client certificates (*.crt or *.pem) in %s", dir)
+	}
+	sort.Slice(certs, func(i, j int) bool { return certs[i].Name < certs[j].Name })
+	return certs, nil
+}
+
+// certTLSConfig returns the TLS configuration presenting cert; it gets a
+// session cache of its own (sessionTLSConfig), as a resumed session carries
+// the identity it was made with
+func certTLSConfig(cert ClientCert) *tls.Config {
+	return &tls.Config{Certificates: []tls.Certificate{cert.Cert}}
+}
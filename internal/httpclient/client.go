@@ -3,30 +3,207 @@ package httpclient
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"hash"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/calummacc/g0/internal/clock"
 )
 
 // Client wraps http.Client with keep-alive enabled
 type Client struct {
 	httpClient *http.Client
+	protocols  map[string]*http.Client // Clients of requests forcing a protocol (Request.Protocol)
+	clock      clock.Clock             // Times the requests
+
+	// Clients presenting each of Options.ClientCerts over connections of their
+	// own, picked by Request.ClientCert (nil = no client certificates)
+	certs []*Client
+}
+
+// DefaultTimeout is the client-level timeout used when none is configured
+const DefaultTimeout = 30 * time.Second
+
+// Options configures the HTTP client
+type Options struct {
+	// Timeout bounds each request at the client level (0 = no client timeout)
+	Timeout time.Duration
+
+	// ReadBuffer sets the socket receive buffer in bytes (0 = OS default); with slow
+	// reads a small buffer fills quickly, so the server feels the backpressure
+	ReadBuffer int
+
+	// ExpectContinueTimeout is how long requests sent with Request.ExpectContinue wait
+	// for 100 Continue before sending the body anyway
+	ExpectContinueTimeout time.Duration
+
+	// DNSServer resolves host names through this server (host:port, see ParseDNSServer)
+	// instead of the system resolver ("" = system resolver)
+	DNSServer string
+
+	// Clock times the requests (nil = the system's monotonic clock)
+	Clock clock.Clock
+
+	// Auth answers the servers' authentication challenges, e.g. Digest, NTLM or
+	// Negotiate (nil = requests are sent as configured)
+	Auth Authenticator
+
+	// ClientCerts are presented to servers asking for a client certificate, one
+	// per request as picked by Request.ClientCert (nil = none)
+	ClientCerts []ClientCert
+
+	// DisableResumption makes every TLS connection do a full handshake instead of
+	// resuming a session of an earlier connection to the same server
+	DisableResumption bool
+
+	// RequireOCSPStaple fails connections to servers that staple no OCSP response
+	// to their certificate (ErrNoOCSPStaple)
+	RequireOCSPStaple bool
+
+	// ProxyProtocol prepends a PROXY protocol header to each new connection (nil = none)
+	ProxyProtocol *ProxyProtocol
+
+	// ServerNames is how many distinct Request.ServerName values are sent, so the
+	// TLS session cache keeps a session for each (0 = the default cache size)
+	ServerNames int
+}
+
+// DefaultOptions returns the default client options
+func DefaultOptions() Options {
+	return Options{
+		Timeout: DefaultTimeout,
+	}
 }
 
 // New creates a new HTTP client with keep-alive enabled
-func New() *Client {
+func New(opts Options) *Client {
+	if len(opts.ClientCerts) == 0 {
+		return newClient(opts, sessionTLSConfig(&tls.Config{}, opts))
+	}
+	// Connection pools are per host, so each certificate needs its own
+	c := &Client{clock: clock.Or(opts.Clock)}
+	for _, cert := range opts.ClientCerts {
+		c.certs = append(c.certs, newClient(opts, sessionTLSConfig(certTLSConfig(cert), opts)))
+	}
+	return c
+}
+
+// sessionTLSConfig gives config a session cache of its own, so later
+// connections resume the TLS session of an earlier one as browsers do, or
+// disables resumption (Options.DisableResumption)
+// The cache keeps a session per server name
+func sessionTLSConfig(config *tls.Config, opts Options) *tls.Config {
+	if !opts.DisableResumption {
+		config.ClientSessionCache = tls.NewLRUClientSessionCache(opts.ServerNames)
+	} else {
+		config.ClientSessionCache = nil
+		config.SessionTicketsDisabled = true
+	}
+	return config
+}
+
+// newClient creates a client whose TLS connections use tlsConfig
+func newClient(opts Options, tlsConfig *tls.Config) *Client {
+	if opts.RequireOCSPStaple {
+		tlsConfig.VerifyConnection = requireStaple
+	}
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 10,
 		IdleConnTimeout:     90 * time.Second,
 		DisableKeepAlives:   false,
+
+		ExpectContinueTimeout: opts.ExpectContinueTimeout,
+		TLSClientConfig:       tlsConfig,
 	}
+	dial := Dialer(opts)
+	transport.DialContext = dial
+	// The custom dialer would otherwise switch off HTTP/2 negotiation
+	transport.ForceAttemptHTTP2 = true
 
+	// A non-nil empty TLSNextProto keeps HTTP/2 from being negotiated
+	http11 := transport.Clone()
+	http11.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	http2 := transport.Clone()
+	http2.ForceAttemptHTTP2 = true
+	// TLS is negotiated here rather than by net/http, to time the certificate
+	// verification; each transport offers its own protocols
+	for _, t := range []*http.Transport{transport, http11, http2} {
+		t.DialTLSContext = dialTLS(t, dial)
+	}
+
+	wrap := func(rt http.RoundTripper) *http.Client {
+		if opts.Auth != nil {
+			rt = &authTransport{base: rt, auth: opts.Auth}
+		}
+		return &http.Client{Transport: rt, Timeout: opts.Timeout}
+	}
+	// A scheme authenticating connections (NTLM, Negotiate) needs HTTP/1.1
+	// connections of its own, authenticated as they are dialed
+	var negotiated http.RoundTripper = transport
+	if opts.Auth != nil && opts.Auth.PerConnection() {
+		d := &authDialer{dial: dial, tls: http11.TLSClientConfig, auth: opts.Auth}
+		http11.DialContext = d.DialContext
+		http11.DialTLSContext = d.DialTLSContext
+		negotiated = http11
+	}
+	// A replayed request reports the response it got, redirects included
+	raw := wrap(&rawTransport{http10Transport{dial: dial, tls: tlsConfig}})
+	raw.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
 	return &Client{
-		httpClient: &http.Client{
-			Transport: transport,
-			Timeout:   30 * time.Second,
+		httpClient: wrap(negotiated),
+		protocols: map[string]*http.Client{
+			ProtocolHTTP10: wrap(&http10Transport{dial: dial, tls: tlsConfig}),
+			ProtocolHTTP11: wrap(http11),
+			ProtocolHTTP2:  wrap(http2),
+			ProtocolRaw:    raw,
 		},
+		clock: clock.Or(opts.Clock),
+	}
+}
+
+// Dialer returns the function clients created with opts dial connections
+// with: through Options.DNSServer, with Options.ReadBuffer, sending the
+// Options.ProxyProtocol header first
+func Dialer(opts Options) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	if opts.DNSServer != "" {
+		dialer.Resolver = newResolver(opts.DNSServer)
+	}
+	// Requests presenting a server name connect to the address of their URL
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, connectAddr(ctx, addr))
+		if tcp, ok := conn.(*net.TCPConn); ok && opts.ReadBuffer > 0 {
+			tcp.SetReadBuffer(opts.ReadBuffer)
+		}
+		if err == nil && opts.ProxyProtocol != nil {
+			if err := opts.ProxyProtocol.writeHeader(conn); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, err
+	}
+}
+
+// CloseIdleConnections closes the client's kept-alive connections
+func (c *Client) CloseIdleConnections() {
+	for _, cert := range c.certs {
+		cert.CloseIdleConnections()
+	}
+	if c.httpClient == nil {
+		return
+	}
+	c.httpClient.CloseIdleConnections()
+	for _, client := range c.protocols {
+		client.CloseIdleConnections()
 	}
 }
 
@@ -37,23 +214,107 @@ type Request struct {
 	Body    string
 	Headers map[string]string
 	Context context.Context // Context for request cancellation
+
+	// AcceptEncoding requests compressed responses (e.g., "gzip, br") and
+	// decodes them in the client so compression can be measured
+	AcceptEncoding string
+
+	// BodySource streams the body with chunked transfer encoding instead of Body
+	BodySource BodySource
+	// BodyRate limits how fast a streamed body is sent in bytes per second (0 = unlimited)
+	BodyRate int64
+
+	// MaxBodyBytes stops reading the response body after this many bytes (0 = read all)
+	MaxBodyBytes int64
+	// MaxResponseBytes fails the request with ErrResponseTooLarge once the response
+	// body exceeds this many bytes on the wire or decoded (0 = no limit)
+	MaxResponseBytes int64
+	// SkipBody discards response bodies unread; only headers are measured
+	SkipBody bool
+	// KeepBody returns the (decoded) response body in Response.Body
+	KeepBody bool
+	// HashBody returns the SHA-256 of the (decoded) response body in Response.BodySHA256
+	HashBody bool
+
+	// ReadRate limits how fast the response body is read in bytes per second (0 = unlimited)
+	ReadRate int64
+	// ReadDelay pauses before each read of the response body, of up to ReadChunk bytes
+	ReadDelay time.Duration
+
+	// Timeout is a per-request deadline applied through the request context (0 = none)
+	Timeout time.Duration
+
+	// ExpectContinue sends requests with a body with Expect: 100-continue, holding
+	// the body back until the server sends 100 Continue (see Options.ExpectContinueTimeout)
+	ExpectContinue bool
+
+	// Protocol forces an HTTP version (ProtocolHTTP10, ...; "" = negotiate as usual)
+	// ProtocolRaw sends Body as the complete request instead
+	Protocol string
+
+	// ClientCert is the index into Options.ClientCerts of the certificate to
+	// present (ignored without client certificates)
+	ClientCert int
+
+	// ServerName is presented instead of the URL's host, in SNI, the Host header
+	// and the certificate check, while the connection goes to the URL's address
+	// ("" = the URL's host)
+	ServerName string
 }
 
+// Outcomes of requests sent with Expect: 100-continue (Response.Continue)
+const (
+	ContinueReceived = "continued" // The server sent 100 Continue and then received the body
+	ContinueTimeout  = "timeout"   // No 100 Continue arrived in time; the body was sent anyway
+	ContinueFinal    = "final"     // The server answered before the body was sent (e.g., 401 or 417)
+)
+
 // Response represents the result of an HTTP request
 type Response struct {
 	StatusCode int
-	Latency    time.Duration
+	Latency    time.Duration // Total time from sending the request until the body was received
+	TTFB       time.Duration // Time until the first response byte arrived
+	Download   time.Duration // Time spent receiving the response body
+	Header     http.Header   // Response headers (nil if the request failed)
 	Error      error
+
+	BytesSent       int64         // Request body bytes sent
+	BytesRead       int64         // Response body bytes received on the wire
+	DecodedBytes    int64         // Response body bytes after decompression
+	ContentEncoding string        // Content-Encoding of the response
+	DecompressTime  time.Duration // Time spent decompressing the body
+	Truncated       bool          // Body reading stopped at MaxBodyBytes
+	Body            []byte        // Decoded response body (only with Request.KeepBody)
+	BodySHA256      []byte        // SHA-256 of the decoded response body (only with Request.HashBody)
+
+	Connected  bool // A connection was obtained for the request
+	ConnReused bool // The connection was an idle keep-alive connection rather than a new one
+
+	// Expect: 100-continue outcome (ContinueReceived, ...; "" if not sent with the header)
+	// and the time from sending the request headers until 100 Continue arrived
+	Continue     string
+	ContinueWait time.Duration
+
+	Protocol string // Protocol of the response, e.g. "HTTP/2.0" ("" if the request failed)
+
+	DNSLookup time.Duration // Time spent resolving the host name (0 if no lookup was needed)
+
+	// TLS handshake of a new connection (0 = no handshake) and whether it resumed
+	// an earlier session instead of a full handshake
+	TLSHandshake time.Duration
+	TLSResumed   bool
+	CertVerify   time.Duration // Certificate chain verification within the handshake (0 = none, e.g. resumed)
+	OCSPStapled  bool          // The server stapled an OCSP response to its certificate
+
+	AuthChallenges int // Authentication challenges answered before the response (Options.Auth)
 }
 
 // Do performs an HTTP request and returns the response
 func (c *Client) Do(req Request) Response {
-	start := time.Now()
-
-	var bodyReader io.Reader
-	if req.Body != "" {
-		bodyReader = bytes.NewBufferString(req.Body)
+	if len(c.certs) > 0 {
+		return c.certs[req.ClientCert%len(c.certs)].Do(req)
 	}
+	start := c.clock.Now()
 
 	// Use context-aware request creation to support cancellation
 	// If no context is provided, use context.Background()
@@ -61,38 +322,238 @@ func (c *Client) Do(req Request) Response {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	// The auth transport counts the challenges it answers here
+	var challenges int64
+	ctx = context.WithValue(ctx, authChallengesKey{}, &challenges)
+	// The dial of a new connection times its certificate verification here
+	verification := &certVerification{clock: c.clock}
+	ctx = context.WithValue(ctx, certVerificationKey{}, verification)
+
+	var bodyReader io.Reader
+	var streamed *countingReadCloser
+	if req.BodySource != nil {
+		source, err := req.BodySource.Open()
+		if err != nil {
+			return Response{
+				StatusCode: 0,
+				Latency:    c.clock.Since(start),
+				Error:      err,
+			}
+		}
+		if req.BodyRate > 0 {
+			source = newThrottledReader(ctx, source, req.BodyRate)
+		}
+		streamed = &countingReadCloser{ReadCloser: source}
+		bodyReader = streamed
+	} else if req.Body != "" {
+		bodyReader = bytes.NewBufferString(req.Body)
+	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bodyReader)
 	if err != nil {
+		if streamed != nil {
+			streamed.Close()
+		}
 		return Response{
 			StatusCode: 0,
-			Latency:    time.Since(start),
+			Latency:    c.clock.Since(start),
 			Error:      err,
 		}
 	}
 
+	// Unknown length makes the transport use chunked transfer encoding
+	if streamed != nil {
+		httpReq.ContentLength = -1
+	}
+
 	// Set headers
 	for key, value := range req.Headers {
 		httpReq.Header.Set(key, value)
 	}
 
+	// Setting Accept-Encoding explicitly disables the transport's transparent
+	// gzip handling, so the body arrives compressed and is decoded in readBody
+	if req.AcceptEncoding != "" {
+		httpReq.Header.Set("Accept-Encoding", req.AcceptEncoding)
+	}
+
+	// Hold the body back until the server asks for it
+	var expect *continueTrace
+	if req.ExpectContinue && bodyReader != nil {
+		httpReq.Header.Set("Expect", "100-continue")
+		expect = &continueTrace{clock: c.clock}
+	}
+
+	// Record when the first response byte arrives and whether the connection was reused
+	var ttfb time.Duration
+	var connected, reused bool
+	// The lookup and the TLS handshake run on the dialing goroutine, which may
+	// outlive the request when another connection becomes free first
+	var dialMu sync.Mutex
+	var dnsStart, tlsStart time.Time
+	var lookupTime, handshakeTime time.Duration
+	var resumed, stapled bool
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dialMu.Lock()
+			dnsStart = c.clock.Now()
+			dialMu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			dialMu.Lock()
+			lookupTime = c.clock.Since(dnsStart)
+			dialMu.Unlock()
+		},
+		// net/http reports the handshake of a connection dialed with TLS already
+		// negotiated (authDialer) once more, with no work left; the first report
+		// is the real one
+		TLSHandshakeStart: func() {
+			dialMu.Lock()
+			if handshakeTime == 0 {
+				tlsStart = c.clock.Now()
+			}
+			dialMu.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			dialMu.Lock()
+			if err == nil && handshakeTime == 0 {
+				handshakeTime, resumed = c.clock.Since(tlsStart), state.DidResume
+				stapled = len(state.OCSPResponse) > 0
+			}
+			dialMu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			connected, reused = true, info.Reused
+		},
+		GotFirstResponseByte: func() {
+			ttfb = c.clock.Since(start)
+		},
+	}
+	if expect != nil {
+		expect.hook(trace)
+	}
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), trace))
+	if req.ServerName != "" {
+		httpReq = presentServerName(httpReq, req.ServerName)
+	}
+
 	// Perform the request
-	resp, err := c.httpClient.Do(httpReq)
-	latency := time.Since(start)
+	client := c.httpClient
+	if forced := c.protocols[req.Protocol]; forced != nil {
+		client = forced
+	}
+	resp, err := client.Do(httpReq)
+	latency := c.clock.Since(start)
+	dialMu.Lock()
+	dnsLookup := lookupTime
+	tlsHandshake, tlsResumed, ocspStapled := handshakeTime, resumed, stapled
+	dialMu.Unlock()
+	certVerify := time.Duration(verification.duration.Load())
+	// A handshake is the request's only if it got the new connection
+	if reused {
+		tlsHandshake, tlsResumed, ocspStapled, certVerify = 0, false, false, 0
+	}
+
+	var continueOutcome string
+	var continueWait time.Duration
+	if expect != nil {
+		continueOutcome, continueWait = expect.outcome()
+		if continueOutcome == ContinueReceived {
+			// The first byte was the interim response; the final one arrived with the headers
+			ttfb = latency
+		}
+	}
+
+	bytesSent := int64(len(req.Body))
+	if streamed != nil {
+		bytesSent = streamed.count()
+	} else if req.Protocol == ProtocolRaw {
+		bytesSent = rawBodySize(req.Body)
+	}
 
 	if err != nil {
 		return Response{
 			StatusCode: 0,
 			Latency:    latency,
 			Error:      err,
+			BytesSent:  bytesSent,
+			Connected:  connected,
+			ConnReused: reused,
+			DNSLookup:  dnsLookup,
 		}
 	}
 	defer resp.Body.Close()
 
+	// Read like a slow client would, so server buffering and backpressure show
+	if req.ReadRate > 0 {
+		resp.Body = newThrottledReader(ctx, resp.Body, req.ReadRate)
+	}
+	if req.ReadDelay > 0 {
+		resp.Body = newDelayedReader(ctx, resp.Body, req.ReadDelay)
+	}
+
+	// Drain the body so the connection can be reused by keep-alive
+	var body bodyInfo
+	var kept bytes.Buffer
+	var digest hash.Hash
+	var sinks []io.Writer
+	if req.KeepBody {
+		sinks = append(sinks, &kept)
+	}
+	if req.HashBody {
+		digest = sha256.New()
+		sinks = append(sinks, digest)
+	}
+	var sink io.Writer
+	if len(sinks) > 0 {
+		sink = io.MultiWriter(sinks...)
+	}
+	if req.SkipBody {
+		body = skipBody(resp)
+	} else {
+		// A raw request asks for compressed responses in its own headers
+		decompress := req.AcceptEncoding != "" || req.Protocol == ProtocolRaw
+		body, err = readBody(c.clock, resp, decompress, req.MaxBodyBytes, req.MaxResponseBytes, sink)
+	}
+	var sum []byte
+	if digest != nil {
+		sum = digest.Sum(nil)
+	}
+	if ttfb == 0 {
+		ttfb = latency
+	}
+
 	return Response{
-		StatusCode: resp.StatusCode,
-		Latency:    latency,
-		Error:      nil,
+		StatusCode:      resp.StatusCode,
+		Latency:         latency + body.downloadTime,
+		TTFB:            ttfb,
+		Download:        body.downloadTime,
+		Header:          resp.Header,
+		Error:           err,
+		BytesSent:       bytesSent,
+		BytesRead:       body.bytesRead,
+		DecodedBytes:    body.decodedBytes,
+		ContentEncoding: body.contentEncoding,
+		DecompressTime:  body.decompressTime,
+		Truncated:       body.truncated,
+		Body:            kept.Bytes(),
+		BodySHA256:      sum,
+		Connected:       connected,
+		ConnReused:      reused,
+		Continue:        continueOutcome,
+		ContinueWait:    continueWait,
+		Protocol:        resp.Proto,
+		DNSLookup:       dnsLookup,
+		TLSHandshake:    tlsHandshake,
+		TLSResumed:      tlsResumed,
+		CertVerify:      certVerify,
+		OCSPStapled:     ocspStapled,
+
+		AuthChallenges: int(atomic.LoadInt64(&challenges)),
 	}
 }
-
@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+)
+
+// continueTrace follows a request sent with Expect: 100-continue
+// The transport reports from its read and write goroutines, hence the lock
+type continueTrace struct {
+	clock       clock.Clock
+	mu          sync.Mutex
+	headersSent time.Time // Request headers written
+	continued   time.Time // 100 Continue received (zero if none)
+	bodySent    bool      // The whole request, body included, was written
+}
+
+// hook adds the callbacks following the interim response to trace
+func (c *continueTrace) hook(trace *httptrace.ClientTrace) {
+	trace.WroteHeaders = func() {
+		c.mu.Lock()
+		c.headersSent = c.clock.Now()
+		c.mu.Unlock()
+	}
+	trace.Got100Continue = func() {
+		c.mu.Lock()
+		c.continued = c.clock.Now()
+		c.mu.Unlock()
+	}
+	trace.WroteRequest = func(info httptrace.WroteRequestInfo) {
+		c.mu.Lock()
+		c.bodySent = info.Err == nil
+		c.mu.Unlock()
+	}
+}
+
+// outcome returns how the server handled the expectation and, if it sent 100
+// Continue, how long that took after the request headers
+func (c *continueTrace) outcome() (string, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case !c.continued.IsZero():
+		return ContinueReceived, c.continued.Sub(c.headersSent)
+	case c.bodySent:
+		return ContinueTimeout, 0
+	default:
+		return ContinueFinal, 0
+	}
+}
@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// digestAuth answers Digest challenges (RFC 7616, and RFC 2069 servers without
+// qop); the latest challenge of each host is reused for the following requests,
+// as browsers do, so only a new or stale nonce costs a round trip
+type digestAuth struct {
+	username string
+	password string
+
+	mu    sync.Mutex
+	hosts map[string]*digestNonce
+}
+
+// digestNonce is a challenge of one host with its nonce count
+type digestNonce struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // "auth", or "" for RFC 2069 servers
+	count     uint32 // Requests sent with the nonce
+}
+
+func newDigestAuth(username, password string) *digestAuth {
+	return &digestAuth{username: username, password: password, hosts: make(map[string]*digestNonce)}
+}
+
+// Scheme returns "Digest"
+func (d *digestAuth) Scheme() string {
+	return "Digest"
+}
+
+// Authorize answers the host's latest challenge again, with the next nonce count
+func (d *digestAuth) Authorize(req *http.Request) string {
+	d.mu.Lock()
+	n := d.hosts[req.URL.Host]
+	d.mu.Unlock()
+	if n == nil {
+		return ""
+	}
+	return d.authorization(req, n)
+}
+
+// PerConnection returns false: every request carries its own response
+func (d *digestAuth) PerConnection() bool {
+	return false
+}
+
+// Respond answers a new challenge; a second challenge for the same request
+// means the credentials were rejected, unless the server reports the nonce as stale
+func (d *digestAuth) Respond(req *http.Request, challenge Challenge, leg int) (string, error) {
+	if leg > 0 && !strings.EqualFold(challenge.Params["stale"], "true") {
+		return "", nil
+	}
+	n := &digestNonce{
+		realm:     challenge.Params["realm"],
+		nonce:     challenge.Params["nonce"],
+		opaque:    challenge.Params["opaque"],
+		algorithm: challenge.Params["algorithm"],
+	}
+	if n.nonce == "" {
+		return "", fmt.Errorf("digest challenge without a nonce")
+	}
+	if digestHash(n.algorithm) == nil {
+		return "", fmt.Errorf("unsupported digest algorithm %q", n.algorithm)
+	}
+	if qop, ok := challenge.Params["qop"]; ok {
+		// Only qop=auth is supported; auth-int would need every body hashed
+		for _, option := range strings.Split(qop, ",") {
+			if strings.TrimSpace(option) == "auth" {
+				n.qop = "auth"
+			}
+		}
+		if n.qop == "" {
+			return "", fmt.Errorf("unsupported digest qop %q", qop)
+		}
+	}
+
+	d.mu.Lock()
+	d.hosts[req.URL.Host] = n
+	d.mu.Unlock()
+	return d.authorization(req, n), nil
+}
+
+// authorization returns the Authorization header for req under nonce n
+func (d *digestAuth) authorization(req *http.Request, n *digestNonce) string {
+	d.mu.Lock()
+	n.count++
+	nc := fmt.Sprintf("%08x", n.count)
+	d.mu.Unlock()
+	return d.header(req.Method, req.URL.RequestURI(), n, nc, randomHex(16))
+}
+
+// header returns the Authorization header for a request under nonce n with
+// nonce count nc and client nonce cnonce
+func (d *digestAuth) header(method, uri string, n *digestNonce, nc, cnonce string) string {
+	newHash := digestHash(n.algorithm)
+	h := func(parts ...string) string {
+		sum := newHash()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	ha1 := h(d.username, n.realm, d.password)
+	if strings.HasSuffix(strings.ToLower(n.algorithm), "-sess") {
+		ha1 = h(ha1, n.nonce, cnonce)
+	}
+	ha2 := h(method, uri)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username=%q, realm=%q, nonce=%q, uri=%q`, d.username, n.realm, n.nonce, uri)
+	if n.algorithm != "" {
+		fmt.Fprintf(&b, ", algorithm=%s", n.algorithm)
+	}
+	if n.qop == "" {
+		fmt.Fprintf(&b, ", response=%q", h(ha1, n.nonce, ha2))
+	} else {
+		fmt.Fprintf(&b, `, response=%q, qop=%s, nc=%s, cnonce=%q`, h(ha1, n.nonce, nc, cnonce, n.qop, ha2), n.qop, nc, cnonce)
+	}
+	if n.opaque != "" {
+		fmt.Fprintf(&b, ", opaque=%q", n.opaque)
+	}
+	return b.String()
+}
+
+// digestHash returns the hash of a digest algorithm (nil = unsupported)
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	default:
+		return nil
+	}
+}
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
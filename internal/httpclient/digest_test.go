@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// The example of RFC 7616 section 3.9.1
+const (
+	rfc7616Realm  = "http-auth@example.org"
+	rfc7616Nonce  = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+	rfc7616Opaque = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+	rfc7616CNonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+)
+
+func TestDigestRFC7616Example(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		response  string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	d := newDigestAuth("Mufasa", "Circle of Life")
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			n := &digestNonce{realm: rfc7616Realm, nonce: rfc7616Nonce, opaque: rfc7616Opaque, algorithm: tt.algorithm, qop: "auth"}
+			got := d.header("GET", "/dir/index.html", n, "00000001", rfc7616CNonce)
+			want := `Digest username="Mufasa", realm="http-auth@example.org", nonce="` + rfc7616Nonce +
+				`", uri="/dir/index.html", algorithm=` + tt.algorithm + `, response="` + tt.response +
+				`", qop=auth, nc=00000001, cnonce="` + rfc7616CNonce + `", opaque="` + rfc7616Opaque + `"`
+			if got != want {
+				t.Errorf("header =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDigestRespond(t *testing.T) {
+	d := newDigestAuth("Mufasa", "Circle of Life")
+	req := httptest.NewRequest(http.MethodGet, "http://www.example.org/dir/index.html", nil)
+	challenge := Challenge{Scheme: "Digest", Params: map[string]string{
+		"realm": rfc7616Realm, "nonce": rfc7616Nonce, "opaque": rfc7616Opaque, "qop": "auth, auth-int", "algorithm": "SHA-256",
+	}}
+	header, err := d.Respond(req, challenge, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(header, "qop=auth, nc=00000001,") {
+		t.Errorf("first response %q, want qop=auth with nc=00000001", header)
+	}
+	// Later requests reuse the nonce with the next count
+	if header := d.Authorize(req); !strings.Contains(header, "nc=00000002,") {
+		t.Errorf("second request %q, want nc=00000002", header)
+	}
+	// A second challenge rejects the credentials, unless the nonce was stale
+	if header, err := d.Respond(req, challenge, 1); header != "" || err != nil {
+		t.Errorf("second challenge answered with %q, %v", header, err)
+	}
+	challenge.Params["stale"] = "true"
+	if header, err := d.Respond(req, challenge, 1); !strings.Contains(header, "nc=00000001,") || err != nil {
+		t.Errorf("stale nonce answered with %q, %v, want a new nc=00000001", header, err)
+	}
+	challenge.Params["qop"] = "auth-int"
+	if _, err := d.Respond(req, challenge, 0); err == nil {
+		t.Errorf("qop=auth-int alone accepted")
+	}
+}
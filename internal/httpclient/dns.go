@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// ParseDNSServer validates a DNS server address, adding the default port 53 if missing
+func ParseDNSServer(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, "53"
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+			host = ip.String() // Bare IPv6 address
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("invalid DNS server %q (expected host[:port], e.g. 1.1.1.1:53)", addr)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// newResolver returns a resolver sending every query to server (host:port)
+// instead of the servers in /etc/resolv.conf; /etc/hosts still applies
+func newResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
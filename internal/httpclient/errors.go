@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Error classes used to group failed requests in reports
+const (
+	ErrorClassTimeout   = "timeout"
+	ErrorClassCancelled = "cancelled"
+	ErrorClassDNS       = "dns"
+	ErrorClassRefused   = "connection_refused"
+	ErrorClassReset     = "connection_reset"
+	ErrorClassTLS       = "tls"
+	ErrorClassOther     = "other"
+	ErrorClassTooLarge  = "response_too_large"
+)
+
+// ClassifyError maps a request error to a coarse error class
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, ErrResponseTooLarge) {
+		return ErrorClassTooLarge
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCancelled
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorClassRefused
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return ErrorClassReset
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &recordErr) || strings.Contains(err.Error(), "tls: ") {
+		return ErrorClassTLS
+	}
+
+	return ErrorClassOther
+}
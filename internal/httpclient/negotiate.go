@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	krb5client "github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// negotiateAuth answers Negotiate challenges with SPNEGO-wrapped Kerberos
+// tickets, from a keytab or a ticket cache
+// Like NTLM, Negotiate authenticates the connection: each new connection sends
+// a ticket for the service (requested from the KDC once and then cached) before
+// its first request
+type negotiateAuth struct {
+	client *krb5client.Client
+	spn    string // Service principal ("" = HTTP/<host> of the request)
+}
+
+// newNegotiateAuth logs in with the keytab, or loads the ticket cache, so
+// missing or wrong credentials stop the run before it starts
+func newNegotiateAuth(creds Credentials) (*negotiateAuth, error) {
+	confPath := creds.Krb5Config
+	if confPath == "" {
+		confPath = os.Getenv("KRB5_CONFIG")
+	}
+	if confPath == "" {
+		confPath = "/etc/krb5.conf"
+	}
+	conf, err := config.Load(confPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kerberos configuration: %w", err)
+	}
+	settings := krb5client.DisablePAFXFAST(true)
+
+	if creds.Keytab != "" {
+		if creds.Username == "" {
+			return nil, fmt.Errorf("negotiate authentication with a keytab needs a user name (user or user@REALM)")
+		}
+		kt, err := keytab.Load(creds.Keytab)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keytab %s: %w", creds.Keytab, err)
+		}
+		user, realm, ok := strings.Cut(creds.Username, "@")
+		if !ok {
+			realm = conf.LibDefaults.DefaultRealm
+		}
+		client := krb5client.NewWithKeytab(user, realm, kt, conf, settings)
+		if err := client.Login(); err != nil {
+			return nil, fmt.Errorf("kerberos login of %s@%s failed: %w", user, realm, err)
+		}
+		return &negotiateAuth{client: client, spn: creds.SPN}, nil
+	}
+
+	cachePath := creds.CCache
+	if cachePath == "" {
+		cachePath = strings.TrimPrefix(os.Getenv("KRB5CCNAME"), "FILE:")
+	}
+	if cachePath == "" {
+		cachePath = fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid())
+	}
+	cache, err := credentials.LoadCCache(cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kerberos ticket cache %s (run kinit, or give a keytab): %w", cachePath, err)
+	}
+	client, err := krb5client.NewFromCCache(cache, conf, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use Kerberos ticket cache %s: %w", cachePath, err)
+	}
+	return &negotiateAuth{client: client, spn: creds.SPN}, nil
+}
+
+// Scheme returns "Negotiate"
+func (a *negotiateAuth) Scheme() string {
+	return "Negotiate"
+}
+
+// Authorize sends requests without credentials; an authenticated connection
+// needs none
+func (a *negotiateAuth) Authorize(*http.Request) string {
+	return ""
+}
+
+// PerConnection returns true: Negotiate authenticates the connection
+func (a *negotiateAuth) PerConnection() bool {
+	return true
+}
+
+// Respond answers the bare challenge with a ticket; Kerberos takes a single
+// leg, so any further challenge means the ticket was rejected
+func (a *negotiateAuth) Respond(req *http.Request, challenge Challenge, leg int) (string, error) {
+	if challenge.Token != "" || leg > 0 {
+		return "", nil
+	}
+	probe := &http.Request{URL: req.URL, Host: req.Host, Header: make(http.Header)}
+	if err := spnego.SetSPNEGOHeader(a.client, probe, a.spn); err != nil {
+		return "", fmt.Errorf("kerberos: %w", err)
+	}
+	return probe.Header.Get("Authorization"), nil
+}
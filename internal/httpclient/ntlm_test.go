@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// The NTLMv2 example of MS-NLMP section 4.2.4
+var (
+	nlmpServerChallenge = mustHex("0123456789abcdef")
+	nlmpClientChallenge = mustHex("aaaaaaaaaaaaaaaa")
+	// MsvAvNbDomainName "Domain", MsvAvNbComputerName "Server", MsvAvEOL
+	nlmpTargetInfo = mustHex("02000c0044006f006d00610069006e0001000c00530065007200760065007200" + "00000000")
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestNTLMv2MSNLMPExample(t *testing.T) {
+	if got, want := ntHash("Password"), mustHex("a4f49c406510bdcab6824ee7c30fd852"); !bytes.Equal(got, want) {
+		t.Errorf("NT hash = %x, want %x", got, want)
+	}
+	key := ntowfV2("Password", "User", "Domain")
+	if want := mustHex("0c868a403bfd7a93a3001ef22ef02e3f"); !bytes.Equal(key, want) {
+		t.Fatalf("NTOWFv2 = %x, want %x", key, want)
+	}
+
+	nt, lm := ntlmV2Responses(key, nlmpServerChallenge, nlmpClientChallenge, 0, nlmpTargetInfo)
+	if want := mustHex("68cd0ab851e51c96aabc927bebef6a1c"); !bytes.Equal(nt[:16], want) {
+		t.Errorf("NTProofStr = %x, want %x", nt[:16], want)
+	}
+	temp := append(mustHex("0101000000000000"+"0000000000000000"+"aaaaaaaaaaaaaaaa"+"00000000"), nlmpTargetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+	if !bytes.Equal(nt[16:], temp) {
+		t.Errorf("NTLMv2 client blob = %x, want %x", nt[16:], temp)
+	}
+	if want := mustHex("86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa"); !bytes.Equal(lm, want) {
+		t.Errorf("LMv2 response = %x, want %x", lm, want)
+	}
+	if got, want := hmacMD5(key, nt[:16]), mustHex("8de40ccadbc14a82f15cb0ad0de95ca3"); !bytes.Equal(got, want) {
+		t.Errorf("session base key = %x, want %x", got, want)
+	}
+}
+
+// ntlmChallenge returns a CHALLENGE_MESSAGE with targetInfo
+func ntlmChallenge(flags uint32, targetInfo []byte) string {
+	m := make([]byte, 48)
+	copy(m, ntlmSignature)
+	binary.LittleEndian.PutUint32(m[8:], 2)
+	binary.LittleEndian.PutUint32(m[20:], flags)
+	copy(m[24:], nlmpServerChallenge)
+	binary.LittleEndian.PutUint16(m[40:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(m[42:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(m[44:], uint32(len(m)))
+	return base64.StdEncoding.EncodeToString(append(m, targetInfo...))
+}
+
+func TestNTLMRespond(t *testing.T) {
+	a := newNTLMAuth(`Domain\User`, "Password")
+	if a.domain != "Domain" || a.username != "User" {
+		t.Fatalf(`DOMAIN\user split into %q, %q`, a.domain, a.username)
+	}
+
+	negotiate, err := a.Respond(nil, Challenge{Scheme: "NTLM"}, 0)
+	if err != nil || negotiate != "NTLM "+base64.StdEncoding.EncodeToString(ntlmNegotiate()) {
+		t.Fatalf("bare challenge answered with %q, %v", negotiate, err)
+	}
+
+	// With a server timestamp the NTLMv2 response carries it and the LM response is zeros
+	timestamp := mustHex("0700080000c0fa1d5b5ed901")
+	targetInfo := append(append([]byte{}, timestamp...), nlmpTargetInfo...)
+	header, err := a.Respond(nil, Challenge{Scheme: "NTLM", Token: ntlmChallenge(ntlmNegotiateFlags, targetInfo)}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "NTLM "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(message[:8], ntlmSignature) || binary.LittleEndian.Uint32(message[8:]) != 3 {
+		t.Fatalf("response is not an AUTHENTICATE_MESSAGE: %x", message)
+	}
+	fields := make([][]byte, 4)
+	for i := range fields {
+		field, ok := ntlmField(message, 12+8*i)
+		if !ok {
+			t.Fatalf("field %d out of bounds", i)
+		}
+		fields[i] = field
+	}
+	lm, nt, domain, user := fields[0], fields[1], fields[2], fields[3]
+	if !bytes.Equal(lm, make([]byte, 24)) {
+		t.Errorf("LM response = %x, want zeros", lm)
+	}
+	if !bytes.Equal(nt[24:32], timestamp[4:]) {
+		t.Errorf("NTLMv2 timestamp = %x, want the server's %x", nt[24:32], timestamp[4:])
+	}
+	want, _ := ntlmV2Responses(ntowfV2("Password", "User", "Domain"), nlmpServerChallenge, nt[32:40], binary.LittleEndian.Uint64(timestamp[4:]), targetInfo)
+	if !bytes.Equal(nt, want) {
+		t.Errorf("NTLMv2 response = %x, want %x", nt, want)
+	}
+	if !bytes.Equal(domain, utf16LE("Domain")) || !bytes.Equal(user, utf16LE("User")) {
+		t.Errorf("domain, user = %x, %x, want UTF-16LE Domain, User", domain, user)
+	}
+
+	if _, err := a.Respond(nil, Challenge{Scheme: "NTLM", Token: base64.StdEncoding.EncodeToString([]byte("NTLMSSP\x00"))}, 1); err == nil {
+		t.Errorf("truncated challenge accepted")
+	}
+}
@@ -0,0 +1,228 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+)
+
+// Protocols a request can be forced to use (Request.Protocol; "" = negotiate as usual)
+const (
+	ProtocolHTTP10 = "1.0" // HTTP/1.0: one request per connection, no chunked bodies
+	ProtocolHTTP11 = "1.1" // HTTP/1.1 even if the server offers HTTP/2
+	ProtocolHTTP2  = "2"   // HTTP/2 over TLS; servers without it fall back to HTTP/1.1
+
+	// ProtocolRaw sends the request body as the complete request, byte for
+	// byte, on a connection of its own; the URL only says where to connect
+	ProtocolRaw = "raw"
+)
+
+// ValidateProtocol checks a forced protocol ("" is valid and means no forcing)
+func ValidateProtocol(protocol string) error {
+	switch protocol {
+	case "", ProtocolHTTP10, ProtocolHTTP11, ProtocolHTTP2:
+		return nil
+	}
+	return fmt.Errorf("invalid protocol %q (supported: %s, %s, %s)", protocol, ProtocolHTTP10, ProtocolHTTP11, ProtocolHTTP2)
+}
+
+// dialFunc opens a TCP connection
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// http10Transport sends each request as HTTP/1.0 on a connection of its own
+// net/http always speaks HTTP/1.1, so the request is written and read here
+type http10Transport struct {
+	dial dialFunc
+	tls  *tls.Config // Base TLS configuration of https connections (nil = defaults)
+}
+
+// RoundTrip sends req as HTTP/1.0, reporting progress to the request's client trace
+func (t *http10Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	if req.Body != nil && req.ContentLength < 0 {
+		return nil, fmt.Errorf("HTTP/1.0 cannot send a streamed body (no chunked transfer encoding)")
+	}
+	return t.exchange(req, func(w *bufio.Writer) error {
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		fmt.Fprintf(w, "%s %s HTTP/1.0\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), host)
+		header := req.Header.Clone()
+		if header.Get("User-Agent") == "" {
+			header.Set("User-Agent", "Go-http-client/1.0")
+		}
+		if req.Body != nil {
+			header.Set("Content-Length", fmt.Sprint(req.ContentLength))
+		}
+		if err := header.Write(w); err != nil {
+			return err
+		}
+		_, err := w.WriteString("\r\n")
+		return err
+	}, req.Body)
+}
+
+// exchange sends a request on a new connection, writing its head with head
+// and then body (if any), and reads the response, which closes the connection
+// with its body
+func (t *http10Transport) exchange(req *http.Request, head func(w *bufio.Writer) error, body io.Reader) (*http.Response, error) {
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	conn, err := t.connect(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if trace != nil && trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: conn})
+	}
+
+	// Unblock reads and writes when the request is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	w := bufio.NewWriter(conn)
+	if err := head(w); err != nil {
+		return fail(err)
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if trace != nil && trace.WroteHeaders != nil {
+		trace.WroteHeaders()
+	}
+	if body != nil {
+		if _, err := io.Copy(conn, body); err != nil {
+			return fail(err)
+		}
+	}
+	if trace != nil && trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{})
+	}
+
+	br := bufio.NewReader(conn)
+	if _, err := br.Peek(1); err != nil {
+		return fail(err)
+	}
+	if trace != nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return fail(err)
+	}
+	resp.Body = &connBody{ReadCloser: resp.Body, ctx: ctx, conn: conn, stop: stop}
+	return resp, nil
+}
+
+// rawTransport writes the body of each request as the complete request,
+// verbatim, on a connection of its own (ProtocolRaw)
+// Its headers are the request's own; those set on the http.Request are ignored
+type rawTransport struct {
+	http10Transport
+}
+
+// RoundTrip sends the body of req as written, reporting progress to the
+// request's client trace
+func (t *rawTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return nil, fmt.Errorf("raw request is empty")
+	}
+	defer req.Body.Close()
+	return t.exchange(req, func(w *bufio.Writer) error {
+		_, err := io.Copy(w, req.Body)
+		return err
+	}, nil)
+}
+
+// rawBodySize returns the size of the body of a raw request: what follows the
+// blank line ending its head
+func rawBodySize(raw string) int64 {
+	if i := strings.Index(raw, "\r\n\r\n"); i >= 0 {
+		return int64(len(raw) - i - 4)
+	}
+	return 0
+}
+
+// connect dials the target, negotiating TLS for https
+func (t *http10Transport) connect(ctx context.Context, u *url.URL) (net.Conn, error) {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	conn, err := t.dial(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil || u.Scheme != "https" {
+		return conn, err
+	}
+	config := &tls.Config{}
+	if t.tls != nil {
+		config = t.tls.Clone()
+	}
+	config.ServerName = u.Hostname()
+	// net/http adds h2 to the protocols of the configuration it shares
+	config.NextProtos = nil
+	return clientHandshake(ctx, conn, config)
+}
+
+// clientHandshake negotiates TLS on conn (closing it on failure), timing the
+// certificate verification, and reports the handshake to the request's client
+// trace as net/http does for the connections it dials itself
+func clientHandshake(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	verifyingConfig(ctx, config)
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	tlsConn := tls.Client(conn, config)
+	err := tlsConn.HandshakeContext(ctx)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// connBody closes the connection of an HTTP/1.0 or raw response with its body
+type connBody struct {
+	io.ReadCloser
+	ctx  context.Context
+	conn net.Conn
+	stop func() bool
+}
+
+// Read reports a cancelled request as such rather than as a closed connection
+func (b *connBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		err = b.ctx.Err()
+	}
+	return n, err
+}
+
+func (b *connBody) Close() error {
+	b.stop()
+	b.ReadCloser.Close()
+	return b.conn.Close()
+}
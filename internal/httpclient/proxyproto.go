@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+)
+
+// PROXY protocol versions (ProxyProtocol.Version)
+const (
+	ProxyProtocolV1 = "v1" // Text header, e.g. "PROXY TCP4 203.0.113.7 10.0.0.5 51234 443"
+	ProxyProtocolV2 = "v2" // Binary header
+)
+
+// proxyV2Signature starts every PROXY protocol v2 header
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ProxyProtocol prepends a PROXY protocol header to each new connection, as a
+// PROXY-protocol-aware load balancer does, so servers expecting one accept the
+// connections and see them come from Source
+type ProxyProtocol struct {
+	Version string
+	Source  func() net.IP // Client address of a new connection (nil = the connection's own)
+}
+
+// ValidateProxyProtocol checks a PROXY protocol version
+func ValidateProxyProtocol(version string) error {
+	switch version {
+	case ProxyProtocolV1, ProxyProtocolV2:
+		return nil
+	}
+	return fmt.Errorf("invalid PROXY protocol version %q (supported: %s, %s)", version, ProxyProtocolV1, ProxyProtocolV2)
+}
+
+// writeHeader sends the header of conn before any other byte, including the
+// TLS handshake
+func (p *ProxyProtocol) writeHeader(conn net.Conn) error {
+	src, _ := conn.LocalAddr().(*net.TCPAddr)
+	dst, _ := conn.RemoteAddr().(*net.TCPAddr)
+	if src == nil || dst == nil {
+		return fmt.Errorf("PROXY protocol needs a TCP connection")
+	}
+	srcIP, srcPort := src.IP, src.Port
+	if p.Source != nil {
+		// An ephemeral port, as the client's OS would pick
+		srcIP, srcPort = p.Source(), 32768+rand.Intn(28232)
+	}
+	_, err := conn.Write(proxyHeader(p.Version, srcIP, dst.IP, srcPort, dst.Port))
+	return err
+}
+
+// proxyHeader builds the header of a connection from src to dst; addresses of
+// different families are both sent as IPv6
+func proxyHeader(version string, src, dst net.IP, srcPort, dstPort int) []byte {
+	v4 := src.To4() != nil && dst.To4() != nil
+	if version == ProxyProtocolV1 {
+		if v4 {
+			return fmt.Appendf(nil, "PROXY TCP4 %s %s %d %d\r\n", src, dst, srcPort, dstPort)
+		}
+		return fmt.Appendf(nil, "PROXY TCP6 %s %s %d %d\r\n", ipv6String(src), ipv6String(dst), srcPort, dstPort)
+	}
+
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x21) // Version 2, PROXY command
+	var addrs []byte
+	if v4 {
+		header = append(header, 0x11) // TCP over IPv4
+		addrs = append(append(addrs, src.To4()...), dst.To4()...)
+	} else {
+		header = append(header, 0x21) // TCP over IPv6
+		addrs = append(append(addrs, src.To16()...), dst.To16()...)
+	}
+	addrs = binary.BigEndian.AppendUint16(addrs, uint16(srcPort))
+	addrs = binary.BigEndian.AppendUint16(addrs, uint16(dstPort))
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addrs)))
+	return append(header, addrs...)
+}
+
+// ipv6String formats ip in IPv6 notation, IPv4 addresses as ::ffff:a.b.c.d
+// (net.IP prints those as plain dotted quads)
+func ipv6String(ip net.IP) string {
+	if ip.To4() != nil {
+		return "::ffff:" + ip.To4().String()
+	}
+	return ip.String()
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestProxyHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		src, dst string
+		srcPort  int
+		dstPort  int
+		want     []byte
+	}{
+		// The examples and the longest headers of the haproxy proxy-protocol.txt spec
+		{"v1 TCP4", ProxyProtocolV1, "192.168.0.1", "192.168.0.11", 56324, 443,
+			[]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n")},
+		{"v1 TCP4 longest", ProxyProtocolV1, "255.255.255.255", "255.255.255.255", 65535, 65535,
+			[]byte("PROXY TCP4 255.255.255.255 255.255.255.255 65535 65535\r\n")},
+		{"v1 TCP6 longest", ProxyProtocolV1, "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", 65535, 65535,
+			[]byte("PROXY TCP6 ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff 65535 65535\r\n")},
+		{"v1 TCP6", ProxyProtocolV1, "2001:db8::1", "2001:db8::2", 51234, 443,
+			[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 51234 443\r\n")},
+		{"v1 mixed families", ProxyProtocolV1, "203.0.113.7", "2001:db8::2", 51234, 443,
+			[]byte("PROXY TCP6 ::ffff:203.0.113.7 2001:db8::2 51234 443\r\n")},
+		{"v2 IPv4", ProxyProtocolV2, "192.168.0.1", "192.168.0.11", 56324, 443, []byte{
+			0x0d, 0x0a, 0x0d, 0x0a, 0x00, 0x0d, 0x0a, 0x51, 0x55, 0x49, 0x54, 0x0a, // Signature
+			0x21,       // Version 2, PROXY
+			0x11,       // AF_INET, STREAM
+			0x00, 0x0c, // 12 address bytes
+			192, 168, 0, 1, 192, 168, 0, 11,
+			0xdc, 0x04, // 56324
+			0x01, 0xbb, // 443
+		}},
+		{"v2 IPv6", ProxyProtocolV2, "2001:db8::1", "2001:db8::2", 56324, 443, []byte{
+			0x0d, 0x0a, 0x0d, 0x0a, 0x00, 0x0d, 0x0a, 0x51, 0x55, 0x49, 0x54, 0x0a,
+			0x21,
+			0x21,       // AF_INET6, STREAM
+			0x00, 0x24, // 36 address bytes
+			0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01,
+			0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x02,
+			0xdc, 0x04,
+			0x01, 0xbb,
+		}},
+		{"v2 mixed families", ProxyProtocolV2, "203.0.113.7", "2001:db8::2", 56324, 443, []byte{
+			0x0d, 0x0a, 0x0d, 0x0a, 0x00, 0x0d, 0x0a, 0x51, 0x55, 0x49, 0x54, 0x0a,
+			0x21,
+			0x21,
+			0x00, 0x24,
+			0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 203, 0, 113, 7,
+			0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x02,
+			0xdc, 0x04,
+			0x01, 0xbb,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := proxyHeader(tt.version, net.ParseIP(tt.src), net.ParseIP(tt.dst), tt.srcPort, tt.dstPort)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("header =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyProtocolWriteHeader(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 28)
+		n, _ := io.ReadFull(conn, buf)
+		received <- buf[:n]
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := &ProxyProtocol{Version: ProxyProtocolV2, Source: func() net.IP { return net.ParseIP("203.0.113.7") }}
+	if err := p.writeHeader(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	header := <-received
+	port := ln.Addr().(*net.TCPAddr).Port
+	if len(header) != 28 || !bytes.Equal(header[:12], proxyV2Signature) ||
+		!bytes.Equal(header[16:24], []byte{203, 0, 113, 7, 127, 0, 0, 1}) ||
+		int(header[26])<<8|int(header[27]) != port {
+		t.Fatalf("header %x, want 203.0.113.7 to 127.0.0.1:%d", header, port)
+	}
+	if srcPort := int(header[24])<<8 | int(header[25]); srcPort < 32768 || srcPort >= 61000 {
+		t.Errorf("source port %d outside the ephemeral range", srcPort)
+	}
+}
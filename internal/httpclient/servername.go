@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// connectAddrKey carries the address a request presenting a server name
+// connects to (Request.ServerName)
+type connectAddrKey struct{}
+
+// connectAddr returns the address to dial for addr: the URL's address of a
+// request presenting a server name, else addr itself
+func connectAddr(ctx context.Context, addr string) string {
+	if to, ok := ctx.Value(connectAddrKey{}).(string); ok {
+		return to
+	}
+	return addr
+}
+
+// presentServerName makes req present name instead of its URL's host (in SNI,
+// the Host header and the certificate check) while still connecting to the
+// URL's address
+// Connections are pooled by the URL's host, so each name gets connections of
+// its own, as with clients that resolved the name to that address
+func presentServerName(req *http.Request, name string) *http.Request {
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ctx := context.WithValue(req.Context(), connectAddrKey{}, net.JoinHostPort(req.URL.Hostname(), port))
+	req = req.WithContext(ctx)
+	u := *req.URL
+	u.Host = name
+	if req.URL.Port() != "" {
+		u.Host = net.JoinHostPort(name, port)
+	}
+	req.URL, req.Host = &u, u.Host
+	return req
+}
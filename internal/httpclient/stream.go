@@ -0,0 +1,145 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// BodySource produces a fresh streaming request body for every request
+// Streamed bodies are sent with chunked transfer encoding and are never held
+// in memory as a whole
+type BodySource interface {
+	Open() (io.ReadCloser, error)
+}
+
+// FileBody streams the request body from a file on disk
+type FileBody struct {
+	Path string
+}
+
+// Open opens the file for reading
+func (f FileBody) Open() (io.ReadCloser, error) {
+	return os.Open(f.Path)
+}
+
+// GeneratedBody streams Size bytes of synthetic data generated on the fly
+type GeneratedBody struct {
+	Size int64
+}
+
+// generatedBlock is the repeating pattern used for generated bodies
+var generatedBlock = func() []byte {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 32*1024)
+	for i := range b {
+		b[i] = alphabet[(i*7+i/len(alphabet))%len(alphabet)]
+	}
+	return b
+}()
+
+// Open returns a reader producing the generated bytes
+func (g GeneratedBody) Open() (io.ReadCloser, error) {
+	return io.NopCloser(io.LimitReader(&patternReader{}, g.Size)), nil
+}
+
+// patternReader endlessly repeats generatedBlock
+type patternReader struct {
+	offset int
+}
+
+func (p *patternReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		c := copy(b[n:], generatedBlock[p.offset:])
+		n += c
+		p.offset = (p.offset + c) % len(generatedBlock)
+	}
+	return n, nil
+}
+
+// countingReadCloser counts the bytes read through it
+// The transport may still be sending when the response arrives, so the count is atomic
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(b []byte) (int, error) {
+	n, err := c.ReadCloser.Read(b)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+// count returns the number of bytes read so far
+func (c *countingReadCloser) count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// throttledReader limits reads to bytesPerSec, sleeping between chunks
+type throttledReader struct {
+	io.ReadCloser
+	ctx         context.Context
+	bytesPerSec int64
+	start       time.Time
+	sent        int64
+}
+
+func newThrottledReader(ctx context.Context, r io.ReadCloser, bytesPerSec int64) *throttledReader {
+	return &throttledReader{ReadCloser: r, ctx: ctx, bytesPerSec: bytesPerSec, start: time.Now()}
+}
+
+func (t *throttledReader) Read(b []byte) (int, error) {
+	// Read in small chunks so the rate stays smooth
+	if max := int(t.bytesPerSec / 10); max > 0 && len(b) > max {
+		b = b[:max]
+	} else if max == 0 && len(b) > 1 {
+		b = b[:1]
+	}
+
+	// Wait until sending more would not exceed the rate
+	expected := time.Duration(float64(t.sent) / float64(t.bytesPerSec) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-t.ctx.Done():
+			timer.Stop()
+			return 0, t.ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	n, err := t.ReadCloser.Read(b)
+	t.sent += int64(n)
+	return n, err
+}
+
+// ReadChunk is the most a delayed reader reads between pauses
+const ReadChunk = 4 << 10
+
+// delayedReader pauses before every read of up to ReadChunk bytes
+type delayedReader struct {
+	io.ReadCloser
+	ctx   context.Context
+	delay time.Duration
+}
+
+func newDelayedReader(ctx context.Context, r io.ReadCloser, delay time.Duration) *delayedReader {
+	return &delayedReader{ReadCloser: r, ctx: ctx, delay: delay}
+}
+
+func (d *delayedReader) Read(b []byte) (int, error) {
+	if len(b) > ReadChunk {
+		b = b[:ReadChunk]
+	}
+	timer := time.NewTimer(d.delay)
+	select {
+	case <-d.ctx.Done():
+		timer.Stop()
+		return 0, d.ctx.Err()
+	case <-timer.C:
+	}
+	return d.ReadCloser.Read(b)
+}
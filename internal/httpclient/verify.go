@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+)
+
+// ErrNoOCSPStaple fails the handshake of a server that stapled no OCSP
+// response while staples are required (Options.RequireOCSPStaple)
+var ErrNoOCSPStaple = errors.New("tls: server stapled no OCSP response")
+
+// certVerificationKey carries the *certVerification of a request to the dial
+// of its connection
+type certVerificationKey struct{}
+
+// certVerification receives the time spent verifying the certificate chain
+// of the connection dialed for a request
+type certVerification struct {
+	clock    clock.Clock
+	duration atomic.Int64
+}
+
+// requireStaple rejects a connection without a stapled OCSP response
+func requireStaple(state tls.ConnectionState) error {
+	if len(state.OCSPResponse) == 0 {
+		return ErrNoOCSPStaple
+	}
+	return nil
+}
+
+// verifyingConfig makes config verify the server's certificate chain itself,
+// as crypto/tls would, so the verification can be timed apart from the rest
+// of the handshake
+// A resumed session was verified when it was made; only its host name is checked
+func verifyingConfig(ctx context.Context, config *tls.Config) {
+	if config.InsecureSkipVerify {
+		return
+	}
+	v, _ := ctx.Value(certVerificationKey{}).(*certVerification)
+	next := config.VerifyConnection
+	config.InsecureSkipVerify = true
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		leaf := state.PeerCertificates[0]
+		if state.DidResume {
+			if err := leaf.VerifyHostname(config.ServerName); err != nil {
+				return &tls.CertificateVerificationError{UnverifiedCertificates: state.PeerCertificates, Err: err}
+			}
+		} else {
+			opts := x509.VerifyOptions{Roots: config.RootCAs, DNSName: config.ServerName, Intermediates: x509.NewCertPool()}
+			for _, cert := range state.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			var start time.Time
+			if v != nil {
+				start = v.clock.Now()
+			}
+			_, err := leaf.Verify(opts)
+			if v != nil {
+				v.duration.Store(int64(v.clock.Since(start)))
+			}
+			if err != nil {
+				return &tls.CertificateVerificationError{UnverifiedCertificates: state.PeerCertificates, Err: err}
+			}
+		}
+		if next != nil {
+			return next(state)
+		}
+		return nil
+	}
+}
+
+// dialTLS returns a DialTLSContext for transport t whose connections verify
+// certificates with verifyingConfig, offering the protocols t would
+// The handshake is left to net/http, which times it for the client trace
+func dialTLS(t *http.Transport, dial dialFunc) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		config := &tls.Config{}
+		if t.TLSClientConfig != nil {
+			config = t.TLSClientConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		verifyingConfig(ctx, config)
+		return tls.Client(conn, config), nil
+	}
+}
@@ -0,0 +1,189 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigMountPath is where the run config (and the files it references) is mounted in the pods
+const ConfigMountPath = "/etc/g0"
+
+// ConfigKey is the ConfigMap key holding the run config
+const ConfigKey = "run.yaml"
+
+// ResultMarker separates the text report from the JSON result in the pod logs
+const ResultMarker = "--- g0 result ---"
+
+// maxConfigMapBytes is the size limit Kubernetes puts on a ConfigMap
+const maxConfigMapBytes = 1 << 20
+
+// JobOptions describes the Job that runs g0 in a cluster
+type JobOptions struct {
+	Name      string
+	Namespace string
+	Image     string
+	Replicas  int
+	Files     map[string]string // ConfigMap data: run.yaml and the files it references
+	Args      []string          // Extra `g0 run` flags for every pod
+}
+
+// metadata is the Kubernetes object metadata used by the generated manifests
+type metadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+type configMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   metadata          `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type job struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   metadata `yaml:"metadata"`
+	Spec       jobSpec  `yaml:"spec"`
+}
+
+type jobSpec struct {
+	Completions    int         `yaml:"completions"`
+	Parallelism    int         `yaml:"parallelism"`
+	CompletionMode string      `yaml:"completionMode"`
+	BackoffLimit   int         `yaml:"backoffLimit"`
+	Template       podTemplate `yaml:"template"`
+}
+
+type podTemplate struct {
+	Metadata metadata `yaml:"metadata"`
+	Spec     podSpec  `yaml:"spec"`
+}
+
+type podSpec struct {
+	RestartPolicy string      `yaml:"restartPolicy"`
+	Containers    []container `yaml:"containers"`
+	Volumes       []volume    `yaml:"volumes"`
+}
+
+type container struct {
+	Name         string        `yaml:"name"`
+	Image        string        `yaml:"image"`
+	Command      []string      `yaml:"command"`
+	VolumeMounts []volumeMount `yaml:"volumeMounts"`
+}
+
+type volumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly"`
+}
+
+type volume struct {
+	Name      string          `yaml:"name"`
+	ConfigMap configMapSource `yaml:"configMap"`
+}
+
+type configMapSource struct {
+	Name string `yaml:"name"`
+}
+
+// Manifests returns the ConfigMap and Job manifests as a multi-document YAML stream
+// Each pod runs `g0 run` against the mounted config, prints the report and then
+// the JSON result after ResultMarker, so `g0 k8s collect` can read it from the logs
+func Manifests(opts JobOptions) ([]byte, error) {
+	size := 0
+	for _, content := range opts.Files {
+		size += len(content)
+	}
+	if size > maxConfigMapBytes {
+		return nil, fmt.Errorf("config and referenced files are %d bytes; a ConfigMap holds at most %d", size, maxConfigMapBytes)
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":     "g0",
+		"app.kubernetes.io/instance": opts.Name,
+	}
+	cm := configMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   metadata{Name: opts.Name + "-config", Namespace: opts.Namespace, Labels: labels},
+		Data:       opts.Files,
+	}
+
+	args := append([]string{"g0", "run", "--config", ConfigMountPath + "/" + ConfigKey}, opts.Args...)
+	args = append(args, "--json", "--output", "/tmp/g0-result.json")
+	script := fmt.Sprintf("%s; code=$?; echo %s; cat /tmp/g0-result.json; exit $code", shellJoin(args), shellQuote(ResultMarker))
+
+	j := job{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Metadata:   metadata{Name: opts.Name, Namespace: opts.Namespace, Labels: labels},
+		Spec: jobSpec{
+			Completions:    opts.Replicas,
+			Parallelism:    opts.Replicas,
+			CompletionMode: "Indexed",
+			BackoffLimit:   0,
+			Template: podTemplate{
+				Metadata: metadata{Name: opts.Name, Labels: labels},
+				Spec: podSpec{
+					RestartPolicy: "Never",
+					Containers: []container{{
+						Name:         "g0",
+						Image:        opts.Image,
+						Command:      []string{"sh", "-c", script},
+						VolumeMounts: []volumeMount{{Name: "config", MountPath: ConfigMountPath, ReadOnly: true}},
+					}},
+					Volumes: []volume{{Name: "config", ConfigMap: configMapSource{Name: cm.Metadata.Name}}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range []interface{}{cm, j} {
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExtractResult returns the JSON result that follows ResultMarker in a pod log
+func ExtractResult(log []byte) ([]byte, error) {
+	i := bytes.LastIndex(log, []byte(ResultMarker))
+	if i < 0 {
+		return nil, fmt.Errorf("no result in log (the run may have failed before finishing)")
+	}
+	result := bytes.TrimSpace(log[i+len(ResultMarker):])
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty result in log")
+	}
+	return result, nil
+}
+
+// shellJoin quotes each argument for sh and joins them with spaces
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes s for sh unless it only contains safe characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
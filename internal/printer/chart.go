@@ -0,0 +1,152 @@
+package printer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+const (
+	// chartHeight is the number of text rows per chart
+	chartHeight = 3
+
+	// Chart width bounds in columns; each column covers one or more seconds
+	minChartWidth = 10
+	maxChartWidth = 100
+
+	// minChartPoints is the shortest timeline worth charting
+	minChartPoints = 3
+
+	// chartLabelWidth is the width of the "  RPS   12.3k " label column
+	chartLabelWidth = 15
+)
+
+// chartMark is an event of the run marked under the charts at the second it happened
+type chartMark struct {
+	At    time.Duration // Offset from the start of the test
+	Label string
+}
+
+// printTimelineCharts draws RPS and p95 latency over the run as block charts,
+// with numbered marks for events such as failovers below them
+func (p *Printer) printTimelineCharts(points []runner.TimelinePoint, duration time.Duration, marks []chartMark) {
+	if len(points) < minChartPoints {
+		return
+	}
+
+	width := p.terminalWidth() - chartLabelWidth - 2
+	if width > maxChartWidth {
+		width = maxChartWidth
+	}
+	if width < minChartWidth {
+		width = minChartWidth
+	}
+	if width > len(points) {
+		width = len(points)
+	}
+
+	// Squeeze the seconds into the available columns: the rate is averaged,
+	// while the latency keeps the worst second so short spikes stay visible
+	rps := make([]float64, width)
+	p95 := make([]float64, width)
+	for col := 0; col < width; col++ {
+		group := points[col*len(points)/width : (col+1)*len(points)/width]
+		for _, point := range group {
+			rps[col] += point.RPS / float64(len(group))
+			if v := float64(point.P95); v > p95[col] {
+				p95[col] = v
+			}
+		}
+	}
+
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Over Time:")
+	p.printChart("RPS", rps, formatRateShort)
+	p.printChart("p95", p95, func(v float64) string { return formatLatencyShort(time.Duration(v)) })
+
+	// Time axis with the start and end of the run under the chart columns
+	start, end := "0s", formatDurationShort(duration)
+	gap := width - utf8.RuneCountInString(start) - utf8.RuneCountInString(end)
+	if gap < 1 {
+		gap = 1
+	}
+	if len(marks) > 0 {
+		p.printChartMarks(marks, len(points), width)
+	}
+	fmt.Fprintf(p.out, "%s%s%s%s\n", strings.Repeat(" ", chartLabelWidth+1), start, strings.Repeat(" ", gap), end)
+	for i, mark := range marks {
+		fmt.Fprintf(p.out, "  %s %s: %s\n", markSymbol(i), formatDurationShort(mark.At), mark.Label)
+	}
+}
+
+// printChartMarks prints the number of each mark under the column of its second;
+// a later mark in the same column takes its place
+func (p *Printer) printChartMarks(marks []chartMark, points, width int) {
+	row := []rune(strings.Repeat(" ", width))
+	for i, mark := range marks {
+		col := int(mark.At/time.Second) * width / points
+		if col >= width {
+			col = width - 1
+		}
+		row[col] = []rune(markSymbol(i))[0]
+	}
+	fmt.Fprintf(p.out, "  %-*s%s\n", chartLabelWidth-1, "events", string(row))
+}
+
+// markSymbol returns the symbol of the i-th mark: 1 to 9, then *
+func markSymbol(i int) string {
+	if i < 9 {
+		return strconv.Itoa(i + 1)
+	}
+	return "*"
+}
+
+// printChart draws one series scaled from zero to its maximum, which labels the top row
+func (p *Printer) printChart(name string, values []float64, format func(float64) string) {
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	style := p.currentStyle()
+	for row := chartHeight - 1; row >= 0; row-- {
+		label := strings.Repeat(" ", chartLabelWidth)
+		if row == chartHeight-1 {
+			label = fmt.Sprintf("  %-4s%8s ", name, format(max))
+		}
+
+		var line strings.Builder
+		for _, v := range values {
+			// Eighths of a cell filled in this row
+			level := 0
+			if max > 0 {
+				level = int(v/max*chartHeight*8+0.5) - row*8
+			}
+			if level < 0 {
+				level = 0
+			}
+			if level > 8 {
+				level = 8
+			}
+			line.WriteString(style.chartLevels[level])
+		}
+		fmt.Fprintf(p.out, "%s%s%s\n", label, style.chartAxis, line.String())
+	}
+}
+
+// formatRateShort formats a request rate compactly for chart labels
+func formatRateShort(v float64) string {
+	switch {
+	case v >= 1e6:
+		return fmt.Sprintf("%.1fM/s", v/1e6)
+	case v >= 1e4:
+		return fmt.Sprintf("%.1fk/s", v/1e3)
+	}
+	return fmt.Sprintf("%.0f/s", v)
+}
@@ -0,0 +1,159 @@
+package printer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// PrintABRound prints a one-line summary of a finished A/B run
+func (p *Printer) PrintABRound(round, rounds int, variant string, summary *runner.Summary) {
+	fmt.Fprintf(p.out, "Round %d/%d  %s  RPS: %.1f | Avg: %s | p95: %s | p99: %s | Errors: %.2f%%\n",
+		round, rounds, variant, summary.RPS,
+		formatDuration(summary.AvgLatency), formatDuration(summary.P95Latency), formatDuration(summary.P99Latency),
+		summary.ErrorRate()*100)
+}
+
+// PrintComparison prints the statistical comparison of two sets of runs
+func (p *Printer) PrintComparison(configA, configB string, rounds int, comparisons []runner.Comparison) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "A/B Comparison (%d rounds):\n", rounds)
+	fmt.Fprintf(p.out, "  A: %s\n", configA)
+	fmt.Fprintf(p.out, "  B: %s\n", configB)
+	fmt.Fprintln(p.out)
+
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Metric\tA (mean ± sd)\tB (mean ± sd)\tChange\tp-value\t")
+	for _, c := range comparisons {
+		change, pValue, verdict := comparisonColumns(c, "B better", "B worse")
+		fmt.Fprintf(w, "  %s\t%s ± %s\t%s ± %s\t%s\t%s\t%s\n", c.Metric,
+			formatMetricValue(c.Metric, c.MeanA), formatMetricValue(c.Metric, c.StdDevA),
+			formatMetricValue(c.Metric, c.MeanB), formatMetricValue(c.Metric, c.StdDevB),
+			change, pValue, verdict)
+	}
+	w.Flush()
+
+	if rounds < 2 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Note: at least 2 rounds (--alternate) are needed to test differences for significance.")
+	}
+}
+
+// comparisonColumns formats the change, p-value and verdict of a comparison;
+// better and worse describe a significant difference in B's favor or against it
+func comparisonColumns(c runner.Comparison, better, worse string) (change, pValue, verdict string) {
+	change, pValue = "n/a", "n/a"
+	if !math.IsNaN(c.Change) {
+		change = fmt.Sprintf("%+.1f%%", c.Change)
+	}
+	if !math.IsNaN(c.PValue) {
+		pValue = fmt.Sprintf("%.3f", c.PValue)
+		switch {
+		case !c.Significant():
+			verdict = "no significant difference"
+		case c.Better():
+			verdict = better
+		default:
+			verdict = worse
+		}
+	}
+	return change, pValue, verdict
+}
+
+// printCanary prints the baseline and canary side by side with significance tests
+func (p *Printer) printCanary(c *runner.CanarySummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Canary (%g%% of requests to %s):\n", c.Percent, c.URL)
+	p.printLatencyGroup("Baseline", c.Baseline)
+	p.printLatencyGroup("Canary", c.Canary)
+	fmt.Fprintln(p.out)
+	p.printGroupComparisons("Baseline", "Canary", c.Comparisons)
+}
+
+// printMirror prints the mirrored requests, side by side with the target when measured
+func (p *Printer) printMirror(m *runner.MirrorSummary) {
+	fmt.Fprintln(p.out)
+	if m.Mode == runner.MirrorForget {
+		fmt.Fprintf(p.out, "Mirror (fire-and-forget to %s):\n", m.URL)
+		fmt.Fprintf(p.out, "  Mirrored: %d requests, %d failed (%.2f%%)\n", m.Mirror.Requests, m.Mirror.Failed, m.Mirror.ErrorRate()*100)
+	} else {
+		fmt.Fprintf(p.out, "Mirror (every request duplicated to %s):\n", m.URL)
+		p.printLatencyGroup("Target", m.Primary)
+		p.printLatencyGroup("Mirror", m.Mirror)
+	}
+	if m.Dropped > 0 {
+		fmt.Fprintf(p.out, "  Not Mirrored: %d (too many mirrored requests in flight)\n", m.Dropped)
+	}
+	if m.Cancelled > 0 {
+		fmt.Fprintf(p.out, "  Cancelled at Deadline: %d\n", m.Cancelled)
+	}
+	if len(m.Comparisons) > 0 {
+		fmt.Fprintln(p.out)
+		p.printGroupComparisons("Target", "Mirror", m.Comparisons)
+	}
+}
+
+// printGroupComparisons prints a table comparing groups a and b of one run
+func (p *Printer) printGroupComparisons(a, b string, comparisons []runner.Comparison) {
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Metric\t%s\t%s\tChange\tp-value\t\n", a, b)
+	lower := strings.ToLower(b)
+	for _, cmp := range comparisons {
+		change, pValue, verdict := comparisonColumns(cmp, lower+" better", lower+" worse")
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", cmp.Metric,
+			formatMetricValue(cmp.Metric, cmp.MeanA), formatMetricValue(cmp.Metric, cmp.MeanB), change, pValue, verdict)
+	}
+	w.Flush()
+}
+
+// PrintModelRun prints the outcome of one run of a closed vs. open model comparison
+func (p *Printer) PrintModelRun(model string, summary *runner.Summary) {
+	fmt.Fprintf(p.out, "%-6s  RPS: %.1f | Avg: %s | p95: %s | p99: %s | Errors: %.2f%%\n",
+		model, summary.RPS,
+		formatDuration(summary.AvgLatency), formatDuration(summary.P95Latency), formatDuration(summary.P99Latency),
+		summary.ErrorRate()*100)
+}
+
+// PrintModelComparison prints a closed-model run next to an open-model run at
+// the given arrival rate and points out coordinated omission in the closed one
+func (p *Printer) PrintModelComparison(closed, open *runner.Summary, rate int) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Closed vs. Open Model (open: %s requests/s):\n", p.count(int64(rate)))
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Metric\tClosed\tOpen\tChange\t")
+	// The latency metric the closed model understates most
+	var worst runner.Comparison
+	worstRatio := 0.0
+	for _, c := range runner.CompareRuns([]*runner.Summary{closed}, []*runner.Summary{open}) {
+		change, _, _ := comparisonColumns(c, "", "")
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t\n", c.Metric,
+			formatMetricValue(c.Metric, c.MeanA), formatMetricValue(c.Metric, c.MeanB), change)
+		if c.Metric != "rps" && c.Metric != "error_rate" && c.MeanA > 0 && c.MeanB/c.MeanA > worstRatio {
+			worst, worstRatio = c, c.MeanB/c.MeanA
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintln(p.out)
+	if a := open.Arrivals; a != nil && a.Late > 0 {
+		fmt.Fprintf(p.out, "Open model: %s of %s requests waited for a free worker (queue delay p99 %s)",
+			p.count(a.Late), p.count(a.Requests), formatDuration(a.Delay.P99))
+		if a.Unsent > 0 {
+			fmt.Fprintf(p.out, " and %s were never sent", p.count(a.Unsent))
+		}
+		fmt.Fprintln(p.out)
+	}
+	if worstRatio > 1.1 {
+		fmt.Fprintf(p.out, "Coordinated omission: the closed model's %s of %s understates the %s clients arriving at %s/s see (%.1fx).\n",
+			worst.Metric, formatMetricValue(worst.Metric, worst.MeanA), formatMetricValue(worst.Metric, worst.MeanB),
+			p.count(int64(rate)), worstRatio)
+		fmt.Fprintln(p.out, "While a slow response held a worker, the closed model simply didn't send the requests that")
+		fmt.Fprintln(p.out, "would have waited behind it, so their latency never reached the statistics.")
+	} else {
+		fmt.Fprintln(p.out, "The models agree on latency within 10%: the workers kept up with the arrivals, so")
+		fmt.Fprintln(p.out, "coordinated omission didn't hide latency in the closed-model run.")
+	}
+}
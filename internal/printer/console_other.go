@@ -0,0 +1,11 @@
+//go:build !windows
+
+package printer
+
+import "os"
+
+// enableVirtualTerminal reports whether ANSI escape sequences can be used on f
+// Unix terminals interpret them natively
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
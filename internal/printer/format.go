@@ -0,0 +1,57 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Result file formats (--output-format)
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// ResultFormats lists the formats results can be saved in
+var ResultFormats = []string{FormatJSON, FormatYAML}
+
+// encodeResult encodes a result in format
+// YAML is converted from the JSON encoding, so it has the same keys in the same
+// order and the two formats never drift apart
+func encodeResult(output JSONOutput, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatYAML:
+		// JSON is valid YAML; decoding it into a node keeps the key order
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to convert the result to YAML: %w", err)
+		}
+		blockStyle(&node)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// blockStyle drops the JSON flow style ({...}, [...], "...") from a decoded
+// node tree, so it is written as block YAML with plain scalars where possible
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,306 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// LoadResultJSON parses a result as written by SaveResultJSON, upgrading
+// results from older versions of g0 first
+func LoadResultJSON(data []byte) (JSONOutput, error) {
+	data, err := UpgradeResult(data)
+	if err != nil {
+		return JSONOutput{}, err
+	}
+	var output JSONOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return JSONOutput{}, fmt.Errorf("invalid result JSON: %w", err)
+	}
+	return output, nil
+}
+
+// MergeResults combines the results of load generators that ran side by side
+// Counts, bytes and RPS are summed and the average latency is weighted by requests;
+// percentiles can't be combined exactly, so the worst replica's value is used as an
+// upper bound. Latency histograms share their bucket bounds and are summed exactly.
+// Per-replica details (timing breakdown, timeline, traces, ...) are not merged
+func MergeResults(results []JSONOutput) JSONOutput {
+	merged := JSONOutput{
+		SchemaVersion: SchemaVersion,
+		Metadata:      JSONMetadata{Replicas: len(results)},
+		Metrics: JSONMetrics{
+			StatusCodes:   make(map[string]int64),
+			StatusClasses: make(map[string]JSONStatusClass),
+		},
+		Passed: true,
+	}
+	if len(results) == 0 {
+		return merged
+	}
+
+	meta := &merged.Metadata
+	meta.Method = results[0].Metadata.Method
+	meta.Headers = results[0].Metadata.Headers
+	meta.RunID = results[0].Metadata.RunID
+	seenURLs := make(map[string]bool)
+	var start, end time.Time
+
+	req := &merged.Metrics.Requests
+	lat := &merged.Metrics.Latency
+	var latencySum float64
+	histogram := make(map[float64]int64)
+	for i, r := range results {
+		m := r.Metadata
+		for _, u := range append([]string{m.URL}, m.URLs...) {
+			if u != "" && !seenURLs[u] {
+				seenURLs[u] = true
+				meta.URLs = append(meta.URLs, u)
+			}
+		}
+		meta.Concurrency += m.Concurrency
+		if m.RunID != meta.RunID {
+			// Replicas of different runs share no ID
+			meta.RunID = ""
+		}
+		if m.DurationMs > meta.DurationMs {
+			meta.DurationMs = m.DurationMs
+			meta.Duration = m.Duration
+		}
+		if t, err := time.Parse(time.RFC3339Nano, m.StartTime); err == nil && (start.IsZero() || t.Before(start)) {
+			start = t
+		}
+		if t, err := time.Parse(time.RFC3339Nano, m.EndTime); err == nil && t.After(end) {
+			end = t
+		}
+		meta.Warnings = append(meta.Warnings, m.Warnings...)
+
+		rr := r.Metrics.Requests
+		req.Total += rr.Total
+		req.Success += rr.Success
+		req.Failed += rr.Failed
+		req.RPS += rr.RPS
+		req.BytesSent += rr.BytesSent
+		req.BytesReceived += rr.BytesReceived
+		req.Cancelled += rr.Cancelled
+		req.NotRecorded += rr.NotRecorded
+		req.QueueWaits += rr.QueueWaits
+		req.QueueWaitMs += rr.QueueWaitMs
+		req.BodySkipped = req.BodySkipped || rr.BodySkipped
+
+		rl := r.Metrics.Latency
+		if i == 0 || rl.Min.Ms < lat.Min.Ms {
+			lat.Min = rl.Min
+		}
+		lat.Max = maxJSONDuration(lat.Max, rl.Max)
+		lat.P90 = maxJSONDuration(lat.P90, rl.P90)
+		lat.P95 = maxJSONDuration(lat.P95, rl.P95)
+		lat.P99 = maxJSONDuration(lat.P99, rl.P99)
+		latencySum += rl.Avg.Ms * float64(rr.Total)
+		for _, b := range r.Metrics.LatencyHistogram {
+			histogram[b.LessThanMs] += b.Count
+		}
+
+		for code, count := range r.Metrics.StatusCodes {
+			merged.Metrics.StatusCodes[code] += count
+		}
+		for class, count := range r.Metrics.Errors {
+			if merged.Metrics.Errors == nil {
+				merged.Metrics.Errors = make(map[string]int64)
+			}
+			merged.Metrics.Errors[class] += count
+		}
+		for class, c := range r.Metrics.StatusClasses {
+			merged.Metrics.StatusClasses[class] = JSONStatusClass{Count: merged.Metrics.StatusClasses[class].Count + c.Count}
+		}
+
+		merged.Passed = merged.Passed && r.Passed
+		merged.Aborted = merged.Aborted || r.Aborted
+	}
+
+	if len(meta.URLs) == 1 {
+		meta.URL = meta.URLs[0]
+		meta.URLs = nil
+	}
+	if !start.IsZero() {
+		meta.StartTime = start.Format(time.RFC3339Nano)
+	}
+	if !end.IsZero() {
+		meta.EndTime = end.Format(time.RFC3339Nano)
+	}
+	if req.Total > 0 {
+		lat.Avg = durationToJSON(time.Duration(latencySum / float64(req.Total) * float64(time.Millisecond)))
+	}
+	for class, c := range merged.Metrics.StatusClasses {
+		c.Percent = percentOf(c.Count, req.Total)
+		merged.Metrics.StatusClasses[class] = c
+	}
+	for bound, count := range histogram {
+		merged.Metrics.LatencyHistogram = append(merged.Metrics.LatencyHistogram, JSONHistogramBucket{LessThanMs: bound, Count: count})
+	}
+	sort.Slice(merged.Metrics.LatencyHistogram, func(i, j int) bool {
+		return merged.Metrics.LatencyHistogram[i].LessThanMs < merged.Metrics.LatencyHistogram[j].LessThanMs
+	})
+	return merged
+}
+
+// AppendResults combines the results of consecutive parts of one run, e.g. the
+// part saved in a checkpoint and the part resumed from it
+// Counts are summed as by MergeResults, but the durations add up and the RPS is
+// the rate over all parts; percentiles are again the worst part's
+func AppendResults(results []JSONOutput) JSONOutput {
+	merged := MergeResults(results)
+	if len(results) == 0 {
+		return merged
+	}
+	meta := &merged.Metadata
+	meta.Replicas = 0
+	meta.Concurrency = results[len(results)-1].Metadata.Concurrency
+	meta.DurationMs = 0
+	for _, r := range results {
+		meta.Segments += max(1, r.Metadata.Segments)
+		meta.DurationMs += r.Metadata.DurationMs
+	}
+	meta.Duration = (time.Duration(meta.DurationMs) * time.Millisecond).String()
+	if meta.DurationMs > 0 {
+		merged.Metrics.Requests.RPS = float64(merged.Metrics.Requests.Total) / (float64(meta.DurationMs) / 1000)
+	}
+
+	// Earlier parts ended at a checkpoint rather than aborting the run, so only
+	// their thresholds and SLOs count
+	last := results[len(results)-1]
+	merged.Aborted = last.Aborted
+	merged.Passed = last.Passed
+	for _, r := range results[:len(results)-1] {
+		for _, t := range r.Thresholds {
+			merged.Passed = merged.Passed && t.Passed
+		}
+		for _, slo := range r.SLOs {
+			merged.Passed = merged.Passed && slo.Passed
+		}
+	}
+	return merged
+}
+
+// EvaluateMergedThresholds evaluates thresholds on a merged result, stores the
+// outcome in it and returns the number that failed
+// Percentiles are the worst replica's, so they are checked against an upper bound
+func EvaluateMergedThresholds(output *JSONOutput, thresholds []runner.Threshold) int {
+	req := output.Metrics.Requests
+	lat := output.Metrics.Latency
+	summary := runner.Summary{
+		TotalRequests:  req.Total,
+		FailedRequests: req.Failed,
+		RPS:            req.RPS,
+		MinLatency:     msToDuration(lat.Min.Ms),
+		AvgLatency:     msToDuration(lat.Avg.Ms),
+		MaxLatency:     msToDuration(lat.Max.Ms),
+		P90Latency:     msToDuration(lat.P90.Ms),
+		P95Latency:     msToDuration(lat.P95.Ms),
+		P99Latency:     msToDuration(lat.P99.Ms),
+	}
+	failed := runner.EvaluateThresholds(&summary, thresholds)
+	for _, t := range summary.Thresholds {
+		output.Thresholds = append(output.Thresholds, JSONThreshold{
+			Expression: t.Expression,
+			Metric:     t.Metric,
+			Operator:   t.Operator,
+			Limit:      t.Value,
+			Actual:     t.Actual,
+			Passed:     t.Passed,
+		})
+		output.Passed = output.Passed && t.Passed
+	}
+	return failed
+}
+
+// msToDuration converts fractional milliseconds to a duration
+func msToDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// maxJSONDuration returns the longer of two durations
+func maxJSONDuration(a, b JSONDuration) JSONDuration {
+	if b.Ms > a.Ms {
+		return b
+	}
+	return a
+}
+
+// PrintMergedResults prints a merged multi-replica result
+func (p *Printer) PrintMergedResults(output JSONOutput) {
+	req := output.Metrics.Requests
+	lat := output.Metrics.Latency
+	part := "replica"
+	if output.Metadata.Segments > 0 {
+		part = "part"
+		fmt.Fprintf(p.out, "Combined Results (%d parts resumed from checkpoints, %s in total):\n", output.Metadata.Segments, output.Metadata.Duration)
+	} else {
+		fmt.Fprintf(p.out, "Merged Results (%d replicas, %d workers in total):\n", output.Metadata.Replicas, output.Metadata.Concurrency)
+	}
+	fmt.Fprintf(p.out, "Total Requests: %s\n", p.total(req.Total))
+	fmt.Fprintf(p.out, "Success: %s\n", p.count(req.Success))
+	fmt.Fprintf(p.out, "Failed: %s\n", p.count(req.Failed))
+	if req.NotRecorded > 0 {
+		fmt.Fprintf(p.out, "Warning: %s results not recorded (requests sent but missing from the totals above)\n", p.count(req.NotRecorded))
+	}
+	fmt.Fprintf(p.out, "RPS: %s\n", p.rate(req.RPS))
+	fmt.Fprintf(p.out, "Data Sent: %s\n", formatBytes(req.BytesSent))
+	fmt.Fprintf(p.out, "Data Received: %s\n", formatBytes(req.BytesReceived))
+	fmt.Fprintln(p.out)
+
+	fmt.Fprintln(p.out, "Latency:")
+	fmt.Fprintf(p.out, "  Min: %s\n", lat.Min.Value)
+	fmt.Fprintf(p.out, "  Avg: %s\n", lat.Avg.Value)
+	fmt.Fprintf(p.out, "  Max: %s\n", lat.Max.Value)
+	fmt.Fprintf(p.out, "  p90: <= %s\n", lat.P90.Value)
+	fmt.Fprintf(p.out, "  p95: <= %s\n", lat.P95.Value)
+	fmt.Fprintf(p.out, "  p99: <= %s\n", lat.P99.Value)
+	fmt.Fprintf(p.out, "  (percentiles are the worst %s's, an upper bound for the merged run)\n", part)
+
+	if len(output.Metrics.StatusCodes) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Status Codes:")
+		codes := make([]string, 0, len(output.Metrics.StatusCodes))
+		for code := range output.Metrics.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(p.out, "  %s: %s\n", code, p.count(output.Metrics.StatusCodes[code]))
+		}
+	}
+
+	if len(output.Metrics.Errors) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Errors:")
+		for class, count := range output.Metrics.Errors {
+			fmt.Fprintf(p.out, "  %s: %s\n", class, p.count(count))
+		}
+	}
+
+	if len(output.Thresholds) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Thresholds:")
+		for _, t := range output.Thresholds {
+			status := "PASS"
+			if !t.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(p.out, "  [%s] %s (actual: %s)\n", status, t.Expression, formatMetricValue(t.Metric, t.Actual))
+		}
+	}
+
+	if !output.Passed {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "At least one %s failed its thresholds or SLOs.\n", part)
+	}
+}
+
+// SaveResultJSON writes a result in format to outputFile (or results/ if empty) and returns the path
+func SaveResultJSON(output JSONOutput, outputFile, format string) (string, error) {
+	return writeJSONOutput(output, outputFile, format)
+}
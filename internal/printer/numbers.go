@@ -0,0 +1,131 @@
+package printer
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// numberFormat holds the digit grouping and decimal mark of a locale
+type numberFormat struct {
+	separator string // Separator between groups of three digits
+	decimal   string // Decimal mark
+}
+
+// Number formats of the locales g0 knows; others print like English
+var (
+	englishNumbers = numberFormat{separator: ",", decimal: "."} // 1,234,567.8
+	dotNumbers     = numberFormat{separator: ".", decimal: ","} // 1.234.567,8
+	spaceNumbers   = numberFormat{separator: " ", decimal: ","} // 1 234 567,8
+	swissNumbers   = numberFormat{separator: "'", decimal: "."} // 1'234'567.8
+)
+
+// localeNumbers maps languages to their number format; a language_COUNTRY entry
+// wins over its language where the countries differ
+var localeNumbers = map[string]numberFormat{
+	"de": dotNumbers, "es": dotNumbers, "it": dotNumbers, "nl": dotNumbers, "pt": dotNumbers,
+	"da": dotNumbers, "id": dotNumbers, "tr": dotNumbers, "vi": dotNumbers,
+	"fr": spaceNumbers, "ru": spaceNumbers, "pl": spaceNumbers, "cs": spaceNumbers, "sv": spaceNumbers,
+	"fi": spaceNumbers, "nb": spaceNumbers, "uk": spaceNumbers, "hu": spaceNumbers,
+	"de_CH": swissNumbers,
+}
+
+// detectNumberFormat reads the number format from LC_ALL, LC_NUMERIC or LANG,
+// as the C library would (e.g., de_DE.UTF-8 prints 1.234.567,8)
+func detectNumberFormat() numberFormat {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		// Drop the encoding and modifier: de_DE.UTF-8@euro -> de_DE
+		if i := strings.IndexAny(locale, ".@"); i >= 0 {
+			locale = locale[:i]
+		}
+		if format, ok := localeNumbers[locale]; ok {
+			return format
+		}
+		language, _, _ := strings.Cut(locale, "_")
+		if format, ok := localeNumbers[language]; ok {
+			return format
+		}
+		return englishNumbers
+	}
+	return englishNumbers
+}
+
+// group inserts the group separator into a string of digits with an optional sign
+func (f numberFormat) group(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteString(f.separator)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// compactUnits are the suffixes of compact numbers, from thousands up
+var compactUnits = []string{"k", "M", "B", "T"}
+
+// count formats a count with digit grouping (1,234,567)
+func (p *Printer) count(n int64) string {
+	if p.RawNumbers {
+		return strconv.FormatInt(n, 10)
+	}
+	return p.numbers.group(strconv.FormatInt(n, 10))
+}
+
+// rate formats a rate with digit grouping and one decimal (45,312.4)
+func (p *Printer) rate(v float64) string {
+	text := strconv.FormatFloat(v, 'f', 1, 64)
+	if p.RawNumbers || math.IsInf(v, 0) || math.IsNaN(v) {
+		return text
+	}
+	whole, fraction, _ := strings.Cut(text, ".")
+	return p.numbers.group(whole) + p.numbers.decimal + fraction
+}
+
+// compact shortens a number to three significant figures at most with a unit
+// suffix (1.2M, 45.3k); numbers below a thousand keep the given decimals
+func (p *Printer) compact(v float64, decimals int) string {
+	if p.RawNumbers || math.Abs(v) < 1000 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return strings.Replace(strconv.FormatFloat(v, 'f', decimals, 64), ".", p.decimalMark(), 1)
+	}
+	unit := -1
+	for unit < len(compactUnits)-1 && math.Abs(v) >= 999.95 {
+		v /= 1000
+		unit++
+	}
+	return strings.Replace(strconv.FormatFloat(v, 'f', 1, 64), ".", p.decimalMark(), 1) + compactUnits[unit]
+}
+
+// decimalMark returns the decimal mark of the printer's number format
+func (p *Printer) decimalMark() string {
+	if p.RawNumbers {
+		return "."
+	}
+	return p.numbers.decimal
+}
+
+// total formats a count with digit grouping, followed by its compact form when
+// the count is long enough to need it: 1,234,567 (1.2M)
+func (p *Printer) total(n int64) string {
+	if p.RawNumbers || n < 10000 {
+		return p.count(n)
+	}
+	return p.count(n) + " (" + p.compact(float64(n), 0) + ")"
+}
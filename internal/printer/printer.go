@@ -0,0 +1,44 @@
+package printer
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Printer writes g0's human-readable output
+// The report goes to one writer and the progress line, alerts and notices to
+// another, so either can be redirected (or captured) on its own
+type Printer struct {
+	out io.Writer // Report
+	err io.Writer // Progress line, alerts and notices
+
+	// RawNumbers prints counts and rates as plain digits, without digit grouping
+	// or compact forms, for scripts that parse the report
+	RawNumbers bool
+	numbers    numberFormat // Digit grouping of the user's locale
+
+	styleOnce sync.Once
+	style     progressStyle
+
+	// lastLineWidth is the width of the previous plain-style progress line,
+	// used to blank out leftovers without ANSI clear-line codes
+	lastLineWidth int
+}
+
+// New creates a printer writing the report to out and the progress to err
+func New(out, err io.Writer) *Printer {
+	return &Printer{out: out, err: err, numbers: detectNumberFormat()}
+}
+
+// Default returns a printer writing the report to stdout and the progress to stderr
+func Default() *Printer {
+	return New(os.Stdout, os.Stderr)
+}
+
+// flush pushes the progress line out to a file or terminal right away
+func (p *Printer) flush() {
+	if f, ok := p.err.(*os.File); ok {
+		f.Sync()
+	}
+}
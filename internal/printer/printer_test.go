@@ -0,0 +1,132 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+	"github.com/calummacc/g0/internal/runner"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testSummary returns the summary of a short, deterministic run
+func testSummary() *runner.Summary {
+	c := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	s := runner.NewStats(c)
+	for i := 0; i < 40; i++ {
+		c.Advance(50 * time.Millisecond)
+		status := 200
+		switch {
+		case i%20 == 7:
+			status = 503
+		case i%10 == 3:
+			status = 404
+		}
+		s.AddResult(runner.Result{
+			Method:     "GET",
+			URL:        "http://example.com/",
+			StatusCode: status,
+			Latency:    time.Duration(10+i) * time.Millisecond,
+			BytesSent:  64,
+			BytesRead:  512,
+		})
+	}
+	s.Finalize()
+	summary := s.GetSummary()
+	summary.Seed = 42
+	summary.RunID = "20240102-150000-abc123"
+	return &summary
+}
+
+// testPrinter returns a printer writing to buf with English digit grouping,
+// whatever the locale running the tests
+func testPrinter(buf *bytes.Buffer) *Printer {
+	p := New(buf, buf)
+	p.numbers = englishNumbers
+	return p
+}
+
+// checkGolden compares got with testdata/name, or rewrites it under -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (run go test -update to accept it)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestPrintResultsGolden(t *testing.T) {
+	var buf bytes.Buffer
+	testPrinter(&buf).PrintResults(testSummary())
+	checkGolden(t, "results.txt", buf.Bytes())
+}
+
+func TestResultJSONGolden(t *testing.T) {
+	output := BuildResultJSON(testSummary(), []string{"http://example.com/"}, 4, 2*time.Second, "GET", nil)
+	if output.SchemaVersion != SchemaVersion || SchemaVersion != 2 {
+		t.Fatalf("schema version %d (current %d), want 2", output.SchemaVersion, SchemaVersion)
+	}
+	data, err := encodeResult(output, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "result-v2.json", data)
+}
+
+func TestUpgradeResultV1(t *testing.T) {
+	v1, err := os.ReadFile(filepath.Join("testdata", "result-v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := ResultVersion(v1); err != nil || version != 1 {
+		t.Fatalf("ResultVersion = %d, %v, want 1", version, err)
+	}
+	upgraded, err := UpgradeResult(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "result-v1-upgraded.json", upgraded)
+
+	var result JSONOutput
+	if err := json.Unmarshal(upgraded, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.SchemaVersion != SchemaVersion {
+		t.Errorf("upgraded schema version %d, want %d", result.SchemaVersion, SchemaVersion)
+	}
+
+	// Upgrading a current result is a no-op
+	again, err := UpgradeResult(upgraded)
+	if err != nil || !bytes.Equal(again, upgraded) {
+		t.Errorf("upgrading a version %d result changed it (err %v)", SchemaVersion, err)
+	}
+}
+
+func TestPrintAuditRecordingStopped(t *testing.T) {
+	var buf bytes.Buffer
+	testPrinter(&buf).printAudit(&runner.AuditSummary{Produced: 5, Received: 5, Counted: 5, Recording: true, Recorded: 3, RecordingStopped: true})
+	for _, want := range []string{"recording stopped early; 2 later results not recorded", "every request is accounted for"} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("audit lacks %q:\n%s", want, buf.String())
+		}
+	}
+	if bytes.Contains(buf.Bytes(), []byte("MISMATCH")) {
+		t.Errorf("stopped recording reported as a mismatch:\n%s", buf.String())
+	}
+}
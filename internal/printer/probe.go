@@ -0,0 +1,142 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// PrintProbeStart prints the probing plan
+func (p *Printer) PrintProbeStart(config runner.ProbeConfig) {
+	fmt.Fprintln(p.out, "Cold Start Probing Started")
+	fmt.Fprintf(p.out, "URL: %s\n", config.URL)
+	fmt.Fprintf(p.out, "Method: %s\n", config.Method)
+	fmt.Fprintf(p.out, "Probes: %d, each after %s idle (about %s in total)\n", config.Probes, config.Idle, time.Duration(config.Probes)*config.Idle)
+	fmt.Fprintf(p.out, "Warm Requests: %d after each probe\n", config.WarmRequests)
+	fmt.Fprintln(p.out)
+}
+
+// PrintProbeWait shows on stderr when the next probe will be sent
+func (p *Printer) PrintProbeWait(number, probes int, next time.Time) {
+	fmt.Fprintf(p.err, "Idle until %s (probe %d/%d)\n", next.Format("15:04:05"), number, probes)
+}
+
+// PrintProbe prints the outcome of one probe
+func (p *Printer) PrintProbe(probe runner.Probe, probes int) {
+	line := fmt.Sprintf("Probe %d/%d at %s: cold %s (TTFB %s, %s)", probe.Number, probes,
+		probe.At.Format("15:04:05"), formatDuration(probe.Cold.Latency), formatDuration(probe.Cold.TTFB), probeOutcome(probe.Cold))
+	if len(probe.Warm) > 0 {
+		var sum time.Duration
+		for _, r := range probe.Warm {
+			sum += r.Latency
+		}
+		avg := sum / time.Duration(len(probe.Warm))
+		line += fmt.Sprintf(" | warm avg %s (%d requests)", formatDuration(avg), len(probe.Warm))
+		if avg > 0 {
+			line += fmt.Sprintf(" | %.1fx", float64(probe.Cold.Latency)/float64(avg))
+		}
+	}
+	fmt.Fprintln(p.out, line)
+}
+
+// probeOutcome describes a probe request's status code or error class
+func probeOutcome(r runner.ProbeRequest) string {
+	if r.StatusCode > 0 {
+		return fmt.Sprintf("%d", r.StatusCode)
+	}
+	return r.ErrorClass
+}
+
+// PrintProbeSummary prints cold start latencies next to the warm baseline
+func (p *Printer) PrintProbeSummary(summary *runner.ProbeSummary) {
+	fmt.Fprintln(p.out)
+	if summary.Aborted {
+		fmt.Fprintf(p.out, "Probing interrupted after %d of %d probes\n", len(summary.Probes), summary.Config.Probes)
+		fmt.Fprintln(p.out)
+	}
+	if len(summary.Probes) == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "Cold Start Probes (%d after %s idle):\n", len(summary.Probes), summary.Config.Idle)
+	p.printLatencyGroup("Cold", summary.Cold)
+	p.printLatencyGroup("Warm", summary.Warm)
+	if summary.Cold.Requests > 0 && summary.Warm.Requests > 0 && summary.Warm.Latency.P50 > 0 {
+		fmt.Fprintf(p.out, "  Cold p50 is %.1fx the warm p50\n", float64(summary.Cold.Latency.P50)/float64(summary.Warm.Latency.P50))
+	}
+}
+
+// JSONProbeOutput represents the JSON structure for cold start probing results
+type JSONProbeOutput struct {
+	URL          string           `json:"url"`
+	Method       string           `json:"method"`
+	IdleMs       int64            `json:"idle_ms"`
+	WarmRequests int              `json:"warm_requests"`
+	Aborted      bool             `json:"aborted,omitempty"`
+	Cold         JSONLatencyGroup `json:"cold"`
+	Warm         JSONLatencyGroup `json:"warm"`
+	Probes       []JSONProbe      `json:"probes"`
+}
+
+// JSONProbe contains one cold request and the warm requests following it
+type JSONProbe struct {
+	At   string             `json:"at"`
+	Cold JSONProbeRequest   `json:"cold"`
+	Warm []JSONProbeRequest `json:"warm,omitempty"`
+}
+
+// JSONProbeRequest contains the outcome of one probe request
+type JSONProbeRequest struct {
+	LatencyMs  float64 `json:"latency_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`
+	StatusCode int     `json:"status_code"`
+	ErrorClass string  `json:"error_class,omitempty"`
+}
+
+// WriteProbeJSON saves the probing results as JSON to path
+func WriteProbeJSON(summary *runner.ProbeSummary, path string) error {
+	output := JSONProbeOutput{
+		URL:          summary.Config.URL,
+		Method:       summary.Config.Method,
+		IdleMs:       summary.Config.Idle.Milliseconds(),
+		WarmRequests: summary.Config.WarmRequests,
+		Aborted:      summary.Aborted,
+		Cold:         latencyGroupToJSON(summary.Cold),
+		Warm:         latencyGroupToJSON(summary.Warm),
+		Probes:       make([]JSONProbe, 0, len(summary.Probes)),
+	}
+	for _, p := range summary.Probes {
+		probe := JSONProbe{At: p.At.Format(time.RFC3339), Cold: probeRequestToJSON(p.Cold)}
+		for _, r := range p.Warm {
+			probe.Warm = append(probe.Warm, probeRequestToJSON(r))
+		}
+		output.Probes = append(output.Probes, probe)
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	return nil
+}
+
+// probeRequestToJSON converts one probe request to JSON format
+func probeRequestToJSON(r runner.ProbeRequest) JSONProbeRequest {
+	return JSONProbeRequest{
+		LatencyMs:  durationToMs(r.Latency),
+		TTFBMs:     durationToMs(r.TTFB),
+		StatusCode: r.StatusCode,
+		ErrorClass: r.ErrorClass,
+	}
+}
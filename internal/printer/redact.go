@@ -0,0 +1,69 @@
+package printer
+
+import (
+	"net/http"
+)
+
+// RedactedValue replaces the values of sensitive headers in saved results
+const RedactedValue = "[REDACTED]"
+
+// DefaultSensitiveHeaders are redacted from saved results; Redaction.Headers adds to them
+var DefaultSensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Amz-Security-Token",
+}
+
+// Redaction keeps credentials out of saved results, which end up in CI
+// artifacts, dashboards and tickets
+type Redaction struct {
+	Headers     []string // Headers redacted besides DefaultSensitiveHeaders (any case)
+	OmitHeaders bool     // Leave the request headers out of the metadata entirely
+}
+
+// sensitive reports whether the values of header must not be saved
+func (r Redaction) sensitive(header string) bool {
+	header = http.CanonicalHeaderKey(header)
+	for _, names := range [][]string{DefaultSensitiveHeaders, r.Headers} {
+		for _, name := range names {
+			if http.CanonicalHeaderKey(name) == header {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply redacts the request headers of a result and the values of sensitive
+// response headers it captured (--capture-header Set-Cookie)
+func (r Redaction) Apply(output *JSONOutput) {
+	if r.OmitHeaders {
+		output.Metadata.Headers = nil
+	} else if len(output.Metadata.Headers) > 0 {
+		headers := make(map[string]string, len(output.Metadata.Headers))
+		for name, value := range output.Metadata.Headers {
+			if r.sensitive(name) {
+				value = RedactedValue
+			}
+			headers[name] = value
+		}
+		output.Metadata.Headers = headers
+	}
+
+	for i, h := range output.Metrics.Headers {
+		if !r.sensitive(h.Name) || len(h.Values) == 0 {
+			continue
+		}
+		// Keep how many responses carried the header, not what it said
+		var count int64
+		for _, n := range h.Values {
+			count += n
+		}
+		output.Metrics.Headers[i].Values = map[string]int64{RedactedValue: count}
+	}
+}
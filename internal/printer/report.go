@@ -1,18 +1,20 @@
 package printer
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/calummacc/g0/internal/httpclient"
 	"github.com/calummacc/g0/internal/runner"
 )
 
 // PrintLogo prints the g0 logo
-func PrintLogo() {
+func (p *Printer) PrintLogo() {
 	logo := `
 	┌───────────────────────────────┐
 	│             g0                │
@@ -20,175 +22,2207 @@ func PrintLogo() {
 	└───────────────────────────────┘
   	`
 
-	fmt.Print(logo)
-	fmt.Println()
+	fmt.Fprint(p.out, logo)
+	fmt.Fprintln(p.out)
 }
 
 // PrintTestStart prints the test configuration
-func PrintTestStart(urls []string, concurrency int, duration time.Duration) {
-	fmt.Println("Load Test Started")
+func (p *Printer) PrintTestStart(urls []string, concurrency int, duration time.Duration, runID string) {
+	fmt.Fprintln(p.out, "Load Test Started")
+	if runID != "" {
+		fmt.Fprintf(p.out, "Run ID: %s\n", runID)
+	}
 	if len(urls) == 1 {
-		fmt.Printf("URL: %s\n", urls[0])
+		fmt.Fprintf(p.out, "URL: %s\n", urls[0])
 	} else {
-		fmt.Printf("URLs (%d endpoints):\n", len(urls))
+		fmt.Fprintf(p.out, "URLs (%d endpoints):\n", len(urls))
 		for i, url := range urls {
-			fmt.Printf("  %d. %s\n", i+1, url)
+			fmt.Fprintf(p.out, "  %d. %s\n", i+1, url)
 		}
 	}
-	fmt.Printf("Concurrency: %d\n", concurrency)
-	fmt.Printf("Duration: %s\n", duration)
-	fmt.Println()
+	fmt.Fprintf(p.out, "Concurrency: %d\n", concurrency)
+	fmt.Fprintf(p.out, "Duration: %s\n", duration)
+	fmt.Fprintln(p.out)
 }
 
 // PrintResults prints the test results in a formatted way
-func PrintResults(summary *runner.Summary) {
-	fmt.Println("Results:")
-	fmt.Printf("Total Requests: %d\n", summary.TotalRequests)
-	fmt.Printf("Success: %d\n", summary.SuccessRequests)
-	fmt.Printf("Failed: %d\n", summary.FailedRequests)
-	fmt.Printf("RPS: %.1f\n", summary.RPS)
-	fmt.Println()
-
-	fmt.Println("Latency:")
-	fmt.Printf("  Min: %s\n", formatDuration(summary.MinLatency))
-	fmt.Printf("  Avg: %s\n", formatDuration(summary.AvgLatency))
-	fmt.Printf("  Max: %s\n", formatDuration(summary.MaxLatency))
-	fmt.Printf("  p90: %s\n", formatDuration(summary.P90Latency))
-	fmt.Printf("  p95: %s\n", formatDuration(summary.P95Latency))
-	fmt.Printf("  p99: %s\n", formatDuration(summary.P99Latency))
+func (p *Printer) PrintResults(summary *runner.Summary) {
+	fmt.Fprintln(p.out, "Results:")
+	fmt.Fprintf(p.out, "Total Requests: %s\n", p.total(summary.TotalRequests))
+	fmt.Fprintf(p.out, "Success: %s\n", p.count(summary.SuccessRequests))
+	fmt.Fprintf(p.out, "Failed: %s\n", p.count(summary.FailedRequests))
+	if summary.BodyVerified > 0 {
+		fmt.Fprintf(p.out, "Body Hash Mismatches: %d of %d verified (%.2f%%)\n",
+			summary.BodyMismatches, summary.BodyVerified, float64(summary.BodyMismatches)/float64(summary.BodyVerified)*100)
+	}
+	if summary.CancelledAtDeadline > 0 {
+		fmt.Fprintf(p.out, "Cancelled at Deadline: %s (in flight when the test ended, not included above)\n", p.count(summary.CancelledAtDeadline))
+	}
+	if d := summary.Delivery; d.NotRecorded > 0 {
+		fmt.Fprintf(p.out, "Warning: %s results not recorded (requests sent but missing from the totals above)\n", p.count(d.NotRecorded))
+	}
+	if d := summary.Delivery; d.Waits > 0 {
+		fmt.Fprintf(p.out, "Result Queue Waits: %s (workers waited %s in total for the stats collector)\n", p.count(d.Waits), formatDuration(d.WaitTime))
+	}
+	fmt.Fprintf(p.out, "RPS: %s\n", p.rate(summary.RPS))
+	fmt.Fprintf(p.out, "Data Sent: %s\n", formatBytes(summary.BytesSent))
+	if summary.BodySkipped {
+		fmt.Fprintln(p.out, "Data Received: n/a (response bodies skipped)")
+	} else {
+		fmt.Fprintf(p.out, "Data Received: %s\n", formatBytes(summary.BytesReceived))
+	}
+	fmt.Fprintf(p.out, "Seed: %d\n", summary.Seed)
+	if summary.RunID != "" {
+		fmt.Fprintf(p.out, "Run ID: %s\n", summary.RunID)
+	}
+	if d := summary.Data; d != nil {
+		fmt.Fprintf(p.out, "Unique Data Rows: %d of %d used", d.Used, d.Rows)
+		if d.Exhausted {
+			fmt.Fprint(p.out, " (all rows used; the test ended early)")
+		}
+		fmt.Fprintln(p.out)
+	}
+	if sch := summary.Schedule; sch != nil {
+		fmt.Fprintf(p.out, "Scheduled Start: %s (started %s later)\n", sch.At.Format("15:04:05.000"), formatDuration(summary.StartTime.Sub(sch.At)))
+		if sch.OffsetKnown {
+			fmt.Fprintf(p.out, "Clock Offset: %s (vs %s)\n", formatSignedDuration(-sch.ClockOffset), sch.NTPServer)
+		}
+		for _, warning := range sch.Warnings {
+			fmt.Fprintf(p.out, "Warning: %s\n", warning)
+		}
+	}
+	fmt.Fprintln(p.out)
+
+	fmt.Fprintln(p.out, "Latency:")
+	fmt.Fprintf(p.out, "  Min: %s\n", formatDuration(summary.MinLatency))
+	fmt.Fprintf(p.out, "  Avg: %s\n", formatDuration(summary.AvgLatency))
+	fmt.Fprintf(p.out, "  Max: %s\n", formatDuration(summary.MaxLatency))
+	fmt.Fprintf(p.out, "  p90: %s\n", formatDuration(summary.P90Latency))
+	fmt.Fprintf(p.out, "  p95: %s\n", formatDuration(summary.P95Latency))
+	fmt.Fprintf(p.out, "  p99: %s\n", formatDuration(summary.P99Latency))
+	if w := summary.WorstSecond(); w != nil && len(summary.Timeline) > 1 {
+		fmt.Fprintf(p.out, "  Worst second: max %s at %s (min %s, p95 %s, %d requests)\n",
+			formatDuration(w.Max), formatDurationShort(w.Offset), formatDuration(w.Min), formatDuration(w.P95), w.Requests)
+	}
+
+	// Chart the run over time, so degradation shows without an external report
+	p.printTimelineCharts(summary.Timeline, summary.Duration, timelineMarks(summary))
+
+	// Print time to first byte vs. body download, which the overall latency hides
+	if summary.BodySkipped {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Note: response bodies were skipped (--skip-body); latency covers headers only")
+		fmt.Fprintln(p.out, "      and download/throughput metrics are excluded.")
+	} else if summary.TTFB.Max > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Timing Breakdown:")
+		fmt.Fprintf(p.out, "  TTFB:     avg %s, p50 %s, p95 %s, p99 %s\n",
+			formatDuration(summary.TTFB.Avg), formatDuration(summary.TTFB.P50), formatDuration(summary.TTFB.P95), formatDuration(summary.TTFB.P99))
+		fmt.Fprintf(p.out, "  Download: avg %s, p50 %s, p95 %s, p99 %s\n",
+			formatDuration(summary.Download.Avg), formatDuration(summary.Download.P50), formatDuration(summary.Download.P95), formatDuration(summary.Download.P99))
+		if summary.DownloadThroughput > 0 {
+			fmt.Fprintf(p.out, "  Download Throughput: %s/s\n", formatBytes(int64(summary.DownloadThroughput)))
+		}
+		if summary.TruncatedResponses > 0 {
+			fmt.Fprintf(p.out, "  Truncated Responses: %d\n", summary.TruncatedResponses)
+		}
+		if n := summary.ErrorClasses[httpclient.ErrorClassTooLarge]; n > 0 {
+			fmt.Fprintf(p.out, "  Oversized Responses: %d aborted past %s (counted as failed)\n", n, formatBytes(summary.MaxResponseBytes))
+		}
+		if client := slowClient(summary); client != "" {
+			fmt.Fprintf(p.out, "  Slow Client: %s (download times include the throttling)\n", client)
+		}
+	}
+
+	// Show which client timeouts the measured latencies would have breached
+	if t := summary.Timeouts; t != nil {
+		p.printTimeouts(t)
+	}
+
+	// Characterize the slowest requests behind a bad p99
+	if t := summary.Tail; t != nil {
+		p.printTail(t)
+	}
+
+	// Compare the canary with the baseline it ran alongside
+	if c := summary.Canary; c != nil {
+		p.printCanary(c)
+	}
+
+	// Compare the mirror with the target its traffic was duplicated from
+	if m := summary.Mirror; m != nil {
+		p.printMirror(m)
+	}
+
+	// Report the token requests apart from the business requests
+	if a := summary.Auth; a != nil {
+		p.printAuth(a)
+	}
+
+	// Report range requests apart from full requests
+	if r := summary.Ranges; r != nil {
+		p.printRanges(r)
+	}
+
+	// Report how the target handled Expect: 100-continue
+	if e := summary.ExpectContinue; e != nil {
+		p.printExpectContinue(e)
+	}
+
+	// Report whether the workers kept up with the arrival rate
+	if a := summary.Arrivals; a != nil {
+		p.printArrivals(a)
+	}
+
+	// Report how closely the run followed the recorded traffic shape
+	if t := summary.RPSTrace; t != nil {
+		p.printRPSTrace(t)
+	}
+
+	// Show what taking targets out of rotation did to the rest of the run
+	if f := summary.Failover; f != nil {
+		p.printFailover(f)
+	}
+
+	// Show what the bursts and pauses did to the target
+	if c := summary.Chaos; c != nil {
+		p.printChaos(c)
+	}
+
+	// Show how the workers were split between the targets
+	if len(summary.WorkerPools) > 0 {
+		p.printWorkerPools(summary.WorkerPools)
+	}
+
+	// Check that the configured concurrency was actually reached
+	if l := summary.LittlesLaw; l != nil {
+		p.printLittlesLaw(l, summary)
+	}
+
+	// Show what held the throughput of a rate-limited run
+	if r := summary.RateTarget; r != nil {
+		p.printRateTarget(r, summary)
+	}
+
+	// Tell client-side queueing apart from server latency
+	if q := summary.Queueing; q != nil && summary.TotalRequests > 0 {
+		p.printQueueing(q, summary)
+	}
+
+	// Report lookups through the custom DNS server
+	if d := summary.DNS; d != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "DNS (via %s):\n", d.Server)
+		fmt.Fprintf(p.out, "  Lookups: %d", d.Lookups)
+		if d.Lookups > 0 {
+			fmt.Fprintf(p.out, ", avg %s, p50 %s, p95 %s, p99 %s",
+				formatDuration(d.Latency.Avg), formatDuration(d.Latency.P50), formatDuration(d.Latency.P95), formatDuration(d.Latency.P99))
+		}
+		fmt.Fprintln(p.out)
+		if d.Failed > 0 {
+			fmt.Fprintf(p.out, "  Failed: %d requests (name not resolved)\n", d.Failed)
+		}
+	}
+
+	// Show what answering authentication challenges costs
+	if h := summary.HTTPAuth; h != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "HTTP Authentication (%s):\n", h.Scheme)
+		fmt.Fprintf(p.out, "  Challenges answered: %s\n", p.count(h.Challenges))
+		p.printLatencyGroup("Challenged", h.Challenged)
+		p.printLatencyGroup("Authenticated", h.Authenticated)
+		if h.Unauthorized > 0 {
+			fmt.Fprintf(p.out, "  Warning: %s requests (%.2f%%) still got 401 Unauthorized; check the credentials\n",
+				p.count(h.Unauthorized), percentOf(h.Unauthorized, summary.TotalRequests))
+		}
+	}
+
+	// Show how the requests spread over the client certificates
+	if c := summary.ClientCerts; c != nil {
+		strategy := "one per worker"
+		if c.PerRequest {
+			strategy = "rotated per request"
+		}
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Client Certificates (%d from %s, %s):\n", c.Certificates, c.Dir, strategy)
+		fmt.Fprintf(p.out, "  Used: %d certificates", c.Used)
+		if c.Used > 0 {
+			fmt.Fprintf(p.out, ", %s-%s requests each", p.count(c.MinRequests), p.count(c.MaxRequests))
+		}
+		fmt.Fprintln(p.out)
+		if c.Failing > 0 {
+			fmt.Fprintf(p.out, "  Certificates with failed requests: %d\n", c.Failing)
+			for _, f := range c.Failures {
+				fmt.Fprintf(p.out, "    %s: %s of %s failed (%.2f%%)\n", f.Name, p.count(f.Failed), p.count(f.Requests), percentOf(f.Failed, f.Requests))
+			}
+		}
+	}
+
+	// Show how the requests spread over the server names
+	if s := summary.SNI; s != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "SNI Fan-out (%d names from %s):\n", s.Names, s.File)
+		fmt.Fprintf(p.out, "  Used: %d names", s.Used)
+		if s.Used > 0 {
+			fmt.Fprintf(p.out, ", %s-%s requests each", p.count(s.MinRequests), p.count(s.MaxRequests))
+		}
+		fmt.Fprintln(p.out)
+		if s.Failing > 0 {
+			fmt.Fprintf(p.out, "  Names with failed requests: %d\n", s.Failing)
+			for _, f := range s.Failures {
+				fmt.Fprintf(p.out, "    %s: %s of %s failed (%.2f%%)\n", f.Name, p.count(f.Failed), p.count(f.Requests), percentOf(f.Failed, f.Requests))
+			}
+		}
+	}
+
+	// Show how often the TLS terminator resumed sessions, what that saved, and
+	// what checking its certificate cost
+	if t := summary.TLS; t != nil {
+		p.printTLS(t)
+	}
+
+	// Compare the first requests of each worker with the steady state
+	if c := summary.ColdStart; c != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Cold Start (first %d requests per worker):\n", c.Requests)
+		p.printLatencyGroup("Cold", c.Cold)
+		p.printLatencyGroup("Steady", c.Steady)
+		if c.Cold.Requests > 0 && c.Steady.Requests > 0 && c.Steady.Latency.P50 > 0 {
+			fmt.Fprintf(p.out, "  Cold p50 is %.1fx the steady p50\n", float64(c.Cold.Latency.P50)/float64(c.Steady.Latency.P50))
+		}
+		p.printLatencyGroup("New Connections", c.NewConnections)
+		p.printLatencyGroup("Reused Connections", c.ReusedConnections)
+	}
+
+	// Break out requests by method when targets mix them
+	if len(summary.Methods) > 1 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Methods:")
+		for _, method := range sortedMethods(summary.Methods) {
+			m := summary.Methods[method]
+			fmt.Fprintf(p.out, "  %s: %s requests, %s failed (%.2f%%), avg %s, p50 %s, p95 %s, p99 %s\n",
+				method, p.count(m.Requests), p.count(m.Failed), m.ErrorRate()*100,
+				formatDuration(m.Latency.Avg), formatDuration(m.Latency.P50), formatDuration(m.Latency.P95), formatDuration(m.Latency.P99))
+		}
+	}
+
+	// Break out requests by target, showing how the URL strategy spread the load
+	if len(summary.Targets) > 1 {
+		p.printTargets(summary)
+	}
+
+	// Break out requests by protocol when it isn't plain HTTP/1.1, e.g. when a proxy
+	// downgrades some connections or a protocol is forced
+	if protocols := summary.Protocols; len(protocols) > 1 || (len(protocols) == 1 && protocols["HTTP/1.1"].Requests == 0) {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Protocols:")
+		for _, protocol := range sortedProtocols(protocols) {
+			p.printLatencyGroup(protocol, protocols[protocol])
+		}
+	}
+
+	// Break out latency by body size when sizes vary, so slow large payloads show
+	p.printSizeCorrelation("Request", summary.RequestSizes)
+	p.printSizeCorrelation("Response", summary.ResponseSizes)
+
+	// Print the status class rollup first, so the health of the run is
+	// readable at a glance even when many codes appear
+	if len(summary.StatusCodeCounts) > 0 {
+		classes := summary.StatusClasses()
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Status Classes:")
+		for _, class := range sortedStatusClasses(classes) {
+			fmt.Fprintf(p.out, "  %s: %s (%.2f%%)\n", class, p.count(classes[class]), percentOf(classes[class], summary.TotalRequests))
+		}
+	}
+
+	// Print status code distribution if there are any
+	if len(summary.StatusCodeCounts) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Status Codes:")
+		for _, code := range sortedStatusCodes(summary.StatusCodeCounts) {
+			fmt.Fprintf(p.out, "  %d: %s\n", code, p.count(summary.StatusCodeCounts[code]))
+		}
+	}
+
+	// Print network-level errors by class
+	if len(summary.ErrorClasses) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Errors:")
+		for _, class := range sortedErrorClasses(summary.ErrorClasses) {
+			fmt.Fprintf(p.out, "  %s: %s\n", class, p.count(summary.ErrorClasses[class]))
+		}
+	}
+
+	// Print schema violations, which don't count as failed requests
+	if c := summary.Schema; c != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Response Schema (%s, %g%% of requests sampled):\n", c.Path, c.Sample)
+		fmt.Fprintf(p.out, "  Validated: %d responses\n", c.Validated)
+		fmt.Fprintf(p.out, "  Violations: %d (%.2f%%)\n", c.Violations, c.ViolationRate()*100)
+		kinds := make([]string, 0, len(c.Kinds))
+		for kind := range c.Kinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Slice(kinds, func(i, j int) bool {
+			if c.Kinds[kinds[i]] != c.Kinds[kinds[j]] {
+				return c.Kinds[kinds[i]] > c.Kinds[kinds[j]]
+			}
+			return kinds[i] < kinds[j]
+		})
+		if len(kinds) > maxSchemaViolationRows {
+			kinds = kinds[:maxSchemaViolationRows]
+		}
+		for _, kind := range kinds {
+			fmt.Fprintf(p.out, "    %s: %d\n", kind, c.Kinds[kind])
+		}
+	}
+
+	// Print how many distinct bodies each target returned; one body for every
+	// response of a dynamic endpoint points at a cache serving it to everyone
+	if len(summary.Bodies) > 0 {
+		p.printBodies(summary.Bodies)
+	}
+
+	// Print compression results if any responses arrived compressed
+	if c := summary.Compression; c.Responses > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Compression:")
+		fmt.Fprintf(p.out, "  Compressed Responses: %d/%d\n", c.Responses, summary.TotalRequests)
+		for encoding, count := range c.Encodings {
+			fmt.Fprintf(p.out, "    %s: %d\n", encoding, count)
+		}
+		fmt.Fprintf(p.out, "  Wire Bytes: %s\n", formatBytes(c.WireBytes))
+		fmt.Fprintf(p.out, "  Decoded Bytes: %s\n", formatBytes(c.DecodedBytes))
+		fmt.Fprintf(p.out, "  Ratio: %.2fx\n", c.Ratio())
+		fmt.Fprintf(p.out, "  Avg Decompress Time: %s\n", formatDuration(c.AvgDecompressTime()))
+	}
+
+	// Print server-reported phase durations next to the latency the client saw
+	if t := summary.ServerTiming; t != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Server Timing (%d/%d responses):\n", t.Responses, summary.TotalRequests)
+		for _, phase := range t.Phases {
+			fmt.Fprintf(p.out, "  %s: avg %s, p50 %s, p95 %s, p99 %s (%d responses)\n", phase.Name,
+				formatDuration(phase.Duration.Avg), formatDuration(phase.Duration.P50), formatDuration(phase.Duration.P95), formatDuration(phase.Duration.P99), phase.Count)
+		}
+		fmt.Fprintf(p.out, "  Server Total: avg %s, p50 %s, p95 %s, p99 %s\n",
+			formatDuration(t.Server.Avg), formatDuration(t.Server.P50), formatDuration(t.Server.P95), formatDuration(t.Server.P99))
+		fmt.Fprintf(p.out, "  Network/Queueing: avg %s, p50 %s, p95 %s, p99 %s (TTFB not covered by server time)\n",
+			formatDuration(t.Remainder.Avg), formatDuration(t.Remainder.P50), formatDuration(t.Remainder.P95), formatDuration(t.Remainder.P99))
+	}
+
+	// Print the value distribution of captured response headers (cache status, backend, ...)
+	if len(summary.Headers) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Response Headers:")
+		for _, h := range summary.Headers {
+			fmt.Fprintf(p.out, "  %s (%d responses, %d distinct values):\n", h.Name, h.Responses, len(h.Values))
+			shown := h.Values
+			if len(shown) > maxHeaderValueRows {
+				shown = shown[:maxHeaderValueRows]
+			}
+			for _, v := range shown {
+				fmt.Fprintf(p.out, "    %s: %d (%.2f%%)\n", v.Value, v.Count, percentOf(v.Count, h.Responses))
+			}
+			if rest := len(h.Values) - len(shown); rest > 0 || h.Untracked > 0 {
+				var count int64
+				for _, v := range h.Values[len(shown):] {
+					count += v.Count
+				}
+				count += h.Untracked
+				fmt.Fprintf(p.out, "    (other values): %d (%.2f%%)\n", count, percentOf(count, h.Responses))
+			}
+			if h.Missing > 0 {
+				fmt.Fprintf(p.out, "    (missing): %d (%.2f%%)\n", h.Missing, percentOf(h.Missing, h.Responses))
+			}
+		}
+	}
+
+	// Print health check outcome; downtime separates "target died" from "target got slow"
+	if h := summary.Health; h != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Health:")
+		fmt.Fprintf(p.out, "  Checks: %d (%d failed) against %s\n", h.Checks, h.Failures, h.URL)
+		if len(h.Downtime) == 0 {
+			fmt.Fprintln(p.out, "  Downtime: none")
+		} else {
+			fmt.Fprintf(p.out, "  Downtime: %s (%d outages)\n", formatDuration(h.TotalDowntime()), len(h.Downtime))
+			for _, w := range h.Downtime {
+				end := formatDurationShort(w.End)
+				if w.Ongoing {
+					end = "end of test"
+				}
+				fmt.Fprintf(p.out, "    %s - %s (%s, %s)\n", formatDurationShort(w.Start), end, formatDuration(w.Duration()), w.Reason)
+			}
+			if h.Paused {
+				fmt.Fprintln(p.out, "  Load was paused during downtime.")
+			}
+		}
+	}
+
+	// Print the target's resource usage scraped during the run
+	if r := summary.Resources; r != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Target Resources:")
+		if len(r.Samples) == 0 {
+			fmt.Fprintf(p.out, "  No samples from %s (%d failed scrapes)\n", r.URL, r.Errors)
+		} else {
+			cpuAvg, cpuMax := r.CPU()
+			memFirst, memMax, memLast := r.Memory()
+			fmt.Fprintf(p.out, "  Source: %s (%d samples, %d failed scrapes)\n", r.Source, len(r.Samples), r.Errors)
+			fmt.Fprintf(p.out, "  CPU: avg %.1f%%, max %.1f%%\n", cpuAvg, cpuMax)
+			fmt.Fprintf(p.out, "  Memory: start %s, max %s, end %s\n", formatBytes(memFirst), formatBytes(memMax), formatBytes(memLast))
+
+			// Show an evenly spaced subset so long runs stay readable
+			step := (len(r.Samples) + maxTimelineRows - 1) / maxTimelineRows
+			fmt.Fprintln(p.out, "  Timeline:")
+			for i := 0; i < len(r.Samples); i += step {
+				sample := r.Samples[i]
+				fmt.Fprintf(p.out, "    %8s  CPU %5.1f%%  Mem %s\n", formatDurationShort(sample.Offset), sample.CPUPercent, formatBytes(sample.MemoryBytes))
+			}
+		}
+	}
+
+	// Print the outcome of the churned connections, kept apart from the requests
+	if c := summary.Churn; c != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Connection Churn (%d/s):\n", c.Rate)
+		fmt.Fprintf(p.out, "  Connections: %d\n", c.Attempts)
+		if c.Skipped > 0 {
+			fmt.Fprintf(p.out, "  Skipped: %d (a second's worth of connections were still being set up)\n", c.Skipped)
+		}
+		fmt.Fprintf(p.out, "  Connect Errors: %d (%.2f%%)\n", c.ConnectErrors, c.ConnectErrorRate()*100)
+		if c.TLS {
+			fmt.Fprintf(p.out, "  Handshake Errors: %d (%.2f%%)\n", c.HandshakeErrors, c.HandshakeErrorRate()*100)
+		}
+		for class, count := range c.Errors {
+			fmt.Fprintf(p.out, "    %s: %d\n", class, count)
+		}
+		if c.Connect.Max > 0 {
+			fmt.Fprintf(p.out, "  Connect: avg %s, p50 %s, p95 %s, p99 %s\n",
+				formatDuration(c.Connect.Avg), formatDuration(c.Connect.P50), formatDuration(c.Connect.P95), formatDuration(c.Connect.P99))
+		}
+		if c.Handshake.Max > 0 {
+			fmt.Fprintf(p.out, "  Handshake: avg %s, p50 %s, p95 %s, p99 %s\n",
+				formatDuration(c.Handshake.Avg), formatDuration(c.Handshake.P50), formatDuration(c.Handshake.P95), formatDuration(c.Handshake.P99))
+		}
+	}
+
+	// Print how well the worker pool kept up with the virtual users
+	if u := summary.Users; u != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Virtual Users (%d, think time %s, %d workers):\n", u.Users, formatDuration(u.ThinkTime), u.Workers)
+		fmt.Fprintf(p.out, "  Requests: %d\n", u.Wakeups)
+		fmt.Fprintf(p.out, "  Wake-up Delay: avg %s, max %s\n", formatDuration(u.AvgDelay), formatDuration(u.MaxDelay))
+		if u.ThinkTime > 0 && u.AvgDelay > u.ThinkTime/10 {
+			fmt.Fprintln(p.out, "  Note: users waited for a free worker; raise -c to keep their pacing")
+		}
+	}
+
+	// Print the generator's own CPU usage, which caps the load it can send
+	if c := summary.GeneratorCPU; c != nil {
+		p.printGeneratorCPU(c)
+	}
+	if m := summary.Memory; m != nil {
+		p.printMemory(m)
+	}
+
+	// Reconcile the request counts of each stage
+	if a := summary.Audit; a != nil {
+		p.printAudit(a)
+	}
+
+	// Print trace IDs of notable requests so they can be looked up in the tracing backend
+	if t := summary.Traces; t != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Traces:")
+		fmt.Fprintf(p.out, "  Run ID: %s (tracestate g0=%s)\n", t.RunID, t.RunID)
+		if len(t.Slowest) > 0 {
+			fmt.Fprintln(p.out, "  Slowest:")
+			for _, sample := range t.Slowest {
+				fmt.Fprintf(p.out, "    %-10s %s\n", formatDuration(sample.Latency), t.Link(sample.TraceID))
+			}
+		}
+		if len(t.Failed) > 0 {
+			fmt.Fprintln(p.out, "  Failed:")
+			for _, sample := range t.Failed {
+				fmt.Fprintf(p.out, "    %-10s %s\n", traceOutcome(sample), t.Link(sample.TraceID))
+			}
+		}
+	}
+
+	// Print where the per-request records went
+	if r := summary.Record; r != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Request Records:")
+		fmt.Fprintf(p.out, "  %s (%s, %d records", r.Path, r.Format, r.Records)
+		if r.Rotated > 0 {
+			fmt.Fprintf(p.out, "; rotated %d times, older parts are %s.1, .2, ...", r.Rotated, r.Path)
+		}
+		fmt.Fprintln(p.out, ")")
+		if r.Error != "" {
+			fmt.Fprintf(p.out, "  Recording stopped early: %s\n", r.Error)
+		}
+	}
+
+	// Print where the failed requests went
+	if l := summary.ErrorLog; l != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Error Log:")
+		fmt.Fprintf(p.out, "  %s (%s failed requests", l.Path, p.count(l.Records))
+		if l.Rotated > 0 {
+			fmt.Fprintf(p.out, "; rotated %d times, older parts are %s.1, .2, ...", l.Rotated, l.Path)
+		}
+		fmt.Fprintln(p.out, ")")
+		if l.Error != "" {
+			fmt.Fprintf(p.out, "  Logging stopped early: %s\n", l.Error)
+		}
+	}
+
+	// Print the resources created by the test and their removal
+	if c := summary.Cleanup; c != nil {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Created Resources:")
+		fmt.Fprintf(p.out, "  Tracked: %d IDs", c.Tracked)
+		if c.Missing > 0 {
+			fmt.Fprintf(p.out, " (%d successful responses without an ID)", c.Missing)
+		}
+		fmt.Fprintln(p.out)
+		if c.File != "" {
+			fmt.Fprintf(p.out, "  IDs Written to: %s\n", c.File)
+		}
+		if c.FileError != "" {
+			fmt.Fprintf(p.out, "  IDs Not Written: %s\n", c.FileError)
+		}
+		if c.DeleteURL != "" && c.Tracked > 0 {
+			fmt.Fprintf(p.out, "  Cleanup: %d deleted, %d failed in %s\n", c.Deleted, c.Failed, formatDuration(c.Duration))
+			for reason, count := range c.Errors {
+				fmt.Fprintf(p.out, "    %s: %d\n", reason, count)
+			}
+		}
+	}
+
+	// Print threshold outcomes
+	if len(summary.Thresholds) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Thresholds:")
+		for _, t := range summary.Thresholds {
+			status := "PASS"
+			if !t.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(p.out, "  [%s] %s (actual: %s)\n", status, t.Expression, formatThresholdActual(t))
+		}
+	}
+
+	// Print SLO outcomes with the share of the error budget the run consumed
+	if len(summary.SLOs) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "SLOs:")
+		for _, r := range summary.SLOs {
+			status := "PASS"
+			if !r.Passed {
+				status = "FAIL"
+			}
+			target := "succeed"
+			if r.Latency > 0 {
+				target = "succeed within " + formatDuration(r.Latency)
+			}
+			fmt.Fprintf(p.out, "  [%s] %s: %g%% must %s\n", status, r.Name, r.Objective, target)
+			fmt.Fprintf(p.out, "         %.3f%% within budget (%d/%d), error budget burned: %.1f%%\n", r.Within, r.Good, r.Total, r.Burn)
+		}
+	}
+
+	if summary.Aborted {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Note: the test was aborted before the configured duration; results are partial.")
+	}
+
+	// Print conditional request results if the mode was enabled
+	if summary.ConditionalRequests > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "Conditional Requests:")
+		fmt.Fprintf(p.out, "  Sent: %d\n", summary.ConditionalRequests)
+		fmt.Fprintf(p.out, "  304 Not Modified: %d (%.1f%%)\n", summary.NotModified, summary.NotModifiedRatio()*100)
+	}
+}
+
+// PrintProgress displays a progress bar with current test statistics
+// It updates in-place on the same line using carriage return
+// spinnerFrame is used for animation when generating report (0-3 for spinner animation)
+func (p *Printer) PrintProgress(elapsed time.Duration, totalDuration time.Duration, stats *runner.ProgressStats, spinnerFrame int) {
+	// Calculate progress percentage
+	progress := float64(elapsed) / float64(totalDuration)
+	isComplete := progress >= 1.0
+	if progress > 1.0 {
+		progress = 1.0
+	}
+
+	// Calculate current RPS
+	var rps float64
+	if elapsed > 0 {
+		rps = float64(stats.TotalRequests) / elapsed.Seconds()
+	}
+
+	// Spinner characters for animation
+	spinnerChars := []string{"|", "/", "-", "\\"}
+
+	var status string
+	if isComplete {
+		// If test is complete, show "Generating report..." message with spinner
+		spinner := spinnerChars[spinnerFrame%len(spinnerChars)]
+		status = fmt.Sprintf("100.0%% | Generating report %s | %s", spinner, p.formatRequestCounts(stats, rps))
+	} else {
+		// Rolling percentiles cover the last few seconds, so degradation shows up immediately
+		eta := totalDuration - elapsed
+		status = fmt.Sprintf("%.1f%% | %s/%s | ETA %s | %s | p50/p95/p99: %s/%s/%s",
+			progress*100, formatDurationShort(elapsed), formatDurationShort(totalDuration), formatDurationShort(eta),
+			p.formatRequestCounts(stats, rps),
+			formatLatencyShort(stats.RecentP50), formatLatencyShort(stats.RecentP95), formatLatencyShort(stats.RecentP99))
+		if stats.RecentRequests > 0 {
+			status += fmt.Sprintf(" | Err %ds: %.1f%%", runner.ErrorWindowSeconds, stats.RecentErrorRate)
+		}
+		if stats.TargetDown {
+			status += " | TARGET DOWN"
+		}
+	}
+
+	// Print progress on the same line
+	p.writeProgressLine(p.progressBar(progress, status) + " " + status)
+}
+
+// formatRequestCounts formats request counters, error rate and RPS for the progress line
+func (p *Printer) formatRequestCounts(stats *runner.ProgressStats, rps float64) string {
+	var errorRate float64
+	if stats.TotalRequests > 0 {
+		errorRate = float64(stats.FailedRequests) / float64(stats.TotalRequests) * 100
+	}
+	style := p.currentStyle()
+	return fmt.Sprintf("Req: %s | %s: %s | %s: %s (%.1f%%) | RPS: %s",
+		p.compact(float64(stats.TotalRequests), 0), style.okMark, p.compact(float64(stats.SuccessRequests), 0),
+		style.failMark, p.compact(float64(stats.FailedRequests), 0), errorRate, p.compact(rps, 1))
+}
+
+// PrintGeneratingReport displays a one-time "Generating report..." message
+func (p *Printer) PrintGeneratingReport(stats *runner.ProgressStats, rps float64) {
+	status := fmt.Sprintf("100.0%% | Generating report... | %s", p.formatRequestCounts(stats, rps))
+	// Clear line and print final message
+	p.writeProgressLine(p.progressBar(1, status) + " " + status)
+}
+
+// PrintWaitingForStart shows the countdown to a scheduled start on the progress line
+func (p *Printer) PrintWaitingForStart(at time.Time, remaining time.Duration) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	p.writeProgressLine(fmt.Sprintf("Waiting to start at %s (in %s)", at.Format("15:04:05.000"), formatDurationShort(remaining)))
+}
+
+// PrintErrorRateWarning prints a highlighted line above the progress line when the
+// rolling error rate rises above the --warn-error-rate limit, optionally ringing the bell
+func (p *Printer) PrintErrorRateWarning(elapsed time.Duration, rate, limit float64, bell bool) {
+	p.printAlert(fmt.Sprintf("WARNING at %s: error rate over the last %ds is %.1f%% (limit %.1f%%)",
+		formatDurationShort(elapsed), runner.ErrorWindowSeconds, rate, limit), ansiRed, bell)
+}
+
+// PrintErrorRateRecovered reports that the rolling error rate fell back below the limit
+func (p *Printer) PrintErrorRateRecovered(elapsed time.Duration, rate, limit float64) {
+	p.printAlert(fmt.Sprintf("Recovered at %s: error rate over the last %ds is %.1f%% (limit %.1f%%)",
+		formatDurationShort(elapsed), runner.ErrorWindowSeconds, rate, limit), ansiGreen, false)
+}
+
+// ANSI colors for alert lines (bold)
+const (
+	ansiRed   = "\033[1;31m"
+	ansiGreen = "\033[1;32m"
+	ansiReset = "\033[0m"
+)
+
+// printAlert prints a line to stderr in place of the progress line, which is
+// redrawn below it on the next refresh; the color only applies on ANSI terminals
+func (p *Printer) printAlert(message, color string, bell bool) {
+	p.ClearProgress()
+	if bell {
+		message = "\a" + message
+	}
+	if p.currentStyle().ansi {
+		message = color + message + ansiReset
+	}
+	fmt.Fprintln(p.err, message)
+}
+
+// ClearProgress clears the progress line
+func (p *Printer) ClearProgress() {
+	// Clear the entire line by printing spaces and returning to start
+	// (stay one column short of the terminal width so the line never wraps)
+	fmt.Fprintf(p.err, "\r%s\r", strings.Repeat(" ", p.terminalWidth()-1))
+	p.lastLineWidth = 0
+	p.flush()
+}
+
+// formatDuration formats a duration in a human-readable way
+func formatDuration(d time.Duration) string {
+	if d < time.Microsecond {
+		return fmt.Sprintf("%.2fns", float64(d.Nanoseconds()))
+	} else if d < time.Millisecond {
+		return fmt.Sprintf("%.2fµs", float64(d.Nanoseconds())/1000.0)
+	} else if d < time.Second {
+		return fmt.Sprintf("%.2fms", float64(d.Nanoseconds())/1000000.0)
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// formatSignedDuration formats a duration with an explicit sign
+func formatSignedDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	return "+" + formatDuration(d)
+}
+
+// formatThresholdActual formats a threshold's measured value in its metric's unit
+func formatThresholdActual(t runner.ThresholdResult) string {
+	return formatMetricValue(t.Metric, t.Actual)
+}
+
+// formatMetricValue formats a threshold metric value (ms for latency, % for rates)
+func formatMetricValue(metric string, v float64) string {
+	switch metric {
+	case "error_rate", "success_rate":
+		return fmt.Sprintf("%.2f%%", v)
+	case "rps":
+		return fmt.Sprintf("%.1f", v)
+	default:
+		return formatDuration(time.Duration(v * float64(time.Millisecond)))
+	}
+}
+
+// sortedStatusClasses returns the status classes in order, network errors last
+func sortedStatusClasses(classes map[string]int64) []string {
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		if class != runner.StatusClassNetworkError {
+			names = append(names, class)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := classes[runner.StatusClassNetworkError]; ok {
+		names = append(names, runner.StatusClassNetworkError)
+	}
+	return names
+}
+
+// slowClient describes the simulated slow client ("" if bodies were read at full speed)
+func slowClient(summary *runner.Summary) string {
+	var parts []string
+	if summary.ReadRate > 0 {
+		parts = append(parts, fmt.Sprintf("reading at %s/s", formatBytes(summary.ReadRate)))
+	}
+	if summary.ReadDelay > 0 {
+		parts = append(parts, fmt.Sprintf("%s pause before each %d KiB read", formatDuration(summary.ReadDelay), httpclient.ReadChunk>>10))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printLatencyGroup prints one line of requests and latency (nothing for an empty group)
+func (p *Printer) printLatencyGroup(name string, g runner.LatencyGroup) {
+	if g.Requests == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "  %s: %s requests, %s failed (%.2f%%), avg %s, p50 %s, p95 %s, p99 %s\n",
+		name, p.count(g.Requests), p.count(g.Failed), g.ErrorRate()*100,
+		formatDuration(g.Latency.Avg), formatDuration(g.Latency.P50), formatDuration(g.Latency.P95), formatDuration(g.Latency.P99))
+}
+
+// printBodies prints the distinct successful response bodies of each target
+func (p *Printer) printBodies(bodies []runner.BodyCardinality) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Response Bodies (distinct successful bodies per target):")
+	for _, b := range bodies {
+		distinct := p.count(int64(b.Distinct))
+		if b.Untracked > 0 {
+			distinct = "over " + distinct
+		}
+		fmt.Fprintf(p.out, "  %s: %s responses, %s distinct, most common %.2f%%\n", b.Target, p.count(b.Responses), distinct, b.TopShare()*100)
+		if b.Identical() {
+			fmt.Fprintln(p.out, "    Warning: every response had the same body; if the endpoint is dynamic, a cache may be serving a stale response")
+		}
+	}
+}
+
+// printAuth prints the token requests of an auth refresh and the business
+// requests by the token they carried
+func (p *Printer) printAuth(a *runner.AuthSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Auth Refresh (%d workers, %g%%, re-authenticating every %s against %s):\n", a.Refreshers, a.Percent, formatDurationShort(a.Interval), a.URL)
+	p.printLatencyGroup("Token Requests", a.Refreshes)
+	reasons := make([]string, 0, len(a.Errors))
+	for reason := range a.Errors {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(p.out, "    %s: %d\n", reason, a.Errors[reason])
+	}
+	for _, b := range a.Business {
+		name := "Cached Token"
+		if b.Token == runner.AuthTokenRefreshed {
+			name = "Refreshed Tokens"
+		}
+		p.printLatencyGroup(name, b.Requests)
+		if b.Unauthorized > 0 {
+			fmt.Fprintf(p.out, "    401 Unauthorized: %d (%.2f%%)\n", b.Unauthorized, percentOf(b.Unauthorized, b.Requests.Requests))
+		}
+	}
+}
+
+// printRanges prints the byte-range requests and how they were answered
+func (p *Printer) printRanges(r *runner.RangeSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Range Requests (%s ranges, %g%% of requests):\n", formatBytes(r.Size), r.Percent)
+	p.printLatencyGroup("Range", r.Ranged)
+	p.printLatencyGroup("Full", r.Full)
+	if r.Ignored > 0 {
+		fmt.Fprintf(p.out, "  Full Object Returned: %d (Range header ignored)\n", r.Ignored)
+	}
+	if r.Mismatched > 0 {
+		fmt.Fprintf(p.out, "  Wrong Range: %d (206 with other bytes than requested)\n", r.Mismatched)
+	}
+}
+
+// printExpectContinue prints how requests sent with Expect: 100-continue were answered
+func (p *Printer) printExpectContinue(e *runner.ExpectContinueSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Expect: 100-continue (body sent after %s without an answer):\n", formatDuration(e.Timeout))
+	fmt.Fprintf(p.out, "  Continued: %d of %d", e.Continued, e.Requests)
+	if e.Continued > 0 {
+		fmt.Fprintf(p.out, " (100 Continue after avg %s, p50 %s, p95 %s, p99 %s)",
+			formatDuration(e.Wait.Avg), formatDuration(e.Wait.P50), formatDuration(e.Wait.P95), formatDuration(e.Wait.P99))
+	}
+	fmt.Fprintln(p.out)
+	if e.TimedOut > 0 {
+		fmt.Fprintf(p.out, "  Timed Out: %d (no 100 Continue; body sent anyway)\n", e.TimedOut)
+	}
+	if e.Final > 0 {
+		fmt.Fprintf(p.out, "  Answered Before Body: %d (final response without 100 Continue)\n", e.Final)
+	}
+}
+
+// printArrivals prints how late requests at a constant arrival rate were sent
+func (p *Printer) printArrivals(a *runner.ArrivalSummary) {
+	fmt.Fprintln(p.out)
+	if a.Rate > 0 {
+		fmt.Fprintf(p.out, "Arrival Rate (%s requests/s, open model):\n", p.count(int64(a.Rate)))
+	} else {
+		fmt.Fprintln(p.out, "Arrival Rate (following the RPS trace, open model):")
+	}
+	fmt.Fprintf(p.out, "  Sent Late: %s of %s (waited for a free worker; the wait is included in latency)\n", p.count(a.Late), p.count(a.Requests))
+	if a.Late > 0 {
+		fmt.Fprintf(p.out, "  Queue Delay: avg %s, p50 %s, p95 %s, p99 %s, max %s\n",
+			formatDuration(a.Delay.Avg), formatDuration(a.Delay.P50), formatDuration(a.Delay.P95), formatDuration(a.Delay.P99), formatDuration(a.Delay.Max))
+	}
+	if a.Unsent > 0 {
+		fmt.Fprintf(p.out, "  Never Sent: %s (due before the end, but every worker was busy; raise -c)\n", p.count(a.Unsent))
+	}
+}
+
+// printTimeouts prints the share of requests slower than each candidate timeout,
+// up to the first that no request exceeded
+func (p *Printer) printTimeouts(t *runner.TimeoutAnalysis) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Timeout Candidates (requests slower than each client timeout):")
+	for _, c := range t.Candidates {
+		if c.Censored {
+			fmt.Fprintf(p.out, "  %8s: unknown (requests ran with a %s timeout)\n", formatDurationShort(c.Timeout), formatDurationShort(t.Limit))
+			break
+		}
+		if c.Breached == 0 {
+			fmt.Fprintf(p.out, "  %8s: none\n", formatDurationShort(c.Timeout))
+			break
+		}
+		fmt.Fprintf(p.out, "  %8s: %s (%.2f%%)\n", formatDurationShort(c.Timeout), p.count(c.Breached), c.Rate*100)
+	}
+	if t.Suggested > 0 {
+		fmt.Fprintf(p.out, "  Suggested: %s (the shortest that cuts off at most 0.1%% of requests)\n", formatDurationShort(t.Suggested))
+	} else {
+		fmt.Fprintln(p.out, "  Suggested: none (each candidate that could be measured cuts off more than 0.1% of requests)")
+	}
+}
+
+// printTail prints where the slowest requests went, what they got back, whether
+// they opened a connection and when they completed
+func (p *Printer) printTail(t *runner.TailAnalysis) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Tail Analysis (slowest %g%% of requests: %s at or above %s):\n", t.Percent, p.count(t.Requests), formatDuration(t.Threshold))
+	p.printTailShares("Target", t.Targets, t)
+	p.printTailShares("Status", t.Statuses, t)
+	p.printTailShares("Connection", t.Connections, t)
+	fmt.Fprintf(p.out, "  Seconds: %d of %d had tail requests\n", t.SecondsHit, t.SecondCount)
+	for _, s := range t.Seconds {
+		fmt.Fprintf(p.out, "    %8s  %s (%.1f%% of the tail, %.1f%% of its %s requests)\n", formatDurationShort(s.Offset),
+			p.count(s.Tail), float64(s.Tail)/float64(t.Requests)*100, float64(s.Tail)/float64(s.Requests)*100, p.count(s.Requests))
+	}
+}
+
+// printTailShares prints the values of one attribute with their share of the
+// tail next to their share of all requests; values at least twice as common in
+// the tail are flagged
+func (p *Printer) printTailShares(name string, shares []runner.TailShare, t *runner.TailAnalysis) {
+	if len(shares) == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "  %s:\n", name)
+	for _, s := range shares {
+		tail := float64(s.Tail) / float64(t.Requests)
+		all := float64(s.Requests) / float64(t.Total)
+		flag := ""
+		if tail >= 2*all {
+			flag = fmt.Sprintf(" (%.1fx overrepresented)", tail/all)
+		}
+		fmt.Fprintf(p.out, "    %s: %.1f%% of the tail vs %.1f%% of all requests%s\n", s.Value, tail*100, all*100, flag)
+	}
+}
+
+// printRPSTrace prints the rates the trace asked for and the seconds the run fell behind
+func (p *Printer) printRPSTrace(t *runner.RPSTraceSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "RPS Trace (%s, %d samples, scaled %gx, %gx speed):\n", t.File, t.Points, t.Scale, t.Speed)
+	fmt.Fprintf(p.out, "  Trace Rate: peak %s req/s, mean %s req/s\n", p.rate(t.Peak), p.rate(t.Mean))
+	percent := 0.0
+	if t.Expected > 0 {
+		percent = float64(t.Sent) / float64(t.Expected) * 100
+	}
+	fmt.Fprintf(p.out, "  Requests: %s of the %s the trace asked for (%.1f%%)\n", p.count(t.Sent), p.count(t.Expected), percent)
+	if t.Behind > 0 {
+		fmt.Fprintf(p.out, "  Behind: %d seconds more than 10%% below the trace, worst at %s: %s of %s req/s (raise -c if requests were sent late)\n",
+			t.Behind, formatDurationShort(t.WorstAt), p.rate(t.WorstRPS), p.rate(t.WorstTarget))
+	}
+}
+
+// printTargets prints the requests of each target and, with the sticky strategy,
+// the workers pinned to it
+func (p *Printer) printTargets(summary *runner.Summary) {
+	fmt.Fprintln(p.out)
+	switch summary.URLStrategy {
+	case runner.StrategySticky:
+		fmt.Fprintln(p.out, "Targets (sticky, each worker pinned to one URL):")
+	case runner.StrategyHash:
+		fmt.Fprintf(p.out, "Targets (by consistent hash of the %s data column):\n", summary.HashKey)
+	default:
+		fmt.Fprintln(p.out, "Targets:")
+	}
+	for _, url := range sortedTargets(summary.Targets) {
+		t := summary.Targets[url]
+		workers := ""
+		if summary.TargetWorkers != nil {
+			workers = fmt.Sprintf("%d workers, ", summary.TargetWorkers[url])
+		}
+		fmt.Fprintf(p.out, "  %s: %s%s requests (%.1f%%), %s failed (%.2f%%), avg %s, p50 %s, p95 %s, p99 %s\n",
+			url, workers, p.count(t.Requests), float64(t.Requests)/float64(summary.TotalRequests)*100, p.count(t.Failed), t.ErrorRate()*100,
+			formatDuration(t.Latency.Avg), formatDuration(t.Latency.P50), formatDuration(t.Latency.P95), formatDuration(t.Latency.P99))
+	}
+}
+
+// printFailover prints each target leaving or rejoining the rotation with the
+// requests of the seconds before and after it
+func (p *Printer) printFailover(f *runner.FailoverSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Failover (up to %s before vs. after each event, bounded by the neighbouring events):\n", formatDurationShort(f.Window))
+	if len(f.Events) == 0 {
+		fmt.Fprintln(p.out, "  No target outage started before the test ended.")
+		return
+	}
+	for _, e := range f.Events {
+		action := "rejoined"
+		if e.Removed {
+			action = "removed"
+		}
+		fmt.Fprintf(p.out, "  %s %s %s\n", formatDurationShort(e.At), action, e.URL)
+		p.printTimelineWindow("Before", e.Before)
+		p.printTimelineWindow("After", e.After)
+	}
+}
+
+// printChaos prints each burst and pause with the requests before, during and after it
+func (p *Printer) printChaos(c *runner.ChaosSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Chaos (windows as long as each event before, during and after it, bounded by the neighbouring events):")
+	if len(c.Events) == 0 {
+		fmt.Fprintln(p.out, "  No chaos event started before the test ended.")
+		return
+	}
+	for _, e := range c.Events {
+		fmt.Fprintf(p.out, "  %s %s\n", formatDurationShort(e.At), e.ChaosEvent)
+		p.printTimelineWindow("Before", e.Before)
+		p.printTimelineWindow("During", e.During)
+		p.printTimelineWindow("After", e.After)
+	}
+}
+
+// printTimelineWindow prints the rate, errors and p95 of part of the run
+func (p *Printer) printTimelineWindow(name string, w runner.TimelineWindow) {
+	fmt.Fprintf(p.out, "    %-7s %s req/s, %.2f%% errors, p95 %s\n", name+":", p.rate(w.RPS), w.ErrorRate()*100, formatDuration(w.P95))
+}
+
+// timelineMarks returns the events of the run to mark under the charts, in order
+func timelineMarks(summary *runner.Summary) []chartMark {
+	var marks []chartMark
+	if f := summary.Failover; f != nil {
+		for _, e := range f.Events {
+			action := "rejoined"
+			if e.Removed {
+				action = "removed"
+			}
+			marks = append(marks, chartMark{At: e.At, Label: action + " " + e.URL})
+		}
+	}
+	if c := summary.Chaos; c != nil {
+		for _, e := range c.Events {
+			marks = append(marks, chartMark{At: e.At, Label: e.ChaosEvent.String()})
+		}
+	}
+	sort.SliceStable(marks, func(i, j int) bool { return marks[i].At < marks[j].At })
+	return marks
+}
+
+// printWorkerPools prints the workers dedicated to each target and the shared pool
+func (p *Printer) printWorkerPools(pools []runner.WorkerPool) {
+	total := 0
+	for _, pool := range pools {
+		total += pool.Workers
+	}
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Worker Pools (%d workers):\n", total)
+	for _, pool := range pools {
+		fmt.Fprintf(p.out, "  %4d (%3.0f%%) %s\n", pool.Workers, float64(pool.Workers)/float64(total)*100, strings.Join(pool.URLs, ", "))
+	}
+}
+
+// printLittlesLaw prints the requests in flight on average (L = λW) against the workers
+func (p *Printer) printLittlesLaw(l *runner.LittlesLawSummary, summary *runner.Summary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Little's Law (L = λW):")
+	fmt.Fprintf(p.out, "  In Flight: %.1f on average (%s req/s × %s avg latency) of %d workers (%.0f%% busy)\n",
+		l.InFlight, p.rate(summary.RPS), formatDuration(summary.AvgLatency), l.Workers, l.Busy*100)
+	switch {
+	case l.Shortfall():
+		fmt.Fprintf(p.out, "  Warning: the concurrency of %d wasn't reached; workers spent %.0f%% of the time outside requests\n", l.Workers, (1-l.Busy)*100)
+		fmt.Fprintln(p.out, "           (a CPU-bound generator, slow templates, result queue waits or health pauses).")
+	case l.Queued():
+		fmt.Fprintln(p.out, "  More requests were in flight than workers: requests queued for a free worker (raise -c).")
+	case l.Idle():
+		fmt.Fprintf(p.out, "  Workers were idle part of the time, as expected with --%s.\n", l.Limit)
+	}
+}
+
+// printRateTarget prints the --max-rps target against the rate achieved and what held it
+func (p *Printer) printRateTarget(r *runner.RateTargetSummary, summary *runner.Summary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Rate Target (--max-rps %s):\n", p.count(int64(r.Target)))
+	fmt.Fprintf(p.out, "  Achieved: %s req/s (%.1f%% of target)\n", p.rate(r.Achieved), r.Percent())
+	g := r.Gaps
+	fmt.Fprintf(p.out, "  Gaps Between Requests: expected %s; avg %s, p50 %s, p95 %s, p99 %s, max %s\n",
+		formatDuration(r.ExpectedGap), formatDuration(g.Avg), formatDuration(g.P50), formatDuration(g.P95), formatDuration(g.P99), formatDuration(g.Max))
+	switch r.Constraint {
+	case runner.ConstraintLimiter:
+		fmt.Fprintln(p.out, "  Constrained By: the rate limiter (target reached)")
+		if r.Percent() > 105 {
+			fmt.Fprintln(p.out, "  Above target: the limiter lets a second's worth of requests through at once at the start.")
+		}
+	case runner.ConstraintWorkers:
+		workers := 0
+		if l := summary.LittlesLaw; l != nil {
+			workers = l.Workers
+		}
+		fmt.Fprintf(p.out, "  Constrained By: the workers; %d workers at the server's avg latency of %s can send at most %s req/s.\n",
+			workers, formatDuration(summary.AvgLatency), p.rate(r.Capacity))
+		fmt.Fprintln(p.out, "  Raise -c, unless the server is already saturated at this load.")
+	case runner.ConstraintGenerator:
+		fmt.Fprintf(p.out, "  Constrained By: the generator; the workers could send %s req/s but requests weren't issued\n", p.rate(r.Capacity))
+		fmt.Fprintln(p.out, "  fast enough (see Generator CPU; consider --procs).")
+	}
+}
+
+// printQueueing prints the sampled requests in flight and the waits for the rate limiter
+func (p *Printer) printQueueing(q *runner.QueueingSummary, summary *runner.Summary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Queueing:")
+	fmt.Fprintf(p.out, "  In Flight: avg %.1f, max %d (sampled every 100ms)\n", q.InFlightAvg, q.InFlightMax)
+	if q.RateLimited {
+		w := q.LimiterWait
+		fmt.Fprintf(p.out, "  Rate Limiter Wait: avg %s, p50 %s, p95 %s, p99 %s, max %s (before sending; not in latency)\n",
+			formatDuration(w.Avg), formatDuration(w.P50), formatDuration(w.P95), formatDuration(w.P99), formatDuration(w.Max))
+		if share := q.LimiterShare(summary.AvgLatency); share >= 0.5 {
+			fmt.Fprintf(p.out, "  Requests spent %.0f%% of each cycle waiting for the rate limiter: --max-rps, not the server,\n", share*100)
+			fmt.Fprintln(p.out, "  set the throughput.")
+		}
+	}
+
+	// Show an evenly spaced subset so long runs stay readable
+	if len(summary.Timeline) > 1 {
+		step := (len(summary.Timeline) + maxTimelineRows - 1) / maxTimelineRows
+		fmt.Fprintln(p.out, "  Timeline:")
+		for i := 0; i < len(summary.Timeline); i += step {
+			point := summary.Timeline[i]
+			fmt.Fprintf(p.out, "    %8s  In flight avg %6.1f max %4d", formatDurationShort(point.Offset), point.InFlightAvg, point.InFlightMax)
+			if q.RateLimited {
+				fmt.Fprintf(p.out, "  Limiter wait %s", formatDuration(point.LimiterWait))
+			}
+			fmt.Fprintf(p.out, "  p95 %s\n", formatDuration(point.P95))
+		}
+	}
+}
+
+// printTLS prints the full and resumed TLS handshakes and their latencies, and
+// the certificate checks of the handshakes
+func (p *Printer) printTLS(t *runner.TLSSummary) {
+	resumption := "resumption on"
+	if !t.Resumption {
+		resumption = "resumption off"
+	}
+	if t.RequireStaple {
+		resumption += ", OCSP staple required"
+	}
+	handshakes := func(name string, count int64, d runner.DurationStats) {
+		fmt.Fprintf(p.out, "  %-8s %s (%.2f%%)", name+":", p.count(count), percentOf(count, t.Full+t.Resumed))
+		if count > 0 {
+			fmt.Fprintf(p.out, ", avg %s, p50 %s, p95 %s, p99 %s",
+				formatDuration(d.Avg), formatDuration(d.P50), formatDuration(d.P95), formatDuration(d.P99))
+		}
+		fmt.Fprintln(p.out)
+	}
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "TLS Handshakes (%s):\n", resumption)
+	handshakes("Full", t.Full, t.FullLatency)
+	handshakes("Resumed", t.Resumed, t.ResumedLatency)
+	switch {
+	case t.Full > 0 && t.Resumed > 0 && t.ResumedLatency.P50 < t.FullLatency.P50:
+		fmt.Fprintf(p.out, "  Resumption saved %s per handshake at p50 (%s vs. %s)\n",
+			formatDuration(t.FullLatency.P50-t.ResumedLatency.P50), formatDuration(t.ResumedLatency.P50), formatDuration(t.FullLatency.P50))
+	case t.Full > 0 && t.Resumed > 0:
+		fmt.Fprintln(p.out, "  Resumed handshakes were no faster than full ones at p50")
+	case t.Resumption && t.Full > int64(t.SessionCaches):
+		fmt.Fprintln(p.out, "  No session was resumed: the server issues no session tickets or forgets them")
+		fmt.Fprintln(p.out, "  (e.g. ticket keys not shared between terminators).")
+	}
+	if t.Verified > 0 {
+		v := t.Verification
+		fmt.Fprintf(p.out, "  Chains Verified: %s, avg %s, p50 %s, p95 %s, p99 %s\n",
+			p.count(t.Verified), formatDuration(v.Avg), formatDuration(v.P50), formatDuration(v.P95), formatDuration(v.P99))
+	}
+	if handshakes := t.Full + t.Resumed; handshakes > 0 {
+		fmt.Fprintf(p.out, "  OCSP Stapled: %s of %s handshakes (%.2f%%)\n", p.count(t.Stapled), p.count(handshakes), percentOf(t.Stapled, handshakes))
+	}
+	if t.Unstapled > 0 {
+		fmt.Fprintf(p.out, "  Failed: %s requests, the server stapled no OCSP response\n", p.count(t.Unstapled))
+	}
+}
+
+// printAudit prints the request counts of each stage and how they reconcile
+func (p *Printer) printAudit(a *runner.AuditSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Request Accounting Audit:")
+	row := func(stage string, count int64, note string) {
+		fmt.Fprintln(p.out, strings.TrimRight(fmt.Sprintf("  %-40s %12d  %s", stage+":", count, note), " "))
+	}
+	check := func(count, expected int64) string {
+		if count == expected {
+			return "ok"
+		}
+		return fmt.Sprintf("MISMATCH (%+d)", count-expected)
+	}
+	if a.RateLimited {
+		row("Rate limiter tokens granted", a.TokensGranted, "")
+	}
+	produced := ""
+	if a.RateLimited && a.TokensGranted > a.Produced {
+		produced = fmt.Sprintf("(%d tokens unused as the test ended)", a.TokensGranted-a.Produced)
+	}
+	row("Results produced by workers", a.Produced, produced)
+	row("Results received by collectors", a.Received, check(a.Received, a.Produced))
+	row("Counted in statistics", a.Counted+a.Cancelled, fmt.Sprintf("%s (%d in totals, %d cancelled at deadline)", check(a.Counted+a.Cancelled, a.Received), a.Counted, a.Cancelled))
+	if a.Recording {
+		recorded := check(a.Recorded, a.Counted)
+		if a.RecordingStopped && a.Recorded <= a.Counted {
+			recorded = fmt.Sprintf("(recording stopped early; %d later results not recorded)", a.Counted-a.Recorded)
+		}
+		row("Records written", a.Recorded, recorded)
+	}
+	if a.CounterHeader != "" {
+		if a.CounterResponses == 0 {
+			fmt.Fprintf(p.out, "  %-40s %12s  no response carried a valid counter\n", "Server counter ("+a.CounterHeader+"):", "-")
+		} else {
+			row("Server counter ("+a.CounterHeader+")", a.ServerCounted, fmt.Sprintf("%+d vs. results produced (includes other clients' requests)", a.ServerCounted-a.Produced))
+		}
+	}
+	if a.Reconciled() {
+		fmt.Fprintln(p.out, "  Result: every request is accounted for")
+	} else {
+		fmt.Fprintln(p.out, "  Result: MISMATCH; some stages lost or double-counted requests")
+	}
+}
+
+// coresPerLine keeps the per-core usage of many-core machines readable
+const coresPerLine = 8
+
+// printGeneratorCPU prints the CPU usage of g0 and of the cores it ran on
+func (p *Printer) printGeneratorCPU(c *runner.GeneratorCPUSummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Generator CPU (GOMAXPROCS %d", c.MaxProcs)
+	if c.Shards > 1 {
+		fmt.Fprintf(p.out, ", %d worker shards", c.Shards)
+	}
+	fmt.Fprintln(p.out, "):")
+	fmt.Fprintf(p.out, "  g0 Process: %.0f%% (%.0f%% of %d CPUs)\n", c.Process, c.Process/float64(c.MaxProcs), c.MaxProcs)
+	for i := 0; i < len(c.Cores); i += coresPerLine {
+		line := make([]string, 0, coresPerLine)
+		for _, core := range c.Cores[i:min(i+coresPerLine, len(c.Cores))] {
+			line = append(line, fmt.Sprintf("cpu%-3d %3.0f%%", core.CPU, core.Busy))
+		}
+		prefix := "         "
+		if i == 0 {
+			prefix = "  Cores: "
+		}
+		fmt.Fprintln(p.out, prefix+strings.Join(line, "  "))
+	}
+	if c.Saturated() {
+		fmt.Fprintln(p.out, "  Note: the generator was CPU-bound, so the results may understate what the target")
+		fmt.Fprintln(p.out, "        can handle; raise --cpus, use --procs or spread the load over more machines.")
+	}
+}
+
+// printMemory prints g0's memory use against its budget and what was shed to keep it
+func (p *Printer) printMemory(m *runner.MemorySummary) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "Generator Memory:")
+	fmt.Fprintf(p.out, "  Peak: %s of %s budget (%.0f%%)\n", formatBytes(m.Peak), formatBytes(m.Budget), float64(m.Peak)/float64(m.Budget)*100)
+	for _, e := range m.Events {
+		fmt.Fprintf(p.out, "  %s at %s: %s\n", formatDurationShort(e.Offset), formatBytes(e.RSS), e.Action)
+	}
+	if m.Stride > 1 {
+		fmt.Fprintf(p.out, "  Note: latency statistics come from 1 in %d requests; counts are exact.\n", m.Stride)
+	}
+}
+
+// PrintMemoryShed prints a highlighted line above the progress line when g0 sheds
+// bookkeeping to stay within its memory budget
+func (p *Printer) PrintMemoryShed(e runner.MemoryEvent, budget int64) {
+	p.printAlert(fmt.Sprintf("MEMORY at %s: g0 uses %s of its %s budget; %s",
+		formatDurationShort(e.Offset), formatBytes(e.RSS), formatBytes(budget), e.Action), ansiRed, false)
+}
+
+// printSizeCorrelation prints the latency of each body size bucket (kind is Request or Response)
+func (p *Printer) printSizeCorrelation(kind string, c *runner.SizeCorrelation) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Latency by %s Size (correlation %+.2f, %s):\n", kind, c.Correlation, describeCorrelation(c.Correlation, strings.ToLower(kind)))
+	for _, b := range c.Buckets {
+		fmt.Fprintf(p.out, "  %s: %d requests, avg %s, p50 %s, p95 %s, p99 %s\n", formatSizeRange(b.MinBytes, b.MaxBytes), b.Requests,
+			formatDuration(b.Avg), formatDuration(b.P50), formatDuration(b.P95), formatDuration(b.P99))
+	}
+}
+
+// describeCorrelation puts a size/latency correlation coefficient into words
+func describeCorrelation(r float64, kind string) string {
+	switch {
+	case math.Abs(r) < 0.1:
+		return "no relation"
+	case r >= 0.5:
+		return fmt.Sprintf("larger %ss are clearly slower", kind)
+	case r > 0:
+		return fmt.Sprintf("larger %ss are somewhat slower", kind)
+	case r <= -0.5:
+		return fmt.Sprintf("larger %ss are clearly faster", kind)
+	default:
+		return fmt.Sprintf("larger %ss are somewhat faster", kind)
+	}
+}
+
+// formatSizeRange formats a size bucket; bounds are powers of two, so they are
+// shown without decimals (e.g. "1 KiB-2 KiB", with the upper bound exclusive)
+func formatSizeRange(min, max int64) string {
+	if max == 0 {
+		return "0 B"
+	}
+	bound := func(n int64) string { return strings.Replace(formatBytes(n), ".00 ", " ", 1) }
+	if max == math.MaxInt64 {
+		return bound(min) + "+"
+	}
+	return bound(min) + "-" + bound(max+1)
+}
+
+// percentOf returns n as a percentage of total (0 if total is 0)
+func percentOf(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+// sortedMethods returns the methods in alphabetical order
+func sortedMethods(methods map[string]runner.MethodSummary) []string {
+	names := make([]string, 0, len(methods))
+	for method := range methods {
+		names = append(names, method)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedStatusCodes returns the status codes in ascending order, network
+// errors (0) first
+func sortedStatusCodes(counts map[int]int64) []int {
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// sortedErrorClasses returns the error classes in alphabetical order
+func sortedErrorClasses(classes map[string]int64) []string {
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedTargets returns the target URLs in alphabetical order
+func sortedTargets(targets map[string]runner.LatencyGroup) []string {
+	urls := make([]string, 0, len(targets))
+	for url := range targets {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// sortedProtocols returns the protocols in ascending version order
+func sortedProtocols(protocols map[string]runner.LatencyGroup) []string {
+	names := make([]string, 0, len(protocols))
+	for protocol := range protocols {
+		names = append(names, protocol)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// maxTimelineRows caps the rows of timelines in the text report
+const maxTimelineRows = 10
+
+// maxHeaderValueRows caps the values listed per captured header in the text report
+
+// maxSchemaViolationRows caps the violation kinds listed in the text report
+const maxSchemaViolationRows = 10
+const maxHeaderValueRows = 10
+
+// traceOutcome describes how a traced request failed (status code or error class)
+func traceOutcome(sample runner.TraceSample) string {
+	if sample.StatusCode > 0 {
+		return fmt.Sprintf("%d", sample.StatusCode)
+	}
+	return sample.ErrorClass
+}
+
+// formatLatencyShort formats a latency compactly for the progress line
+func formatLatencyShort(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%.0fµs", float64(d.Nanoseconds())/1000.0)
+	case d < 100*time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d.Nanoseconds())/1000000.0)
+	case d < time.Second:
+		return fmt.Sprintf("%.0fms", float64(d.Nanoseconds())/1000000.0)
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+// formatBytes formats a byte count using binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDurationShort formats a duration in a short, readable way for progress display
+func formatDurationShort(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%.0fms", float64(d.Nanoseconds())/1000000.0)
+	} else if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	minutes := int(d.Minutes())
+	seconds := int(d.Seconds()) % 60
+	return fmt.Sprintf("%dm%ds", minutes, seconds)
+}
+
+// JSONOutput represents the JSON structure for test results
+type JSONOutput struct {
+	SchemaVersion int               `json:"schema_version"` // See SchemaVersion; g0 convert upgrades older results
+	Metadata      JSONMetadata      `json:"metadata"`
+	Metrics       JSONMetrics       `json:"metrics"`
+	Thresholds    []JSONThreshold   `json:"thresholds,omitempty"`
+	SLOs          []JSONSLO         `json:"slos,omitempty"`
+	Traces        *JSONTraces       `json:"traces,omitempty"`
+	Health        *JSONHealth       `json:"health,omitempty"`
+	Resources     *JSONResources    `json:"resources,omitempty"`
+	Churn         *JSONChurn        `json:"connection_churn,omitempty"`
+	Users         *JSONUsers        `json:"virtual_users,omitempty"`
+	GeneratorCPU  *JSONGeneratorCPU `json:"generator_cpu,omitempty"`
+	Memory        *JSONMemory       `json:"generator_memory,omitempty"`
+	Audit         *JSONAudit        `json:"audit,omitempty"`
+	Cleanup       *JSONCleanup      `json:"created_resources,omitempty"`
+	Record        *JSONRecord       `json:"record,omitempty"`
+	ErrorLog      *JSONErrorLog     `json:"error_log,omitempty"`
+	Passed        bool              `json:"passed"`            // All thresholds passed and the run was not aborted
+	Aborted       bool              `json:"aborted,omitempty"` // Run was interrupted before the configured duration
+}
+
+// JSONHealth contains health check results
+type JSONHealth struct {
+	URL           string         `json:"url"`
+	Checks        int64          `json:"checks"`
+	Failures      int64          `json:"failures"`
+	TotalDowntime JSONDuration   `json:"total_downtime"`
+	Downtime      []JSONDowntime `json:"downtime"`
+	Paused        bool           `json:"paused,omitempty"`
+}
+
+// JSONDowntime is a period during which health checks failed (offsets from the test start)
+type JSONDowntime struct {
+	Start   JSONDuration `json:"start"`
+	End     JSONDuration `json:"end"`
+	Reason  string       `json:"reason"`
+	Ongoing bool         `json:"ongoing,omitempty"`
+}
+
+// JSONResources contains the target's resource usage series
+type JSONResources struct {
+	URL       string               `json:"url"`
+	Source    string               `json:"source,omitempty"`
+	Errors    int64                `json:"failed_scrapes"`
+	CPUAvg    float64              `json:"cpu_avg_percent"`
+	CPUMax    float64              `json:"cpu_max_percent"`
+	MemoryMax int64                `json:"memory_max_bytes"`
+	Samples   []JSONResourceSample `json:"samples"`
+}
+
+// JSONResourceSample is one scrape of the target's resource usage
+type JSONResourceSample struct {
+	OffsetMs    int64   `json:"offset_ms"` // Time since the start of the test
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryBytes int64   `json:"memory_bytes"`
+}
+
+// JSONUsers reports the virtual users of --users and how long they waited for a worker
+type JSONUsers struct {
+	Users     int          `json:"users"`
+	ThinkTime JSONDuration `json:"think_time"`
+	Workers   int          `json:"workers"`
+	Requests  int64        `json:"requests"`
+	AvgDelay  JSONDuration `json:"avg_wakeup_delay"` // Time from a request being due to a worker sending it
+	MaxDelay  JSONDuration `json:"max_wakeup_delay"`
+}
+
+// JSONAudit contains the request counts of each stage reported by --audit
+type JSONAudit struct {
+	TokensGranted    *int64 `json:"rate_limiter_tokens,omitempty"` // Only with --max-rps
+	Produced         int64  `json:"produced"`
+	Received         int64  `json:"received"`
+	Counted          int64  `json:"counted"`
+	Cancelled        int64  `json:"cancelled_at_deadline"`
+	Recorded         *int64 `json:"recorded,omitempty"`          // Only with --record
+	RecordingStopped bool   `json:"recording_stopped,omitempty"` // The recording ended early, so it lacks later results
+	CounterHeader    string `json:"server_counter_header,omitempty"`
+	CounterResponses int64  `json:"server_counter_responses,omitempty"`
+	ServerCounted    *int64 `json:"server_counted,omitempty"` // Only when responses carried the counter
+	Reconciled       bool   `json:"reconciled"`
+}
+
+// JSONGeneratorCPU reports the CPU usage of the machine running g0
+type JSONGeneratorCPU struct {
+	MaxProcs  int             `json:"gomaxprocs"`
+	Shards    int             `json:"worker_shards"`
+	Process   float64         `json:"process_percent"` // Percent of one core (e.g., 350 = 3.5 cores)
+	Cores     []JSONCoreUsage `json:"cores,omitempty"` // Cores the process may run on (Linux only)
+	Saturated bool            `json:"saturated"`       // g0 was CPU-bound; the results may understate the target
+}
+
+// JSONMemory reports g0's memory use against --max-memory
+type JSONMemory struct {
+	BudgetBytes int64             `json:"budget_bytes"`
+	PeakBytes   int64             `json:"peak_bytes"`    // Highest resident memory seen
+	Stride      int               `json:"sample_stride"` // Latencies come from 1 in this many requests (1 = all)
+	Events      []JSONMemoryEvent `json:"events,omitempty"`
+}
+
+// JSONMemoryEvent is something g0 shed to stay within its memory budget
+type JSONMemoryEvent struct {
+	OffsetMs int64  `json:"offset_ms"` // Time since the start of the test
+	RSSBytes int64  `json:"rss_bytes"` // Resident memory that triggered it
+	Action   string `json:"action"`
+}
+
+// JSONCoreUsage is the utilization of one CPU core during the run
+type JSONCoreUsage struct {
+	CPU  int     `json:"cpu"`
+	Busy float64 `json:"busy_percent"`
+}
+
+// JSONChurn contains the outcome of the connections opened with --churn-rate
+type JSONChurn struct {
+	Rate               int               `json:"rate"` // Connections per second
+	Connections        int64             `json:"connections"`
+	Skipped            int64             `json:"skipped,omitempty"` // Not opened while a second's worth were being set up
+	ConnectErrors      int64             `json:"connect_errors"`
+	ConnectErrorRate   float64           `json:"connect_error_rate"`
+	HandshakeErrors    int64             `json:"handshake_errors,omitempty"`
+	HandshakeErrorRate float64           `json:"handshake_error_rate,omitempty"`
+	Errors             map[string]int64  `json:"errors,omitempty"` // Failures by error class
+	Connect            JSONDistribution  `json:"connect"`
+	Handshake          *JSONDistribution `json:"handshake,omitempty"` // HTTPS targets only
+}
+
+// JSONCleanup describes the resources tracked with --track-created and their removal
+type JSONCleanup struct {
+	Tracked   int64            `json:"tracked"`
+	Missing   int64            `json:"missing,omitempty"` // Successful responses without an ID
+	File      string           `json:"file,omitempty"`
+	FileError string           `json:"file_error,omitempty"`
+	DeleteURL string           `json:"cleanup_url,omitempty"`
+	Deleted   int64            `json:"deleted"`
+	Failed    int64            `json:"failed"`
+	Errors    map[string]int64 `json:"errors,omitempty"` // Failed deletions by status code or error class
+	Duration  *JSONDuration    `json:"duration,omitempty"`
+}
+
+// JSONRecord describes the per-request record file written with --record
+type JSONRecord struct {
+	Path    string `json:"path"`
+	Format  string `json:"format"`
+	Records int64  `json:"records"`
+	Rotated int    `json:"rotated,omitempty"` // Times the file was moved to <path>.1 and a new one started
+	Error   string `json:"error,omitempty"`
+}
+
+// JSONErrorLog describes the log of failed requests
+type JSONErrorLog struct {
+	Path    string `json:"path"`
+	Records int64  `json:"records"`
+	Rotated int    `json:"rotated,omitempty"` // Times the log was moved to <path>.1 and a new one started
+	Error   string `json:"error,omitempty"`
+}
+
+// JSONSLO contains the outcome of an SLO
+type JSONSLO struct {
+	Name       string   `json:"name"`
+	Objective  float64  `json:"objective_percent"`
+	Latency    *float64 `json:"latency_ms,omitempty"`
+	Total      int64    `json:"total"`
+	Good       int64    `json:"good"`
+	Within     float64  `json:"within_percent"`
+	BudgetBurn float64  `json:"error_budget_burn_percent"`
+	Passed     bool     `json:"passed"`
+}
+
+// JSONTraces links the run to the tracing backend
+type JSONTraces struct {
+	RunID   string            `json:"run_id"`
+	Slowest []JSONTraceSample `json:"slowest,omitempty"`
+	Failed  []JSONTraceSample `json:"failed,omitempty"`
+}
+
+// JSONTraceSample identifies a traced request
+type JSONTraceSample struct {
+	TraceID    string       `json:"trace_id"`
+	Link       string       `json:"link,omitempty"`
+	Latency    JSONDuration `json:"latency"`
+	StatusCode int          `json:"status_code,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// JSONThreshold contains the outcome of a pass/fail threshold
+type JSONThreshold struct {
+	Expression string  `json:"expression"`
+	Metric     string  `json:"metric"`
+	Operator   string  `json:"operator"`
+	Limit      float64 `json:"limit"`
+	Actual     float64 `json:"actual"`
+	Passed     bool    `json:"passed"`
+}
+
+// JSONMetadata contains test configuration and timing information
+type JSONMetadata struct {
+	URL         string            `json:"url,omitempty"`  // Single URL (if only one)
+	URLs        []string          `json:"urls,omitempty"` // Multiple URLs (if more than one)
+	Method      string            `json:"method"`
+	Concurrency int               `json:"concurrency"`
+	Duration    string            `json:"duration"`
+	DurationMs  int64             `json:"duration_ms"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Seed        int64             `json:"seed"`                  // Pass to --seed to reproduce randomized values
+	RunID       string            `json:"run_id,omitempty"`      // Correlates the result with the run's metrics and requests
+	Data        *JSONDataUsage    `json:"unique_data,omitempty"` // Rows used of a unique data feed
+	Replicas    int               `json:"replicas,omitempty"`    // Generators merged into this result (g0 k8s collect)
+	Segments    int               `json:"segments,omitempty"`    // Parts of a run resumed from checkpoints (g0 run --resume)
+	StartTime   string            `json:"start_time,omitempty"`
+	EndTime     string            `json:"end_time,omitempty"`
+
+	ScheduledStart string   `json:"scheduled_start,omitempty"` // --start-at/--start-after time the run waited for
+	ClockOffsetMs  *float64 `json:"clock_offset_ms,omitempty"` // Local clock minus NTP time
+	NTPServer      string   `json:"ntp_server,omitempty"`
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+// JSONDataUsage reports how many rows of a unique data feed were used
+type JSONDataUsage struct {
+	Rows      int   `json:"rows"`
+	Used      int64 `json:"used"`
+	Exhausted bool  `json:"exhausted"` // Every row was used and the test ended early
+}
+
+// JSONMetrics contains all test metrics
+type JSONMetrics struct {
+	Requests      JSONRequests                `json:"requests"`
+	Latency       JSONLatency                 `json:"latency"`
+	StatusCodes   map[string]int64            `json:"status_codes"`
+	StatusClasses map[string]JSONStatusClass  `json:"status_classes"`             // 2xx, 3xx, ..., "error" for network errors
+	Errors        map[string]int64            `json:"errors,omitempty"`           // Network-level errors by class
+	Methods       map[string]JSONMethod       `json:"methods,omitempty"`          // Per-method breakdown (only with mixed methods)
+	Protocols     map[string]JSONLatencyGroup `json:"protocols,omitempty"`        // Requests by response protocol (e.g., HTTP/2.0)
+	Targets       map[string]JSONTarget       `json:"targets,omitempty"`          // Requests by target URL as configured (only with several targets)
+	URLStrategy   string                      `json:"url_strategy,omitempty"`     // How workers picked their targets
+	HashKey       string                      `json:"hash_key,omitempty"`         // Data column hashed to pick the target (hash strategy only)
+	Headers       []JSONHeader                `json:"response_headers,omitempty"` // Captured response header values
+	ServerTiming  *JSONServerTiming           `json:"server_timing,omitempty"`
+	Conditional   *JSONConditional            `json:"conditional,omitempty"`
+	Compression   *JSONCompression            `json:"compression,omitempty"`
+	Timing        *JSONTiming                 `json:"timing,omitempty"`
+
+	LatencyHistogram []JSONHistogramBucket `json:"latency_histogram,omitempty"`  // Non-empty latency buckets, ascending
+	Timeline         []JSONTimelinePoint   `json:"timeline,omitempty"`           // Per-second requests, rate and latency
+	WorstSecond      *JSONTimelinePoint    `json:"worst_second,omitempty"`       // Timeline entry with the slowest single request
+	Timeouts         *JSONTimeouts         `json:"timeout_candidates,omitempty"` // Requests slower than candidate client timeouts
+	Tail             *JSONTail             `json:"tail,omitempty"`               // Where the slowest 1% of requests came from
+
+	RequestSizes  *JSONSizeCorrelation `json:"request_sizes,omitempty"`  // Latency by request body size (only when sizes vary)
+	ResponseSizes *JSONSizeCorrelation `json:"response_sizes,omitempty"` // Latency by response body size (only when sizes vary)
+
+	ColdStart *JSONColdStart `json:"cold_start,omitempty"`      // First requests of each worker vs. steady state (--cold-requests)
+	Schema    *JSONSchema    `json:"response_schema,omitempty"` // Response bodies validated with --response-schema
+	Bodies    []JSONBodies   `json:"response_bodies,omitempty"` // Distinct response bodies per target (--body-cardinality)
+	Canary    *JSONCanary    `json:"canary,omitempty"`          // Baseline vs. canary target (--canary-url)
+	Mirror    *JSONMirror    `json:"mirror,omitempty"`          // Target vs. mirror target (--mirror)
+	Auth      *JSONAuth      `json:"auth_refresh,omitempty"`    // Token requests vs. business requests (--auth-url)
+	Ranges    *JSONRanges    `json:"range_requests,omitempty"`  // Byte-range vs. full requests (--range-size)
+
+	ExpectContinue *JSONExpectContinue `json:"expect_continue,omitempty"` // Handling of Expect: 100-continue (--expect-continue)
+	DNS            *JSONDNS            `json:"dns,omitempty"`             // Lookups through --dns-server
+	HTTPAuth       *JSONHTTPAuth       `json:"http_auth,omitempty"`       // Requests with and without an auth challenge (--http-auth)
+	ClientCerts    *JSONClientCerts    `json:"client_certs,omitempty"`    // Requests per client certificate (--client-cert-dir)
+	SNI            *JSONSNI            `json:"sni,omitempty"`             // Requests per server name (--sni-list)
+	TLS            *JSONTLS            `json:"tls_handshakes,omitempty"`  // Full vs. resumed TLS handshakes
+	Arrivals       *JSONArrivals       `json:"arrivals,omitempty"`        // Lateness of requests at --arrival-rate
+	Failover       *JSONFailover       `json:"failover,omitempty"`        // Targets taken out of rotation and their impact
+	Chaos          *JSONChaos          `json:"chaos,omitempty"`           // Bursts and pauses of the load and their impact
+	RPSTrace       *JSONRPSTrace       `json:"rps_trace,omitempty"`       // Run vs. the traffic shape of --rps-trace
+	WorkerPools    []JSONWorkerPool    `json:"worker_pools,omitempty"`    // Workers dedicated to each target
+	LittlesLaw     *JSONLittlesLaw     `json:"littles_law,omitempty"`     // Requests in flight derived from throughput and latency
+	Queueing       *JSONQueueing       `json:"queueing,omitempty"`        // Sampled requests in flight and waits for the rate limiter
+	RateTarget     *JSONRateTarget     `json:"rate_target,omitempty"`     // --max-rps vs. the rate achieved
+}
+
+// JSONRateTarget compares the --max-rps target with the rate achieved
+type JSONRateTarget struct {
+	Target      int              `json:"target_rps"`
+	Achieved    float64          `json:"achieved_rps"`
+	Percent     float64          `json:"percent"`
+	Capacity    float64          `json:"worker_capacity_rps"` // Workers / average latency
+	ExpectedGap JSONDuration     `json:"expected_gap"`
+	Gaps        JSONDistribution `json:"gaps"`       // Time between consecutive requests
+	Constraint  string           `json:"constraint"` // limiter, workers or generator
+}
+
+// JSONQueueing separates client-side queueing from the time the server took
+type JSONQueueing struct {
+	InFlightAvg float64           `json:"in_flight_avg"`
+	InFlightMax int64             `json:"in_flight_max"`
+	LimiterWait *JSONDistribution `json:"rate_limiter_wait,omitempty"` // Only with --max-rps; not part of latency
+}
+
+// JSONLittlesLaw reports the requests in flight on average (L = λW) against the workers
+type JSONLittlesLaw struct {
+	Workers     int     `json:"workers"`
+	InFlight    float64 `json:"in_flight"`
+	BusyPercent float64 `json:"busy_percent"`
+	Limit       string  `json:"limit,omitempty"`     // What paced the workers by design: max-rps, arrival-rate or users
+	Shortfall   bool    `json:"shortfall,omitempty"` // The configured concurrency wasn't reached for no configured reason
+}
+
+// JSONFailover reports the targets taken out of rotation and put back
+type JSONFailover struct {
+	WindowSeconds float64             `json:"window_seconds"` // Length of the windows compared around each event
+	Events        []JSONFailoverEvent `json:"events"`
+}
+
+// JSONTail characterizes the slowest requests of the run
+type JSONTail struct {
+	Percent     float64          `json:"percent"` // Share of the slowest requests analyzed
+	Threshold   JSONDuration     `json:"threshold"`
+	Requests    int64            `json:"requests"`
+	Targets     []JSONTailShare  `json:"targets,omitempty"`
+	Statuses    []JSONTailShare  `json:"statuses"`
+	Connections []JSONTailShare  `json:"connections"`
+	Seconds     []JSONTailSecond `json:"seconds"` // Seconds with the most tail requests
+	SecondsHit  int              `json:"seconds_with_tail"`
+}
+
+// JSONTailShare is a value of an attribute with its share of the tail and of all requests
+type JSONTailShare struct {
+	Value        string  `json:"value"`
+	TailRequests int64   `json:"tail_requests"`
+	TailPercent  float64 `json:"tail_percent"`
+	Percent      float64 `json:"percent"` // Share of all requests
+}
+
+// JSONTailSecond is a second of the run with its tail requests
+type JSONTailSecond struct {
+	OffsetMs     int64 `json:"offset_ms"`
+	TailRequests int64 `json:"tail_requests"`
+	Requests     int64 `json:"requests"`
+}
+
+// JSONTimeouts reports the share of requests candidate client timeouts would have cut off
+type JSONTimeouts struct {
+	Limit      JSONDuration           `json:"request_timeout"` // Timeout the requests ran with
+	Candidates []JSONTimeoutCandidate `json:"candidates"`
+	Suggested  *JSONDuration          `json:"suggested,omitempty"` // Shortest candidate cutting off at most 0.1% of requests
+}
+
+// JSONTimeoutCandidate is a client timeout with the requests that took longer
+type JSONTimeoutCandidate struct {
+	Timeout  JSONDuration `json:"timeout"`
+	Requests int64        `json:"requests"`
+	Percent  float64      `json:"percent"`
+	Censored bool         `json:"censored,omitempty"` // Not below the run's own timeout, so slower requests can't be counted
+}
+
+// JSONRPSTrace reports how closely the run followed a recorded traffic shape
+type JSONRPSTrace struct {
+	File     string           `json:"file"`
+	Points   int              `json:"samples"`
+	Scale    float64          `json:"scale"`
+	Speed    float64          `json:"speed"`
+	Peak     float64          `json:"peak_rps"`
+	Mean     float64          `json:"mean_rps"`
+	Expected int64            `json:"expected_requests"`
+	Sent     int64            `json:"requests"`
+	Behind   int              `json:"seconds_behind"` // Seconds more than 10% below the trace's rate
+	Worst    *JSONTraceSecond `json:"worst_second,omitempty"`
+}
+
+// JSONTraceSecond is a second of the run with the trace's rate and the rate achieved
+type JSONTraceSecond struct {
+	AtSeconds float64 `json:"at_seconds"`
+	TraceRPS  float64 `json:"trace_rps"`
+	RPS       float64 `json:"rps"`
+}
+
+// JSONChaos reports the bursts and pauses of the load
+type JSONChaos struct {
+	Events []JSONChaosEvent `json:"events"`
+}
+
+// JSONChaosEvent is a burst or pause with the requests before, during and after it
+type JSONChaosEvent struct {
+	Kind            string             `json:"kind"` // burst or pause
+	AtSeconds       float64            `json:"at_seconds"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	Factor          float64            `json:"factor,omitempty"` // Rate multiplier of a burst
+	Before          JSONTimelineWindow `json:"before"`
+	During          JSONTimelineWindow `json:"during"`
+	After           JSONTimelineWindow `json:"after"`
+}
+
+// JSONFailoverEvent is a target leaving or rejoining the rotation
+type JSONFailoverEvent struct {
+	AtSeconds float64            `json:"at_seconds"`
+	URL       string             `json:"url"`
+	Action    string             `json:"action"` // removed or rejoined
+	Before    JSONTimelineWindow `json:"before"`
+	After     JSONTimelineWindow `json:"after"`
+}
+
+// JSONTimelineWindow describes the requests of part of the run, e.g. before or after an event
+type JSONTimelineWindow struct {
+	Requests  int64   `json:"requests"`
+	Failed    int64   `json:"failed"`
+	ErrorRate float64 `json:"error_rate"`
+	RPS       float64 `json:"rps"`
+	P95Ms     float64 `json:"p95_ms"`
+}
+
+// JSONWorkerPool reports a group of workers and the targets they sent requests to
+type JSONWorkerPool struct {
+	URLs    []string `json:"urls"`
+	Workers int      `json:"workers"`
+}
+
+// JSONArrivals reports how well the workers kept up with a constant arrival rate
+type JSONArrivals struct {
+	Rate     int              `json:"rate"`
+	Requests int64            `json:"requests"`
+	Late     int64            `json:"late"`   // Sent more than 1ms after they were due
+	Unsent   int64            `json:"unsent"` // Due before the end but never sent
+	Delay    JSONDistribution `json:"queue_delay"`
+}
+
+// JSONDNS reports the lookups made through a custom DNS server
+type JSONDNS struct {
+	Server  string            `json:"server"`
+	Lookups int64             `json:"lookups"`
+	Failed  int64             `json:"failed"`
+	Latency *JSONDistribution `json:"latency,omitempty"` // Only when lookups were made
+}
+
+// JSONHTTPAuth reports the requests of a run with HTTP authentication
+type JSONHTTPAuth struct {
+	Scheme        string           `json:"scheme"`
+	Challenged    JSONLatencyGroup `json:"challenged"`    // Answered a challenge before the response
+	Authenticated JSONLatencyGroup `json:"authenticated"` // Authenticated without a challenge
+	Challenges    int64            `json:"challenges"`
+	Unauthorized  int64            `json:"unauthorized"` // Still answered with 401
+}
+
+// JSONClientCerts reports how the requests spread over the client certificates
+type JSONClientCerts struct {
+	Dir          string                   `json:"dir"`
+	Strategy     string                   `json:"strategy"` // worker or request
+	Certificates int                      `json:"certificates"`
+	Used         int                      `json:"used"`
+	MinRequests  int64                    `json:"min_requests"` // Fewest requests of a used certificate
+	MaxRequests  int64                    `json:"max_requests"`
+	Failing      int                      `json:"failing"` // Certificates with failed requests
+	Failures     []JSONClientCertFailures `json:"failures,omitempty"`
+}
+
+// JSONClientCertFailures is a client certificate with failed requests
+type JSONClientCertFailures struct {
+	Name     string `json:"name"`
+	Requests int64  `json:"requests"`
+	Failed   int64  `json:"failed"`
+}
+
+// JSONSNI reports how the requests spread over the server names
+type JSONSNI struct {
+	File        string            `json:"file"`
+	Names       int               `json:"names"`
+	Used        int               `json:"used"`
+	MinRequests int64             `json:"min_requests"` // Fewest requests of a used name
+	MaxRequests int64             `json:"max_requests"`
+	Failing     int               `json:"failing"` // Names with failed requests
+	Failures    []JSONSNIFailures `json:"failures,omitempty"`
+}
+
+// JSONSNIFailures is a server name with failed requests
+type JSONSNIFailures struct {
+	Name     string `json:"name"`
+	Requests int64  `json:"requests"`
+	Failed   int64  `json:"failed"`
+}
+
+// JSONTLS reports the TLS handshakes of new connections
+type JSONTLS struct {
+	Resumption     bool              `json:"resumption"` // false with --no-tls-resumption
+	Full           int64             `json:"full"`
+	Resumed        int64             `json:"resumed"`
+	ResumedPercent float64           `json:"resumed_percent"`
+	FullLatency    *JSONDistribution `json:"full_latency,omitempty"`    // Only when full handshakes were made
+	ResumedLatency *JSONDistribution `json:"resumed_latency,omitempty"` // Only when sessions were resumed
+
+	Verified     int64             `json:"verified"`               // Certificate chains verified
+	Verification *JSONDistribution `json:"verification,omitempty"` // Only when chains were verified
+
+	RequireStaple bool  `json:"require_ocsp_staple"`
+	Stapled       int64 `json:"ocsp_stapled"`   // Handshakes with an OCSP staple
+	Unstapled     int64 `json:"ocsp_unstapled"` // Requests failed for a missing staple
+}
+
+// JSONExpectContinue reports how the target handled Expect: 100-continue
+type JSONExpectContinue struct {
+	Timeout   JSONDuration      `json:"timeout"`
+	Requests  int64             `json:"requests"`
+	Continued int64             `json:"continued"` // 100 Continue received before the body was sent
+	TimedOut  int64             `json:"timed_out"` // Body sent after the timeout without 100 Continue
+	Final     int64             `json:"answered_before_body"`
+	Wait      *JSONDistribution `json:"continue_wait,omitempty"` // Time from the request headers to 100 Continue
+}
+
+// JSONRanges reports the byte-range requests apart from full requests
+type JSONRanges struct {
+	SizeBytes  int64             `json:"size_bytes"`
+	Percent    float64           `json:"percent"`
+	Range      JSONLatencyGroup  `json:"range"`
+	Full       *JSONLatencyGroup `json:"full,omitempty"`       // Only when some requests fetched the full object
+	Ignored    int64             `json:"full_object_returned"` // Answered with 200 and the whole object
+	Mismatched int64             `json:"wrong_range"`          // 206 with other bytes than requested
+}
+
+// JSONMirror compares the target with the mirror every request was duplicated to
+type JSONMirror struct {
+	URL         string             `json:"url"`
+	Mode        string             `json:"mode"`
+	Target      JSONLatencyGroup   `json:"target"`
+	Mirror      JSONLatencyGroup   `json:"mirror"` // Latencies cover response headers only in forget mode
+	Cancelled   int64              `json:"cancelled_at_deadline"`
+	Dropped     int64              `json:"not_mirrored"`
+	Comparisons []JSONMirrorMetric `json:"comparisons,omitempty"`
+}
+
+// JSONAuth reports the token requests of an auth refresh apart from the
+// business requests
+type JSONAuth struct {
+	URL        string           `json:"url"`
+	IntervalMs int64            `json:"interval_ms"`
+	Percent    float64          `json:"percent"`
+	Refreshers int              `json:"refreshing_workers"`
+	Tokens     JSONLatencyGroup `json:"token_requests"`
+	Errors     map[string]int64 `json:"token_errors,omitempty"` // Failed token requests by status code or error class
+
+	Business map[string]JSONAuthBusiness `json:"business"` // Business requests by token: refreshed or cached
+}
+
+// JSONAuthBusiness reports the business requests sent with one kind of token
+type JSONAuthBusiness struct {
+	JSONLatencyGroup
+	Unauthorized int64 `json:"unauthorized"` // Answered with 401
+}
+
+// JSONMirrorMetric is the difference in one metric between target and mirror
+// Latencies are in ms and the error rate in percent; change and p-value are null when undefined
+type JSONMirrorMetric struct {
+	Metric      string   `json:"metric"`
+	Target      float64  `json:"target"`
+	Mirror      float64  `json:"mirror"`
+	Change      *float64 `json:"change_percent"`
+	PValue      *float64 `json:"p_value"`
+	Significant bool     `json:"significant"`
+	Better      bool     `json:"mirror_better,omitempty"` // Only set for significant differences
+}
+
+// JSONSchema contains the outcome of the response schema check
+type JSONSchema struct {
+	Path          string           `json:"path"`
+	SamplePercent float64          `json:"sample_percent"`
+	Validated     int64            `json:"validated"`
+	Violations    int64            `json:"violations"`
+	ViolationRate float64          `json:"violation_rate"`
+	Kinds         map[string]int64 `json:"violation_kinds,omitempty"` // Violations by message
+}
+
+// JSONBodies contains the distinct successful response bodies of a target
+type JSONBodies struct {
+	Target    string  `json:"target"`
+	Responses int64   `json:"responses"`
+	Distinct  int     `json:"distinct"`
+	TopShare  float64 `json:"top_share"`           // Share of the responses with the most common body
+	Untracked int64   `json:"untracked,omitempty"` // Responses beyond the distinct body limit
+	Identical bool    `json:"identical,omitempty"` // Every response had the same body
+}
+
+// JSONCanary compares the baseline target with the canary
+type JSONCanary struct {
+	URL         string             `json:"url"`
+	Percent     float64            `json:"percent"`
+	Baseline    JSONLatencyGroup   `json:"baseline"`
+	Canary      JSONLatencyGroup   `json:"canary"`
+	Comparisons []JSONCanaryMetric `json:"comparisons"`
+}
+
+// JSONCanaryMetric is the difference in one metric between baseline and canary
+// Latencies are in ms and the error rate in percent; change and p-value are null when undefined
+type JSONCanaryMetric struct {
+	Metric      string   `json:"metric"`
+	Baseline    float64  `json:"baseline"`
+	Canary      float64  `json:"canary"`
+	Change      *float64 `json:"change_percent"`
+	PValue      *float64 `json:"p_value"`
+	Significant bool     `json:"significant"`
+	Better      bool     `json:"canary_better,omitempty"` // Only set for significant differences
+}
+
+// JSONColdStart compares the first requests of each worker with the steady state
+type JSONColdStart struct {
+	RequestsPerWorker int64            `json:"requests_per_worker"`
+	Cold              JSONLatencyGroup `json:"cold"`
+	Steady            JSONLatencyGroup `json:"steady"`
+	NewConnections    JSONLatencyGroup `json:"new_connections"`
+	ReusedConnections JSONLatencyGroup `json:"reused_connections"`
+}
 
-	// Print status code distribution if there are any
-	if len(summary.StatusCodeCounts) > 0 {
-		fmt.Println()
-		fmt.Println("Status Codes:")
-		for code, count := range summary.StatusCodeCounts {
-			fmt.Printf("  %d: %d\n", code, count)
-		}
-	}
+// JSONLatencyGroup contains the requests and latency of one group of requests
+type JSONLatencyGroup struct {
+	Requests  int64            `json:"requests"`
+	Failed    int64            `json:"failed"`
+	ErrorRate float64          `json:"error_rate"`
+	Latency   JSONDistribution `json:"latency"`
 }
 
-// PrintProgress displays a progress bar with current test statistics
-// It updates in-place on the same line using carriage return
-// spinnerFrame is used for animation when generating report (0-3 for spinner animation)
-func PrintProgress(elapsed time.Duration, totalDuration time.Duration, stats *runner.ProgressStats, spinnerFrame int) {
-	// Calculate progress percentage
-	progress := float64(elapsed) / float64(totalDuration)
-	isComplete := progress >= 1.0
-	if progress > 1.0 {
-		progress = 1.0
-	}
+// JSONTarget reports the requests of one target
+type JSONTarget struct {
+	JSONLatencyGroup
+	Workers *int `json:"workers,omitempty"` // Workers pinned to the target (sticky strategy only)
+}
 
-	// Create progress bar (40 characters wide for better display)
-	barWidth := 40
-	filled := int(progress * float64(barWidth))
-	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+// JSONSizeCorrelation relates latency to body size
+type JSONSizeCorrelation struct {
+	Correlation float64          `json:"correlation"` // Pearson coefficient between size and latency, -1 to 1
+	Buckets     []JSONSizeBucket `json:"buckets"`
+}
 
-	// Calculate current RPS
-	var rps float64
-	if elapsed > 0 {
-		rps = float64(stats.TotalRequests) / elapsed.Seconds()
-	}
+// JSONSizeBucket contains the latencies of requests with a body of min_bytes to max_bytes
+type JSONSizeBucket struct {
+	MinBytes int64        `json:"min_bytes"`
+	MaxBytes int64        `json:"max_bytes"`
+	Requests int64        `json:"requests"`
+	Avg      JSONDuration `json:"avg"`
+	P50      JSONDuration `json:"p50"`
+	P95      JSONDuration `json:"p95"`
+	P99      JSONDuration `json:"p99"`
+}
 
-	// Format elapsed time
-	elapsedStr := formatDurationShort(elapsed)
-	totalStr := formatDurationShort(totalDuration)
+// JSONHistogramBucket counts the latencies below LessThanMs (and at or above the previous bucket's bound)
+type JSONHistogramBucket struct {
+	LessThanMs float64 `json:"lt_ms"`
+	Count      int64   `json:"count"`
+}
 
-	// Spinner characters for animation
-	spinnerChars := []string{"|", "/", "-", "\\"}
+// JSONTimelinePoint contains the requests completed in one second of the run
+type JSONTimelinePoint struct {
+	OffsetMs int64   `json:"offset_ms"` // Start of the second, relative to the test start
+	Requests int64   `json:"requests"`
+	Failed   int64   `json:"failed"`
+	RPS      float64 `json:"rps"`
+	MinMs    float64 `json:"min_ms"`
+	MaxMs    float64 `json:"max_ms"`
+	P50Ms    float64 `json:"p50_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
 
-	// ANSI escape code to clear the line: \033[2K clears entire line, \r returns to start
-	clearLine := "\033[2K\r"
+	InFlightAvg   float64 `json:"in_flight_avg"`
+	InFlightMax   int64   `json:"in_flight_max"`
+	LimiterWaitMs float64 `json:"rate_limiter_wait_ms,omitempty"` // Average wait for the rate limiter
+}
 
-	// If test is complete, show "Generating report..." message with spinner
-	if isComplete {
-		spinner := spinnerChars[spinnerFrame%len(spinnerChars)]
-		fmt.Fprintf(os.Stderr, "%s[%s] 100.0%% | Generating report %s | Req: %d | ✓: %d | ✗: %d | RPS: %.1f   ",
-			clearLine, strings.Repeat("█", barWidth), spinner, stats.TotalRequests, stats.SuccessRequests, stats.FailedRequests, rps)
-	} else {
-		// Print progress on the same line (using clearLine to clear and return to start)
-		// Add spaces at the end to clear any remaining characters from previous updates
-		fmt.Fprintf(os.Stderr, "%s[%s] %.1f%% | %s/%s | Req: %d | ✓: %d | ✗: %d | RPS: %.1f   ",
-			clearLine, bar, progress*100, elapsedStr, totalStr,
-			stats.TotalRequests, stats.SuccessRequests, stats.FailedRequests, rps)
-	}
+// JSONStatusClass contains the requests in one status class
+type JSONStatusClass struct {
+	Count   int64   `json:"count"`
+	Percent float64 `json:"percent"`
+}
 
-	// Flush to ensure immediate display
-	os.Stderr.Sync()
+// JSONServerTiming contains server-reported durations from Server-Timing headers
+type JSONServerTiming struct {
+	Responses int64                      `json:"responses"`
+	Phases    map[string]JSONServerPhase `json:"phases"`
+	Server    JSONDistribution           `json:"server"`    // "total" entry or the sum of entries per response
+	Remainder JSONDistribution           `json:"remainder"` // TTFB not covered by server time
 }
 
-// PrintGeneratingReport displays a one-time "Generating report..." message
-func PrintGeneratingReport(stats *runner.ProgressStats, rps float64) {
-	barWidth := 40
-	bar := strings.Repeat("█", barWidth)
-	// Clear line and print final message
-	fmt.Fprintf(os.Stderr, "\033[2K\r[%s] 100.0%% | Generating report... | Req: %d | ✓: %d | ✗: %d | RPS: %.1f   ",
-		bar, stats.TotalRequests, stats.SuccessRequests, stats.FailedRequests, rps)
-	os.Stderr.Sync()
+// JSONServerPhase contains the durations of one Server-Timing entry
+type JSONServerPhase struct {
+	Count    int64            `json:"count"`
+	Duration JSONDistribution `json:"duration"`
 }
 
-// ClearProgress clears the progress line
-func ClearProgress() {
-	// Clear the entire line by printing spaces and returning to start
-	fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", 200))
-	os.Stderr.Sync()
+// JSONHeader contains the value distribution of a captured response header
+type JSONHeader struct {
+	Name      string           `json:"name"`
+	Responses int64            `json:"responses"`
+	Values    map[string]int64 `json:"values"`
+	Missing   int64            `json:"missing"`
+	Untracked int64            `json:"untracked,omitempty"` // Values beyond the distinct value limit
 }
 
-// formatDuration formats a duration in a human-readable way
-func formatDuration(d time.Duration) string {
-	if d < time.Microsecond {
-		return fmt.Sprintf("%.2fns", float64(d.Nanoseconds()))
-	} else if d < time.Millisecond {
-		return fmt.Sprintf("%.2fµs", float64(d.Nanoseconds())/1000.0)
-	} else if d < time.Second {
-		return fmt.Sprintf("%.2fms", float64(d.Nanoseconds())/1000000.0)
-	}
-	return d.Round(time.Millisecond).String()
+// JSONMethod contains the statistics for one HTTP method
+type JSONMethod struct {
+	Requests  int64            `json:"requests"`
+	Failed    int64            `json:"failed"`
+	ErrorRate float64          `json:"error_rate"`
+	Latency   JSONDistribution `json:"latency"`
 }
 
-// formatDurationShort formats a duration in a short, readable way for progress display
-func formatDurationShort(d time.Duration) string {
-	if d < time.Second {
-		return fmt.Sprintf("%.0fms", float64(d.Nanoseconds())/1000000.0)
-	} else if d < time.Minute {
-		return fmt.Sprintf("%.1fs", d.Seconds())
-	}
-	minutes := int(d.Minutes())
-	seconds := int(d.Seconds()) % 60
-	return fmt.Sprintf("%dm%ds", minutes, seconds)
+// JSONTiming splits latency into time to first byte and body download
+type JSONTiming struct {
+	TTFB               JSONDistribution `json:"ttfb"`
+	Download           JSONDistribution `json:"download"`
+	DownloadThroughput float64          `json:"download_throughput_bytes_per_sec"`
+	TruncatedResponses int64            `json:"truncated_responses,omitempty"`
+	OversizedResponses int64            `json:"oversized_responses,omitempty"` // Aborted past --max-response-bytes
 }
 
-// JSONOutput represents the JSON structure for test results
-type JSONOutput struct {
-	Metadata JSONMetadata `json:"metadata"`
-	Metrics  JSONMetrics  `json:"metrics"`
+// JSONDistribution contains distribution statistics for a duration metric
+type JSONDistribution struct {
+	Min JSONDuration `json:"min"`
+	Avg JSONDuration `json:"avg"`
+	Max JSONDuration `json:"max"`
+	P50 JSONDuration `json:"p50"`
+	P90 JSONDuration `json:"p90"`
+	P95 JSONDuration `json:"p95"`
+	P99 JSONDuration `json:"p99"`
 }
 
-// JSONMetadata contains test configuration and timing information
-type JSONMetadata struct {
-	URL         string            `json:"url,omitempty"`         // Single URL (if only one)
-	URLs        []string          `json:"urls,omitempty"`        // Multiple URLs (if more than one)
-	Method      string            `json:"method"`
-	Concurrency int               `json:"concurrency"`
-	Duration    string            `json:"duration"`
-	DurationMs  int64             `json:"duration_ms"`
-	Headers     map[string]string `json:"headers,omitempty"`
-	StartTime   string            `json:"start_time,omitempty"`
-	EndTime     string            `json:"end_time,omitempty"`
+// JSONCompression contains statistics for compressed responses
+type JSONCompression struct {
+	Responses         int64            `json:"responses"`
+	Encodings         map[string]int64 `json:"encodings"`
+	WireBytes         int64            `json:"wire_bytes"`
+	DecodedBytes      int64            `json:"decoded_bytes"`
+	Ratio             float64          `json:"ratio"`
+	AvgDecompressTime JSONDuration     `json:"avg_decompress_time"`
 }
 
-// JSONMetrics contains all test metrics
-type JSONMetrics struct {
-	Requests    JSONRequests     `json:"requests"`
-	Latency     JSONLatency      `json:"latency"`
-	StatusCodes map[string]int64 `json:"status_codes"`
+// JSONConditional contains conditional request (If-None-Match/If-Modified-Since) statistics
+type JSONConditional struct {
+	Requests         int64   `json:"requests"`
+	NotModified      int64   `json:"not_modified"`
+	NotModifiedRatio float64 `json:"not_modified_ratio"`
 }
 
 // JSONRequests contains request statistics
 type JSONRequests struct {
-	Total   int64   `json:"total"`
-	Success int64   `json:"success"`
-	Failed  int64   `json:"failed"`
-	RPS     float64 `json:"rps"`
+	Total         int64   `json:"total"`
+	Success       int64   `json:"success"`
+	Failed        int64   `json:"failed"`
+	RPS           float64 `json:"rps"`
+	BytesSent     int64   `json:"bytes_sent"`
+	Cancelled     int64   `json:"cancelled_at_deadline"` // In flight when the test ended, excluded from total
+	BytesReceived int64   `json:"bytes_received"`
+	BodySkipped   bool    `json:"body_skipped,omitempty"` // Bodies discarded unread; byte and download metrics excluded
+
+	BodyVerified   int64 `json:"body_verified,omitempty"`   // Successful bodies checked against --expect-body-sha256
+	BodyMismatches int64 `json:"body_mismatches,omitempty"` // Verified bodies with an unexpected hash (included in failed)
+
+	NotRecorded int64   `json:"not_recorded,omitempty"`         // Requests sent whose result is missing from the totals
+	QueueWaits  int64   `json:"result_queue_waits,omitempty"`   // Results that waited for the stats collector
+	QueueWaitMs float64 `json:"result_queue_wait_ms,omitempty"` // Total time workers spent waiting for it
+
+	ReadRate    int64    `json:"client_bandwidth_bytes_per_sec,omitempty"` // --client-bandwidth
+	ReadDelayMs *float64 `json:"read_delay_ms,omitempty"`                  // --read-delay
 }
 
 // JSONLatency contains latency statistics
@@ -207,9 +2241,8 @@ type JSONDuration struct {
 	Ms    float64 `json:"ms"`    // Duration in milliseconds
 }
 
-// PrintResultsJSON prints the test results in JSON format and saves to file
-// Returns the file path where JSON was saved
-func PrintResultsJSON(summary *runner.Summary, urls []string, concurrency int, duration time.Duration, method string, headers map[string]string, outputFile string) (string, error) {
+// BuildResultJSON converts the test results into the JSON output structure
+func BuildResultJSON(summary *runner.Summary, urls []string, concurrency int, duration time.Duration, method string, headers map[string]string) JSONOutput {
 	// Convert status codes map from int keys to string keys for JSON
 	// Status code 0 represents network/connection errors
 	statusCodes := make(map[string]int64)
@@ -229,23 +2262,55 @@ func PrintResultsJSON(summary *runner.Summary, urls []string, concurrency int, d
 		Duration:    duration.String(),
 		DurationMs:  duration.Milliseconds(),
 		Headers:     headers,
+		Seed:        summary.Seed,
+		RunID:       summary.RunID,
+	}
+	if d := summary.Data; d != nil {
+		metadata.Data = &JSONDataUsage{Rows: d.Rows, Used: d.Used, Exhausted: d.Exhausted}
+	}
+	if !summary.StartTime.IsZero() {
+		metadata.StartTime = summary.StartTime.Format(time.RFC3339Nano)
+		metadata.EndTime = summary.EndTime.Format(time.RFC3339Nano)
+	}
+	if sch := summary.Schedule; sch != nil {
+		metadata.ScheduledStart = sch.At.Format(time.RFC3339Nano)
+		metadata.NTPServer = sch.NTPServer
+		metadata.Warnings = sch.Warnings
+		if sch.OffsetKnown {
+			ms := float64((-sch.ClockOffset).Nanoseconds()) / 1e6
+			metadata.ClockOffsetMs = &ms
+		}
 	}
-	
+
 	// Set URL or URLs based on count
 	if len(urls) == 1 {
 		metadata.URL = urls[0]
 	} else {
 		metadata.URLs = urls
 	}
-	
+
 	output := JSONOutput{
-		Metadata: metadata,
+		SchemaVersion: SchemaVersion,
+		Metadata:      metadata,
 		Metrics: JSONMetrics{
 			Requests: JSONRequests{
 				Total:   summary.TotalRequests,
 				Success: summary.SuccessRequests,
 				Failed:  summary.FailedRequests,
 				RPS:     summary.RPS,
+
+				BytesSent:     summary.BytesSent,
+				Cancelled:     summary.CancelledAtDeadline,
+				BytesReceived: summary.BytesReceived,
+				BodySkipped:   summary.BodySkipped,
+				ReadRate:      summary.ReadRate,
+
+				BodyVerified:   summary.BodyVerified,
+				BodyMismatches: summary.BodyMismatches,
+
+				NotRecorded: summary.Delivery.NotRecorded,
+				QueueWaits:  summary.Delivery.Waits,
+				QueueWaitMs: durationToMs(summary.Delivery.WaitTime),
 			},
 			Latency: JSONLatency{
 				Min: durationToJSON(summary.MinLatency),
@@ -256,13 +2321,613 @@ func PrintResultsJSON(summary *runner.Summary, urls []string, concurrency int, d
 				P99: durationToJSON(summary.P99Latency),
 			},
 			StatusCodes: statusCodes,
+			Errors:      summary.ErrorClasses,
 		},
 	}
 
-	// Marshal to JSON with indentation for readability
-	jsonBytes, err := json.MarshalIndent(output, "", "  ")
+	for _, b := range summary.LatencyHistogram {
+		output.Metrics.LatencyHistogram = append(output.Metrics.LatencyHistogram, JSONHistogramBucket{
+			LessThanMs: durationToMs(b.UpperBound),
+			Count:      b.Count,
+		})
+	}
+	for _, p := range summary.Timeline {
+		output.Metrics.Timeline = append(output.Metrics.Timeline, timelinePointToJSON(p))
+	}
+	if w := summary.WorstSecond(); w != nil {
+		point := timelinePointToJSON(*w)
+		output.Metrics.WorstSecond = &point
+	}
+	if summary.ReadDelay > 0 {
+		ms := durationToMs(summary.ReadDelay)
+		output.Metrics.Requests.ReadDelayMs = &ms
+	}
+	output.Metrics.RequestSizes = sizeCorrelationToJSON(summary.RequestSizes)
+	output.Metrics.ResponseSizes = sizeCorrelationToJSON(summary.ResponseSizes)
+	if c := summary.Canary; c != nil {
+		canary := &JSONCanary{
+			URL:      c.URL,
+			Percent:  c.Percent,
+			Baseline: latencyGroupToJSON(c.Baseline),
+			Canary:   latencyGroupToJSON(c.Canary),
+		}
+		for _, cmp := range c.Comparisons {
+			canary.Comparisons = append(canary.Comparisons, JSONCanaryMetric{
+				Metric:      cmp.Metric,
+				Baseline:    cmp.MeanA,
+				Canary:      cmp.MeanB,
+				Change:      finiteOrNil(cmp.Change),
+				PValue:      finiteOrNil(cmp.PValue),
+				Significant: cmp.Significant(),
+				Better:      cmp.Significant() && cmp.Better(),
+			})
+		}
+		output.Metrics.Canary = canary
+	}
+	if a := summary.Auth; a != nil {
+		auth := &JSONAuth{
+			URL:        a.URL,
+			IntervalMs: a.Interval.Milliseconds(),
+			Percent:    a.Percent,
+			Refreshers: a.Refreshers,
+			Tokens:     latencyGroupToJSON(a.Refreshes),
+			Errors:     a.Errors,
+			Business:   make(map[string]JSONAuthBusiness, len(a.Business)),
+		}
+		for _, b := range a.Business {
+			auth.Business[b.Token] = JSONAuthBusiness{JSONLatencyGroup: latencyGroupToJSON(b.Requests), Unauthorized: b.Unauthorized}
+		}
+		output.Metrics.Auth = auth
+	}
+	if m := summary.Mirror; m != nil {
+		mirror := &JSONMirror{
+			URL:       m.URL,
+			Mode:      m.Mode,
+			Target:    latencyGroupToJSON(m.Primary),
+			Mirror:    latencyGroupToJSON(m.Mirror),
+			Cancelled: m.Cancelled,
+			Dropped:   m.Dropped,
+		}
+		for _, cmp := range m.Comparisons {
+			mirror.Comparisons = append(mirror.Comparisons, JSONMirrorMetric{
+				Metric:      cmp.Metric,
+				Target:      cmp.MeanA,
+				Mirror:      cmp.MeanB,
+				Change:      finiteOrNil(cmp.Change),
+				PValue:      finiteOrNil(cmp.PValue),
+				Significant: cmp.Significant(),
+				Better:      cmp.Significant() && cmp.Better(),
+			})
+		}
+		output.Metrics.Mirror = mirror
+	}
+	if r := summary.Ranges; r != nil {
+		ranges := &JSONRanges{
+			SizeBytes:  r.Size,
+			Percent:    r.Percent,
+			Range:      latencyGroupToJSON(r.Ranged),
+			Ignored:    r.Ignored,
+			Mismatched: r.Mismatched,
+		}
+		if r.Full.Requests > 0 {
+			full := latencyGroupToJSON(r.Full)
+			ranges.Full = &full
+		}
+		output.Metrics.Ranges = ranges
+	}
+	if d := summary.DNS; d != nil {
+		dns := &JSONDNS{Server: d.Server, Lookups: d.Lookups, Failed: d.Failed}
+		if d.Lookups > 0 {
+			latency := distributionToJSON(d.Latency)
+			dns.Latency = &latency
+		}
+		output.Metrics.DNS = dns
+	}
+	if c := summary.ClientCerts; c != nil {
+		certs := &JSONClientCerts{
+			Dir:          c.Dir,
+			Strategy:     runner.ClientCertPerWorker,
+			Certificates: c.Certificates,
+			Used:         c.Used,
+			MinRequests:  c.MinRequests,
+			MaxRequests:  c.MaxRequests,
+			Failing:      c.Failing,
+		}
+		if c.PerRequest {
+			certs.Strategy = runner.ClientCertPerRequest
+		}
+		for _, f := range c.Failures {
+			certs.Failures = append(certs.Failures, JSONClientCertFailures{Name: f.Name, Requests: f.Requests, Failed: f.Failed})
+		}
+		output.Metrics.ClientCerts = certs
+	}
+	if s := summary.SNI; s != nil {
+		sni := &JSONSNI{
+			File:        s.File,
+			Names:       s.Names,
+			Used:        s.Used,
+			MinRequests: s.MinRequests,
+			MaxRequests: s.MaxRequests,
+			Failing:     s.Failing,
+		}
+		for _, f := range s.Failures {
+			sni.Failures = append(sni.Failures, JSONSNIFailures{Name: f.Name, Requests: f.Requests, Failed: f.Failed})
+		}
+		output.Metrics.SNI = sni
+	}
+	if t := summary.TLS; t != nil {
+		handshakes := &JSONTLS{
+			Resumption:     t.Resumption,
+			Full:           t.Full,
+			Resumed:        t.Resumed,
+			ResumedPercent: t.ResumedPercent(),
+			Verified:       t.Verified,
+			RequireStaple:  t.RequireStaple,
+			Stapled:        t.Stapled,
+			Unstapled:      t.Unstapled,
+		}
+		if t.Full > 0 {
+			latency := distributionToJSON(t.FullLatency)
+			handshakes.FullLatency = &latency
+		}
+		if t.Resumed > 0 {
+			latency := distributionToJSON(t.ResumedLatency)
+			handshakes.ResumedLatency = &latency
+		}
+		if t.Verified > 0 {
+			latency := distributionToJSON(t.Verification)
+			handshakes.Verification = &latency
+		}
+		output.Metrics.TLS = handshakes
+	}
+	if h := summary.HTTPAuth; h != nil {
+		output.Metrics.HTTPAuth = &JSONHTTPAuth{
+			Scheme:        h.Scheme,
+			Challenged:    latencyGroupToJSON(h.Challenged),
+			Authenticated: latencyGroupToJSON(h.Authenticated),
+			Challenges:    h.Challenges,
+			Unauthorized:  h.Unauthorized,
+		}
+	}
+	if e := summary.ExpectContinue; e != nil {
+		expect := &JSONExpectContinue{
+			Timeout:   durationToJSON(e.Timeout),
+			Requests:  e.Requests,
+			Continued: e.Continued,
+			TimedOut:  e.TimedOut,
+			Final:     e.Final,
+		}
+		if e.Continued > 0 {
+			wait := distributionToJSON(e.Wait)
+			expect.Wait = &wait
+		}
+		output.Metrics.ExpectContinue = expect
+	}
+	if r := summary.RateTarget; r != nil {
+		output.Metrics.RateTarget = &JSONRateTarget{
+			Target:      r.Target,
+			Achieved:    r.Achieved,
+			Percent:     r.Percent(),
+			Capacity:    r.Capacity,
+			ExpectedGap: durationToJSON(r.ExpectedGap),
+			Gaps:        distributionToJSON(r.Gaps),
+			Constraint:  r.Constraint,
+		}
+	}
+	if q := summary.Queueing; q != nil {
+		queueing := &JSONQueueing{InFlightAvg: q.InFlightAvg, InFlightMax: q.InFlightMax}
+		if q.RateLimited {
+			wait := distributionToJSON(q.LimiterWait)
+			queueing.LimiterWait = &wait
+		}
+		output.Metrics.Queueing = queueing
+	}
+	if l := summary.LittlesLaw; l != nil {
+		output.Metrics.LittlesLaw = &JSONLittlesLaw{
+			Workers:     l.Workers,
+			InFlight:    l.InFlight,
+			BusyPercent: l.Busy * 100,
+			Limit:       l.Limit,
+			Shortfall:   l.Shortfall(),
+		}
+	}
+	if f := summary.Failover; f != nil {
+		output.Metrics.Failover = &JSONFailover{WindowSeconds: f.Window.Seconds(), Events: []JSONFailoverEvent{}}
+		for _, e := range f.Events {
+			action := "rejoined"
+			if e.Removed {
+				action = "removed"
+			}
+			output.Metrics.Failover.Events = append(output.Metrics.Failover.Events, JSONFailoverEvent{
+				AtSeconds: e.At.Seconds(),
+				URL:       e.URL,
+				Action:    action,
+				Before:    timelineWindowToJSON(e.Before),
+				After:     timelineWindowToJSON(e.After),
+			})
+		}
+	}
+	if c := summary.Chaos; c != nil {
+		output.Metrics.Chaos = &JSONChaos{Events: []JSONChaosEvent{}}
+		for _, e := range c.Events {
+			output.Metrics.Chaos.Events = append(output.Metrics.Chaos.Events, JSONChaosEvent{
+				Kind:            string(e.Kind),
+				AtSeconds:       e.At.Seconds(),
+				DurationSeconds: e.Duration.Seconds(),
+				Factor:          e.Factor,
+				Before:          timelineWindowToJSON(e.Before),
+				During:          timelineWindowToJSON(e.During),
+				After:           timelineWindowToJSON(e.After),
+			})
+		}
+	}
+	if t := summary.Tail; t != nil {
+		output.Metrics.Tail = &JSONTail{
+			Percent:     t.Percent,
+			Threshold:   durationToJSON(t.Threshold),
+			Requests:    t.Requests,
+			Targets:     tailSharesToJSON(t.Targets, t),
+			Statuses:    tailSharesToJSON(t.Statuses, t),
+			Connections: tailSharesToJSON(t.Connections, t),
+			Seconds:     []JSONTailSecond{},
+			SecondsHit:  t.SecondsHit,
+		}
+		for _, s := range t.Seconds {
+			output.Metrics.Tail.Seconds = append(output.Metrics.Tail.Seconds, JSONTailSecond{OffsetMs: s.Offset.Milliseconds(), TailRequests: s.Tail, Requests: s.Requests})
+		}
+	}
+	if t := summary.Timeouts; t != nil {
+		output.Metrics.Timeouts = &JSONTimeouts{Limit: durationToJSON(t.Limit), Candidates: []JSONTimeoutCandidate{}}
+		for _, c := range t.Candidates {
+			output.Metrics.Timeouts.Candidates = append(output.Metrics.Timeouts.Candidates, JSONTimeoutCandidate{
+				Timeout:  durationToJSON(c.Timeout),
+				Requests: c.Breached,
+				Percent:  c.Rate * 100,
+				Censored: c.Censored,
+			})
+		}
+		if t.Suggested > 0 {
+			suggested := durationToJSON(t.Suggested)
+			output.Metrics.Timeouts.Suggested = &suggested
+		}
+	}
+	if t := summary.RPSTrace; t != nil {
+		output.Metrics.RPSTrace = &JSONRPSTrace{
+			File:     t.File,
+			Points:   t.Points,
+			Scale:    t.Scale,
+			Speed:    t.Speed,
+			Peak:     t.Peak,
+			Mean:     t.Mean,
+			Expected: t.Expected,
+			Sent:     t.Sent,
+			Behind:   t.Behind,
+		}
+		if t.Behind > 0 {
+			output.Metrics.RPSTrace.Worst = &JSONTraceSecond{AtSeconds: t.WorstAt.Seconds(), TraceRPS: t.WorstTarget, RPS: t.WorstRPS}
+		}
+	}
+	for _, pool := range summary.WorkerPools {
+		output.Metrics.WorkerPools = append(output.Metrics.WorkerPools, JSONWorkerPool{URLs: pool.URLs, Workers: pool.Workers})
+	}
+	if a := summary.Arrivals; a != nil {
+		output.Metrics.Arrivals = &JSONArrivals{
+			Rate:     a.Rate,
+			Requests: a.Requests,
+			Late:     a.Late,
+			Unsent:   a.Unsent,
+			Delay:    distributionToJSON(a.Delay),
+		}
+	}
+	if c := summary.Schema; c != nil {
+		output.Metrics.Schema = &JSONSchema{
+			Path:          c.Path,
+			SamplePercent: c.Sample,
+			Validated:     c.Validated,
+			Violations:    c.Violations,
+			ViolationRate: c.ViolationRate(),
+			Kinds:         c.Kinds,
+		}
+	}
+	for _, b := range summary.Bodies {
+		output.Metrics.Bodies = append(output.Metrics.Bodies, JSONBodies{
+			Target:    b.Target,
+			Responses: b.Responses,
+			Distinct:  b.Distinct,
+			TopShare:  b.TopShare(),
+			Untracked: b.Untracked,
+			Identical: b.Identical(),
+		})
+	}
+	if c := summary.ColdStart; c != nil {
+		output.Metrics.ColdStart = &JSONColdStart{
+			RequestsPerWorker: c.Requests,
+			Cold:              latencyGroupToJSON(c.Cold),
+			Steady:            latencyGroupToJSON(c.Steady),
+			NewConnections:    latencyGroupToJSON(c.NewConnections),
+			ReusedConnections: latencyGroupToJSON(c.ReusedConnections),
+		}
+	}
+
+	output.Metrics.StatusClasses = make(map[string]JSONStatusClass)
+	for class, count := range summary.StatusClasses() {
+		output.Metrics.StatusClasses[class] = JSONStatusClass{Count: count, Percent: percentOf(count, summary.TotalRequests)}
+	}
+
+	if t := summary.ServerTiming; t != nil {
+		timing := &JSONServerTiming{
+			Responses: t.Responses,
+			Phases:    make(map[string]JSONServerPhase, len(t.Phases)),
+			Server:    distributionToJSON(t.Server),
+			Remainder: distributionToJSON(t.Remainder),
+		}
+		for _, phase := range t.Phases {
+			timing.Phases[phase.Name] = JSONServerPhase{Count: phase.Count, Duration: distributionToJSON(phase.Duration)}
+		}
+		output.Metrics.ServerTiming = timing
+	}
+
+	for _, h := range summary.Headers {
+		header := JSONHeader{
+			Name:      h.Name,
+			Responses: h.Responses,
+			Values:    make(map[string]int64, len(h.Values)),
+			Missing:   h.Missing,
+			Untracked: h.Untracked,
+		}
+		for _, v := range h.Values {
+			header.Values[v.Value] = v.Count
+		}
+		output.Metrics.Headers = append(output.Metrics.Headers, header)
+	}
+
+	if len(summary.Methods) > 1 {
+		output.Metrics.Methods = make(map[string]JSONMethod, len(summary.Methods))
+		for method, m := range summary.Methods {
+			output.Metrics.Methods[method] = JSONMethod{
+				Requests:  m.Requests,
+				Failed:    m.Failed,
+				ErrorRate: m.ErrorRate(),
+				Latency:   distributionToJSON(m.Latency),
+			}
+		}
+	}
+
+	if len(summary.Targets) > 1 {
+		output.Metrics.Targets = make(map[string]JSONTarget, len(summary.Targets))
+		for url, t := range summary.Targets {
+			target := JSONTarget{JSONLatencyGroup: latencyGroupToJSON(t)}
+			if workers, ok := summary.TargetWorkers[url]; ok {
+				target.Workers = &workers
+			}
+			output.Metrics.Targets[url] = target
+		}
+	}
+	output.Metrics.URLStrategy = string(summary.URLStrategy)
+	output.Metrics.HashKey = summary.HashKey
+	if len(summary.Protocols) > 0 {
+		output.Metrics.Protocols = make(map[string]JSONLatencyGroup, len(summary.Protocols))
+		for protocol, p := range summary.Protocols {
+			output.Metrics.Protocols[protocol] = latencyGroupToJSON(p)
+		}
+	}
+
+	if summary.ConditionalRequests > 0 {
+		output.Metrics.Conditional = &JSONConditional{
+			Requests:         summary.ConditionalRequests,
+			NotModified:      summary.NotModified,
+			NotModifiedRatio: summary.NotModifiedRatio(),
+		}
+	}
+
+	if c := summary.Compression; c.Responses > 0 {
+		output.Metrics.Compression = &JSONCompression{
+			Responses:         c.Responses,
+			Encodings:         c.Encodings,
+			WireBytes:         c.WireBytes,
+			DecodedBytes:      c.DecodedBytes,
+			Ratio:             c.Ratio(),
+			AvgDecompressTime: durationToJSON(c.AvgDecompressTime()),
+		}
+	}
+
+	if summary.TTFB.Max > 0 && !summary.BodySkipped {
+		output.Metrics.Timing = &JSONTiming{
+			TTFB:               distributionToJSON(summary.TTFB),
+			Download:           distributionToJSON(summary.Download),
+			DownloadThroughput: summary.DownloadThroughput,
+			TruncatedResponses: summary.TruncatedResponses,
+			OversizedResponses: summary.ErrorClasses[httpclient.ErrorClassTooLarge],
+		}
+	}
+
+	output.Passed = !summary.Aborted
+	output.Aborted = summary.Aborted
+	for _, t := range summary.Thresholds {
+		output.Thresholds = append(output.Thresholds, JSONThreshold{
+			Expression: t.Expression,
+			Metric:     t.Metric,
+			Operator:   t.Operator,
+			Limit:      t.Value,
+			Actual:     t.Actual,
+			Passed:     t.Passed,
+		})
+		output.Passed = output.Passed && t.Passed
+	}
+
+	if h := summary.Health; h != nil {
+		output.Health = &JSONHealth{
+			URL:           h.URL,
+			Checks:        h.Checks,
+			Failures:      h.Failures,
+			TotalDowntime: durationToJSON(h.TotalDowntime()),
+			Downtime:      make([]JSONDowntime, 0, len(h.Downtime)),
+			Paused:        h.Paused,
+		}
+		for _, w := range h.Downtime {
+			output.Health.Downtime = append(output.Health.Downtime, JSONDowntime{
+				Start:   durationToJSON(w.Start),
+				End:     durationToJSON(w.End),
+				Reason:  w.Reason,
+				Ongoing: w.Ongoing,
+			})
+		}
+	}
+
+	if r := summary.Resources; r != nil {
+		cpuAvg, cpuMax := r.CPU()
+		_, memMax, _ := r.Memory()
+		output.Resources = &JSONResources{
+			URL:       r.URL,
+			Source:    r.Source,
+			Errors:    r.Errors,
+			CPUAvg:    cpuAvg,
+			CPUMax:    cpuMax,
+			MemoryMax: memMax,
+			Samples:   make([]JSONResourceSample, 0, len(r.Samples)),
+		}
+		for _, sample := range r.Samples {
+			output.Resources.Samples = append(output.Resources.Samples, JSONResourceSample{
+				OffsetMs:    sample.Offset.Milliseconds(),
+				CPUPercent:  sample.CPUPercent,
+				MemoryBytes: sample.MemoryBytes,
+			})
+		}
+	}
+
+	if c := summary.Churn; c != nil {
+		output.Churn = &JSONChurn{
+			Rate:               c.Rate,
+			Connections:        c.Attempts,
+			Skipped:            c.Skipped,
+			ConnectErrors:      c.ConnectErrors,
+			ConnectErrorRate:   c.ConnectErrorRate(),
+			HandshakeErrors:    c.HandshakeErrors,
+			HandshakeErrorRate: c.HandshakeErrorRate(),
+			Errors:             c.Errors,
+			Connect:            distributionToJSON(c.Connect),
+		}
+		if c.TLS {
+			handshake := distributionToJSON(c.Handshake)
+			output.Churn.Handshake = &handshake
+		}
+	}
+
+	if a := summary.Audit; a != nil {
+		output.Audit = &JSONAudit{
+			Produced:         a.Produced,
+			Received:         a.Received,
+			Counted:          a.Counted,
+			Cancelled:        a.Cancelled,
+			CounterHeader:    a.CounterHeader,
+			CounterResponses: a.CounterResponses,
+			Reconciled:       a.Reconciled(),
+		}
+		if a.RateLimited {
+			output.Audit.TokensGranted = &a.TokensGranted
+		}
+		if a.Recording {
+			output.Audit.Recorded = &a.Recorded
+			output.Audit.RecordingStopped = a.RecordingStopped
+		}
+		if a.CounterResponses > 0 {
+			output.Audit.ServerCounted = &a.ServerCounted
+		}
+	}
+
+	if c := summary.GeneratorCPU; c != nil {
+		output.GeneratorCPU = &JSONGeneratorCPU{
+			MaxProcs:  c.MaxProcs,
+			Shards:    c.Shards,
+			Process:   c.Process,
+			Saturated: c.Saturated(),
+		}
+		for _, core := range c.Cores {
+			output.GeneratorCPU.Cores = append(output.GeneratorCPU.Cores, JSONCoreUsage{CPU: core.CPU, Busy: core.Busy})
+		}
+	}
+
+	if m := summary.Memory; m != nil {
+		output.Memory = &JSONMemory{BudgetBytes: m.Budget, PeakBytes: m.Peak, Stride: m.Stride}
+		for _, e := range m.Events {
+			output.Memory.Events = append(output.Memory.Events, JSONMemoryEvent{
+				OffsetMs: e.Offset.Milliseconds(),
+				RSSBytes: e.RSS,
+				Action:   e.Action,
+			})
+		}
+	}
+
+	if u := summary.Users; u != nil {
+		output.Users = &JSONUsers{
+			Users:     u.Users,
+			ThinkTime: durationToJSON(u.ThinkTime),
+			Workers:   u.Workers,
+			Requests:  u.Wakeups,
+			AvgDelay:  durationToJSON(u.AvgDelay),
+			MaxDelay:  durationToJSON(u.MaxDelay),
+		}
+	}
+
+	if c := summary.Cleanup; c != nil {
+		output.Cleanup = &JSONCleanup{
+			Tracked:   c.Tracked,
+			Missing:   c.Missing,
+			File:      c.File,
+			FileError: c.FileError,
+			DeleteURL: c.DeleteURL,
+			Deleted:   c.Deleted,
+			Failed:    c.Failed,
+			Errors:    c.Errors,
+		}
+		if c.DeleteURL != "" {
+			d := durationToJSON(c.Duration)
+			output.Cleanup.Duration = &d
+		}
+	}
+
+	if t := summary.Traces; t != nil {
+		output.Traces = &JSONTraces{
+			RunID:   t.RunID,
+			Slowest: traceSamplesToJSON(t, t.Slowest),
+			Failed:  traceSamplesToJSON(t, t.Failed),
+		}
+	}
+
+	if r := summary.Record; r != nil {
+		output.Record = &JSONRecord{Path: r.Path, Format: r.Format, Records: r.Records, Rotated: r.Rotated, Error: r.Error}
+	}
+	if l := summary.ErrorLog; l != nil {
+		output.ErrorLog = &JSONErrorLog{Path: l.Path, Records: l.Records, Rotated: l.Rotated, Error: l.Error}
+	}
+
+	for _, r := range summary.SLOs {
+		slo := JSONSLO{
+			Name:       r.Name,
+			Objective:  r.Objective,
+			Total:      r.Total,
+			Good:       r.Good,
+			Within:     r.Within,
+			BudgetBurn: r.Burn,
+			Passed:     r.Passed,
+		}
+		if r.Latency > 0 {
+			ms := float64(r.Latency.Nanoseconds()) / 1e6
+			slo.Latency = &ms
+		}
+		output.SLOs = append(output.SLOs, slo)
+		output.Passed = output.Passed && r.Passed
+	}
+
+	return output
+}
+
+// writeJSONOutput saves a result in format to outputFile, or to a timestamped
+// file under results/ if outputFile is empty, and returns the path
+func writeJSONOutput(output JSONOutput, outputFile, format string) (string, error) {
+	data, err := encodeResult(output, format)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		return "", err
 	}
 
 	// Determine output file path
@@ -284,26 +2949,151 @@ func PrintResultsJSON(summary *runner.Summary, urls []string, concurrency int, d
 			return "", fmt.Errorf("failed to create results directory: %w", err)
 		}
 
-		// Generate filename with timestamp: g0-result-YYYYMMDD-HHMMSS.json
-		timestamp := time.Now().Format("20060102-150405")
-		filePath = filepath.Join(resultsDir, fmt.Sprintf("g0-result-%s.json", timestamp))
+		// Name the file after the run (g0-result-YYYYMMDD-HHMMSS-xxxxxx.json), or
+		// after the current time for results without a run ID
+		name := output.Metadata.RunID
+		if name == "" {
+			name = time.Now().Format("20060102-150405")
+		}
+		filePath = filepath.Join(resultsDir, fmt.Sprintf("g0-result-%s.%s", name, format))
 	}
 
-	// Write JSON to file
-	if err := os.WriteFile(filePath, jsonBytes, 0644); err != nil {
-		return "", fmt.Errorf("failed to write JSON file: %w", err)
+	// Write the result to file
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s file: %w", strings.ToUpper(format), err)
 	}
 
-	// Don't print JSON to stdout - results are already shown in text format
-	// JSON is only saved to file
+	// Don't print the result to stdout - results are already shown in text format
+	// The result is only saved to file
 
 	return filePath, nil
 }
 
+// tailSharesToJSON converts the values of one tail attribute (nil stays nil)
+func tailSharesToJSON(shares []runner.TailShare, t *runner.TailAnalysis) []JSONTailShare {
+	if shares == nil {
+		return nil
+	}
+	out := make([]JSONTailShare, 0, len(shares))
+	for _, s := range shares {
+		out = append(out, JSONTailShare{
+			Value:        s.Value,
+			TailRequests: s.Tail,
+			TailPercent:  float64(s.Tail) / float64(t.Requests) * 100,
+			Percent:      float64(s.Requests) / float64(t.Total) * 100,
+		})
+	}
+	return out
+}
+
 // durationToJSON converts a time.Duration to JSONDuration format
 func durationToJSON(d time.Duration) JSONDuration {
 	return JSONDuration{
 		Value: formatDuration(d),
-		Ms:    float64(d.Nanoseconds()) / 1000000.0, // Convert to milliseconds
+		Ms:    durationToMs(d),
+	}
+}
+
+// timelinePointToJSON converts one second of the timeline to JSON format
+func timelinePointToJSON(p runner.TimelinePoint) JSONTimelinePoint {
+	return JSONTimelinePoint{
+		OffsetMs: p.Offset.Milliseconds(),
+		Requests: p.Requests,
+		Failed:   p.Failed,
+		RPS:      p.RPS,
+		MinMs:    durationToMs(p.Min),
+		MaxMs:    durationToMs(p.Max),
+		P50Ms:    durationToMs(p.P50),
+		P95Ms:    durationToMs(p.P95),
+		P99Ms:    durationToMs(p.P99),
+
+		InFlightAvg:   p.InFlightAvg,
+		InFlightMax:   p.InFlightMax,
+		LimiterWaitMs: durationToMs(p.LimiterWait),
+	}
+}
+
+// finiteOrNil returns a pointer to v, or nil for NaN (which JSON can't represent)
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// latencyGroupToJSON converts a group of requests to JSON format
+func latencyGroupToJSON(g runner.LatencyGroup) JSONLatencyGroup {
+	return JSONLatencyGroup{
+		Requests:  g.Requests,
+		Failed:    g.Failed,
+		ErrorRate: g.ErrorRate(),
+		Latency:   distributionToJSON(g.Latency),
+	}
+}
+
+// timelineWindowToJSON converts the requests of part of the run to JSON format
+func timelineWindowToJSON(f runner.TimelineWindow) JSONTimelineWindow {
+	return JSONTimelineWindow{
+		Requests:  f.Requests,
+		Failed:    f.Failed,
+		ErrorRate: f.ErrorRate(),
+		RPS:       f.RPS,
+		P95Ms:     durationToMs(f.P95),
+	}
+}
+
+// sizeCorrelationToJSON converts latency by body size to JSON format (nil stays nil)
+func sizeCorrelationToJSON(c *runner.SizeCorrelation) *JSONSizeCorrelation {
+	if c == nil {
+		return nil
+	}
+	out := &JSONSizeCorrelation{Correlation: c.Correlation}
+	for _, b := range c.Buckets {
+		out.Buckets = append(out.Buckets, JSONSizeBucket{
+			MinBytes: b.MinBytes,
+			MaxBytes: b.MaxBytes,
+			Requests: b.Requests,
+			Avg:      durationToJSON(b.Avg),
+			P50:      durationToJSON(b.P50),
+			P95:      durationToJSON(b.P95),
+			P99:      durationToJSON(b.P99),
+		})
+	}
+	return out
+}
+
+// durationToMs converts a duration to fractional milliseconds
+func durationToMs(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1000000.0
+}
+
+// distributionToJSON converts duration distribution statistics to JSON format
+func distributionToJSON(d runner.DurationStats) JSONDistribution {
+	return JSONDistribution{
+		Min: durationToJSON(d.Min),
+		Avg: durationToJSON(d.Avg),
+		Max: durationToJSON(d.Max),
+		P50: durationToJSON(d.P50),
+		P90: durationToJSON(d.P90),
+		P95: durationToJSON(d.P95),
+		P99: durationToJSON(d.P99),
+	}
+}
+
+// traceSamplesToJSON converts trace samples, adding links if a link template is set
+func traceSamplesToJSON(t *runner.TraceSummary, samples []runner.TraceSample) []JSONTraceSample {
+	out := make([]JSONTraceSample, 0, len(samples))
+	for _, sample := range samples {
+		j := JSONTraceSample{
+			TraceID:    sample.TraceID,
+			Latency:    durationToJSON(sample.Latency),
+			StatusCode: sample.StatusCode,
+			Error:      sample.ErrorClass,
+		}
+		if t.LinkTemplate != "" {
+			j.Link = t.Link(sample.TraceID)
+		}
+		out = append(out, j)
 	}
+	return out
 }
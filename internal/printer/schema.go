@@ -0,0 +1,119 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// SchemaVersion is the version of the JSON result format written by this build
+// Bump it (and add an upgrade below) whenever existing fields are renamed, moved
+// or change meaning, or a new field is one older results should have too, so
+// g0 convert fills it in from their other fields; other additive fields don't
+// need a new version
+//
+// Versions:
+//
+//	1: results written before the format was versioned (no schema_version field)
+//	2: adds schema_version and metrics.status_classes, which the upgrade
+//	   computes from metrics.status_codes
+const SchemaVersion = 2
+
+// resultUpgrades[i] upgrades a result from version i+1 to version i+2
+// Upgrades work on the decoded JSON so fields they don't know about are preserved
+var resultUpgrades = []func(result map[string]interface{}) error{
+	upgradeResultV1,
+}
+
+// ResultVersion returns the schema version of a JSON result
+func ResultVersion(data []byte) (int, error) {
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("invalid result JSON: %w", err)
+	}
+	if header.SchemaVersion == nil {
+		return 1, nil
+	}
+	return *header.SchemaVersion, nil
+}
+
+// UpgradeResult converts a JSON result of any earlier schema version to the
+// current one; results that are already current are returned unchanged
+func UpgradeResult(data []byte) ([]byte, error) {
+	version, err := ResultVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case version == SchemaVersion:
+		return data, nil
+	case version > SchemaVersion:
+		return nil, fmt.Errorf("result has schema version %d, but this g0 only supports up to %d; upgrade g0", version, SchemaVersion)
+	case version < 1:
+		return nil, fmt.Errorf("invalid schema version %d", version)
+	}
+
+	// Keep numbers as written; float64 would round large values such as the seed
+	var result map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid result JSON: %w", err)
+	}
+	for v := version; v < SchemaVersion; v++ {
+		if err := resultUpgrades[v-1](result); err != nil {
+			return nil, fmt.Errorf("failed to upgrade result from version %d: %w", v, err)
+		}
+		result["schema_version"] = v + 1
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
+
+// upgradeResultV1 adds the status class rollup computed from the status codes
+func upgradeResultV1(result map[string]interface{}) error {
+	metrics, ok := result["metrics"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("missing metrics")
+	}
+	if _, ok := metrics["status_classes"]; ok {
+		return nil
+	}
+
+	var total float64
+	if requests, ok := metrics["requests"].(map[string]interface{}); ok {
+		if n, ok := requests["total"].(json.Number); ok {
+			total, _ = n.Float64()
+		}
+	}
+	counts := make(map[string]int64)
+	codes, _ := metrics["status_codes"].(map[string]interface{})
+	for code, raw := range codes {
+		n, ok := raw.(json.Number)
+		if !ok {
+			return fmt.Errorf("invalid count for status code %s", code)
+		}
+		count, err := n.Int64()
+		if err != nil {
+			return fmt.Errorf("invalid count for status code %s: %w", code, err)
+		}
+		class := "error"
+		if n, err := strconv.Atoi(code); err == nil && n > 0 {
+			class = fmt.Sprintf("%dxx", n/100)
+		}
+		counts[class] += count
+	}
+
+	classes := make(map[string]interface{}, len(counts))
+	for class, count := range counts {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(count) / total * 100
+		}
+		classes[class] = map[string]interface{}{"count": count, "percent": percent}
+	}
+	metrics["status_classes"] = classes
+	return nil
+}
@@ -0,0 +1,39 @@
+package printer
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// PrintSweepStage prints the outcome of one stage of a payload size sweep
+func (p *Printer) PrintSweepStage(stage, stages int, s runner.SweepStage) {
+	summary := s.Summary
+	fmt.Fprintf(p.out, "Stage %d/%d  size %s  RPS: %s | Avg: %s | p95: %s | p99: %s | Errors: %.2f%%\n",
+		stage, stages, s.Label, p.rate(summary.RPS),
+		formatDuration(summary.AvgLatency), formatDuration(summary.P95Latency), formatDuration(summary.P99Latency),
+		summary.ErrorRate()*100)
+}
+
+// PrintSweep prints latency and throughput by payload size for the stages of a sweep
+func (p *Printer) PrintSweep(stages []runner.SweepStage) {
+	fmt.Fprintln(p.out)
+	fmt.Fprintf(p.out, "Payload Size Sweep (%d stages):\n", len(stages))
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Size\tRequests\tRPS\tAvg\tp95\tp99\tErrors\tThroughput\t")
+	for _, s := range stages {
+		summary := s.Summary
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%.2f%%\t%s/s\t\n",
+			s.Label, p.count(summary.TotalRequests), p.rate(summary.RPS),
+			formatDuration(summary.AvgLatency), formatDuration(summary.P95Latency), formatDuration(summary.P99Latency),
+			summary.ErrorRate()*100, formatBytes(int64(s.Throughput())))
+	}
+	w.Flush()
+
+	if fit, ok := runner.FitSweep(stages); ok {
+		fmt.Fprintln(p.out)
+		fmt.Fprintf(p.out, "Average latency: about %s + %s per MiB of payload (least-squares fit)\n",
+			formatDuration(fit.Base), formatDuration(fit.PerMiB))
+	}
+}
@@ -0,0 +1,56 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/calummacc/g0/internal/runner"
+)
+
+// ReportData is what a report template is executed with
+// The summary is embedded, so its fields read as {{.TotalRequests}}, {{.P95Latency}}, ...
+type ReportData struct {
+	*runner.Summary
+	URLs        []string          // Target URLs
+	Concurrency int               // Number of workers
+	Method      string            // Default HTTP method
+	Headers     map[string]string // Request headers
+}
+
+// reportFuncs are the helpers available in report templates, formatting values
+// as the built-in report does
+var reportFuncs = template.FuncMap{
+	"duration": formatDuration,
+	"bytes":    formatBytes,
+	"ms":       durationToMs,
+	"percent": func(fraction float64) string {
+		return fmt.Sprintf("%.2f%%", fraction*100)
+	},
+}
+
+// ParseReportTemplate reads a text/template file for the final report
+func ParseReportTemplate(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(reportFuncs).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid report template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// PrintReportTemplate prints the report produced by tmpl
+// Nothing is printed if the template fails, so the caller can fall back to PrintResults
+func (p *Printer) PrintReportTemplate(tmpl *template.Template, data ReportData) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to execute report template: %w", err)
+	}
+	_, err := buf.WriteTo(p.out)
+	return err
+}
@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	// defaultTerminalWidth is used when the terminal size can't be determined
+	defaultTerminalWidth = 120
+
+	// Progress bar width bounds; the bar shrinks to fit narrow terminals
+	minBarWidth = 10
+	maxBarWidth = 40
+)
+
+// progressStyle controls how the in-place progress line and report charts are drawn
+type progressStyle struct {
+	ansi      bool   // Terminal understands ANSI escape sequences
+	barFilled string // Characters for the progress bar
+	barEmpty  string
+	okMark    string // Labels for success and failure counts
+	failMark  string
+
+	chartLevels [9]string // Chart cells filled to 0/8 ... 8/8
+	chartAxis   string    // Vertical axis of charts in the report
+}
+
+var (
+	// ansiStyle uses escape codes and Unicode block characters
+	ansiStyle = progressStyle{ansi: true, barFilled: "█", barEmpty: "░", okMark: "✓", failMark: "✗",
+		chartLevels: [9]string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}, chartAxis: "│"}
+
+	// plainStyle only relies on carriage returns and ASCII, which every console
+	// (including legacy Windows code pages and redirected output) renders correctly
+	plainStyle = progressStyle{barFilled: "#", barEmpty: "-", okMark: "OK", failMark: "ERR",
+		chartLevels: [9]string{" ", " ", " ", "_", "_", "#", "#", "#", "#"}, chartAxis: "|"}
+)
+
+// terminal returns the terminal w writes to, or nil if it isn't one
+func terminal(w io.Writer) *os.File {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return f
+	}
+	return nil
+}
+
+// currentStyle detects the capabilities of the progress writer once and returns
+// the progress style to use
+func (p *Printer) currentStyle() progressStyle {
+	p.styleOnce.Do(func() {
+		p.style = plainStyle
+		if f := terminal(p.err); f != nil && os.Getenv("TERM") != "dumb" && enableVirtualTerminal(f) {
+			p.style = ansiStyle
+		}
+	})
+	return p.style
+}
+
+// writeProgressLine redraws the progress line in place
+func (p *Printer) writeProgressLine(line string) {
+	style := p.currentStyle()
+	if style.ansi {
+		// \033[2K clears the entire line, \r returns to start
+		io.WriteString(p.err, "\033[2K\r"+line)
+	} else {
+		// Overwrite the previous line with spaces when the new one is shorter
+		width := utf8.RuneCountInString(line)
+		padding := ""
+		if p.lastLineWidth > width {
+			padding = strings.Repeat(" ", p.lastLineWidth-width)
+		}
+		p.lastLineWidth = width
+		io.WriteString(p.err, "\r"+line+padding)
+	}
+	p.flush()
+}
+
+// terminalWidth returns the width of the terminal the progress writer is attached to
+func (p *Printer) terminalWidth() int {
+	if f := terminal(p.err); f != nil {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return defaultTerminalWidth
+}
+
+// progressBar renders a bar that leaves room for the given status text on one line
+func (p *Printer) progressBar(progress float64, status string) string {
+	// "[" + bar + "] " + status, keeping one spare column so the line never wraps
+	width := p.terminalWidth() - utf8.RuneCountInString(status) - 4
+	if width > maxBarWidth {
+		width = maxBarWidth
+	}
+	if width < minBarWidth {
+		width = minBarWidth
+	}
+
+	style := p.currentStyle()
+	filled := int(progress * float64(width))
+	return "[" + strings.Repeat(style.barFilled, filled) + strings.Repeat(style.barEmpty, width-filled) + "]"
+}
@@ -0,0 +1,185 @@
+package runner
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+)
+
+// ArrivalScheduler starts requests at a constant arrival rate (open model)
+// Each request is due at a fixed time regardless of how long earlier requests
+// took; a worker that takes a request after it was due sends it at once, and the
+// lateness counts toward its latency, so a slow target can't hide behind busy
+// workers (coordinated omission)
+type ArrivalScheduler struct {
+	start    time.Time
+	interval time.Duration // Time between arrivals at the base rate
+	steps    []rateStep    // Changes of the rate in order (nil = constant rate)
+	next     int64         // Index of the next arrival (atomic)
+	clock    clock.Clock
+}
+
+// rateStep sets the arrival rate to Factor times the base rate from At on
+type rateStep struct {
+	At     time.Duration // Offset from the start of the schedule
+	Factor float64       // 0 = no arrivals
+}
+
+// NewArrivalScheduler creates a schedule of rate arrivals per second from now,
+// changed by steps (e.g., chaos bursts and pauses; nil = constant)
+// If rate is 0 or negative, requests are not scheduled (returns nil)
+func NewArrivalScheduler(rate int, steps []rateStep, c clock.Clock) *ArrivalScheduler {
+	if rate <= 0 {
+		return nil
+	}
+	c = clock.Or(c)
+	return &ArrivalScheduler{start: c.Now(), interval: time.Second / time.Duration(rate), steps: steps, clock: c}
+}
+
+// Wait blocks until the next arrival is due and returns when it was due
+// It returns at once if the arrival is already overdue, and false if ctx is
+// cancelled first; without a schedule it returns the zero time
+func (a *ArrivalScheduler) Wait(ctx context.Context) (time.Time, bool) {
+	if a == nil {
+		return time.Time{}, true
+	}
+	n := atomic.AddInt64(&a.next, 1) - 1
+	due := a.start.Add(a.due(n))
+	if wait := due.Sub(a.clock.Now()); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return time.Time{}, false
+		case <-timer.C:
+		}
+	}
+	return due, true
+}
+
+// due returns the offset at which arrival n is due, walking through the rate steps
+func (a *ArrivalScheduler) due(n int64) time.Duration {
+	at, factor, left := time.Duration(0), 1.0, float64(n)
+	for _, step := range a.steps {
+		count := float64(step.At-at) / float64(a.interval) * factor
+		if left < count {
+			break
+		}
+		left -= count
+		at, factor = step.At, step.Factor
+	}
+	if factor == 0 {
+		// No more arrivals; the test ends first
+		return math.MaxInt64 / 2
+	}
+	return at + time.Duration(left/factor*float64(a.interval))
+}
+
+// combineSteps merges two schedules of rate steps, multiplying their factors
+func combineSteps(a, b []rateStep) []rateStep {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	var steps []rateStep
+	factorA, factorB := 1.0, 1.0
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		at := a[min(i, len(a)-1)].At
+		if i == len(a) || j < len(b) && b[j].At < at {
+			at = b[j].At
+		}
+		for ; i < len(a) && a[i].At == at; i++ {
+			factorA = a[i].Factor
+		}
+		for ; j < len(b) && b[j].At == at; j++ {
+			factorB = b[j].Factor
+		}
+		steps = append(steps, rateStep{At: at, Factor: factorA * factorB})
+	}
+	return steps
+}
+
+// dueBy returns how many arrivals are due by offset
+func (a *ArrivalScheduler) dueBy(offset time.Duration) int64 {
+	at, factor, count := time.Duration(0), 1.0, 0.0
+	for _, step := range a.steps {
+		if step.At >= offset {
+			break
+		}
+		count += float64(step.At-at) / float64(a.interval) * factor
+		at, factor = step.At, step.Factor
+	}
+	count += float64(offset-at) / float64(a.interval) * factor
+	return int64(count) + 1
+}
+
+// unsent returns how many arrivals were due by end but never taken by a worker
+func (a *ArrivalScheduler) unsent(end time.Time) int64 {
+	due := a.dueBy(end.Sub(a.start))
+	if taken := atomic.LoadInt64(&a.next); taken < due {
+		return due - taken
+	}
+	return 0
+}
+
+// arrivalLateness is how long after it was due a request may be sent and still
+// count as on time
+const arrivalLateness = time.Millisecond
+
+// arrivalStats measures how late scheduled requests were sent
+type arrivalStats struct {
+	rate     int
+	requests int64
+	late     int64
+	delays   []time.Duration // Time from when each sampled request was due until it was sent
+}
+
+// add accounts a result, keeping its delay if sampled; a nil arrivalStats (no
+// arrival schedule) ignores it
+func (a *arrivalStats) add(result Result, sampled bool) {
+	if a == nil {
+		return
+	}
+	a.requests++
+	if sampled {
+		a.delays = append(a.delays, result.QueueDelay)
+	}
+	if result.QueueDelay > arrivalLateness {
+		a.late++
+	}
+}
+
+// thin drops every other delay sample, as Stats.downsample does
+func (a *arrivalStats) thin() {
+	if a != nil {
+		a.delays = thinned(a.delays)
+	}
+}
+
+// ArrivalSummary reports how well the workers kept up with a constant arrival rate
+type ArrivalSummary struct {
+	Rate     int   // Requests due per second (0 = following an RPS trace)
+	Requests int64 // Completed scheduled requests
+	Late     int64 // Sent more than a millisecond after they were due, for lack of a free worker
+	Unsent   int64 // Due before the test ended but never sent, because every worker was busy
+
+	Delay DurationStats // Time from when requests were due until they were sent (included in latency)
+}
+
+// summary returns the arrival schedule results (nil without a schedule)
+func (a *arrivalStats) summary() *ArrivalSummary {
+	if a == nil {
+		return nil
+	}
+	return &ArrivalSummary{
+		Rate:     a.rate,
+		Requests: a.requests,
+		Late:     a.late,
+		Delay:    NewDurationStats(a.delays),
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ServerCounter reads a running request count the target returns in a response
+// header, so the requests the server counted can be compared with g0's counts
+type ServerCounter struct {
+	Header string // Canonical header name
+
+	mu        sync.Mutex
+	responses int64 // Responses carrying a valid counter
+	min, max  int64
+}
+
+// NewServerCounter creates a counter read from header
+func NewServerCounter(header string) *ServerCounter {
+	return &ServerCounter{Header: http.CanonicalHeaderKey(header)}
+}
+
+// observe reads the counter from a response's headers; a nil ServerCounter ignores it
+func (c *ServerCounter) observe(header http.Header) {
+	if c == nil {
+		return
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(header.Get(c.Header)), 10, 64)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.responses == 0 || value < c.min {
+		c.min = value
+	}
+	if c.responses == 0 || value > c.max {
+		c.max = value
+	}
+	c.responses++
+}
+
+// AuditSummary reconciles the request counts of each stage a request passes,
+// from the rate limiter to the statistics and the record file
+type AuditSummary struct {
+	RateLimited   bool  // A rate limiter handed out tokens (--max-rps)
+	TokensGranted int64 // Tokens taken from the rate limiter
+
+	Produced  int64 // Results produced, counted by each worker on its own
+	Received  int64 // Results the stats collectors received
+	Counted   int64 // Results in the totals
+	Cancelled int64 // Results counted as cancelled at deadline
+
+	Recording        bool  // Results were written to a record file (--record)
+	Recorded         int64 // Records written
+	RecordingStopped bool  // The recording was stopped early, e.g. shed to save memory, so later results are missing
+
+	CounterHeader    string // Response header with the server's request count ("" = none)
+	CounterResponses int64  // Responses carrying the counter
+	ServerCounted    int64  // Requests the server counted between the first and last counter seen
+}
+
+// Reconciled reports whether every stage accounts for the same results
+// The rate limiter and the server counter are informational: unused tokens and
+// requests from other clients are expected
+func (a *AuditSummary) Reconciled() bool {
+	if a.Produced != a.Received || a.Received != a.Counted+a.Cancelled {
+		return false
+	}
+	if a.RecordingStopped {
+		return a.Recorded <= a.Counted
+	}
+	return !a.Recording || a.Recorded == a.Counted
+}
+
+// auditSummary collects the counts of a finished run
+func auditSummary(summary *Summary, limiter *RateLimiter, workers []*Worker, received int64, counter *ServerCounter) *AuditSummary {
+	audit := &AuditSummary{
+		RateLimited:   limiter != nil,
+		TokensGranted: limiter.Granted(),
+		Received:      received,
+		Counted:       summary.TotalRequests,
+		Cancelled:     summary.CancelledAtDeadline,
+	}
+	for _, w := range workers {
+		audit.Produced += w.produced
+	}
+	if r := summary.Record; r != nil {
+		audit.Recording = true
+		audit.Recorded = r.Records
+		audit.RecordingStopped = r.Stopped
+	}
+	if counter != nil {
+		counter.mu.Lock()
+		defer counter.mu.Unlock()
+		audit.CounterHeader = counter.Header
+		audit.CounterResponses = counter.responses
+		if counter.responses > 0 {
+			audit.ServerCounted = counter.max - counter.min + 1
+		}
+	}
+	return audit
+}
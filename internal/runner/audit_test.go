@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditRecordingStopped(t *testing.T) {
+	recorder, err := NewRecorder(filepath.Join(t.TempDir(), "records.jsonl"), RecordJSONL, Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if i == 3 {
+			// As the memory guard sheds the recording near the budget
+			recorder.stop("g0 neared its --max-memory budget")
+		}
+		recorder.record(Result{StatusCode: 200, Latency: time.Millisecond})
+	}
+	record := recorder.Close()
+	if record.Records != 3 || !record.Stopped {
+		t.Fatalf("%d records, stopped %v, want 3 records of a stopped recording", record.Records, record.Stopped)
+	}
+
+	summary := &Summary{TotalRequests: 5, Record: record}
+	audit := auditSummary(summary, nil, nil, 5, nil)
+	audit.Produced = 5
+	if !audit.RecordingStopped || !audit.Reconciled() {
+		t.Errorf("stopped recording of %d of %d results: reconciled %v, want true", audit.Recorded, audit.Counted, audit.Reconciled())
+	}
+
+	// A recording that wasn't stopped must hold every result
+	audit.RecordingStopped = false
+	if audit.Reconciled() {
+		t.Errorf("recording of %d of %d results reconciled", audit.Recorded, audit.Counted)
+	}
+}
@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// DefaultAuthTokenField is the field of the token endpoint's JSON response that holds the token
+const DefaultAuthTokenField = "access_token"
+
+// ErrorClassAuthNoToken marks successful token responses without a token in them
+const ErrorClassAuthNoToken = "no_token"
+
+// How a request authenticated (Result.AuthToken)
+const (
+	AuthTokenRefreshed = "refreshed" // With a token the worker obtained itself
+	AuthTokenCached    = "cached"    // With the token cached for all workers
+)
+
+// AuthRefresh load tests an authentication system alongside the business
+// requests: g0 authenticates once before the run and caches the token, and a
+// share of the workers re-authenticate against the token endpoint periodically
+// while the others keep using the cached token
+// Business requests carry the token as a bearer token; the token requests are
+// reported on their own and kept out of the overall statistics
+type AuthRefresh struct {
+	URL        string
+	Method     string
+	Body       string
+	Headers    map[string]string
+	TokenField []string      // Field path of the token in the JSON response
+	Interval   time.Duration // How often a refreshing worker re-authenticates
+	Percent    float64       // Share of the workers that re-authenticate (0-100)
+
+	refreshers int // Workers with an ID below this re-authenticate
+
+	mu    sync.RWMutex
+	token string // Latest token, shared with the workers that don't refresh
+}
+
+// NewAuthRefresh creates an auth refresh against the token endpoint at rawURL;
+// field is the dot-separated path of the token in the JSON response
+func NewAuthRefresh(rawURL, method, body string, headers map[string]string, field string, interval time.Duration, percent float64) (*AuthRefresh, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid auth URL %q (expected http:// or https://)", rawURL)
+	}
+	if field == "" {
+		return nil, fmt.Errorf("auth token field must not be empty")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("auth refresh interval must be greater than 0")
+	}
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("auth refresh percent must be between 0 and 100")
+	}
+	if method == "" {
+		method = "POST"
+	}
+	return &AuthRefresh{
+		URL:        rawURL,
+		Method:     strings.ToUpper(method),
+		Body:       body,
+		Headers:    headers,
+		TokenField: strings.Split(field, "."),
+		Interval:   interval,
+		Percent:    percent,
+	}, nil
+}
+
+// setWorkers picks how many of workers re-authenticate: at least one unless
+// the percentage is 0
+func (a *AuthRefresh) setWorkers(workers int) {
+	a.refreshers = int(math.Round(float64(workers) * a.Percent / 100))
+	if a.refreshers == 0 && a.Percent > 0 {
+		a.refreshers = 1
+	}
+}
+
+// refreshes reports whether worker id re-authenticates, and when it first does;
+// the refreshing workers are spread evenly over one interval
+func (a *AuthRefresh) refreshes(id int, start time.Time) (time.Time, bool) {
+	if a == nil || id >= a.refreshers {
+		return time.Time{}, false
+	}
+	return start.Add(a.Interval * time.Duration(id+1) / time.Duration(a.refreshers)), true
+}
+
+// request returns the token request
+func (a *AuthRefresh) request(ctx context.Context, timeout time.Duration) httpclient.Request {
+	return httpclient.Request{
+		Method:   a.Method,
+		URL:      a.URL,
+		Body:     a.Body,
+		Headers:  a.Headers,
+		Context:  ctx,
+		Timeout:  timeout,
+		KeepBody: true,
+	}
+}
+
+// authenticate requests a token; the result is the token request's (Auth set)
+// and the token is "" unless the request succeeded
+func (a *AuthRefresh) authenticate(ctx context.Context, client *httpclient.Client, timeout time.Duration) (Result, string) {
+	sentAt := time.Now()
+	resp := client.Do(a.request(ctx, timeout))
+	result := Result{
+		Method:     a.Method,
+		URL:        a.URL,
+		SentAt:     sentAt,
+		Latency:    resp.Latency,
+		StatusCode: resp.StatusCode,
+		Error:      resp.Error,
+		ErrorClass: httpclient.ClassifyError(resp.Error),
+		Auth:       true,
+	}
+	if resp.Error != nil && requestEnded(ctx) {
+		result.ErrorClass = ErrorClassCancelledAtDeadline
+	}
+	if resp.Error != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, ""
+	}
+	token, ok := jsonField(resp.Body, a.TokenField)
+	if !ok {
+		result.ErrorClass = ErrorClassAuthNoToken
+		return result, ""
+	}
+	return result, token
+}
+
+// login obtains the first token, before the workers start
+func (a *AuthRefresh) login(ctx context.Context, client *httpclient.Client, timeout time.Duration) error {
+	result, token := a.authenticate(ctx, client, timeout)
+	if token == "" {
+		reason := result.ErrorClass
+		switch {
+		case result.Error != nil:
+			reason = result.Error.Error()
+		case result.StatusCode >= 300 || result.StatusCode < 200:
+			reason = "HTTP " + strconv.Itoa(result.StatusCode)
+		case result.ErrorClass == ErrorClassAuthNoToken:
+			reason = fmt.Sprintf("no %q field in the response", strings.Join(a.TokenField, "."))
+		}
+		return fmt.Errorf("authentication against %s failed: %s", a.URL, reason)
+	}
+	a.store(token)
+	return nil
+}
+
+// store caches a token for the workers that don't refresh
+func (a *AuthRefresh) store(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+}
+
+// cached returns the cached token
+func (a *AuthRefresh) cached() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.token
+}
+
+// authFailed reports whether a token request failed
+func authFailed(result Result) bool {
+	return result.failed() || result.ErrorClass == ErrorClassAuthNoToken
+}
+
+// authStats keeps the token requests apart from the business requests and
+// splits the business requests by the token they carried
+type authStats struct {
+	auth     *AuthRefresh
+	refresh  latencyGroup
+	errors   map[string]int64 // Failed token requests by status code or error class
+	business map[string]*authBusiness
+}
+
+// authBusiness counts the business requests sent with one kind of token
+type authBusiness struct {
+	group        latencyGroup
+	unauthorized int64 // Answered with 401
+}
+
+// add accounts a token request; the caller returns right after so it stays out
+// of the overall statistics
+func (a *authStats) add(result Result) {
+	if a == nil || result.ErrorClass == ErrorClassCancelledAtDeadline {
+		return
+	}
+	failed := authFailed(result)
+	a.refresh.add(result.Latency, failed)
+	if !failed {
+		return
+	}
+	reason := result.ErrorClass
+	if result.StatusCode > 0 && reason == "" {
+		reason = strconv.Itoa(result.StatusCode)
+	}
+	a.errors[reason]++
+}
+
+// addBusiness accounts a business request, keeping its latency if sampled; a
+// nil authStats ignores it
+func (a *authStats) addBusiness(result Result, failed, sampled bool) {
+	if a == nil || result.AuthToken == "" {
+		return
+	}
+	b := a.business[result.AuthToken]
+	if b == nil {
+		b = &authBusiness{}
+		a.business[result.AuthToken] = b
+	}
+	if sampled {
+		b.group.add(result.Latency, failed)
+	} else {
+		b.group.count(failed)
+	}
+	if result.StatusCode == 401 {
+		b.unauthorized++
+	}
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (a *authStats) thin() {
+	if a == nil {
+		return
+	}
+	for _, b := range a.business {
+		b.group.latencies = thinned(b.group.latencies)
+	}
+}
+
+// AuthTokenGroup is the business requests sent with one kind of token
+type AuthTokenGroup struct {
+	Token        string // AuthTokenRefreshed or AuthTokenCached
+	Requests     LatencyGroup
+	Unauthorized int64 // Answered with 401, e.g. because a refresh revoked the token
+}
+
+// AuthSummary reports the token requests of an auth refresh run apart from the
+// business requests
+type AuthSummary struct {
+	URL        string
+	Interval   time.Duration
+	Percent    float64
+	Refreshers int // Workers that re-authenticated
+
+	Refreshes LatencyGroup     // Token requests during the run (the initial one excluded)
+	Errors    map[string]int64 // Failed token requests by status code or error class
+
+	Business []AuthTokenGroup // Business requests by token, refreshed first
+}
+
+// summary returns the auth results (nil without auth refresh)
+func (a *authStats) summary() *AuthSummary {
+	if a == nil {
+		return nil
+	}
+	s := &AuthSummary{
+		URL:        a.auth.URL,
+		Interval:   a.auth.Interval,
+		Percent:    a.auth.Percent,
+		Refreshers: a.auth.refreshers,
+		Refreshes:  a.refresh.summary(),
+		Errors:     maps.Clone(a.errors),
+	}
+	for token, b := range a.business {
+		s.Business = append(s.Business, AuthTokenGroup{Token: token, Requests: b.group.summary(), Unauthorized: b.unauthorized})
+	}
+	sort.Slice(s.Business, func(i, j int) bool { return s.Business[i].Token > s.Business[j].Token })
+	return s
+}
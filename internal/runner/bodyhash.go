@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ErrorClassBodyMismatch marks successful responses whose body matches none of the expected hashes
+const ErrorClassBodyMismatch = "body_mismatch"
+
+// BodyHashes lists the SHA-256 digests a successful response body may have; a
+// body matching none of them (e.g., truncated or corrupted on the way) fails the request
+type BodyHashes [][]byte
+
+// ParseBodyHashes parses hex-encoded SHA-256 digests (as printed by sha256sum)
+func ParseBodyHashes(values []string) (BodyHashes, error) {
+	hashes := make(BodyHashes, 0, len(values))
+	for _, value := range values {
+		sum, err := hex.DecodeString(strings.TrimSpace(value))
+		if err != nil || len(sum) != 32 {
+			return nil, fmt.Errorf("invalid SHA-256 %q (expected 64 hex digits)", value)
+		}
+		hashes = append(hashes, sum)
+	}
+	return hashes, nil
+}
+
+// match reports whether sum is one of the expected digests
+func (h BodyHashes) match(sum []byte) bool {
+	for _, expected := range h {
+		if bytes.Equal(expected, sum) {
+			return true
+		}
+	}
+	return false
+}
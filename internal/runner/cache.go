@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// cacheBustParam is the query parameter appended by the cache buster
+const cacheBustParam = "_g0cb"
+
+// CacheBuster makes every request URL unique by appending a query parameter,
+// forcing caches and CDNs to forward each request to the origin
+type CacheBuster struct {
+	prefix string // Run-specific prefix so values are unique across runs
+	n      int64  // Atomic request counter
+}
+
+// NewCacheBuster creates a new cache buster
+func NewCacheBuster() *CacheBuster {
+	return &CacheBuster{prefix: strconv.FormatInt(time.Now().UnixNano(), 36)}
+}
+
+// Bust returns rawURL with a unique cache-busting query parameter appended
+func (c *CacheBuster) Bust(rawURL string) string {
+	value := c.prefix + "-" + strconv.FormatInt(atomic.AddInt64(&c.n, 1), 36)
+
+	// Insert before any fragment
+	fragment := ""
+	if i := strings.IndexByte(rawURL, '#'); i >= 0 {
+		rawURL, fragment = rawURL[:i], rawURL[i:]
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + cacheBustParam + "=" + url.QueryEscape(value) + fragment
+}
+
+// validators holds the cache validators captured from a response
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+// ValidatorCache captures ETag/Last-Modified from the first successful response
+// for each URL and replays them as If-None-Match/If-Modified-Since on later requests
+type ValidatorCache struct {
+	entries sync.Map // URL -> validators
+}
+
+// NewValidatorCache creates an empty validator cache
+func NewValidatorCache() *ValidatorCache {
+	return &ValidatorCache{}
+}
+
+// Apply adds conditional headers to the request if validators are known for key
+// Returns true if the request was made conditional
+func (v *ValidatorCache) Apply(key string, req *httpclient.Request) bool {
+	entry, ok := v.entries.Load(key)
+	if !ok {
+		return false
+	}
+	val := entry.(validators)
+
+	// Copy headers so the shared target header map is never mutated
+	headers := make(map[string]string, len(req.Headers)+2)
+	for k, h := range req.Headers {
+		headers[k] = h
+	}
+	if val.etag != "" {
+		headers["If-None-Match"] = val.etag
+	}
+	if val.lastModified != "" {
+		headers["If-Modified-Since"] = val.lastModified
+	}
+	req.Headers = headers
+	return true
+}
+
+// Store records the validators from a response for key, keeping the first ones seen
+func (v *ValidatorCache) Store(key string, statusCode int, header http.Header) {
+	if statusCode < 200 || statusCode >= 300 || header == nil {
+		return
+	}
+	val := validators{
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+	}
+	if val.etag == "" && val.lastModified == "" {
+		return
+	}
+	v.entries.LoadOrStore(key, val)
+}
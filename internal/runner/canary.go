@@ -0,0 +1,215 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Canary sends a share of the requests to a second target, keeping the path and
+// query of each request so both targets see the same traffic mix
+type Canary struct {
+	URL     string  // Canary base URL
+	Percent float64 // Share of requests sent to the canary (0-100)
+
+	base baseURL
+}
+
+// baseURL moves request URLs to another target, keeping their path and query
+type baseURL struct {
+	scheme     string
+	host       string
+	pathPrefix string
+}
+
+// parseBaseURL parses a target base URL: scheme, host and an optional path prefix
+func parseBaseURL(raw, what string) (baseURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return baseURL{}, fmt.Errorf("invalid %s URL %q (expected e.g. https://%s.example.com)", what, raw, what)
+	}
+	return baseURL{scheme: u.Scheme, host: u.Host, pathPrefix: strings.TrimSuffix(u.Path, "/")}, nil
+}
+
+// rewrite points a request URL at the base URL
+func (b baseURL) rewrite(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = b.scheme
+	u.Host = b.host
+	u.Path = b.pathPrefix + u.Path
+	if u.RawPath != "" {
+		u.RawPath = b.pathPrefix + u.RawPath
+	}
+	return u.String()
+}
+
+// NewCanary creates a canary routing percent of the requests to base (scheme, host
+// and an optional path prefix, e.g. https://canary.example.com or http://10.0.0.5:8080/v2)
+func NewCanary(base string, percent float64) (*Canary, error) {
+	b, err := parseBaseURL(base, "canary")
+	if err != nil {
+		return nil, err
+	}
+	if percent <= 0 || percent >= 100 {
+		return nil, fmt.Errorf("canary percent must be between 0 and 100 (exclusive)")
+	}
+	return &Canary{URL: base, Percent: percent, base: b}, nil
+}
+
+// pick reports whether the next request goes to the canary
+func (c *Canary) pick(rng *rand.Rand) bool {
+	return rng.Float64()*100 < c.Percent
+}
+
+// rewrite points a request URL at the canary
+func (c *Canary) rewrite(raw string) string {
+	return c.base.rewrite(raw)
+}
+
+// canaryStats splits the requests into baseline and canary
+type canaryStats struct {
+	canary           *Canary
+	baseline, target latencyGroup
+}
+
+// add accounts a result, keeping its latency if sampled; a nil canaryStats
+// (no canary) ignores it
+func (c *canaryStats) add(result Result, failed, sampled bool) {
+	if c == nil {
+		return
+	}
+	if result.Canary {
+		c.target.record(result.Latency, failed, sampled)
+	} else {
+		c.baseline.record(result.Latency, failed, sampled)
+	}
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (c *canaryStats) thin() {
+	if c != nil {
+		c.baseline.thin()
+		c.target.thin()
+	}
+}
+
+// CanarySummary compares the baseline target with the canary within one run
+type CanarySummary struct {
+	URL         string
+	Percent     float64
+	Baseline    LatencyGroup
+	Canary      LatencyGroup
+	Comparisons []Comparison // A = baseline, B = canary
+}
+
+// canaryPercentiles lists the compared latency percentiles, in report order
+var canaryPercentiles = []struct {
+	name string
+	p    float64
+}{
+	{"p50", 50}, {"p90", 90}, {"p95", 95}, {"p99", 99},
+}
+
+// summary compares baseline and canary (nil without a canary)
+func (c *canaryStats) summary() *CanarySummary {
+	if c == nil {
+		return nil
+	}
+	return &CanarySummary{
+		URL:         c.canary.URL,
+		Percent:     c.canary.Percent,
+		Baseline:    c.baseline.summary(),
+		Canary:      c.target.summary(),
+		Comparisons: compareGroups(&c.baseline, &c.target),
+	}
+}
+
+// compareGroups compares the latencies and error rates of two groups sent within one run
+// Per-request samples allow tests within one run: Welch's t-test for the mean
+// latency, and for each percentile and the error rate a two-proportion z-test
+// (for percentiles, on the share of each side slower than the pooled percentile)
+func compareGroups(ga, gb *latencyGroup) []Comparison {
+	var comparisons []Comparison
+	a, b := durationsToMs(ga.latencies), durationsToMs(gb.latencies)
+	avg := newCanaryComparison("avg", true, mean(a), mean(b))
+	avg.PValue = welchTTest(a, b)
+	comparisons = append(comparisons, avg)
+
+	if len(a) > 0 && len(b) > 0 {
+		pooled := append(append(make([]time.Duration, 0, len(a)+len(b)), ga.latencies...), gb.latencies...)
+		sort.Slice(pooled, func(i, j int) bool { return pooled[i] < pooled[j] })
+		for _, q := range canaryPercentiles {
+			cmp := newCanaryComparison(q.name, true,
+				durationMs(Percentile(ga.latencies, q.p)), durationMs(Percentile(gb.latencies, q.p)))
+			cut := sortedPercentile(pooled, q.p)
+			cmp.PValue = proportionZTest(countAbove(ga.latencies, cut), int64(len(a)), countAbove(gb.latencies, cut), int64(len(b)))
+			comparisons = append(comparisons, cmp)
+		}
+	}
+
+	sa, sb := ga.summary(), gb.summary()
+	errors := newCanaryComparison("error_rate", true, sa.ErrorRate()*100, sb.ErrorRate()*100)
+	errors.PValue = proportionZTest(ga.failed, ga.requests, gb.failed, gb.requests)
+	return append(comparisons, errors)
+}
+
+// newCanaryComparison creates a comparison of single values (no run-to-run deviation)
+func newCanaryComparison(metric string, lowerIsBetter bool, a, b float64) Comparison {
+	c := Comparison{Metric: metric, LowerIsBetter: lowerIsBetter, A: []float64{a}, B: []float64{b}, MeanA: a, MeanB: b, Change: math.NaN(), PValue: math.NaN()}
+	if a != 0 {
+		c.Change = (b - a) / a * 100
+	}
+	return c
+}
+
+// proportionZTest returns the two-sided p-value of a two-proportion z-test for
+// x1 of n1 versus x2 of n2 (NaN if either side is empty)
+func proportionZTest(x1, n1, x2, n2 int64) float64 {
+	if n1 == 0 || n2 == 0 {
+		return math.NaN()
+	}
+	p1, p2 := float64(x1)/float64(n1), float64(x2)/float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		if p1 == p2 {
+			return 1
+		}
+		return 0
+	}
+	z := (p1 - p2) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// countAbove counts the durations greater than cut
+func countAbove(durations []time.Duration, cut time.Duration) int64 {
+	var n int64
+	for _, d := range durations {
+		if d > cut {
+			n++
+		}
+	}
+	return n
+}
+
+// durationsToMs converts durations to fractional milliseconds
+func durationsToMs(durations []time.Duration) []float64 {
+	ms := make([]float64, len(durations))
+	for i, d := range durations {
+		ms[i] = durationMs(d)
+	}
+	return ms
+}
+
+// mean returns the mean of values (0 if empty)
+func mean(values []float64) float64 {
+	m, _ := meanStdDev(values)
+	return m
+}
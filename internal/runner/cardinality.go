@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// maxDistinctBodies bounds the distinct bodies counted per target, so a truly
+// dynamic endpoint can't grow the counts without limit
+const maxDistinctBodies = 10000
+
+// minIdenticalResponses is how many responses a target needs before identical
+// bodies are worth pointing out
+const minIdenticalResponses = 100
+
+// bodyKey shortens the SHA-256 of a body to the 64 bits kept per distinct body
+func bodyKey(sum []byte) uint64 {
+	if len(sum) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(sum)
+}
+
+// bodyCounter counts the distinct successful response bodies of one target
+type bodyCounter struct {
+	responses int64
+	bodies    map[uint64]int64
+	untracked int64 // Responses with a body beyond maxDistinctBodies
+}
+
+// bodyStats counts distinct response bodies per target as configured, so one
+// body served to every request of a templated or "dynamic" URL stands out
+type bodyStats struct {
+	targets map[string]*bodyCounter
+}
+
+// add counts the body of a hashed response
+func (b *bodyStats) add(result Result) {
+	if b == nil || result.BodyHash == 0 {
+		return
+	}
+	c := b.targets[result.Target]
+	if c == nil {
+		c = &bodyCounter{bodies: make(map[uint64]int64)}
+		b.targets[result.Target] = c
+	}
+	c.responses++
+	if c.bodies[result.BodyHash] > 0 || len(c.bodies) < maxDistinctBodies {
+		c.bodies[result.BodyHash]++
+	} else {
+		c.untracked++
+	}
+}
+
+// BodyCardinality is how many distinct bodies the successful responses of a
+// target had
+type BodyCardinality struct {
+	Target    string
+	Responses int64 // Successful responses hashed
+	Distinct  int   // Distinct bodies (at least this many when Untracked > 0)
+	Top       int64 // Responses with the most common body
+	Untracked int64 // Responses whose body was not tracked (too many distinct bodies)
+}
+
+// TopShare returns the share of the responses with the most common body (0-1)
+func (c BodyCardinality) TopShare() float64 {
+	if c.Responses == 0 {
+		return 0
+	}
+	return float64(c.Top) / float64(c.Responses)
+}
+
+// Identical reports whether enough responses were hashed and all had the same
+// body, which for a dynamic endpoint suggests a cached or stale response
+func (c BodyCardinality) Identical() bool {
+	return c.Distinct == 1 && c.Untracked == 0 && c.Responses >= minIdenticalResponses
+}
+
+// summary returns the cardinality per target, in target order (nil = not counted)
+func (b *bodyStats) summary() []BodyCardinality {
+	if b == nil {
+		return nil
+	}
+	summary := make([]BodyCardinality, 0, len(b.targets))
+	for target, c := range b.targets {
+		card := BodyCardinality{Target: target, Responses: c.responses, Distinct: len(c.bodies), Untracked: c.untracked}
+		for _, count := range c.bodies {
+			card.Top = max(card.Top, count)
+		}
+		summary = append(summary, card)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Target < summary[j].Target })
+	return summary
+}
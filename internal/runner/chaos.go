@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosKind is what a chaos event does to the load
+type ChaosKind string
+
+const (
+	ChaosBurst ChaosKind = "burst" // Multiply the request rate
+	ChaosPause ChaosKind = "pause" // Stop sending requests
+)
+
+// ChaosEvent changes the load for part of the run, to model bursty real-world
+// traffic and thundering herds
+type ChaosEvent struct {
+	Kind     ChaosKind
+	At       time.Duration // Offset from the start of the test
+	Duration time.Duration
+	Factor   float64 // Rate multiplier of a burst
+}
+
+// End returns the offset at which the event is over
+func (e ChaosEvent) End() time.Duration {
+	return e.At + e.Duration
+}
+
+// String describes the event, e.g. "burst 3x for 10s"
+func (e ChaosEvent) String() string {
+	if e.Kind == ChaosBurst {
+		return fmt.Sprintf("burst %sx for %s", strconv.FormatFloat(e.Factor, 'f', -1, 64), e.Duration)
+	}
+	return fmt.Sprintf("pause for %s", e.Duration)
+}
+
+// ParseChaosEvent parses an event as burst@AT:DURATION:FACTOR or pause@AT:DURATION,
+// e.g. "burst@2m:10s:3x" (3 times the rate for 10s at 2m) or "pause@4m:5s"
+func ParseChaosEvent(s string) (ChaosEvent, error) {
+	kind, spec, ok := strings.Cut(s, "@")
+	if !ok {
+		return ChaosEvent{}, fmt.Errorf("invalid chaos event %q (expected burst@AT:DURATION:FACTOR or pause@AT:DURATION, e.g. burst@2m:10s:3x)", s)
+	}
+	event := ChaosEvent{Kind: ChaosKind(strings.ToLower(kind))}
+	parts := strings.Split(spec, ":")
+	switch {
+	case event.Kind == ChaosBurst && len(parts) == 3:
+		factor, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(parts[2]), "x"), 64)
+		if err != nil || factor <= 0 {
+			return ChaosEvent{}, fmt.Errorf("invalid factor %q of chaos event %q", parts[2], s)
+		}
+		event.Factor = factor
+	case event.Kind == ChaosPause && len(parts) == 2:
+	default:
+		return ChaosEvent{}, fmt.Errorf("invalid chaos event %q (expected burst@AT:DURATION:FACTOR or pause@AT:DURATION, e.g. burst@2m:10s:3x)", s)
+	}
+
+	var err error
+	if event.At, err = time.ParseDuration(parts[0]); err != nil || event.At < 0 {
+		return ChaosEvent{}, fmt.Errorf("invalid start %q of chaos event %q", parts[0], s)
+	}
+	if event.Duration, err = time.ParseDuration(parts[1]); err != nil || event.Duration <= 0 {
+		return ChaosEvent{}, fmt.Errorf("invalid duration %q of chaos event %q", parts[1], s)
+	}
+	return event, nil
+}
+
+// ValidateChaos checks that chaos events don't overlap and that bursts have a
+// rate to multiply
+func ValidateChaos(events []ChaosEvent, rated bool) error {
+	sorted := sortedChaos(events)
+	for i, e := range sorted {
+		if e.Kind == ChaosBurst && !rated {
+			return fmt.Errorf("chaos bursts multiply the request rate and need --max-rps or --arrival-rate")
+		}
+		if i > 0 && e.At < sorted[i-1].End() {
+			return fmt.Errorf("chaos events at %s and %s overlap", sorted[i-1].At, e.At)
+		}
+	}
+	return nil
+}
+
+// sortedChaos returns a copy of the events in order of their start
+func sortedChaos(events []ChaosEvent) []ChaosEvent {
+	sorted := append([]ChaosEvent(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At < sorted[j].At })
+	return sorted
+}
+
+// chaosSteps returns the changes of the arrival rate the events make
+func chaosSteps(events []ChaosEvent) []rateStep {
+	var steps []rateStep
+	for _, e := range sortedChaos(events) {
+		steps = append(steps, rateStep{At: e.At, Factor: e.Factor}, rateStep{At: e.End(), Factor: 1})
+	}
+	return steps
+}
+
+// ChaosResult is a chaos event as it happened, with the requests before, during
+// and after it (windows as long as the event, never past the neighbouring events)
+type ChaosResult struct {
+	ChaosEvent
+	Before TimelineWindow
+	During TimelineWindow
+	After  TimelineWindow
+}
+
+// ChaosSummary lists the chaos events of the run with their impact
+type ChaosSummary struct {
+	Events []ChaosResult
+}
+
+// ChaosSchedule applies chaos events while the test runs: bursts change the
+// rate limiter, pauses hold the workers (arrival schedules follow the events
+// through their rate steps)
+type ChaosSchedule struct {
+	events      []ChaosEvent
+	rateLimiter *RateLimiter
+	rate        int // Base rate of the rate limiter
+
+	mu      sync.Mutex
+	resumed chan struct{} // Closed while no pause is in progress
+	started []ChaosEvent  // Events that started, at the offset they did
+}
+
+// NewChaosSchedule creates a schedule of events (nil if there are none)
+func NewChaosSchedule(events []ChaosEvent, rateLimiter *RateLimiter, rate int) *ChaosSchedule {
+	if len(events) == 0 {
+		return nil
+	}
+	resumed := make(chan struct{})
+	close(resumed)
+	return &ChaosSchedule{events: sortedChaos(events), rateLimiter: rateLimiter, rate: rate, resumed: resumed}
+}
+
+// Run applies the events until ctx is done; start is the start of the test
+func (c *ChaosSchedule) Run(ctx context.Context, start time.Time) {
+	for _, e := range c.events {
+		if !sleepUntil(ctx, start.Add(e.At)) {
+			return
+		}
+		c.mu.Lock()
+		started := e
+		started.At = time.Since(start)
+		c.started = append(c.started, started)
+		if e.Kind == ChaosPause {
+			c.resumed = make(chan struct{})
+		}
+		c.mu.Unlock()
+		if e.Kind == ChaosBurst {
+			c.rateLimiter.setRate(float64(c.rate) * e.Factor)
+		}
+
+		ended := sleepUntil(ctx, start.Add(e.End()))
+		c.mu.Lock()
+		if e.Kind == ChaosPause {
+			close(c.resumed)
+		}
+		c.mu.Unlock()
+		if e.Kind == ChaosBurst {
+			c.rateLimiter.setRate(float64(c.rate))
+		}
+		if !ended {
+			return
+		}
+	}
+}
+
+// sleepUntil waits until t; it returns false if ctx is done first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Wait blocks while a pause is in progress; it returns false if ctx is done first
+// A nil schedule never blocks
+func (c *ChaosSchedule) Wait(ctx context.Context) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// summary returns the events that started with the requests around them (nil
+// without chaos events)
+// c.started is in the order of c.events, so event i+1 is the next one scheduled
+func (c *ChaosSchedule) summary(timeline []TimelinePoint) *ChaosSummary {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &ChaosSummary{Events: []ChaosResult{}}
+	for i, e := range c.started {
+		at := e.At.Truncate(timelineInterval)
+		end := at + e.Duration
+		from, to := at-e.Duration, end+e.Duration
+		if i > 0 {
+			from = max(from, c.started[i-1].At.Truncate(timelineInterval)+c.started[i-1].Duration)
+		}
+		if i < len(c.events)-1 {
+			to = min(to, c.events[i+1].At)
+		}
+		s.Events = append(s.Events, ChaosResult{
+			ChaosEvent: e,
+			Before:     timelineWindow(timeline, from, at),
+			During:     timelineWindow(timeline, at, end),
+			After:      timelineWindow(timeline, end, to),
+		})
+	}
+	return s
+}
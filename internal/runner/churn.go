@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// churnTimeout bounds the connect and the TLS handshake of each churned connection
+const churnTimeout = 10 * time.Second
+
+// ConnectionChurner opens and immediately closes connections to the target at a
+// fixed rate alongside the load, stressing connection tables and TLS session
+// handling on proxies and load balancers
+// Connections are dialed as the load's own are, through the same DNS server
+// and with the same PROXY protocol header, and present the server names of an
+// SNI list in turn
+type ConnectionChurner struct {
+	addr      string      // host:port to dial
+	tlsConfig *tls.Config // nil for plain HTTP
+	sni       *SNIList    // Server names presented in turn (nil = the URL's host)
+	rate      int         // Connections per second
+
+	// dial dials as the HTTP client of the load does (httpclient.Dialer)
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	slots chan struct{} // Semaphore of connections being set up
+	next  atomic.Uint64 // Connections started, picking the next server name
+
+	mu              sync.Mutex
+	attempts        int64
+	skipped         int64 // Connections not opened because too many were still being set up
+	connectErrors   int64
+	handshakeErrors int64
+	errorClasses    map[string]int64
+	connectTimes    []time.Duration
+	handshakeTimes  []time.Duration
+}
+
+// NewConnectionChurner creates a churner for the host of targetURL dialing with
+// dial (see httpclient.Dialer) and presenting the names of sni (nil = the URL's host)
+// At most one second's worth of connections are set up at a time, so a target
+// that stops answering can't pile up goroutines and sockets
+func NewConnectionChurner(targetURL string, rate int, dial func(ctx context.Context, network, addr string) (net.Conn, error), sni *SNIList) (*ConnectionChurner, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid churn target %q: %w", targetURL, err)
+	}
+	c := &ConnectionChurner{
+		dial:         dial,
+		sni:          sni,
+		rate:         rate,
+		slots:        make(chan struct{}, rate),
+		errorClasses: make(map[string]int64),
+	}
+	port := u.Port()
+	switch u.Scheme {
+	case "http":
+		if port == "" {
+			port = "80"
+		}
+	case "https":
+		if port == "" {
+			port = "443"
+		}
+		// The shared session cache lets the target resume sessions, exercising
+		// session ticket handling as returning clients would; it keeps one per
+		// server name
+		cacheSize := 64
+		if sni != nil {
+			cacheSize = max(cacheSize, len(sni.Names))
+		}
+		c.tlsConfig = &tls.Config{
+			ServerName:         u.Hostname(),
+			ClientSessionCache: tls.NewLRUClientSessionCache(cacheSize),
+		}
+	default:
+		return nil, fmt.Errorf("invalid churn target %q: scheme must be http or https", targetURL)
+	}
+	c.addr = net.JoinHostPort(u.Hostname(), port)
+	return c, nil
+}
+
+// Run opens connections at the configured rate until ctx is cancelled, then waits
+// for the connections still being set up
+func (c *ConnectionChurner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(time.Second / time.Duration(c.rate))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			select {
+			case c.slots <- struct{}{}:
+			default:
+				c.mu.Lock()
+				c.skipped++
+				c.mu.Unlock()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-c.slots }()
+				c.churn(ctx)
+			}()
+		}
+	}
+}
+
+// churn opens one connection, completes the TLS handshake for HTTPS targets and closes it
+func (c *ConnectionChurner) churn(ctx context.Context) {
+	dialCtx, cancel := context.WithTimeout(ctx, churnTimeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := c.dial(dialCtx, "tcp", c.addr)
+	connectTime := time.Since(start)
+	if err != nil {
+		c.recordConnect(ctx, connectTime, err)
+		return
+	}
+	defer conn.Close()
+	c.recordConnect(ctx, connectTime, nil)
+
+	if c.tlsConfig == nil {
+		return
+	}
+	config := c.tlsConfig
+	if c.sni != nil {
+		config = config.Clone()
+		config.ServerName = c.sni.Names[(c.next.Add(1)-1)%uint64(len(c.sni.Names))]
+	}
+	start = time.Now()
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.HandshakeContext(dialCtx)
+	c.recordHandshake(ctx, time.Since(start), err)
+	if err == nil {
+		tlsConn.Close()
+	}
+}
+
+// recordConnect accounts one connection attempt; failures caused by the end of the
+// test are dropped, as they say nothing about the target
+func (c *ConnectionChurner) recordConnect(ctx context.Context, d time.Duration, err error) {
+	if err != nil && requestEnded(ctx) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	if err != nil {
+		c.connectErrors++
+		c.errorClasses[httpclient.ClassifyError(err)]++
+		return
+	}
+	c.connectTimes = append(c.connectTimes, d)
+}
+
+// recordHandshake accounts the TLS handshake of an established connection
+func (c *ConnectionChurner) recordHandshake(ctx context.Context, d time.Duration, err error) {
+	if err != nil && requestEnded(ctx) {
+		// Cut off by the end of the test; the connect was counted, so count this
+		// as neither a failed nor a completed handshake
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.handshakeErrors++
+		c.errorClasses[httpclient.ClassifyError(err)]++
+		return
+	}
+	c.handshakeTimes = append(c.handshakeTimes, d)
+}
+
+// ChurnSummary contains the outcome of the churned connections
+type ChurnSummary struct {
+	Rate            int  // Connections opened per second
+	TLS             bool // Connections included a TLS handshake
+	Attempts        int64
+	Skipped         int64            // Connections not opened because a second's worth were still being set up
+	ConnectErrors   int64            // Connections that could not be established
+	HandshakeErrors int64            // TLS handshakes that failed
+	Errors          map[string]int64 // Failures by error class
+	Connect         DurationStats    // TCP connect time
+	Handshake       DurationStats    // TLS handshake time (HTTPS targets only)
+}
+
+// ConnectErrorRate returns the fraction of attempts that could not connect
+func (s *ChurnSummary) ConnectErrorRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.ConnectErrors) / float64(s.Attempts)
+}
+
+// HandshakeErrorRate returns the fraction of established connections whose TLS handshake failed
+func (s *ChurnSummary) HandshakeErrorRate() float64 {
+	connected := s.Attempts - s.ConnectErrors
+	if connected == 0 {
+		return 0
+	}
+	return float64(s.HandshakeErrors) / float64(connected)
+}
+
+// Summary returns the churn results; call it after Run returned
+func (c *ConnectionChurner) Summary() *ChurnSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &ChurnSummary{
+		Rate:            c.rate,
+		TLS:             c.tlsConfig != nil,
+		Attempts:        c.attempts,
+		Skipped:         c.skipped,
+		ConnectErrors:   c.connectErrors,
+		HandshakeErrors: c.handshakeErrors,
+		Errors:          c.errorClasses,
+		Connect:         NewDurationStats(c.connectTimes),
+		Handshake:       NewDurationStats(c.handshakeTimes),
+	}
+}
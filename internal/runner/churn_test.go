@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectionChurnerDialsThroughDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// The churner dials what it is given, e.g. the load's PROXY protocol dialer
+	var dials atomic.Int64
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	c, err := NewConnectionChurner("http://"+ln.Addr().String()+"/", 100, dial, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c.Run(ctx)
+
+	summary := c.Summary()
+	// A dial cut off by the end of the test is not an attempt
+	if summary.Attempts == 0 || summary.Attempts > dials.Load() {
+		t.Errorf("%d attempts, %d dials, want attempts made through dial", summary.Attempts, dials.Load())
+	}
+	if summary.ConnectErrors != 0 || summary.Skipped != 0 {
+		t.Errorf("%d connect errors (%v), %d skipped, want none", summary.ConnectErrors, summary.Errors, summary.Skipped)
+	}
+}
+
+func TestConnectionChurnerBoundsConnectionsInFlight(t *testing.T) {
+	const rate = 50
+
+	// A target that never answers holds every connection until the test ends
+	var inFlight, maxInFlight atomic.Int64
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	c, err := NewConnectionChurner("http://192.0.2.1/", rate, dial, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	c.Run(ctx)
+
+	if m := maxInFlight.Load(); m > rate {
+		t.Errorf("%d connections set up at once, want at most %d", m, rate)
+	}
+	summary := c.Summary()
+	if summary.Skipped == 0 {
+		t.Errorf("no connections skipped while the target hung")
+	}
+	// Failures caused by the end of the test are not counted
+	if summary.Attempts != 0 || summary.ConnectErrors != 0 {
+		t.Errorf("%d attempts, %d connect errors, want none", summary.Attempts, summary.ConnectErrors)
+	}
+}
+
+func TestConnectionChurnerPresentsSNIList(t *testing.T) {
+	sni := &SNIList{Names: []string{"a.example.com", "b.example.com"}}
+	c, err := NewConnectionChurner("https://lb.example.com/", 10, nil, sni)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each handshake presents the next name of the list
+	presented := make(chan string, 3)
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			buf := make([]byte, 4096)
+			n, _ := server.Read(buf)
+			presented <- sniOf(buf[:n])
+		}()
+		return client, nil
+	}
+	c.dial = dial
+	for i := 0; i < 3; i++ {
+		c.churn(context.Background())
+	}
+	for _, want := range []string{"a.example.com", "b.example.com", "a.example.com"} {
+		if got := <-presented; got != want {
+			t.Errorf("presented %q, want %q", got, want)
+		}
+	}
+}
+
+// sniOf returns the server name of a TLS ClientHello, found by its
+// server_name extension layout: list length, type 0, name length, name
+func sniOf(hello []byte) string {
+	for i := 0; i+9 < len(hello); i++ {
+		if hello[i] != 0 || hello[i+1] != 0 { // Extension type server_name
+			continue
+		}
+		extLen := int(hello[i+2])<<8 | int(hello[i+3])
+		listLen := int(hello[i+4])<<8 | int(hello[i+5])
+		nameLen := int(hello[i+7])<<8 | int(hello[i+8])
+		if hello[i+6] == 0 && extLen == listLen+2 && listLen == nameLen+3 && i+9+nameLen <= len(hello) {
+			return string(hello[i+9 : i+9+nameLen])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// CleanupIDPlaceholder is replaced by the ID of each created resource in the cleanup URL
+const CleanupIDPlaceholder = "{id}"
+
+// ResourceTracker records the IDs of resources created by successful requests
+// so they can be written to a file and deleted after the test
+type ResourceTracker struct {
+	header    string   // Response header holding the ID ("" = use jsonPath)
+	jsonPath  []string // Field of the JSON response body holding the ID
+	deleteURL string   // URL deleted for each ID ("" = don't delete)
+	file      string   // File receiving the IDs ("" = none)
+
+	mu      sync.Mutex
+	ids     []string
+	missing int64 // Successful responses without an ID
+}
+
+// NewResourceTracker creates a tracker reading IDs from "header:NAME" (e.g.
+// header:Location; relative locations are resolved against the request URL) or
+// "json:PATH" (a dot-separated field path, e.g. json:data.id)
+// deleteURL contains {id} and file names the ID list; both are optional
+func NewResourceTracker(from, deleteURL, file string) (*ResourceTracker, error) {
+	t := &ResourceTracker{deleteURL: deleteURL, file: file}
+	kind, where, _ := strings.Cut(from, ":")
+	switch {
+	case kind == "header" && where != "":
+		t.header = http.CanonicalHeaderKey(where)
+	case kind == "json" && where != "":
+		t.jsonPath = strings.Split(where, ".")
+	default:
+		return nil, fmt.Errorf("invalid created resource ID source %q (expected header:NAME or json:PATH)", from)
+	}
+	if deleteURL != "" && !strings.Contains(deleteURL, CleanupIDPlaceholder) {
+		return nil, fmt.Errorf("cleanup URL must contain %s", CleanupIDPlaceholder)
+	}
+	return t, nil
+}
+
+// File returns the file receiving the IDs ("" = none, or a nil tracker)
+func (t *ResourceTracker) File() string {
+	if t == nil {
+		return ""
+	}
+	return t.file
+}
+
+// needsBody reports whether IDs are read from response bodies
+func (t *ResourceTracker) needsBody() bool {
+	return t != nil && t.jsonPath != nil
+}
+
+// track records the ID of the resource created by a successful (2xx) response;
+// a nil tracker ignores it
+func (t *ResourceTracker) track(requestURL string, resp httpclient.Response) {
+	if t == nil || resp.Error != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return
+	}
+	id, ok := t.extract(requestURL, resp)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !ok {
+		t.missing++
+		return
+	}
+	t.ids = append(t.ids, id)
+}
+
+// extract finds the ID in a response
+func (t *ResourceTracker) extract(requestURL string, resp httpclient.Response) (string, bool) {
+	if t.header != "" {
+		value := resp.Header.Get(t.header)
+		if value == "" {
+			return "", false
+		}
+		if t.header == "Location" {
+			base, err := url.Parse(requestURL)
+			ref, err2 := url.Parse(value)
+			if err == nil && err2 == nil {
+				value = base.ResolveReference(ref).String()
+			}
+		}
+		return value, true
+	}
+	return jsonField(resp.Body, t.jsonPath)
+}
+
+// jsonField returns the string or number at a field path of a JSON document
+func jsonField(body []byte, path []string) (string, bool) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", false
+	}
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", false
+			}
+			v = node[i]
+		default:
+			return "", false
+		}
+	}
+	switch id := v.(type) {
+	case string:
+		return id, id != ""
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// CleanupSummary reports the tracked resources and their removal
+type CleanupSummary struct {
+	Tracked   int64  // IDs recorded
+	Missing   int64  // Successful responses in which no ID was found
+	File      string // File the IDs were written to ("" = none)
+	FileError string // Why the IDs could not be written ("" = no error)
+
+	DeleteURL string           // Cleanup URL ("" = resources were not deleted)
+	Deleted   int64            // Resources deleted (2xx, or 404/410 when already gone)
+	Failed    int64            // Deletions that failed
+	Errors    map[string]int64 // Failed deletions by status code or error class
+	Duration  time.Duration    // Time spent deleting
+}
+
+// cleanup writes the IDs to the file and deletes each resource with up to
+// workers concurrent requests sharing the test's headers
+// Resources are removed even if the test was interrupted, so it runs on its own context
+func (t *ResourceTracker) cleanup(client *httpclient.Client, headers map[string]string, workers int, timeout time.Duration) *CleanupSummary {
+	// Take the IDs, so a tracker shared by several runs (e.g., g0 ab rounds) starts over
+	t.mu.Lock()
+	ids := t.ids
+	s := &CleanupSummary{Tracked: int64(len(ids)), Missing: t.missing, DeleteURL: t.deleteURL, Errors: make(map[string]int64)}
+	t.ids, t.missing = nil, 0
+	t.mu.Unlock()
+
+	// Write the IDs first, so they survive a failed or interrupted cleanup
+	if t.file != "" {
+		if err := writeIDs(t.file, ids); err != nil {
+			s.FileError = err.Error()
+		} else {
+			s.File = t.file
+		}
+	}
+	if t.deleteURL == "" || len(ids) == 0 {
+		return s
+	}
+
+	start := time.Now()
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				resp := client.Do(httpclient.Request{
+					Method:  http.MethodDelete,
+					URL:     strings.ReplaceAll(t.deleteURL, CleanupIDPlaceholder, id),
+					Headers: headers,
+					Context: context.Background(),
+					Timeout: timeout,
+				})
+				mu.Lock()
+				switch {
+				case resp.Error != nil:
+					s.Failed++
+					s.Errors[httpclient.ClassifyError(resp.Error)]++
+				case resp.StatusCode < 300 || resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+					s.Deleted++
+				default:
+					s.Failed++
+					s.Errors[strconv.Itoa(resp.StatusCode)]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+	s.Duration = time.Since(start)
+	return s
+}
+
+// writeIDs writes one ID per line to path, creating parent directories as needed
+func writeIDs(path string, ids []string) error {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cleanup file directory: %w", err)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write cleanup file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, id := range ids {
+		w.WriteString(id)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write cleanup file: %w", err)
+	}
+	return f.Close()
+}
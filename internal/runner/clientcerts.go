@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// Client certificate rotation strategies
+const (
+	ClientCertPerWorker  = "worker"  // One certificate per worker for the whole run
+	ClientCertPerRequest = "request" // The next certificate of the pool for every request
+)
+
+// maxClientCertFailures is how many certificates with failed requests the summary lists
+const maxClientCertFailures = 5
+
+// ClientCertPool presents a different client certificate per worker or per
+// request, so mutual TLS session caches and per-client authorization on the
+// server see as many clients as real traffic would
+// Each certificate keeps connections and TLS sessions of its own
+type ClientCertPool struct {
+	Dir        string
+	Certs      []httpclient.ClientCert
+	PerRequest bool
+
+	next atomic.Uint64 // Next certificate with per-request rotation
+}
+
+// NewClientCertPool loads the certificates in dir and rotates them with a
+// strategy (worker or request)
+func NewClientCertPool(dir, strategy string) (*ClientCertPool, error) {
+	p := &ClientCertPool{Dir: dir}
+	switch strings.ToLower(strategy) {
+	case ClientCertPerWorker, "":
+	case ClientCertPerRequest:
+		p.PerRequest = true
+	default:
+		return nil, fmt.Errorf("unknown client certificate strategy %q (supported: %s, %s)", strategy, ClientCertPerWorker, ClientCertPerRequest)
+	}
+	certs, err := httpclient.LoadClientCerts(dir)
+	if err != nil {
+		return nil, err
+	}
+	p.Certs = certs
+	return p, nil
+}
+
+// pick returns the certificate of a request sent by worker id
+func (p *ClientCertPool) pick(id int) int {
+	if p.PerRequest {
+		return int((p.next.Add(1) - 1) % uint64(len(p.Certs)))
+	}
+	return id % len(p.Certs)
+}
+
+// certStats counts the requests and failures of each client certificate
+type certStats struct {
+	pool     *ClientCertPool
+	requests []int64
+	failed   []int64
+}
+
+// add accounts a result; a nil certStats (no client certificates) ignores it
+func (c *certStats) add(result Result, failed bool) {
+	if c == nil || result.ClientCert < 0 || result.ClientCert >= len(c.requests) {
+		return
+	}
+	c.requests[result.ClientCert]++
+	if failed {
+		c.failed[result.ClientCert]++
+	}
+}
+
+// ClientCertFailures is a client certificate with failed requests
+type ClientCertFailures struct {
+	Name     string
+	Requests int64
+	Failed   int64
+}
+
+// ClientCertSummary reports how the requests spread over the client
+// certificates and which certificates saw failures, e.g. ones the server's
+// authorization rejects
+type ClientCertSummary struct {
+	Dir          string
+	PerRequest   bool
+	Certificates int                  // Certificates in the pool
+	Used         int                  // Certificates presented by at least one request
+	MinRequests  int64                // Fewest requests of a used certificate
+	MaxRequests  int64                // Most requests of a certificate
+	Failing      int                  // Certificates with failed requests
+	Failures     []ClientCertFailures // Certificates with the most failed requests, most first
+}
+
+// summary returns the requests per certificate (nil without client certificates)
+func (c *certStats) summary() *ClientCertSummary {
+	if c == nil {
+		return nil
+	}
+	s := &ClientCertSummary{Dir: c.pool.Dir, PerRequest: c.pool.PerRequest, Certificates: len(c.pool.Certs)}
+	for i, requests := range c.requests {
+		if requests == 0 {
+			continue
+		}
+		if s.Used == 0 || requests < s.MinRequests {
+			s.MinRequests = requests
+		}
+		s.MaxRequests = max(s.MaxRequests, requests)
+		s.Used++
+		if c.failed[i] > 0 {
+			s.Failing++
+			s.Failures = append(s.Failures, ClientCertFailures{Name: c.pool.Certs[i].Name, Requests: requests, Failed: c.failed[i]})
+		}
+	}
+	sort.Slice(s.Failures, func(i, j int) bool {
+		if s.Failures[i].Failed != s.Failures[j].Failed {
+			return s.Failures[i].Failed > s.Failures[j].Failed
+		}
+		return s.Failures[i].Name < s.Failures[j].Name
+	})
+	if len(s.Failures) > maxClientCertFailures {
+		s.Failures = s.Failures[:maxClientCertFailures]
+	}
+	return s
+}
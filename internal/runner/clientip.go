@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+)
+
+// Client IP spoofing strategies
+const (
+	ClientIPRandom    = "random" // A new address for every request
+	ClientIPPerWorker = "worker" // One address per worker for the whole run
+)
+
+// ClientIPSpoofer sets a header such as X-Forwarded-For to a synthetic client
+// address, so per-IP logic (rate limits, WAF rules, geo) behind a proxy can be
+// exercised from a single load generator
+type ClientIPSpoofer struct {
+	Header    string
+	PerWorker bool
+	pool      *net.IPNet // Addresses are drawn from this network (nil = public IPv4 space)
+}
+
+// NewClientIPSpoofer creates a spoofer for header using a strategy (random or worker)
+// and an optional CIDR pool such as "203.0.113.0/24" or "2001:db8::/64"
+func NewClientIPSpoofer(header, strategy, cidr string) (*ClientIPSpoofer, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, fmt.Errorf("client IP header name is empty")
+	}
+
+	s := &ClientIPSpoofer{Header: header}
+	switch strings.ToLower(strategy) {
+	case ClientIPRandom, "":
+	case ClientIPPerWorker:
+		s.PerWorker = true
+	default:
+		return nil, fmt.Errorf("unknown client IP strategy %q (supported: %s, %s)", strategy, ClientIPRandom, ClientIPPerWorker)
+	}
+
+	if cidr != "" {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client IP pool %q: %w", cidr, err)
+		}
+		s.pool = network
+	}
+	return s, nil
+}
+
+// pick returns a random client address
+func (s *ClientIPSpoofer) pick(rng *rand.Rand) string {
+	if s.pool != nil {
+		ip := make(net.IP, len(s.pool.IP))
+		for i := range ip {
+			// Keep the network bits and randomize the host bits
+			ip[i] = s.pool.IP[i] | byte(rng.Intn(256))&^s.pool.Mask[i]
+		}
+		return ip.String()
+	}
+
+	for {
+		ip := net.IPv4(byte(rng.Intn(224)), byte(rng.Intn(256)), byte(rng.Intn(256)), byte(rng.Intn(256)))
+		if isPublicIPv4(ip) {
+			return ip.String()
+		}
+	}
+}
+
+// reservedIPv4 lists networks that are not plausible public client addresses
+var reservedIPv4 = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.0.0.0/24", "192.0.2.0/24", "192.168.0.0/16",
+		"198.18.0.0/15", "198.51.100.0/24", "203.0.113.0/24",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		nets = append(nets, network)
+	}
+	return nets
+}()
+
+// isPublicIPv4 reports whether ip is outside the reserved networks (multicast and
+// above are excluded by the caller)
+func isPublicIPv4(ip net.IP) bool {
+	for _, network := range reservedIPv4 {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
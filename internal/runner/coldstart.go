@@ -0,0 +1,131 @@
+package runner
+
+import "time"
+
+// coldStartStats splits requests into the first few of each worker (cold caches,
+// connection and TLS setup, server warmup) and the steady state after them, and
+// separately into requests on new and on reused connections
+type coldStartStats struct {
+	requests int64 // Requests per worker counted as cold
+
+	cold, steady        latencyGroup
+	newConn, reusedConn latencyGroup
+}
+
+// latencyGroup collects the latencies of one group of requests
+type latencyGroup struct {
+	requests  int64
+	failed    int64
+	latencies []time.Duration
+}
+
+func (g *latencyGroup) add(latency time.Duration, failed bool) {
+	g.requests++
+	if failed {
+		g.failed++
+	}
+	g.latencies = append(g.latencies, latency)
+}
+
+// count accounts a request whose latency isn't sampled
+func (g *latencyGroup) count(failed bool) {
+	g.requests++
+	if failed {
+		g.failed++
+	}
+}
+
+// record accounts a request, keeping its latency if sampled
+func (g *latencyGroup) record(latency time.Duration, failed, sampled bool) {
+	if sampled {
+		g.add(latency, failed)
+	} else {
+		g.count(failed)
+	}
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (g *latencyGroup) thin() {
+	g.latencies = thinned(g.latencies)
+}
+
+func (g *latencyGroup) summary() LatencyGroup {
+	return LatencyGroup{Requests: g.requests, Failed: g.failed, Latency: NewDurationStats(g.latencies)}
+}
+
+// add accounts a result, keeping its latency if sampled; a nil coldStartStats
+// (cold-start reporting off) ignores it
+func (c *coldStartStats) add(result Result, failed, sampled bool) {
+	if c == nil {
+		return
+	}
+	if result.Cold {
+		c.cold.record(result.Latency, failed, sampled)
+	} else {
+		c.steady.record(result.Latency, failed, sampled)
+	}
+
+	// Requests that failed before getting a connection belong to neither group
+	if result.Connection == ConnectionNew {
+		c.newConn.record(result.Latency, failed, sampled)
+	} else if result.Connection == ConnectionReused {
+		c.reusedConn.record(result.Latency, failed, sampled)
+	}
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (c *coldStartStats) thin() {
+	if c == nil {
+		return
+	}
+	for _, g := range []*latencyGroup{&c.cold, &c.steady, &c.newConn, &c.reusedConn} {
+		g.thin()
+	}
+}
+
+// Connection states of a request (Result.Connection)
+const (
+	ConnectionNone   = ""       // No connection was obtained (e.g. DNS or dial error)
+	ConnectionNew    = "new"    // The request opened a new connection
+	ConnectionReused = "reused" // The request reused an idle keep-alive connection
+)
+
+// LatencyGroup contains the requests and latency of one group of requests
+type LatencyGroup struct {
+	Requests int64
+	Failed   int64
+	Latency  DurationStats
+}
+
+// ErrorRate returns the fraction of the group's requests that failed
+func (g LatencyGroup) ErrorRate() float64 {
+	if g.Requests == 0 {
+		return 0
+	}
+	return float64(g.Failed) / float64(g.Requests)
+}
+
+// ColdStartSummary compares the first requests of each worker with the steady state
+type ColdStartSummary struct {
+	Requests int64 // Requests per worker counted as cold
+
+	Cold   LatencyGroup // The first Requests requests of each worker
+	Steady LatencyGroup // All later requests
+
+	NewConnections    LatencyGroup // Requests that opened a connection
+	ReusedConnections LatencyGroup // Requests on a kept-alive connection
+}
+
+// summary returns the cold-start comparison (nil if cold-start reporting is off)
+func (c *coldStartStats) summary() *ColdStartSummary {
+	if c == nil {
+		return nil
+	}
+	return &ColdStartSummary{
+		Requests:          c.requests,
+		Cold:              c.cold.summary(),
+		Steady:            c.steady.summary(),
+		NewConnections:    c.newConn.summary(),
+		ReusedConnections: c.reusedConn.summary(),
+	}
+}
@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"math"
+)
+
+// comparisonMetrics lists the metrics compared between A/B runs, in report order
+// Names refer to thresholdMetrics, so units match thresholds (ms for latency, % for rates)
+var comparisonMetrics = []struct {
+	name          string
+	lowerIsBetter bool
+}{
+	{"rps", false},
+	{"avg", true},
+	{"p90", true},
+	{"p95", true},
+	{"p99", true},
+	{"error_rate", true},
+}
+
+// significanceLevel is the p-value below which a difference is reported as significant
+const significanceLevel = 0.05
+
+// Comparison is the difference in one metric between two sets of runs
+type Comparison struct {
+	Metric        string
+	LowerIsBetter bool
+	A, B          []float64 // Per-run values in the metric's unit
+	MeanA, MeanB  float64
+	StdDevA       float64
+	StdDevB       float64
+	Change        float64 // Relative change from A to B in percent (NaN if A is 0)
+	PValue        float64 // Two-sided Welch's t-test p-value (NaN with fewer than 2 runs per side)
+}
+
+// Significant reports whether the difference is unlikely to be noise
+func (c Comparison) Significant() bool {
+	return !math.IsNaN(c.PValue) && c.PValue < significanceLevel
+}
+
+// Better reports whether B improved on A; only meaningful if the difference is significant
+func (c Comparison) Better() bool {
+	if c.LowerIsBetter {
+		return c.MeanB < c.MeanA
+	}
+	return c.MeanB > c.MeanA
+}
+
+// CompareRuns compares the summaries of repeated runs of two configurations
+func CompareRuns(a, b []*Summary) []Comparison {
+	comparisons := make([]Comparison, 0, len(comparisonMetrics))
+	for _, m := range comparisonMetrics {
+		metric := thresholdMetrics[m.name]
+		c := Comparison{Metric: m.name, LowerIsBetter: m.lowerIsBetter}
+		for _, s := range a {
+			c.A = append(c.A, metric(s))
+		}
+		for _, s := range b {
+			c.B = append(c.B, metric(s))
+		}
+		c.MeanA, c.StdDevA = meanStdDev(c.A)
+		c.MeanB, c.StdDevB = meanStdDev(c.B)
+		c.Change = math.NaN()
+		if c.MeanA != 0 {
+			c.Change = (c.MeanB - c.MeanA) / c.MeanA * 100
+		}
+		c.PValue = welchTTest(c.A, c.B)
+		comparisons = append(comparisons, c)
+	}
+	return comparisons
+}
+
+// meanStdDev returns the mean and sample standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+// welchTTest returns the two-sided p-value of Welch's t-test for a difference in means
+func welchTTest(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return math.NaN()
+	}
+	t, df := welchT(a, b)
+
+	// Without any variance the samples are either identical or certainly different
+	if math.IsNaN(t) {
+		return 1
+	}
+	if math.IsInf(t, 0) {
+		return 0
+	}
+	return regIncBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// welchT returns Welch's t statistic and its Welch–Satterthwaite degrees of
+// freedom; t is NaN for identical constant samples and ±Inf for different ones
+func welchT(a, b []float64) (t, df float64) {
+	meanA, sdA := meanStdDev(a)
+	meanB, sdB := meanStdDev(b)
+	va := sdA * sdA / float64(len(a))
+	vb := sdB * sdB / float64(len(b))
+	if va+vb == 0 {
+		if meanA == meanB {
+			return math.NaN(), 0
+		}
+		return math.Copysign(math.Inf(1), meanA-meanB), 0
+	}
+	t = (meanA - meanB) / math.Sqrt(va+vb)
+	df = (va + vb) * (va + vb) / (va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+	return t, df
+}
+
+// regIncBeta computes the regularized incomplete beta function I_x(a, b)
+func regIncBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges quickly only below this point; use the symmetry otherwise
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction for the incomplete beta
+// function with the modified Lentz method
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+
+		// Even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// Odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
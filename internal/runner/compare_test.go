@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo reports whether got is within a relative tolerance of want
+func closeTo(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance*math.Abs(want)
+}
+
+// The samples of the two examples in Wikipedia's "Welch's t-test" article
+// (t = -2.46, df = 24.99, p = 0.021 and t = -1.57, df = 9.90, p = 0.149); the
+// expected values below are those to full precision, the p-values from
+// numerically integrating the t density
+var (
+	welchA1 = []float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4}
+	welchA2 = []float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4}
+	welchB1 = []float64{17.2, 20.9, 22.6, 18.1, 21.7, 21.4, 23.5, 24.2, 14.7, 21.8}
+	welchB2 = []float64{21.5, 22.8, 21.0, 23.0, 21.6, 23.6, 22.5, 20.7, 23.4, 21.8, 20.7, 21.7, 21.5, 22.5, 23.6, 21.5, 22.5, 23.5, 21.5, 21.8}
+)
+
+func TestWelchTTest(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  []float64
+		t, df float64
+		p     float64
+	}{
+		{"wikipedia example 1", welchA1, welchA2, -2.45535639828601, 24.98852929023142, 0.02137800146286588},
+		{"wikipedia example 2", welchB1, welchB2, -1.5654335235985073, 9.904741248650831, 0.14884169660532473},
+		{"well separated", []float64{10, 11, 12, 13, 14}, []float64{30, 31, 32, 33, 34, 35}, -19.695762918025714, 8.989361702127662, 1.0555731062822426e-08},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotT, gotDF := welchT(tt.a, tt.b)
+			if !closeTo(gotT, tt.t, 1e-12) || !closeTo(gotDF, tt.df, 1e-12) {
+				t.Errorf("t, df = %v, %v, want %v, %v", gotT, gotDF, tt.t, tt.df)
+			}
+			if p := welchTTest(tt.a, tt.b); !closeTo(p, tt.p, 1e-6) {
+				t.Errorf("p = %v, want %v", p, tt.p)
+			}
+			// The test is symmetric
+			if p, q := welchTTest(tt.a, tt.b), welchTTest(tt.b, tt.a); !closeTo(p, q, 1e-12) {
+				t.Errorf("p(a, b) = %v, p(b, a) = %v", p, q)
+			}
+		})
+	}
+}
+
+func TestWelchTTestEdgeCases(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		p    float64
+	}{
+		{"equal samples", welchA1, welchA1, 1},
+		{"equal means", []float64{1, 2, 3}, []float64{0, 2, 4}, 1},
+		{"identical constants", []float64{5, 5, 5}, []float64{5, 5}, 1},
+		{"different constants", []float64{5, 5, 5}, []float64{6, 6}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := welchTTest(tt.a, tt.b); math.Abs(p-tt.p) > 1e-12 {
+				t.Errorf("p = %v, want %v", p, tt.p)
+			}
+		})
+	}
+	if p := welchTTest([]float64{1}, []float64{1, 2}); !math.IsNaN(p) {
+		t.Errorf("p with a single sample = %v, want NaN", p)
+	}
+}
+
+func TestRegIncBeta(t *testing.T) {
+	tests := []struct {
+		x, a, b float64
+		want    float64
+	}{
+		// Closed forms: I_x(1, 1) = x, I_x(a, 1) = x^a, I_x(1, b) = 1 - (1-x)^b,
+		// I_x(2, 3) = 6x² - 8x³ + 3x⁴ and I_½(a, a) = ½
+		{0.3, 1, 1, 0.3},
+		{0.5, 3, 1, 0.125},
+		{0.2, 0.5, 1, math.Sqrt(0.2)},
+		{0.4, 1, 4, 1 - math.Pow(0.6, 4)},
+		{0.25, 2, 3, 6*0.0625 - 8*0.015625 + 3*0.00390625},
+		{0.9, 2, 3, 6*0.81 - 8*0.729 + 3*0.6561},
+		{0.5, 7.5, 7.5, 0.5},
+		{0.5, 40, 40, 0.5},
+		{0, 2, 3, 0},
+		{1, 2, 3, 1},
+	}
+	for _, tt := range tests {
+		if got := regIncBeta(tt.x, tt.a, tt.b); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("I_%v(%v, %v) = %v, want %v", tt.x, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
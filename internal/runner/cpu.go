@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// saturatedPercent is the utilization from which the generator counts as CPU-bound
+const saturatedPercent = 90
+
+// coreTimes is the cumulative busy and total time of one CPU core
+type coreTimes struct {
+	busy, total uint64
+}
+
+// cpuSampler measures the generator's CPU usage between its creation and Summary
+type cpuSampler struct {
+	shards      int
+	start       time.Time
+	startProc   time.Duration
+	startCores  map[int]coreTimes
+	processOK   bool
+	allowedCPUs []int // Cores the process may run on (nil = unknown)
+}
+
+// startCPUSampler takes the first sample; shards is the number of worker shards
+func startCPUSampler(shards int) *cpuSampler {
+	c := &cpuSampler{shards: shards, start: time.Now(), allowedCPUs: allowedCPUs()}
+	c.startProc, c.processOK = processCPUTime()
+	c.startCores, _ = readCoreTimes()
+	return c
+}
+
+// CoreUsage is the utilization of one CPU core during the run
+type CoreUsage struct {
+	CPU  int
+	Busy float64 // Percent of the time the core was busy (any process)
+}
+
+// GeneratorCPUSummary reports the CPU usage of the machine running g0, telling
+// whether the generator rather than the target limited the load
+type GeneratorCPUSummary struct {
+	MaxProcs int         // GOMAXPROCS during the run
+	Shards   int         // Worker shards (1 = not sharded)
+	Process  float64     // CPU used by g0 in percent of one core (e.g., 350 = 3.5 cores)
+	Cores    []CoreUsage // Cores the process may run on, by CPU number
+}
+
+// Saturated reports whether g0 used (nearly) all the CPU it may use, or its
+// cores were busy with other work
+func (s *GeneratorCPUSummary) Saturated() bool {
+	if s.Process >= float64(s.MaxProcs)*saturatedPercent {
+		return true
+	}
+	if len(s.Cores) == 0 {
+		return false
+	}
+	var busy float64
+	for _, core := range s.Cores {
+		busy += core.Busy
+	}
+	return busy/float64(len(s.Cores)) >= saturatedPercent
+}
+
+// Summary returns the CPU usage since the sampler started (nil if the platform
+// doesn't expose it)
+func (c *cpuSampler) Summary() *GeneratorCPUSummary {
+	elapsed := time.Since(c.start)
+	endProc, processOK := processCPUTime()
+	endCores, err := readCoreTimes()
+	if (!c.processOK || !processOK) && (err != nil || c.startCores == nil) {
+		return nil
+	}
+
+	summary := &GeneratorCPUSummary{MaxProcs: runtime.GOMAXPROCS(0), Shards: c.shards}
+	if c.processOK && processOK && elapsed > 0 {
+		summary.Process = float64(endProc-c.startProc) / float64(elapsed) * 100
+	}
+	if err == nil && c.startCores != nil {
+		allowed := make(map[int]bool, len(c.allowedCPUs))
+		for _, cpu := range c.allowedCPUs {
+			allowed[cpu] = true
+		}
+		for cpu, end := range endCores {
+			start, ok := c.startCores[cpu]
+			if !ok || (len(allowed) > 0 && !allowed[cpu]) || end.total <= start.total {
+				continue
+			}
+			busy := float64(end.busy-start.busy) / float64(end.total-start.total) * 100
+			summary.Cores = append(summary.Cores, CoreUsage{CPU: cpu, Busy: busy})
+		}
+		sort.Slice(summary.Cores, func(i, j int) bool { return summary.Cores[i].CPU < summary.Cores[j].CPU })
+	}
+	return summary
+}
+
+// ParseCPUList parses a list of CPU numbers and ranges, e.g. "0-3,8,10-11"
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(first)
+		to := from
+		if err == nil && isRange {
+			to, err = strconv.Atoi(last)
+		}
+		if err != nil || from < 0 || to < from {
+			return nil, fmt.Errorf("invalid CPU list %q (expected e.g. 0-3,8)", list)
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package runner
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetCPUAffinity restricts the process to the given CPUs
+// Only Linux lets a process pin itself, so it fails elsewhere
+func SetCPUAffinity(cpus []int) error {
+	return fmt.Errorf("CPU affinity is only supported on Linux")
+}
+
+// allowedCPUs returns the CPUs the process may run on (unknown outside Linux)
+func allowedCPUs() []int {
+	return nil
+}
+
+// processCPUTime returns the CPU time used by the process (not measured outside Linux)
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
+
+// readCoreTimes reads the per-core times (only available on Linux)
+func readCoreTimes() (map[int]coreTimes, error) {
+	return nil, fmt.Errorf("per-core CPU times are only available on Linux")
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+)
+
+// collectBatch is the most results a collector adds to the stats under one lock
+const collectBatch = 256
+
+// resultDelivery accounts the results the workers owe the stats collectors, so
+// a result that never arrives is reported instead of silently missing from the
+// totals, and measures how long workers waited for a collector that fell behind
+type resultDelivery struct {
+	owed     int64 // Results the workers produced or are still producing (atomic)
+	waits    int64 // Results that found their queue full (atomic)
+	waitTime int64 // Time workers spent waiting for room in a queue, in ns (atomic)
+
+	clock clock.Clock
+}
+
+// owe accounts a request whose result must reach the collector
+func (d *resultDelivery) owe() {
+	if d != nil {
+		atomic.AddInt64(&d.owed, 1)
+	}
+}
+
+// send queues a result, waiting for room if the collector fell behind
+// Results are never dropped: a worker that can't deliver stops sending
+func (d *resultDelivery) send(results chan<- Result, result Result) {
+	select {
+	case results <- result:
+		return
+	default:
+	}
+	if d == nil {
+		results <- result
+		return
+	}
+	start := d.clock.Now()
+	results <- result
+	atomic.AddInt64(&d.waits, 1)
+	atomic.AddInt64(&d.waitTime, int64(d.clock.Since(start)))
+}
+
+// DeliverySummary reports how results travelled from the workers to the statistics
+type DeliverySummary struct {
+	NotRecorded int64         // Requests whose result never reached the statistics (e.g., a worker crashed)
+	Waits       int64         // Results that waited for room because the collector fell behind
+	WaitTime    time.Duration // Total time workers spent waiting instead of sending requests
+}
+
+// summary compares the owed results with the recorded ones
+func (d *resultDelivery) summary(recorded int64) DeliverySummary {
+	summary := DeliverySummary{
+		Waits:    atomic.LoadInt64(&d.waits),
+		WaitTime: time.Duration(atomic.LoadInt64(&d.waitTime)),
+	}
+	if owed := atomic.LoadInt64(&d.owed); owed > recorded {
+		summary.NotRecorded = owed - recorded
+	}
+	return summary
+}
+
+// collect adds the results of one queue to stats and the record until the queue
+// is closed; results already queued are taken in batches, so the stats lock is
+// taken once per batch while workers keep up a high rate
+// It returns the number of results received, mirrored and token requests excluded
+func collect(results <-chan Result, stats *Stats, recorder *Recorder, errorLog *ErrorLog) int64 {
+	var received int64
+	batch := make([]Result, 0, collectBatch)
+	for result := range results {
+		batch = append(batch[:0], result)
+	drain:
+		for len(batch) < cap(batch) {
+			select {
+			case next, ok := <-results:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		stats.AddResults(batch)
+		for _, r := range batch {
+			if r.Mirror || r.Auth {
+				continue
+			}
+			received++
+			if r.ErrorClass != ErrorClassCancelledAtDeadline {
+				recorder.record(r)
+				if r.failed() {
+					errorLog.log(r)
+				}
+			}
+		}
+	}
+	return received
+}
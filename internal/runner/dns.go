@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// dnsStats collects host name lookups made through a custom DNS server
+type dnsStats struct {
+	server  string
+	count   int64           // Successful lookups
+	lookups []time.Duration // Times of the sampled ones
+	failed  int64
+}
+
+// add accounts a result; a nil dnsStats (system resolver) ignores it
+func (d *dnsStats) add(result Result, sampled bool) {
+	if d == nil {
+		return
+	}
+	if result.ErrorClass == httpclient.ErrorClassDNS {
+		d.failed++
+		return
+	}
+	if result.DNSLookup <= 0 {
+		return
+	}
+	d.count++
+	if sampled {
+		d.lookups = append(d.lookups, result.DNSLookup)
+	}
+}
+
+// thin drops every other lookup sample, as Stats.downsample does
+func (d *dnsStats) thin() {
+	if d != nil {
+		d.lookups = thinned(d.lookups)
+	}
+}
+
+// DNSSummary reports the lookups made through a custom DNS server
+// Lookups only happen when a connection is opened, so kept-alive connections make few
+type DNSSummary struct {
+	Server  string
+	Lookups int64 // Successful lookups
+	Failed  int64 // Requests that failed because the name could not be resolved
+	Latency DurationStats
+}
+
+// summary returns the DNS lookups (nil with the system resolver)
+func (d *dnsStats) summary() *DNSSummary {
+	if d == nil {
+		return nil
+	}
+	return &DNSSummary{
+		Server:  d.server,
+		Lookups: d.count,
+		Failed:  d.failed,
+		Latency: NewDurationStats(d.lookups),
+	}
+}
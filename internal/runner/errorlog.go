@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// DefaultErrorLogMaxBytes is the size at which the error log is rotated
+const DefaultErrorLogMaxBytes = 100 << 20
+
+// ErrorClassHTTPStatus classifies requests in the error log that failed with an HTTP error status
+const ErrorClassHTTPStatus = "http_status"
+
+// errorRecord is the error log entry of a failed request
+type errorRecord struct {
+	Timestamp  string `json:"timestamp"` // RFC 3339 with microseconds, when the request was sent
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	ErrorClass string `json:"error_class"`
+	Message    string `json:"message"`
+	LatencyUs  int64  `json:"latency_us"`
+	TraceID    string `json:"trace_id,omitempty"`
+}
+
+// newErrorRecord converts a failed result into an error log entry
+func newErrorRecord(r Result) errorRecord {
+	rec := errorRecord{
+		Timestamp:  r.SentAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		Method:     r.Method,
+		URL:        r.URL,
+		StatusCode: r.StatusCode,
+		ErrorClass: r.ErrorClass,
+		LatencyUs:  r.Latency.Microseconds(),
+		TraceID:    r.TraceID,
+	}
+	switch {
+	case r.Error != nil:
+		rec.Message = r.Error.Error()
+	case r.StatusCode >= 400:
+		rec.Message = fmt.Sprintf("HTTP %d %s", r.StatusCode, http.StatusText(r.StatusCode))
+	default:
+		rec.Message = r.ErrorClass
+	}
+	if rec.ErrorClass == "" {
+		rec.ErrorClass = ErrorClassHTTPStatus
+	}
+	return rec
+}
+
+// ErrorLog writes every failed request to an NDJSON file, so failures can be
+// analyzed after the run without having recorded every request
+// The file is only created once a request fails and is appended to; it is
+// rotated as its Rotation says, by default when it reaches its size cap
+// It is safe for concurrent use by the stats collectors of sharded workers
+type ErrorLog struct {
+	mu       sync.Mutex
+	path     string
+	rotation Rotation
+	file     *rotatingFile
+	w        *bufio.Writer
+	records  int64
+	err      error // First write error; logging stops after it
+}
+
+// NewErrorLog returns an error log writing to path, rotated as given
+func NewErrorLog(path string, rotation Rotation) *ErrorLog {
+	return &ErrorLog{path: path, rotation: rotation}
+}
+
+// log writes a failed result; after the first error further results are dropped
+func (l *ErrorLog) log(result Result) {
+	if l == nil {
+		return
+	}
+	line, err := json.Marshal(newErrorRecord(result))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	if l.err = err; l.err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	if l.file == nil {
+		file, err := openRotatingFile(l.path, l.rotation, true)
+		if err != nil {
+			l.err = fmt.Errorf("failed to open error log: %w", err)
+			return
+		}
+		l.file, l.w = file, bufio.NewWriterSize(file, 64<<10)
+	}
+	if pending := int64(l.w.Buffered()); (l.file.size > 0 || pending > 0) && l.file.due(pending+int64(len(line))) {
+		if l.err = l.w.Flush(); l.err == nil {
+			l.err = l.file.rotate()
+		}
+		if l.err != nil {
+			l.err = fmt.Errorf("failed to rotate error log: %w", l.err)
+			return
+		}
+	}
+	if _, l.err = l.w.Write(line); l.err == nil {
+		l.records++
+	}
+}
+
+// Close flushes and closes the error log and returns a summary of it (nil if
+// there is no log or no request failed)
+func (l *ErrorLog) Close() *ErrorLogSummary {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rotated := 0
+	if l.file != nil {
+		err := l.w.Flush()
+		if closeErr := l.file.Close(); err == nil {
+			err = closeErr
+		}
+		if l.err == nil {
+			l.err = err
+		}
+		rotated = l.file.rotated
+		l.file = nil
+	}
+	if l.records == 0 && l.err == nil {
+		return nil
+	}
+
+	summary := &ErrorLogSummary{Path: l.path, Records: l.records, Rotated: rotated}
+	if l.err != nil {
+		summary.Error = l.err.Error()
+	}
+	return summary
+}
+
+// ErrorLogSummary describes the error log of a run
+type ErrorLogSummary struct {
+	Path    string
+	Records int64 // Failed requests written
+	Rotated int   // Segments moved aside by rotation
+	Error   string
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// continueStats counts how requests sent with Expect: 100-continue were handled
+type continueStats struct {
+	timeout                    time.Duration
+	continued, timedOut, final int64
+	waits                      []time.Duration // Time until 100 Continue arrived
+}
+
+// add accounts a result, keeping its wait if sampled; a nil continueStats
+// (Expect: 100-continue off) ignores it
+func (c *continueStats) add(result Result, sampled bool) {
+	if c == nil {
+		return
+	}
+	switch result.Continue {
+	case httpclient.ContinueReceived:
+		c.continued++
+		if sampled {
+			c.waits = append(c.waits, result.ContinueWait)
+		}
+	case httpclient.ContinueTimeout:
+		c.timedOut++
+	case httpclient.ContinueFinal:
+		c.final++
+	}
+}
+
+// thin drops every other wait sample, as Stats.downsample does
+func (c *continueStats) thin() {
+	if c != nil {
+		c.waits = thinned(c.waits)
+	}
+}
+
+// ExpectContinueSummary reports how the target handled Expect: 100-continue
+type ExpectContinueSummary struct {
+	Timeout   time.Duration // How long requests waited for 100 Continue before sending the body anyway
+	Requests  int64         // Completed requests sent with the header
+	Continued int64         // 100 Continue received, then the body was sent
+	TimedOut  int64         // No 100 Continue in time; the body was sent anyway
+	Final     int64         // Answered before the body was sent (e.g., 401 or 417)
+
+	Wait DurationStats // Time from the request headers to 100 Continue
+}
+
+// summary returns the Expect: 100-continue results (nil if the header wasn't sent)
+func (c *continueStats) summary() *ExpectContinueSummary {
+	if c == nil {
+		return nil
+	}
+	return &ExpectContinueSummary{
+		Timeout:   c.timeout,
+		Requests:  c.continued + c.timedOut + c.final,
+		Continued: c.continued,
+		TimedOut:  c.timedOut,
+		Final:     c.final,
+		Wait:      NewDurationStats(c.waits),
+	}
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the metrics served on the Prometheus endpoint (see --prometheus-listen)
+const (
+	MetricRequests       = "g0_requests_total"
+	MetricFailedRequests = "g0_requests_failed_total"
+	MetricDuration       = "g0_request_duration_seconds"
+	MetricBytesSent      = "g0_bytes_sent_total"
+	MetricBytesReceived  = "g0_bytes_received_total"
+	MetricWorkers        = "g0_workers"
+)
+
+// durationBuckets are the upper bounds (in seconds) of the request duration histogram
+var durationBuckets = [...]float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// durationHistogram counts request durations in durationBuckets for the Prometheus endpoint
+type durationHistogram struct {
+	counts [len(durationBuckets)]int64 // Requests per bucket (not cumulative); longer requests only count in the total
+	sum    float64                     // Sum of all durations in seconds
+}
+
+// add records a request duration
+func (h *durationHistogram) add(d time.Duration) {
+	seconds := d.Seconds()
+	h.sum += seconds
+	if i := sort.SearchFloat64s(durationBuckets[:], seconds); i < len(durationBuckets) {
+		h.counts[i]++
+	}
+}
+
+// PrometheusExporter serves the live run statistics in the Prometheus text format
+// so dashboards can follow a test while it runs
+type PrometheusExporter struct {
+	server  *http.Server
+	stats   *Stats
+	workers int
+	runID   string // Added to every sample as the run_id label ("" = none)
+}
+
+// StartPrometheusExporter listens on addr and serves /metrics until Close is called
+func StartPrometheusExporter(addr string, stats *Stats, workers int, runID string) (*PrometheusExporter, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for Prometheus scrapes: %w", err)
+	}
+
+	e := &PrometheusExporter{stats: stats, workers: workers, runID: runID}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.serveMetrics)
+	e.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go e.server.Serve(listener)
+	return e, nil
+}
+
+// Close stops the endpoint, letting scrapes in progress finish
+func (e *PrometheusExporter) Close() {
+	if e == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	e.server.Shutdown(ctx)
+}
+
+func (e *PrometheusExporter) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	e.stats.writePrometheus(bw, e.workers, e.runID)
+	bw.Flush()
+}
+
+// sampleLabels renders the labels of a sample: the run ID, if any, and the given
+// name/value pairs
+func sampleLabels(runID string, pairs ...string) string {
+	if runID != "" {
+		pairs = append([]string{"run_id", runID}, pairs...)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	labels := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, fmt.Sprintf("%s=%q", pairs[i], pairs[i+1]))
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// writePrometheus writes the counters in the Prometheus text exposition format
+// Every sample carries the run's ID as the run_id label
+func (s *Stats) writePrometheus(w io.Writer, workers int, runID string) {
+	s.mu.RLock()
+	classes := (&Summary{StatusCodeCounts: s.StatusCodeCounts}).StatusClasses()
+	failed := s.FailedRequests
+	total := s.TotalRequests
+	sent, received := s.BytesSent, s.BytesReceived
+	histogram := s.durations
+	s.mu.RUnlock()
+
+	fmt.Fprintf(w, "# HELP %s Completed requests by status class (error = no HTTP response).\n", MetricRequests)
+	fmt.Fprintf(w, "# TYPE %s counter\n", MetricRequests)
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Strings(names)
+	for _, class := range names {
+		fmt.Fprintf(w, "%s%s %d\n", MetricRequests, sampleLabels(runID, "status_class", class), classes[class])
+	}
+
+	fmt.Fprintf(w, "# HELP %s Requests that failed with an error or a status of 400 or above.\n", MetricFailedRequests)
+	fmt.Fprintf(w, "# TYPE %s counter\n", MetricFailedRequests)
+	fmt.Fprintf(w, "%s%s %d\n", MetricFailedRequests, sampleLabels(runID), failed)
+
+	fmt.Fprintf(w, "# HELP %s Request latency.\n", MetricDuration)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", MetricDuration)
+	var cumulative int64
+	for i, bound := range durationBuckets {
+		cumulative += histogram.counts[i]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", MetricDuration, sampleLabels(runID, "le", strconv.FormatFloat(bound, 'g', -1, 64)), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", MetricDuration, sampleLabels(runID, "le", "+Inf"), total)
+	fmt.Fprintf(w, "%s_sum%s %g\n", MetricDuration, sampleLabels(runID), histogram.sum)
+	fmt.Fprintf(w, "%s_count%s %d\n", MetricDuration, sampleLabels(runID), total)
+
+	fmt.Fprintf(w, "# HELP %s Request body bytes sent.\n", MetricBytesSent)
+	fmt.Fprintf(w, "# TYPE %s counter\n", MetricBytesSent)
+	fmt.Fprintf(w, "%s%s %d\n", MetricBytesSent, sampleLabels(runID), sent)
+
+	fmt.Fprintf(w, "# HELP %s Response body bytes received on the wire.\n", MetricBytesReceived)
+	fmt.Fprintf(w, "# TYPE %s counter\n", MetricBytesReceived)
+	fmt.Fprintf(w, "%s%s %d\n", MetricBytesReceived, sampleLabels(runID), received)
+
+	fmt.Fprintf(w, "# HELP %s Concurrent workers of the run.\n", MetricWorkers)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", MetricWorkers)
+	fmt.Fprintf(w, "%s%s %d\n", MetricWorkers, sampleLabels(runID), workers)
+}
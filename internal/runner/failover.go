@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failoverWindow is how much of the run before and after a failover event is
+// compared to show its impact
+const failoverWindow = 10 * time.Second
+
+// TargetOutage takes a target out of rotation for part of the run, as if its
+// instance went down; its requests go to the other targets in the meantime
+type TargetOutage struct {
+	URL  string        // Target URL as configured
+	From time.Duration // Offset from the start of the test
+	To   time.Duration // Offset at which the target rejoins (0 = stays out until the end)
+}
+
+// ParseTargetOutage parses an outage as URL@FROM or URL@FROM-TO, with offsets
+// from the start of the test, e.g. "https://b.example.com/api@1m-1m30s"
+func ParseTargetOutage(s string) (TargetOutage, error) {
+	at := strings.LastIndex(s, "@")
+	if at <= 0 {
+		return TargetOutage{}, fmt.Errorf("invalid target outage %q (expected URL@FROM or URL@FROM-TO, e.g. https://b.example.com@1m-1m30s)", s)
+	}
+	outage := TargetOutage{URL: s[:at]}
+	from, to, hasTo := strings.Cut(s[at+1:], "-")
+
+	var err error
+	if outage.From, err = time.ParseDuration(from); err != nil || outage.From < 0 {
+		return TargetOutage{}, fmt.Errorf("invalid start %q of target outage %q", from, s)
+	}
+	if hasTo {
+		if outage.To, err = time.ParseDuration(to); err != nil || outage.To <= outage.From {
+			return TargetOutage{}, fmt.Errorf("invalid end %q of target outage %q (must be after the start)", to, s)
+		}
+	}
+	return outage, nil
+}
+
+// down reports whether the outage has the target out of rotation at offset
+func (o TargetOutage) down(offset time.Duration) bool {
+	return offset >= o.From && (o.To == 0 || offset < o.To)
+}
+
+// FailoverEvent is a target leaving or rejoining the rotation
+type FailoverEvent struct {
+	At      time.Duration // Offset from the start of the test
+	URL     string
+	Removed bool           // The target left the rotation (false = it rejoined)
+	Before  TimelineWindow // Requests of the window before the event
+	After   TimelineWindow // Requests of the window after the event
+}
+
+// FailoverSummary lists the failover events of the run with their impact
+type FailoverSummary struct {
+	Window time.Duration // Length of the windows compared around each event
+	Events []FailoverEvent
+}
+
+// failover takes targets out of rotation and puts them back on schedule
+type failover struct {
+	outages  []TargetOutage
+	rotators []*URLRotator
+
+	mu     sync.Mutex
+	events []FailoverEvent
+}
+
+// ValidateTargetOutages checks outages before the run: each must name one of the
+// URLs or targets, and no worker pool may be left without a target to send to
+func ValidateTargetOutages(outages []TargetOutage, urls []string, targets []Target) error {
+	all := make([]Target, 0, len(urls)+len(targets))
+	for _, u := range urls {
+		all = append(all, Target{URL: u})
+	}
+	all = append(all, targets...)
+	_, groups := partitionWorkers(all, 1)
+	return checkOutages(outages, groups)
+}
+
+// checkOutages checks the outages against the targets of each worker pool
+func checkOutages(outages []TargetOutage, groups [][]Target) error {
+	for _, o := range outages {
+		group := -1
+		for i, targets := range groups {
+			for _, t := range targets {
+				if t.URL == o.URL {
+					group = i
+				}
+			}
+		}
+		if group < 0 {
+			return fmt.Errorf("target outage names %s, which is not a target of the run", o.URL)
+		}
+
+		// A pool is only left empty when some outage starts while all its targets are out
+		up := 0
+		for _, t := range groups[group] {
+			down := false
+			for _, other := range outages {
+				if other.URL == t.URL && other.down(o.From) {
+					down = true
+				}
+			}
+			if !down {
+				up++
+			}
+		}
+		if up == 0 {
+			return fmt.Errorf("target outages at %s leave no target for the workers of %s", o.From, o.URL)
+		}
+	}
+	return nil
+}
+
+// newFailover returns the schedule of outages for the worker pools' rotators
+// (nil if there are none)
+func newFailover(outages []TargetOutage, groups [][]Target, rotators []*URLRotator) (*failover, error) {
+	if len(outages) == 0 {
+		return nil, nil
+	}
+	if err := checkOutages(outages, groups); err != nil {
+		return nil, err
+	}
+	return &failover{outages: outages, rotators: rotators}, nil
+}
+
+// Run applies the outages until ctx is done; start is the start of the test
+func (f *failover) Run(ctx context.Context, start time.Time) {
+	type change struct {
+		at   time.Duration
+		url  string
+		down bool
+	}
+	var changes []change
+	for _, o := range f.outages {
+		changes = append(changes, change{at: o.From, url: o.URL, down: true})
+		if o.To > 0 {
+			changes = append(changes, change{at: o.To, url: o.URL, down: false})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].at < changes[j].at })
+
+	for _, c := range changes {
+		if !sleepUntil(ctx, start.Add(c.at)) {
+			return
+		}
+
+		for _, r := range f.rotators {
+			r.setDown(c.url, c.down)
+		}
+		f.mu.Lock()
+		f.events = append(f.events, FailoverEvent{At: time.Since(start), URL: c.url, Removed: c.down})
+		f.mu.Unlock()
+	}
+}
+
+// summary returns the events with the requests of the seconds around each one,
+// up to failoverWindow and never past the neighbouring events (nil without outages)
+func (f *failover) summary(timeline []TimelinePoint) *FailoverSummary {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := &FailoverSummary{Window: failoverWindow, Events: append([]FailoverEvent(nil), f.events...)}
+	for i := range s.Events {
+		at := s.Events[i].At.Truncate(timelineInterval)
+		from, to := at-failoverWindow, at+failoverWindow
+		if i > 0 {
+			from = max(from, s.Events[i-1].At.Truncate(timelineInterval))
+		}
+		if i < len(s.Events)-1 {
+			to = min(to, s.Events[i+1].At.Truncate(timelineInterval))
+		}
+		s.Events[i].Before = timelineWindow(timeline, from, at)
+		s.Events[i].After = timelineWindow(timeline, at, to)
+	}
+	return s
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FeedMode selects how data rows are assigned to requests
+type FeedMode string
+
+const (
+	FeedSequential FeedMode = "sequential" // Rows in file order across all workers, wrapping around
+	FeedPerWorker  FeedMode = "worker"     // Each worker keeps one row for the whole run (row = worker ID)
+	FeedRandom     FeedMode = "random"     // A random row per request (reproducible with --seed)
+	FeedUnique     FeedMode = "unique"     // Rows in file order, each used at most once; the run ends when all are used
+)
+
+// FeedModes lists the supported feed modes
+var FeedModes = []FeedMode{FeedSequential, FeedPerWorker, FeedRandom, FeedUnique}
+
+// errDataExhausted is returned for requests after every row of a unique feed was used
+var errDataExhausted = fmt.Errorf("all data rows have been used")
+
+// DataFeeder supplies rows of a CSV file as template variables ({{.column}})
+type DataFeeder struct {
+	columns []string
+	rows    []templateData
+	mode    FeedMode
+	next    int64 // Atomic row counter for sequential and unique mode
+
+	exhausted     chan struct{} // Closed when a unique feed runs out of rows
+	exhaustedOnce sync.Once
+}
+
+// LoadCSV reads a CSV file whose first line names the columns
+func LoadCSV(path string, mode FeedMode) (*DataFeeder, error) {
+	switch mode {
+	case FeedSequential, FeedPerWorker, FeedRandom, FeedUnique:
+	default:
+		return nil, fmt.Errorf("unknown data mode %q (supported: sequential, worker, random, unique)", mode)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data file %s: %w", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("data file %s needs a header line and at least one row", path)
+	}
+
+	columns := records[0]
+	for i, c := range columns {
+		columns[i] = strings.TrimSpace(c)
+		if columns[i] == "" {
+			return nil, fmt.Errorf("data file %s: column %d has no name", path, i+1)
+		}
+	}
+
+	feeder := &DataFeeder{columns: columns, mode: mode, exhausted: make(chan struct{})}
+	for _, record := range records[1:] {
+		row := make(templateData, len(columns))
+		for i, c := range columns {
+			row[c] = record[i]
+		}
+		feeder.rows = append(feeder.rows, row)
+	}
+	return feeder, nil
+}
+
+// Columns returns the column names (available as {{.name}} in templates)
+func (f *DataFeeder) Columns() []string {
+	return f.columns
+}
+
+// HasColumn reports whether the data file has a column of the given name
+func (f *DataFeeder) HasColumn(name string) bool {
+	for _, c := range f.columns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of data rows
+func (f *DataFeeder) Len() int {
+	return len(f.rows)
+}
+
+// Mode returns how rows are assigned to requests
+func (f *DataFeeder) Mode() FeedMode {
+	return f.mode
+}
+
+// Shard keeps every count-th row starting at index (0-based), so generators
+// running the same test with different indexes never share a row
+func (f *DataFeeder) Shard(index, count int) error {
+	if count < 1 || index < 0 || index >= count {
+		return fmt.Errorf("invalid data shard %d/%d (expected INDEX/COUNT with 0 <= INDEX < COUNT)", index, count)
+	}
+	var rows []templateData
+	for i := index; i < len(f.rows); i += count {
+		rows = append(rows, f.rows[i])
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("data shard %d/%d is empty (%d rows)", index, count, len(f.rows))
+	}
+	f.rows = rows
+	return nil
+}
+
+// row returns the data row for a worker's next request
+// A unique feed returns errDataExhausted once every row was handed out
+func (f *DataFeeder) row(workerID int, rng *rand.Rand) (templateData, error) {
+	switch f.mode {
+	case FeedPerWorker:
+		return f.rows[workerID%len(f.rows)], nil
+	case FeedRandom:
+		return f.rows[rng.Intn(len(f.rows))], nil
+	case FeedUnique:
+		i := atomic.AddInt64(&f.next, 1) - 1
+		if i >= int64(len(f.rows)) {
+			f.exhaustedOnce.Do(func() { close(f.exhausted) })
+			return nil, errDataExhausted
+		}
+		return f.rows[i], nil
+	default:
+		i := atomic.AddInt64(&f.next, 1) - 1
+		return f.rows[i%int64(len(f.rows))], nil
+	}
+}
+
+// Exhausted is closed once a unique feed has no rows left (never for other modes or a nil feeder)
+func (f *DataFeeder) Exhausted() <-chan struct{} {
+	if f == nil {
+		return nil
+	}
+	return f.exhausted
+}
+
+// DataUsage reports how many rows of a unique feed were used
+type DataUsage struct {
+	Rows      int   // Rows available to this generator
+	Used      int64 // Rows sent, each exactly once
+	Exhausted bool  // Every row was used before the test duration ended
+}
+
+// Usage returns the rows used so far (nil unless rows are unique)
+func (f *DataFeeder) Usage() *DataUsage {
+	if f == nil || f.mode != FeedUnique {
+		return nil
+	}
+	used := atomic.LoadInt64(&f.next)
+	u := &DataUsage{Rows: len(f.rows), Used: used}
+	if used >= int64(len(f.rows)) {
+		u.Used = int64(len(f.rows))
+		select {
+		case <-f.exhausted:
+			u.Exhausted = true
+		default:
+		}
+	}
+	return u
+}
+
+// sample returns the first row, for validating templates before the run
+// It returns an empty set of variables for a nil feeder
+func (f *DataFeeder) sample() templateData {
+	if f == nil {
+		return templateData{}
+	}
+	return f.rows[0]
+}
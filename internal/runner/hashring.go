@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// hashRingReplicas is the number of points each target has on the ring; more
+// points spread the keys more evenly between targets
+const hashRingReplicas = 160
+
+// hashRing maps keys to targets by consistent hashing: each target owns the arcs
+// of the ring ending at its points, so adding or removing a target only moves
+// the keys on its own arcs and every other key keeps its target
+type hashRing struct {
+	points []uint64 // Sorted positions on the ring
+	owners []int    // Target index of each point
+}
+
+// newHashRing places hashRingReplicas points for each target URL on the ring
+func newHashRing(urls []string) *hashRing {
+	type point struct {
+		hash  uint64
+		owner int
+	}
+	points := make([]point, 0, len(urls)*hashRingReplicas)
+	for i, url := range urls {
+		for j := 0; j < hashRingReplicas; j++ {
+			points = append(points, point{hash: hashKey(url + "#" + strconv.Itoa(j)), owner: i})
+		}
+	}
+	sort.Slice(points, func(a, b int) bool { return points[a].hash < points[b].hash })
+
+	h := &hashRing{points: make([]uint64, len(points)), owners: make([]int, len(points))}
+	for i, p := range points {
+		h.points[i] = p.hash
+		h.owners[i] = p.owner
+	}
+	return h
+}
+
+// lookup returns the index of the target owning key: the first point at or after
+// the key's position, wrapping around the ring; points of targets that are down
+// are passed over, so only their keys move to other targets
+func (h *hashRing) lookup(key string, down func(int) bool) int {
+	hash := hashKey(key)
+	start := sort.Search(len(h.points), func(i int) bool { return h.points[i] >= hash })
+	for n := 0; n < len(h.points); n++ {
+		if owner := h.owners[(start+n)%len(h.points)]; !down(owner) {
+			return owner
+		}
+	}
+	return h.owners[start%len(h.points)]
+}
+
+// hashKey hashes s with FNV-1a and mixes the bits (splitmix64 finalizer), since
+// FNV alone clusters similar keys such as consecutive IDs
+func hashKey(s string) uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(s))
+	z := f.Sum64()
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
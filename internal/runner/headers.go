@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxHeaderValues bounds the distinct values tracked per captured header, so
+// unique values such as request IDs don't grow without limit
+const maxHeaderValues = 1000
+
+// ParseCaptureHeaders validates and canonicalizes response header names to capture
+func ParseCaptureHeaders(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	var headers []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, ": \t") {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		headers = append(headers, name)
+	}
+	return headers, nil
+}
+
+// captureHeaders returns the values of the named headers ("" if absent)
+func captureHeaders(names []string, header http.Header) []string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = header.Get(name)
+	}
+	return values
+}
+
+// headerCounter counts the values of one captured response header
+type headerCounter struct {
+	values    map[string]int64
+	missing   int64
+	untracked int64 // Responses with a value beyond maxHeaderValues
+}
+
+// add counts a value ("" = header absent)
+func (c *headerCounter) add(value string) {
+	switch {
+	case value == "":
+		c.missing++
+	case c.values[value] > 0 || len(c.values) < maxHeaderValues:
+		c.values[value]++
+	default:
+		c.untracked++
+	}
+}
+
+// HeaderValue is a response header value and the number of responses that carried it
+type HeaderValue struct {
+	Value string
+	Count int64
+}
+
+// HeaderSummary is the value distribution of a captured response header
+type HeaderSummary struct {
+	Name      string
+	Responses int64         // Responses received (with or without the header)
+	Values    []HeaderValue // Values by descending count
+	Missing   int64         // Responses without the header
+	Untracked int64         // Responses whose value was not tracked (too many distinct values)
+}
+
+// newHeaderSummary sorts the counted values of a header
+func newHeaderSummary(name string, c *headerCounter) HeaderSummary {
+	s := HeaderSummary{Name: name, Missing: c.missing, Untracked: c.untracked}
+	for value, count := range c.values {
+		s.Values = append(s.Values, HeaderValue{Value: value, Count: count})
+		s.Responses += count
+	}
+	s.Responses += c.missing + c.untracked
+	sort.Slice(s.Values, func(i, j int) bool {
+		if s.Values[i].Count != s.Values[j].Count {
+			return s.Values[i].Count > s.Values[j].Count
+		}
+		return s.Values[i].Value < s.Values[j].Value
+	})
+	return s
+}
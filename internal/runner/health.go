@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// HealthCheck polls a health endpoint before and during the run, so an outage of
+// the target can be told apart from it getting slow
+type HealthCheck struct {
+	URL      string
+	Interval time.Duration // Time between checks
+	Timeout  time.Duration // Per-check deadline (0 = Interval, capped at 5s)
+	Pause    bool          // Stop sending load while the target is unhealthy
+}
+
+// DowntimeWindow is a period during which health checks failed
+// Start and End are offsets from the start of the test
+type DowntimeWindow struct {
+	Start   time.Duration
+	End     time.Duration
+	Reason  string // Error class or status code of the first failed check
+	Ongoing bool   // The target was still down when the test ended
+}
+
+// Duration returns the length of the window
+func (w DowntimeWindow) Duration() time.Duration {
+	return w.End - w.Start
+}
+
+// HealthSummary describes the target's health during the run
+type HealthSummary struct {
+	URL      string
+	Checks   int64
+	Failures int64
+	Downtime []DowntimeWindow
+	Paused   bool // Load was paused while the target was down
+}
+
+// TotalDowntime returns the combined length of all downtime windows
+func (h *HealthSummary) TotalDowntime() time.Duration {
+	var total time.Duration
+	for _, w := range h.Downtime {
+		total += w.Duration()
+	}
+	return total
+}
+
+// HealthMonitor runs health checks and gates workers while the target is down
+type HealthMonitor struct {
+	check  HealthCheck
+	client *httpclient.Client
+	start  time.Time
+
+	mu       sync.Mutex
+	healthy  bool
+	resumed  chan struct{} // Closed when the target becomes healthy again
+	checks   int64
+	failures int64
+	windows  []DowntimeWindow
+}
+
+// NewHealthMonitor creates a monitor for the given health check
+func NewHealthMonitor(check HealthCheck) *HealthMonitor {
+	if check.Timeout <= 0 {
+		check.Timeout = check.Interval
+		if check.Timeout > 5*time.Second {
+			check.Timeout = 5 * time.Second
+		}
+	}
+	resumed := make(chan struct{})
+	close(resumed)
+	return &HealthMonitor{
+		check:   check,
+		client:  httpclient.New(httpclient.DefaultOptions()),
+		healthy: true,
+		resumed: resumed,
+	}
+}
+
+// probe performs a single health check; 2xx and 3xx responses are healthy
+// It returns "" if healthy, or the reason the check failed
+func (h *HealthMonitor) probe(ctx context.Context) string {
+	resp := h.client.Do(httpclient.Request{
+		Method:  "GET",
+		URL:     h.check.URL,
+		Context: ctx,
+		Timeout: h.check.Timeout,
+	})
+	switch {
+	case resp.Error != nil:
+		return httpclient.ClassifyError(resp.Error)
+	case resp.StatusCode >= 400:
+		return fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return ""
+}
+
+// CheckOnce verifies the target is healthy before the test starts
+func (h *HealthMonitor) CheckOnce(ctx context.Context) error {
+	if reason := h.probe(ctx); reason != "" {
+		return fmt.Errorf("health check %s failed before the test started: %s", h.check.URL, reason)
+	}
+	return nil
+}
+
+// Run checks the health endpoint every interval until ctx is cancelled
+func (h *HealthMonitor) Run(ctx context.Context, start time.Time) {
+	h.mu.Lock()
+	h.start = start
+	h.mu.Unlock()
+
+	ticker := time.NewTicker(h.check.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reason := h.probe(ctx)
+		if ctx.Err() != nil {
+			// The check was cut off by the end of the test; it says nothing about the target
+			return
+		}
+		h.record(reason, time.Now())
+	}
+}
+
+// record updates the health state with the outcome of a check
+func (h *HealthMonitor) record(reason string, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks++
+	offset := now.Sub(h.start)
+	switch {
+	case reason != "" && h.healthy:
+		h.failures++
+		h.healthy = false
+		h.resumed = make(chan struct{})
+		h.windows = append(h.windows, DowntimeWindow{Start: offset, End: offset, Reason: reason, Ongoing: true})
+	case reason != "":
+		h.failures++
+		h.windows[len(h.windows)-1].End = offset
+	case !h.healthy:
+		h.healthy = true
+		close(h.resumed)
+		w := &h.windows[len(h.windows)-1]
+		w.End = offset
+		w.Ongoing = false
+	}
+}
+
+// Wait blocks while the target is down and load is paused
+// It returns false if ctx is cancelled first; a nil monitor never blocks
+func (h *HealthMonitor) Wait(ctx context.Context) bool {
+	if h == nil || !h.check.Pause {
+		return true
+	}
+	h.mu.Lock()
+	resumed := h.resumed
+	h.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Healthy reports whether the last health check succeeded
+func (h *HealthMonitor) Healthy() bool {
+	if h == nil {
+		return true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.healthy
+}
+
+// Summary returns the health results; ongoing downtime is extended to end
+func (h *HealthMonitor) Summary(end time.Time) *HealthSummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	summary := &HealthSummary{
+		URL:      h.check.URL,
+		Checks:   h.checks,
+		Failures: h.failures,
+		Downtime: append([]DowntimeWindow(nil), h.windows...),
+		Paused:   h.check.Pause,
+	}
+	if n := len(summary.Downtime); n > 0 && summary.Downtime[n-1].Ongoing {
+		summary.Downtime[n-1].End = end.Sub(h.start)
+	}
+	return summary
+}
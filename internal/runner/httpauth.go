@@ -0,0 +1,68 @@
+package runner
+
+// httpAuthStats splits the requests of a run with HTTP authentication (Digest,
+// NTLM, Negotiate) by whether they had to answer a challenge first, which is
+// what a legacy scheme costs under load
+type httpAuthStats struct {
+	scheme        string
+	challenged    latencyGroup // Answered at least one challenge before the response
+	authenticated latencyGroup // Sent with reused credentials or on an authenticated connection
+	challenges    int64
+	unauthorized  int64 // Final responses still 401
+}
+
+// add accounts a result, keeping its latency if sampled; a nil httpAuthStats
+// (no HTTP authentication) ignores it
+func (h *httpAuthStats) add(result Result, failed, sampled bool) {
+	if h == nil {
+		return
+	}
+	group := &h.authenticated
+	if result.AuthChallenges > 0 {
+		group = &h.challenged
+	}
+	if sampled {
+		group.add(result.Latency, failed)
+	} else {
+		group.count(failed)
+	}
+	h.challenges += int64(result.AuthChallenges)
+	if result.StatusCode == 401 {
+		h.unauthorized++
+	}
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (h *httpAuthStats) thin() {
+	if h == nil {
+		return
+	}
+	h.challenged.latencies = thinned(h.challenged.latencies)
+	h.authenticated.latencies = thinned(h.authenticated.latencies)
+}
+
+// HTTPAuthSummary reports the requests of a run with HTTP authentication
+// With NTLM and Negotiate every new connection costs a challenged request (two
+// extra round trips for NTLM, one for Kerberos); with Digest only new or stale
+// nonces do
+type HTTPAuthSummary struct {
+	Scheme        string       // e.g. "NTLM"
+	Challenged    LatencyGroup // Requests that answered a challenge first
+	Authenticated LatencyGroup // Requests authenticated without a challenge
+	Challenges    int64        // Challenges answered (NTLM answers two per handshake)
+	Unauthorized  int64        // Requests still answered with 401, e.g. for wrong credentials
+}
+
+// summary returns the HTTP authentication results (nil without HTTP authentication)
+func (h *httpAuthStats) summary() *HTTPAuthSummary {
+	if h == nil {
+		return nil
+	}
+	return &HTTPAuthSummary{
+		Scheme:        h.scheme,
+		Challenged:    h.challenged.summary(),
+		Authenticated: h.authenticated.summary(),
+		Challenges:    h.challenges,
+		Unauthorized:  h.unauthorized,
+	}
+}
@@ -0,0 +1,19 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// IdempotencyKeyHeader carries a unique key per request when WorkerOptions.IdempotencyKey is set
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// newIdempotencyKey returns a random (version 4) UUID, the key format payment
+// and other critical APIs commonly expect
+func newIdempotencyKey(rng *rand.Rand) string {
+	var b [16]byte
+	rng.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,59 @@
+package runner
+
+// littleShortfall is the share of the workers below which the run is reported as
+// not having reached its configured concurrency
+const littleShortfall = 0.9
+
+// Causes that keep workers idle by design, so a low in-flight count is expected
+const (
+	LimitMaxRPS      = "max-rps"
+	LimitArrivalRate = "arrival-rate"
+	LimitUsers       = "users"
+)
+
+// LittlesLawSummary checks the run against Little's Law: the requests in flight
+// on average equal the throughput times the average latency (L = λW)
+type LittlesLawSummary struct {
+	Workers  int     // Configured concurrency
+	InFlight float64 // Requests in flight on average (RPS × average latency)
+	Busy     float64 // InFlight as a share of Workers (1 = every worker was always waiting on a response)
+	Limit    string  // What paced the workers by design (LimitMaxRPS, ...; "" = nothing)
+}
+
+// Idle reports whether the workers were noticeably idle between requests
+func (l *LittlesLawSummary) Idle() bool {
+	return l.Busy < littleShortfall
+}
+
+// Shortfall reports whether the workers were idle for no configured reason, so
+// the configured concurrency wasn't actually achieved
+func (l *LittlesLawSummary) Shortfall() bool {
+	return l.Limit == "" && l.Idle()
+}
+
+// Queued reports whether more requests were in flight than there were workers:
+// with an arrival rate, requests waiting for a worker count as in flight
+func (l *LittlesLawSummary) Queued() bool {
+	return l.Busy > 1
+}
+
+// newLittlesLaw derives the average requests in flight of a run (nil without requests)
+func newLittlesLaw(summary *Summary, config Config) *LittlesLawSummary {
+	if summary.TotalRequests == 0 || config.Concurrency == 0 {
+		return nil
+	}
+	l := &LittlesLawSummary{
+		Workers:  config.Concurrency,
+		InFlight: summary.RPS * summary.AvgLatency.Seconds(),
+	}
+	l.Busy = l.InFlight / float64(l.Workers)
+	switch {
+	case config.MaxRPS > 0:
+		l.Limit = LimitMaxRPS
+	case config.ArrivalRate > 0 || config.RPSTrace != nil:
+		l.Limit = LimitArrivalRate
+	case config.Users > 0:
+		l.Limit = LimitUsers
+	}
+	return l
+}
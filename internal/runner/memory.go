@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Shares of the memory budget at which g0 sheds load on itself
+const (
+	memoryShedRecording = 0.8 // Stop the per-request record
+	memoryDownsample    = 0.9 // Halve the latency samples kept
+)
+
+// memoryCheckInterval is how often the memory guard reads the resident memory
+const memoryCheckInterval = time.Second
+
+// memoryDownsampleGap is the least time between two downsamplings, so the memory
+// freed by one shows in the resident memory before the next is considered
+const memoryDownsampleGap = 10 * time.Second
+
+// maxSampleStride bounds the downsampling: at least one in this many requests
+// keeps its latencies
+const maxSampleStride = 1024
+
+// MemoryEvent is something the memory guard shed to stay within the budget
+type MemoryEvent struct {
+	Offset time.Duration // Time since the start of the test
+	RSS    int64         // Resident memory that triggered it
+	Action string
+}
+
+// MemorySummary reports g0's memory use against --max-memory
+type MemorySummary struct {
+	Budget int64
+	Peak   int64 // Highest resident memory seen
+	Stride int   // Latencies were kept for one in Stride requests at the end (1 = all)
+	Events []MemoryEvent
+}
+
+// memoryGuard watches g0's resident memory during the run and degrades the
+// run's bookkeeping rather than letting the process be OOM-killed: first the
+// per-request record is stopped, then the latency samples are halved, as often
+// as needed
+// Counts stay exact; latency percentiles come from the samples kept
+type memoryGuard struct {
+	budget   int64
+	stats    *Stats
+	recorder *Recorder
+	start    time.Time
+
+	mu             sync.Mutex
+	peak           int64
+	events         []MemoryEvent
+	recordingShed  bool
+	lastDownsample time.Time
+}
+
+// newMemoryGuard returns a guard keeping g0 within budget bytes (nil = no budget)
+func newMemoryGuard(budget int64, stats *Stats, recorder *Recorder) *memoryGuard {
+	if budget <= 0 {
+		return nil
+	}
+	return &memoryGuard{budget: budget, stats: stats, recorder: recorder}
+}
+
+// Run checks the memory every second until ctx is done; the garbage collector
+// also works harder as the heap nears the budget
+func (g *memoryGuard) Run(ctx context.Context, start time.Time) {
+	g.start = start
+	previous := debug.SetMemoryLimit(g.budget)
+	defer debug.SetMemoryLimit(previous)
+
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if rss, ok := processRSS(); ok {
+				g.check(now, rss)
+			}
+		}
+	}
+}
+
+// check sheds what the resident memory calls for
+func (g *memoryGuard) check(now time.Time, rss int64) {
+	g.mu.Lock()
+	g.peak = max(g.peak, rss)
+	shedRecording := g.recorder != nil && !g.recordingShed && float64(rss) >= float64(g.budget)*memoryShedRecording
+	downsample := float64(rss) >= float64(g.budget)*memoryDownsample && now.Sub(g.lastDownsample) >= memoryDownsampleGap
+	if shedRecording {
+		g.recordingShed = true
+	}
+	if downsample {
+		g.lastDownsample = now
+	}
+	g.mu.Unlock()
+
+	// Shed outside the lock; the stats take their own
+	if shedRecording {
+		g.recorder.stop("g0 neared its --max-memory budget")
+		g.log(now, rss, "stopped the per-request record")
+	}
+	if downsample {
+		if stride := g.stats.downsample(); stride > 0 {
+			// Hand the dropped samples back to the OS so the next check sees them gone
+			debug.FreeOSMemory()
+			g.log(now, rss, fmt.Sprintf("kept latencies of 1 in %d requests", stride))
+		}
+	}
+}
+
+// log records a shedding event
+func (g *memoryGuard) log(now time.Time, rss int64, action string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.events = append(g.events, MemoryEvent{Offset: now.Sub(g.start), RSS: rss, Action: action})
+}
+
+// eventList returns the events so far (nil without a guard)
+func (g *memoryGuard) eventList() []MemoryEvent {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]MemoryEvent(nil), g.events...)
+}
+
+// Summary returns the memory use of the run (nil without a guard)
+func (g *memoryGuard) Summary() *MemorySummary {
+	if g == nil {
+		return nil
+	}
+	stride := g.stats.sampleStride()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if rss, ok := processRSS(); ok {
+		g.peak = max(g.peak, rss)
+	}
+	return &MemorySummary{Budget: g.budget, Peak: g.peak, Stride: stride, Events: append([]MemoryEvent(nil), g.events...)}
+}
+
+// thinned returns every other element of s, starting with the first, in a new
+// array so the memory of the dropped half can be freed
+func thinned[T any](s []T) []T {
+	kept := make([]T, (len(s)+1)/2)
+	for i := range kept {
+		kept[i] = s[2*i]
+	}
+	return kept
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package runner
+
+import "runtime"
+
+// processRSS approximates the resident memory of the process by the memory the
+// Go runtime holds from the OS, as resident memory isn't read outside Linux
+func processRSS() (int64, bool) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.Sys - m.HeapReleased), true
+}
@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// Mirror modes
+const (
+	MirrorMeasure = "measure" // Mirrored responses are measured and compared with the primary target
+	MirrorForget  = "forget"  // Mirrored responses are discarded unread; only outcomes are counted
+)
+
+// mirrorInFlightPerWorker bounds the mirrored requests in flight per worker, so a
+// slow shadow target neither holds back the load nor piles up goroutines
+const mirrorInFlightPerWorker = 4
+
+// Mirror duplicates every request to a shadow target, keeping the path and query
+// of each request so both targets see identical traffic
+type Mirror struct {
+	URL  string // Mirror base URL
+	Mode string // MirrorMeasure or MirrorForget
+
+	base baseURL
+}
+
+// NewMirror creates a mirror duplicating requests to base (scheme, host and an
+// optional path prefix, e.g. http://10.0.0.6:8080)
+func NewMirror(base, mode string) (*Mirror, error) {
+	b, err := parseBaseURL(base, "mirror")
+	if err != nil {
+		return nil, err
+	}
+	if mode != MirrorMeasure && mode != MirrorForget {
+		return nil, fmt.Errorf("invalid mirror mode %q (expected %s or %s)", mode, MirrorMeasure, MirrorForget)
+	}
+	return &Mirror{URL: base, Mode: mode, base: b}, nil
+}
+
+// MirrorSender sends the mirrored requests of all workers in the background
+type MirrorSender struct {
+	mirror  *Mirror
+	client  *httpclient.Client
+	results chan<- Result
+	slots   chan struct{} // Semaphore of mirrored requests in flight
+	wg      sync.WaitGroup
+	dropped int64 // Mirrored requests skipped because too many were in flight (atomic)
+}
+
+// NewMirrorSender creates a sender reporting mirrored results to results; the
+// channel must stay open until Wait returned
+func NewMirrorSender(mirror *Mirror, client *httpclient.Client, results chan<- Result, workers int) *MirrorSender {
+	return &MirrorSender{
+		mirror:  mirror,
+		client:  client,
+		results: results,
+		slots:   make(chan struct{}, workers*mirrorInFlightPerWorker),
+	}
+}
+
+// send duplicates request to the mirror without waiting for the response
+func (m *MirrorSender) send(request httpclient.Request) {
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		atomic.AddInt64(&m.dropped, 1)
+		return
+	}
+
+	request.URL = m.mirror.base.rewrite(request.URL)
+	request.KeepBody, request.HashBody = false, false // Mirrored bodies are never inspected
+	request.ServerName = ""                           // The mirror is reached by its own name
+	if m.mirror.Mode == MirrorForget {
+		request.SkipBody = true
+		request.ReadRate, request.ReadDelay = 0, 0
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() { <-m.slots }()
+
+		sentAt := time.Now()
+		resp := m.client.Do(request)
+		result := Result{
+			Method:     request.Method,
+			URL:        request.URL,
+			SentAt:     sentAt,
+			Latency:    resp.Latency,
+			StatusCode: resp.StatusCode,
+			Error:      resp.Error,
+			ErrorClass: httpclient.ClassifyError(resp.Error),
+			Mirror:     true,
+		}
+		if resp.Error != nil && request.Context != nil && requestEnded(request.Context) {
+			result.ErrorClass = ErrorClassCancelledAtDeadline
+		}
+		m.results <- result
+	}()
+}
+
+// Wait waits for the mirrored requests still in flight
+func (m *MirrorSender) Wait() {
+	m.wg.Wait()
+}
+
+// Dropped returns the number of requests that were not mirrored
+func (m *MirrorSender) Dropped() int64 {
+	return atomic.LoadInt64(&m.dropped)
+}
+
+// mirrorStats splits the results into primary and mirrored requests
+type mirrorStats struct {
+	mirror           *Mirror
+	primary, shadow  latencyGroup
+	cancelledShadows int64
+}
+
+// add accounts a result, keeping its latency if sampled; a nil mirrorStats
+// (no mirror) ignores it
+func (m *mirrorStats) add(result Result, failed, sampled bool) {
+	if m == nil {
+		return
+	}
+	if !result.Mirror {
+		m.primary.record(result.Latency, failed, sampled)
+		return
+	}
+	if result.ErrorClass == ErrorClassCancelledAtDeadline {
+		m.cancelledShadows++
+		return
+	}
+	m.shadow.record(result.Latency, failed, sampled)
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (m *mirrorStats) thin() {
+	if m != nil {
+		m.primary.thin()
+		m.shadow.thin()
+	}
+}
+
+// MirrorSummary compares the primary target with the mirror it was duplicated to
+type MirrorSummary struct {
+	URL       string
+	Mode      string
+	Primary   LatencyGroup
+	Mirror    LatencyGroup // Latencies cover response headers only in MirrorForget mode
+	Cancelled int64        // Mirrored requests cut off by the end of the test
+	Dropped   int64        // Requests not mirrored because too many mirrored requests were in flight
+
+	Comparisons []Comparison // A = primary, B = mirror (MirrorMeasure mode only)
+}
+
+// summary compares primary and mirror (nil without a mirror)
+func (m *mirrorStats) summary() *MirrorSummary {
+	if m == nil {
+		return nil
+	}
+	s := &MirrorSummary{
+		URL:       m.mirror.URL,
+		Mode:      m.mirror.Mode,
+		Primary:   m.primary.summary(),
+		Mirror:    m.shadow.summary(),
+		Cancelled: m.cancelledShadows,
+	}
+	if m.mirror.Mode == MirrorMeasure {
+		s.Comparisons = compareGroups(&m.primary, &m.shadow)
+	}
+	return s
+}
@@ -36,3 +36,79 @@ func Percentile(durations []time.Duration, percentile float64) time.Duration {
 	return time.Duration(lowerValue + weight*(upperValue-lowerValue))
 }
 
+// DurationStats summarizes a distribution of durations
+type DurationStats struct {
+	Min time.Duration
+	Avg time.Duration
+	Max time.Duration
+	P50 time.Duration
+	P90 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// NewDurationStats computes distribution statistics for the given durations
+func NewDurationStats(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+
+	return DurationStats{
+		Min: sorted[0],
+		Avg: sum / time.Duration(len(sorted)),
+		Max: sorted[len(sorted)-1],
+		P50: sortedPercentile(sorted, 50),
+		P90: sortedPercentile(sorted, 90),
+		P95: sortedPercentile(sorted, 95),
+		P99: sortedPercentile(sorted, 99),
+	}
+}
+
+// sortedPercentile is Percentile for an already sorted slice
+func sortedPercentile(sorted []time.Duration, percentile float64) time.Duration {
+	index := float64(len(sorted)-1) * percentile / 100.0
+	lower := int(index)
+	upper := lower + 1
+
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	weight := index - float64(lower)
+	return time.Duration(float64(sorted[lower]) + weight*float64(sorted[upper]-sorted[lower]))
+}
+
+// HistogramBucket counts the latencies below UpperBound and at or above the
+// previous bucket's bound
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// NewLatencyHistogram buckets durations geometrically (about ±2.5% precision, as
+// for the live percentiles) and returns the non-empty buckets in ascending order
+func NewLatencyHistogram(durations []time.Duration) []HistogramBucket {
+	var counts [histogramBuckets]int64
+	for _, d := range durations {
+		counts[latencyBucket(d)]++
+	}
+
+	var buckets []HistogramBucket
+	for b, count := range counts {
+		if count > 0 {
+			buckets = append(buckets, HistogramBucket{UpperBound: bucketUpperBound(b), Count: count})
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,62 @@
+package runner
+
+// WorkerPool is a group of workers that only send requests to its own targets
+type WorkerPool struct {
+	URLs    []string
+	Workers int
+}
+
+// PinnedTargets reports whether any target has its own worker pool
+func PinnedTargets(targets []Target) bool {
+	for _, t := range targets {
+		if t.Concurrency > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TotalWorkers returns the number of workers a run starts: the dedicated workers
+// of the targets that set a concurrency, plus the shared pool of concurrency
+// workers if any URL or target is left to share it
+func TotalWorkers(urls int, targets []Target, concurrency int) int {
+	total := 0
+	shared := urls > 0
+	for _, t := range targets {
+		if t.Concurrency > 0 {
+			total += t.Concurrency
+		} else {
+			shared = true
+		}
+	}
+	if shared {
+		total += concurrency
+	}
+	return total
+}
+
+// partitionWorkers splits resolved targets into worker pools: one per target with
+// its own concurrency, and a shared pool of concurrency workers for the rest
+// The shared pool comes first so its workers keep the lowest IDs
+func partitionWorkers(targets []Target, concurrency int) ([]WorkerPool, [][]Target) {
+	var pools []WorkerPool
+	var pinned, shared []Target
+	for _, t := range targets {
+		if t.Concurrency > 0 {
+			pinned = append(pinned, t)
+		} else {
+			shared = append(shared, t)
+		}
+	}
+
+	groups := make([][]Target, 0, len(pinned)+1)
+	if len(shared) > 0 {
+		pools = append(pools, WorkerPool{URLs: TargetURLs(shared), Workers: concurrency})
+		groups = append(groups, shared)
+	}
+	for _, t := range pinned {
+		pools = append(pools, WorkerPool{URLs: []string{t.URL}, Workers: t.Concurrency})
+		groups = append(groups, []Target{t})
+	}
+	return pools, groups
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// ProbeConfig configures cold start probing: after each idle period one request
+// is sent on a new connection, followed by a few warm requests for comparison
+type ProbeConfig struct {
+	URL     string
+	Method  string
+	Body    string
+	Headers map[string]string
+
+	Idle         time.Duration // Time without any request before each probe
+	Probes       int           // Number of probes
+	WarmRequests int           // Requests sent right after each probe as the warm baseline
+	Timeout      time.Duration // Per-request timeout; cold starts can take much longer than warm requests
+}
+
+// ProbeRequest is the outcome of one probe request
+type ProbeRequest struct {
+	Latency    time.Duration
+	TTFB       time.Duration
+	StatusCode int
+	ErrorClass string // Coarse error classification ("" if the request got a response)
+}
+
+// Failed reports whether the request failed (error or status 400+)
+func (r ProbeRequest) Failed() bool {
+	return r.StatusCode == 0 || r.StatusCode >= 400
+}
+
+// Probe is one cold request after an idle period and the warm requests following it
+type Probe struct {
+	Number int       // 1-based probe number
+	At     time.Time // When the cold request was sent
+	Cold   ProbeRequest
+	Warm   []ProbeRequest
+}
+
+// ProbeSummary contains the results of all probes
+type ProbeSummary struct {
+	Config  ProbeConfig
+	Probes  []Probe
+	Cold    LatencyGroup // First request after each idle period
+	Warm    LatencyGroup // Requests right after a probe, on the warmed-up target
+	Aborted bool         // Probing was interrupted before all probes were sent
+}
+
+// RunProbes sends the configured probes, calling onWait before each idle period and
+// onProbe after each probe; it stops early when ctx is cancelled
+func RunProbes(ctx context.Context, config ProbeConfig, onWait func(next time.Time), onProbe func(Probe)) *ProbeSummary {
+	summary := &ProbeSummary{Config: config}
+	var cold, warm latencyGroup
+
+	for n := 1; n <= config.Probes; n++ {
+		// Stay idle so the platform can scale the target down
+		if onWait != nil {
+			onWait(time.Now().Add(config.Idle))
+		}
+		timer := time.NewTimer(config.Idle)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			summary.Aborted = true
+		case <-timer.C:
+		}
+		if summary.Aborted {
+			break
+		}
+
+		// A new client per probe, so the cold request also pays for the connection
+		// setup a real client returning after the idle period would see
+		client := httpclient.New(httpclient.Options{Timeout: config.Timeout})
+		probe := Probe{Number: n, At: time.Now()}
+		probe.Cold = sendProbe(ctx, client, config)
+		for i := 0; i < config.WarmRequests && ctx.Err() == nil; i++ {
+			probe.Warm = append(probe.Warm, sendProbe(ctx, client, config))
+		}
+		client.CloseIdleConnections()
+
+		// A probe cut short by Ctrl+C didn't measure anything useful
+		if ctx.Err() != nil {
+			summary.Aborted = true
+			break
+		}
+		cold.add(probe.Cold.Latency, probe.Cold.Failed())
+		for _, r := range probe.Warm {
+			warm.add(r.Latency, r.Failed())
+		}
+		summary.Probes = append(summary.Probes, probe)
+		if onProbe != nil {
+			onProbe(probe)
+		}
+	}
+
+	summary.Cold = cold.summary()
+	summary.Warm = warm.summary()
+	return summary
+}
+
+// sendProbe sends one request of a probe
+func sendProbe(ctx context.Context, client *httpclient.Client, config ProbeConfig) ProbeRequest {
+	resp := client.Do(httpclient.Request{
+		Method:  config.Method,
+		URL:     config.URL,
+		Body:    config.Body,
+		Headers: config.Headers,
+		Context: ctx,
+	})
+	return ProbeRequest{
+		Latency:    resp.Latency,
+		TTFB:       resp.TTFB,
+		StatusCode: resp.StatusCode,
+		ErrorClass: httpclient.ClassifyError(resp.Error),
+	}
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// Client addresses of PROXY protocol headers, besides a network or address
+const (
+	ProxySourceRandom = "random" // A random public IPv4 address per connection
+	ProxySourceLocal  = "local"  // The connection's own address
+)
+
+// NewProxyProtocol prepends a PROXY protocol header (v1 or v2) to each new
+// connection, claiming a client address from source: random, local, a network
+// such as 203.0.113.0/24 to draw addresses from, or a single address
+func NewProxyProtocol(version, source string) (*httpclient.ProxyProtocol, error) {
+	version = strings.ToLower(version)
+	if err := httpclient.ValidateProxyProtocol(version); err != nil {
+		return nil, err
+	}
+	p := &httpclient.ProxyProtocol{Version: version}
+	switch source = strings.TrimSpace(source); strings.ToLower(source) {
+	case ProxySourceLocal:
+		return p, nil
+	case ProxySourceRandom, "":
+		p.Source = newAddressSource(&ClientIPSpoofer{})
+		return p, nil
+	}
+	if ip := net.ParseIP(source); ip != nil {
+		p.Source = func() net.IP { return ip }
+		return p, nil
+	}
+	_, network, err := net.ParseCIDR(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol source %q (supported: %s, %s, an address or a network)", source, ProxySourceRandom, ProxySourceLocal)
+	}
+	p.Source = newAddressSource(&ClientIPSpoofer{pool: network})
+	return p, nil
+}
+
+// newAddressSource draws addresses as spoofer does; connections are dialed
+// concurrently, so draws are serialized
+func newAddressSource(spoofer *ClientIPSpoofer) func() net.IP {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return func() net.IP {
+		mu.Lock()
+		defer mu.Unlock()
+		return net.ParseIP(spoofer.pick(rng))
+	}
+}
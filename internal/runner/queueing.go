@@ -0,0 +1,213 @@
+package runner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// inFlightSampleInterval is how often the requests in flight are sampled
+const inFlightSampleInterval = 100 * time.Millisecond
+
+// InFlightGauge counts the requests in flight and samples the count into
+// per-second buckets of the timeline
+type InFlightGauge struct {
+	current int64 // Requests sent and not yet answered (atomic)
+
+	mu      sync.Mutex
+	sums    []int64 // Sum of the samples per second
+	samples []int64 // Samples per second
+	maxes   []int64 // Largest sample per second
+}
+
+// NewInFlightGauge creates a gauge with no requests in flight
+func NewInFlightGauge() *InFlightGauge {
+	return &InFlightGauge{}
+}
+
+// add changes the requests in flight by delta; a nil gauge ignores it
+func (g *InFlightGauge) add(delta int64) {
+	if g != nil {
+		atomic.AddInt64(&g.current, delta)
+	}
+}
+
+// Run samples the requests in flight until ctx is cancelled; start is the test start
+func (g *InFlightGauge) Run(ctx context.Context, start time.Time) {
+	ticker := time.NewTicker(inFlightSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.sample(now.Sub(start), atomic.LoadInt64(&g.current))
+		}
+	}
+}
+
+// sample records the requests in flight at offset from the test start
+func (g *InFlightGauge) sample(offset time.Duration, n int64) {
+	if offset < 0 {
+		offset = 0
+	}
+	bucket := int(offset / timelineInterval)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for len(g.sums) <= bucket {
+		g.sums = append(g.sums, 0)
+		g.samples = append(g.samples, 0)
+		g.maxes = append(g.maxes, 0)
+	}
+	g.sums[bucket] += n
+	g.samples[bucket]++
+	if n > g.maxes[bucket] {
+		g.maxes[bucket] = n
+	}
+}
+
+// fill adds the sampled requests in flight to the timeline and returns their
+// average and maximum over the run
+func (g *InFlightGauge) fill(points []TimelinePoint) (avg float64, max int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var sum, samples int64
+	for i := range g.sums {
+		sum += g.sums[i]
+		samples += g.samples[i]
+		if g.maxes[i] > max {
+			max = g.maxes[i]
+		}
+		if i < len(points) && g.samples[i] > 0 {
+			points[i].InFlightAvg = float64(g.sums[i]) / float64(g.samples[i])
+			points[i].InFlightMax = g.maxes[i]
+		}
+	}
+	if samples > 0 {
+		avg = float64(sum) / float64(samples)
+	}
+	return avg, max
+}
+
+// limiterStats collects how long workers waited for the rate limiter and when
+// the requests were sent
+type limiterStats struct {
+	target  int // Requests per second asked for
+	workers int
+	waits   []time.Duration
+	sent    []time.Time
+}
+
+// add accounts a result if sampled; a nil limiterStats (no rate limit) ignores it
+func (l *limiterStats) add(result Result, sampled bool) {
+	if l != nil && sampled {
+		l.waits = append(l.waits, result.LimiterWait)
+		l.sent = append(l.sent, result.SentAt)
+	}
+}
+
+// thin drops every other sample, as Stats.downsample does
+func (l *limiterStats) thin() {
+	if l != nil {
+		l.waits = thinned(l.waits)
+		l.sent = thinned(l.sent)
+	}
+}
+
+// QueueingSummary separates client-side queueing from the time the server took
+type QueueingSummary struct {
+	InFlightAvg float64 // Requests in flight on average, sampled every 100ms
+	InFlightMax int64   // Most requests in flight in a sample
+
+	RateLimited bool          // Requests waited for --max-rps tokens
+	LimiterWait DurationStats // Time each request waited for the rate limiter (not part of its latency)
+}
+
+// LimiterShare returns the share of each worker's cycle (limiter wait plus
+// latency) spent waiting for the rate limiter, given the average latency
+func (q *QueueingSummary) LimiterShare(avgLatency time.Duration) float64 {
+	cycle := q.LimiterWait.Avg + avgLatency
+	if !q.RateLimited || cycle <= 0 {
+		return 0
+	}
+	return float64(q.LimiterWait.Avg) / float64(cycle)
+}
+
+// summary returns the waits for the rate limiter into q
+func (l *limiterStats) summary(q *QueueingSummary) {
+	if l != nil {
+		q.RateLimited = true
+		q.LimiterWait = NewDurationStats(l.waits)
+	}
+}
+
+// What held the throughput of a rate-limited run
+const (
+	ConstraintLimiter   = "limiter"   // The target rate was reached; the limiter set the pace
+	ConstraintWorkers   = "workers"   // Every worker was waiting on a response; -c or the server's latency capped the rate
+	ConstraintGenerator = "generator" // Workers were free but requests weren't issued fast enough
+)
+
+// rateTargetReached is the share of --max-rps above which the target counts as reached
+const rateTargetReached = 0.95
+
+// RateTargetSummary compares a --max-rps target with the rate achieved
+type RateTargetSummary struct {
+	Target   int     // Requests per second asked for
+	Achieved float64 // Requests per second sent
+	Capacity float64 // Rate the workers could sustain at the average latency (workers / avg latency)
+
+	ExpectedGap time.Duration // Time between requests at the target rate
+	Gaps        DurationStats // Time between consecutive requests across all workers
+
+	Constraint string // What held the throughput (ConstraintLimiter, ...)
+}
+
+// Percent returns the achieved rate as a percentage of the target
+func (r *RateTargetSummary) Percent() float64 {
+	return r.Achieved / float64(r.Target) * 100
+}
+
+// rateTarget compares the achieved rate with the --max-rps target (nil without
+// a rate limit or requests)
+// With one in stride requests sampled (stride > 1), the gaps between sampled
+// sends span stride requests and are divided among them
+func (l *limiterStats) rateTarget(summary *Summary, stride int) *RateTargetSummary {
+	if l == nil || summary.TotalRequests == 0 {
+		return nil
+	}
+	r := &RateTargetSummary{
+		Target:      l.target,
+		Achieved:    summary.RPS,
+		ExpectedGap: time.Second / time.Duration(l.target),
+	}
+	if summary.AvgLatency > 0 {
+		r.Capacity = float64(l.workers) / summary.AvgLatency.Seconds()
+	}
+
+	// Results arrive in completion order; gaps are between send times
+	if len(l.sent) > 1 {
+		sorted := append([]time.Time(nil), l.sent...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+		gaps := make([]time.Duration, len(sorted)-1)
+		for i := range gaps {
+			gaps[i] = sorted[i+1].Sub(sorted[i])
+			if stride > 1 {
+				gaps[i] /= time.Duration(stride)
+			}
+		}
+		r.Gaps = NewDurationStats(gaps)
+	}
+
+	switch {
+	case r.Achieved >= rateTargetReached*float64(r.Target):
+		r.Constraint = ConstraintLimiter
+	case r.Capacity < float64(r.Target)/rateTargetReached:
+		r.Constraint = ConstraintWorkers
+	default:
+		r.Constraint = ConstraintGenerator
+	}
+	return r
+}
@@ -0,0 +1,200 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// Error classes of range requests that were not answered as requested
+const (
+	ErrorClassRangeIgnored  = "range_ignored"  // A range request was answered with the full object (200)
+	ErrorClassRangeMismatch = "range_mismatch" // A 206 response covered other bytes than requested
+)
+
+// maxRangeObjects bounds the object sizes remembered, so templated URLs can't grow the cache without limit
+const maxRangeObjects = 10000
+
+// RangeRequests turns a share of the requests into single byte-range requests at
+// random offsets of the target object, as video players and download managers
+// send them, and verifies that each is answered with the requested part (206)
+type RangeRequests struct {
+	Size    int64   // Bytes per range
+	Percent float64 // Share of requests sent as range requests (0-100]
+
+	mu    sync.Mutex
+	sizes map[string]int64 // Object size per URL, learned from Content-Range
+}
+
+// NewRangeRequests creates range requests of size bytes for percent of the requests
+// Object sizes are learned from the responses, so the first range of each URL starts at 0
+func NewRangeRequests(size int64, percent float64) (*RangeRequests, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("range size must be positive")
+	}
+	if percent <= 0 || percent > 100 {
+		return nil, fmt.Errorf("range percent must be between 0 (exclusive) and 100")
+	}
+	return &RangeRequests{Size: size, Percent: percent, sizes: make(map[string]int64)}, nil
+}
+
+// byteRange is an inclusive range of byte offsets
+type byteRange struct {
+	start, end int64
+}
+
+func (b byteRange) header() string {
+	return fmt.Sprintf("bytes=%d-%d", b.start, b.end)
+}
+
+// pick returns the range to request from url, or false to request the full object
+func (r *RangeRequests) pick(url string, rng *rand.Rand) (byteRange, bool) {
+	if r == nil || (r.Percent < 100 && rng.Float64()*100 >= r.Percent) {
+		return byteRange{}, false
+	}
+	r.mu.Lock()
+	size, known := r.sizes[url]
+	r.mu.Unlock()
+	if !known || size <= r.Size {
+		return byteRange{start: 0, end: r.Size - 1}, true
+	}
+	start := rng.Int63n(size - r.Size + 1)
+	return byteRange{start: start, end: start + r.Size - 1}, true
+}
+
+// verify checks the response to a range request and remembers the object size
+// It returns the error class of a response that doesn't match the range ("" if it
+// does or failed otherwise); checkLength compares the body length as well
+func (r *RangeRequests) verify(url string, part byteRange, resp httpclient.Response, checkLength bool) string {
+	if resp.Error != nil {
+		return ""
+	}
+	start, end, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+	if ok && size >= 0 {
+		r.mu.Lock()
+		if _, known := r.sizes[url]; known || len(r.sizes) < maxRangeObjects {
+			r.sizes[url] = size
+		}
+		r.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return ErrorClassRangeIgnored
+	case resp.StatusCode != http.StatusPartialContent:
+		return ""
+	}
+	want := part
+	if size >= 0 && want.end >= size {
+		want.end = size - 1
+	}
+	if !ok || start < 0 || start != want.start || end != want.end {
+		return ErrorClassRangeMismatch
+	}
+	if checkLength && resp.BytesRead != end-start+1 {
+		return ErrorClassRangeMismatch
+	}
+	return ""
+}
+
+// parseContentRange parses "bytes START-END/SIZE" and "bytes */SIZE"; missing
+// values are -1 (e.g., SIZE "*" when the server doesn't know the object size)
+func parseContentRange(value string) (start, end, size int64, ok bool) {
+	unit, spec, found := strings.Cut(strings.TrimSpace(value), " ")
+	if !found || !strings.EqualFold(unit, "bytes") {
+		return 0, 0, 0, false
+	}
+	span, total, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	start, end, size = -1, -1, -1
+	if total != "*" {
+		if size, ok = parseOffset(total); !ok {
+			return 0, 0, 0, false
+		}
+	}
+	if span != "*" {
+		first, last, found := strings.Cut(span, "-")
+		if !found {
+			return 0, 0, 0, false
+		}
+		var okFirst, okLast bool
+		start, okFirst = parseOffset(first)
+		end, okLast = parseOffset(last)
+		if !okFirst || !okLast || end < start {
+			return 0, 0, 0, false
+		}
+	}
+	return start, end, size, true
+}
+
+// parseOffset parses a non-negative byte offset or length
+func parseOffset(s string) (int64, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil && n >= 0
+}
+
+// rangeStats splits the requests into range and full requests
+type rangeStats struct {
+	ranges              *RangeRequests
+	ranged, full        latencyGroup
+	ignored, mismatched int64
+}
+
+// add accounts a result, keeping its latency if sampled; a nil rangeStats (no
+// range requests) ignores it
+func (r *rangeStats) add(result Result, failed, sampled bool) {
+	if r == nil {
+		return
+	}
+	if !result.Range {
+		r.full.record(result.Latency, failed, sampled)
+		return
+	}
+	r.ranged.record(result.Latency, failed, sampled)
+	switch result.ErrorClass {
+	case ErrorClassRangeIgnored:
+		r.ignored++
+	case ErrorClassRangeMismatch:
+		r.mismatched++
+	}
+}
+
+// thin drops every other latency sample, as Stats.downsample does
+func (r *rangeStats) thin() {
+	if r != nil {
+		r.ranged.thin()
+		r.full.thin()
+	}
+}
+
+// RangeSummary reports the range requests apart from full requests
+type RangeSummary struct {
+	Size       int64
+	Percent    float64
+	Ranged     LatencyGroup // Range requests
+	Full       LatencyGroup // Requests for the full object (Percent < 100 only)
+	Ignored    int64        // Range requests answered with the full object (counted as failed)
+	Mismatched int64        // Partial responses with other bytes than requested (counted as failed)
+}
+
+// summary returns the range request results (nil without range requests)
+func (r *rangeStats) summary() *RangeSummary {
+	if r == nil {
+		return nil
+	}
+	return &RangeSummary{
+		Size:       r.ranges.Size,
+		Percent:    r.ranges.Percent,
+		Ranged:     r.ranged.summary(),
+		Full:       r.full.summary(),
+		Ignored:    r.ignored,
+		Mismatched: r.mismatched,
+	}
+}
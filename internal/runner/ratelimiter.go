@@ -2,6 +2,7 @@ package runner
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,8 +11,10 @@ import (
 type RateLimiter struct {
 	tokens   chan struct{}
 	interval time.Duration
+	retune   chan time.Duration // New refill intervals while the rate changes mid-run
 	ctx      context.Context
 	cancel   context.CancelFunc
+	granted  int64 // Tokens handed out (atomic)
 }
 
 // NewRateLimiter creates a new rate limiter with the specified max RPS
@@ -25,6 +28,7 @@ func NewRateLimiter(maxRPS int) *RateLimiter {
 	rl := &RateLimiter{
 		tokens:   make(chan struct{}, maxRPS), // Buffer allows burst up to maxRPS
 		interval: time.Second / time.Duration(maxRPS),
+		retune:   make(chan time.Duration),
 		ctx:      ctx,
 		cancel:   cancel,
 	}
@@ -49,6 +53,8 @@ func (rl *RateLimiter) refill() {
 		select {
 		case <-rl.ctx.Done():
 			return
+		case interval := <-rl.retune:
+			ticker.Reset(interval)
 		case <-ticker.C:
 			// Try to add a token, but don't block if bucket is full
 			select {
@@ -60,6 +66,18 @@ func (rl *RateLimiter) refill() {
 	}
 }
 
+// setRate changes the refill rate to rps tokens per second, e.g. for a burst;
+// the bucket keeps its size
+func (rl *RateLimiter) setRate(rps float64) {
+	if rl == nil || rps <= 0 {
+		return
+	}
+	select {
+	case rl.retune <- time.Duration(float64(time.Second) / rps):
+	case <-rl.ctx.Done():
+	}
+}
+
 // Wait blocks until a token is available, ensuring rate limit is respected
 // Returns false if context is cancelled
 func (rl *RateLimiter) Wait(ctx context.Context) bool {
@@ -73,14 +91,22 @@ func (rl *RateLimiter) Wait(ctx context.Context) bool {
 	case <-rl.ctx.Done():
 		return false
 	case <-rl.tokens:
+		atomic.AddInt64(&rl.granted, 1)
 		return true // Token acquired, proceed
 	}
 }
 
+// Granted returns the number of tokens handed out (0 without rate limiting)
+func (rl *RateLimiter) Granted() int64 {
+	if rl == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&rl.granted)
+}
+
 // Stop stops the rate limiter
 func (rl *RateLimiter) Stop() {
 	if rl != nil {
 		rl.cancel()
 	}
 }
-
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// LoadRawRequest reads a literal HTTP/1.x request, as Burp or ZAP export it,
+// into a target that sends it byte for byte to the scheme and address of url
+// (its path and query are not used; the request line and Host header are the
+// file's own)
+// A head with bare LF line endings, as an editor may leave it, is sent with
+// CRLF, and a missing blank line after it is added; the body is sent as is
+func LoadRawRequest(path, url string) (Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Target{}, fmt.Errorf("failed to read raw request: %w", err)
+	}
+	raw := string(data)
+
+	// The head ends at the first blank line, whichever line endings it uses
+	head, body := strings.TrimRight(raw, "\r\n"), ""
+	crlf, lf := strings.Index(raw, "\r\n\r\n"), strings.Index(raw, "\n\n")
+	switch {
+	case crlf >= 0 && (lf < 0 || crlf < lf):
+		head, body = raw[:crlf], raw[crlf+4:]
+	case lf >= 0:
+		head, body = raw[:lf], raw[lf+2:]
+	}
+	lines := strings.Split(strings.ReplaceAll(head, "\r\n", "\n"), "\n")
+
+	parts := strings.Split(lines[0], " ")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || (parts[2] != "HTTP/1.1" && parts[2] != "HTTP/1.0") {
+		return Target{}, fmt.Errorf("raw request %s: %q is not an HTTP/1.x request line (e.g. GET /path HTTP/1.1)", path, lines[0])
+	}
+	return Target{
+		URL:      url,
+		Method:   parts[0],
+		Body:     strings.Join(lines, "\r\n") + "\r\n\r\n" + body,
+		Protocol: httpclient.ProtocolRaw,
+	}, nil
+}
+
+// fitContentLength sets the Content-Length of a raw request rendered from
+// template to the length of the rendered body if the template's body has
+// template actions; a body sent as written keeps its length, right or wrong
+func fitContentLength(template, rendered string) string {
+	if _, body, _ := strings.Cut(template, "\r\n\r\n"); !isTemplate(body) {
+		return rendered
+	}
+	head, body, ok := strings.Cut(rendered, "\r\n\r\n")
+	if !ok {
+		return rendered
+	}
+	lines := strings.Split(head, "\r\n")
+	i := contentLengthLine(lines)
+	if i < 0 {
+		return rendered
+	}
+	name, _, _ := strings.Cut(lines[i], ":")
+	lines[i] = name + ": " + strconv.Itoa(len(body))
+	return strings.Join(lines, "\r\n") + "\r\n\r\n" + body
+}
+
+// contentLengthLine returns the index of the one Content-Length header among
+// the lines of a request head (-1 if there is none or more than one)
+func contentLengthLine(lines []string) int {
+	found := -1
+	for i, line := range lines[1:] {
+		name, _, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			continue
+		}
+		if found >= 0 {
+			return -1
+		}
+		found = i + 1
+	}
+	return found
+}
@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Per-request record formats
+const (
+	RecordJSONL   = "jsonl"
+	RecordParquet = "parquet"
+)
+
+// parquetRowGroupSize bounds the rows buffered in memory before a row group is written
+const parquetRowGroupSize = 32 << 20
+
+// RecordFormatFor returns the record format for a file name (.parquet = Parquet, otherwise JSONL)
+func RecordFormatFor(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".parquet") {
+		return RecordParquet
+	}
+	return RecordJSONL
+}
+
+// requestRecord is the per-request record written to the record file
+type requestRecord struct {
+	Timestamp     int64  `json:"timestamp" parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MICROS"` // Unix microseconds when the request was sent
+	Method        string `json:"method" parquet:"name=method, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	URL           string `json:"url" parquet:"name=url, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	StatusCode    int32  `json:"status_code" parquet:"name=status_code, type=INT32"`
+	LatencyUs     int64  `json:"latency_us" parquet:"name=latency_us, type=INT64"`
+	TTFBUs        int64  `json:"ttfb_us" parquet:"name=ttfb_us, type=INT64"`
+	BytesSent     int64  `json:"bytes_sent" parquet:"name=bytes_sent, type=INT64"`
+	BytesReceived int64  `json:"bytes_received" parquet:"name=bytes_received, type=INT64"`
+	ErrorClass    string `json:"error_class,omitempty" parquet:"name=error_class, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	TraceID       string `json:"trace_id,omitempty" parquet:"name=trace_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// newRequestRecord converts a result into a record
+func newRequestRecord(r Result) requestRecord {
+	return requestRecord{
+		Timestamp:     r.SentAt.UnixMicro(),
+		Method:        r.Method,
+		URL:           r.URL,
+		StatusCode:    int32(r.StatusCode),
+		LatencyUs:     r.Latency.Microseconds(),
+		TTFBUs:        r.TTFB.Microseconds(),
+		BytesSent:     r.BytesSent,
+		BytesReceived: r.BytesRead,
+		ErrorClass:    r.ErrorClass,
+		TraceID:       r.TraceID,
+	}
+}
+
+// Recorder writes one record per request to a file
+// With a Rotation the file is split into segments; Parquet segments are each a
+// complete file, rotated at row group boundaries
+// It is safe for concurrent use by the stats collectors of sharded workers
+type Recorder struct {
+	mu      sync.Mutex
+	path    string
+	format  string
+	file    *rotatingFile
+	jsonl   *bufio.Writer
+	parquet *writer.ParquetWriter
+	records int64
+	segment int64 // Records in the current segment
+	stopped bool  // Ended early by stop; the file is already finished
+	err     error // First write error; recording stops after it
+}
+
+// NewRecorder creates the record file in the given format (jsonl or parquet),
+// rotated as given (zero Rotation = never)
+func NewRecorder(path, format string, rotation Rotation) (*Recorder, error) {
+	if format != RecordJSONL && format != RecordParquet {
+		return nil, fmt.Errorf("invalid record format %q (available: %s, %s)", format, RecordJSONL, RecordParquet)
+	}
+	file, err := openRotatingFile(path, rotation, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record file: %w", err)
+	}
+
+	r := &Recorder{path: path, format: format, file: file}
+	if format == RecordParquet {
+		if r.err = r.startParquet(); r.err != nil {
+			file.Close()
+			return nil, r.err
+		}
+	} else {
+		r.jsonl = bufio.NewWriterSize(file, 256<<10)
+	}
+	return r, nil
+}
+
+// startParquet starts a Parquet file in the current segment
+func (r *Recorder) startParquet() error {
+	pw, err := writer.NewParquetWriterFromWriter(r.file, new(requestRecord), 4)
+	if err != nil {
+		return fmt.Errorf("failed to create Parquet writer: %w", err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	if limit := r.file.rotation.MaxBytes; limit > 0 && limit < pw.RowGroupSize {
+		// Row groups are only written out whole, so keep them within a segment
+		pw.RowGroupSize = limit
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	r.parquet = pw
+	return nil
+}
+
+// record writes a result; after the first error further results are dropped
+func (r *Recorder) record(result Result) {
+	if r == nil {
+		return
+	}
+	rec := newRequestRecord(result)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.parquet != nil {
+		if r.segment > 0 && r.file.due(0) {
+			r.err = r.rotate()
+		}
+		if r.err == nil {
+			r.err = r.parquet.Write(rec)
+		}
+	} else {
+		var line []byte
+		if line, r.err = json.Marshal(rec); r.err == nil {
+			line = append(line, '\n')
+			if r.segment > 0 && r.file.due(int64(r.jsonl.Buffered()+len(line))) {
+				r.err = r.rotate()
+			}
+			if r.err == nil {
+				_, r.err = r.jsonl.Write(line)
+			}
+		}
+	}
+	if r.err == nil {
+		r.records++
+		r.segment++
+	}
+}
+
+// finish writes out the buffered records: the Parquet footer or the JSONL buffer
+func (r *Recorder) finish() error {
+	if r.parquet != nil {
+		return r.parquet.WriteStop()
+	}
+	return r.jsonl.Flush()
+}
+
+// stop ends the recording early for reason, e.g. to save memory; the records
+// so far are written out and kept
+func (r *Recorder) stop(reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = errors.New(reason)
+	if err := r.finish(); err != nil {
+		r.err = fmt.Errorf("%s; %w", reason, err)
+	}
+	r.stopped = true
+}
+
+// rotate finishes the current segment and starts the next
+func (r *Recorder) rotate() error {
+	err := r.finish()
+	if err == nil {
+		err = r.file.rotate()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rotate record file: %w", err)
+	}
+	r.segment = 0
+	if r.parquet != nil {
+		return r.startParquet()
+	}
+	return nil
+}
+
+// Close flushes and closes the record file and returns a summary of the recording
+func (r *Recorder) Close() *RecordSummary {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var err error
+	if !r.stopped {
+		err = r.finish()
+	}
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	if r.err == nil {
+		r.err = err
+	}
+
+	summary := &RecordSummary{Path: r.path, Format: r.format, Records: r.records, Rotated: r.file.rotated, Stopped: r.stopped}
+	if r.err != nil {
+		summary.Error = r.err.Error()
+	}
+	return summary
+}
+
+// RecordSummary describes the per-request record file of a run
+type RecordSummary struct {
+	Path    string
+	Format  string
+	Records int64
+	Rotated int    // Segments moved aside by rotation
+	Error   string // Write error that ended the recording early ("" = none)
+	Stopped bool   // Ended early on purpose (Error has the reason), e.g. shed to save memory
+}
@@ -0,0 +1,278 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resource metric sources, picked from what the scraped endpoint exposes
+const (
+	ResourceSourceNode    = "node_exporter" // Host CPU and memory (node_cpu_seconds_total, node_memory_*)
+	ResourceSourceProcess = "process"       // Process CPU and memory (process_cpu_seconds_total, process_resident_memory_bytes)
+)
+
+// ResourceSample is one scrape of the target's resource usage
+type ResourceSample struct {
+	Offset      time.Duration // Time since the start of the test
+	CPUPercent  float64       // Host CPU busy % (node_exporter) or process CPU % of one core (process)
+	MemoryBytes int64         // Host memory in use (node_exporter) or process resident memory (process)
+}
+
+// ResourceSummary holds the resource usage series scraped during the run
+type ResourceSummary struct {
+	URL     string
+	Source  string // ResourceSourceNode or ResourceSourceProcess ("" if no scrape succeeded)
+	Samples []ResourceSample
+	Errors  int64 // Failed scrapes
+}
+
+// CPU returns the average and maximum CPU usage across samples
+func (r *ResourceSummary) CPU() (avg, max float64) {
+	if len(r.Samples) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, s := range r.Samples {
+		sum += s.CPUPercent
+		if s.CPUPercent > max {
+			max = s.CPUPercent
+		}
+	}
+	return sum / float64(len(r.Samples)), max
+}
+
+// Memory returns the first, maximum and last memory usage across samples
+func (r *ResourceSummary) Memory() (first, max, last int64) {
+	if len(r.Samples) == 0 {
+		return 0, 0, 0
+	}
+	for _, s := range r.Samples {
+		if s.MemoryBytes > max {
+			max = s.MemoryBytes
+		}
+	}
+	return r.Samples[0].MemoryBytes, max, r.Samples[len(r.Samples)-1].MemoryBytes
+}
+
+// resourceReading holds the raw values of one scrape
+type resourceReading struct {
+	at       time.Time
+	cpuBusy  float64 // Busy CPU seconds (node: all modes but idle and iowait; process: CPU seconds)
+	cpuTotal float64 // Total CPU seconds (node only)
+	memory   int64
+}
+
+// ResourceScraper scrapes a Prometheus text endpoint at intervals during the run
+type ResourceScraper struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+
+	mu      sync.Mutex
+	start   time.Time
+	source  string
+	prev    *resourceReading
+	samples []ResourceSample
+	errors  int64
+}
+
+// NewResourceScraper creates a scraper for a Prometheus metrics endpoint
+func NewResourceScraper(url string, interval time.Duration) *ResourceScraper {
+	timeout := interval
+	if timeout > 5*time.Second {
+		timeout = 5 * time.Second
+	}
+	return &ResourceScraper{url: url, interval: interval, client: &http.Client{Timeout: timeout}}
+}
+
+// Run scrapes once as a baseline and then every interval until ctx is cancelled
+func (s *ResourceScraper) Run(ctx context.Context, start time.Time) {
+	s.mu.Lock()
+	s.start = start
+	s.mu.Unlock()
+
+	s.scrape(ctx)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.scrape(ctx)
+		}
+	}
+}
+
+// scrape fetches the endpoint and records a sample relative to the previous reading
+func (s *ResourceScraper) scrape(ctx context.Context) {
+	reading, source, err := s.fetch(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.errors++
+		return
+	}
+	s.source = source
+
+	// CPU usage is a rate, so the first reading only serves as the baseline
+	if prev := s.prev; prev != nil {
+		sample := ResourceSample{Offset: reading.at.Sub(s.start), MemoryBytes: reading.memory}
+		busy := reading.cpuBusy - prev.cpuBusy
+		if source == ResourceSourceNode {
+			if total := reading.cpuTotal - prev.cpuTotal; total > 0 {
+				sample.CPUPercent = busy / total * 100
+			}
+		} else if elapsed := reading.at.Sub(prev.at).Seconds(); elapsed > 0 {
+			sample.CPUPercent = busy / elapsed * 100
+		}
+		s.samples = append(s.samples, sample)
+	}
+	s.prev = &reading
+}
+
+// fetch scrapes the endpoint and extracts CPU and memory readings
+func (s *ResourceScraper) fetch(ctx context.Context) (resourceReading, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return resourceReading{}, "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return resourceReading{}, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return resourceReading{}, "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	reading := resourceReading{at: time.Now()}
+	var memTotal, memAvailable, processCPU, processMemory float64
+	var hasNode, hasProcess bool
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		name, labels, value, ok := parseMetricLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		switch name {
+		case "node_cpu_seconds_total":
+			hasNode = true
+			reading.cpuTotal += value
+			if mode := labels["mode"]; mode != "idle" && mode != "iowait" {
+				reading.cpuBusy += value
+			}
+		case "node_memory_MemTotal_bytes":
+			memTotal = value
+		case "node_memory_MemAvailable_bytes":
+			memAvailable = value
+		case "process_cpu_seconds_total":
+			hasProcess = true
+			processCPU = value
+		case "process_resident_memory_bytes":
+			processMemory = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return resourceReading{}, "", err
+	}
+
+	switch {
+	case hasNode:
+		reading.memory = int64(memTotal - memAvailable)
+		return reading, ResourceSourceNode, nil
+	case hasProcess:
+		reading.cpuBusy = processCPU
+		reading.memory = int64(processMemory)
+		return reading, ResourceSourceProcess, nil
+	}
+	return resourceReading{}, "", fmt.Errorf("no node_cpu_seconds_total or process_cpu_seconds_total metrics found")
+}
+
+// Summary returns the scraped series
+func (s *ResourceScraper) Summary() *ResourceSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &ResourceSummary{
+		URL:     s.url,
+		Source:  s.source,
+		Samples: append([]ResourceSample(nil), s.samples...),
+		Errors:  s.errors,
+	}
+}
+
+// parseMetricLine parses a Prometheus text exposition line: name{labels} value [timestamp]
+// Comments, blank lines and unparseable values are skipped
+func parseMetricLine(line string) (name string, labels map[string]string, value float64, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", nil, 0, false
+	}
+
+	rest := line
+	if i := strings.IndexAny(line, "{ "); i >= 0 && line[i] == '{' {
+		end := strings.LastIndexByte(line, '}')
+		if end < i {
+			return "", nil, 0, false
+		}
+		name = line[:i]
+		labels = parseMetricLabels(line[i+1 : end])
+		rest = line[end+1:]
+	} else if i >= 0 {
+		name = line[:i]
+		rest = line[i:]
+	} else {
+		return "", nil, 0, false
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	return name, labels, value, true
+}
+
+// parseMetricLabels parses `a="x",b="y"` label pairs
+func parseMetricLabels(s string) map[string]string {
+	labels := make(map[string]string)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			break
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s[:eq]), ","))
+		s = s[eq+2:]
+
+		var value strings.Builder
+		i := 0
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			value.WriteByte(s[i])
+		}
+		labels[key] = value.String()
+		if i >= len(s) {
+			break
+		}
+		s = s[i+1:]
+	}
+	return labels
+}
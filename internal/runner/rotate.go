@@ -0,0 +1,200 @@
+package runner
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultRotateKeep is how many rotated segments of an output file are kept
+const DefaultRotateKeep = 5
+
+// Rotation splits an output file of a long run into segments so it can't fill
+// the disk: the file is moved to <path>.1 (older segments shifting to .2, .3, ...)
+// and a new one started
+type Rotation struct {
+	MaxBytes int64         // Rotate when the file would grow past this size (0 = no size limit)
+	Interval time.Duration // Rotate when the file is this old (0 = no time limit)
+	Keep     int           // Rotated segments kept; older ones are deleted (0 = keep all)
+	Gzip     bool          // Compress rotated segments to <path>.N.gz
+}
+
+// rotatingFile is an output file that rotates when Rotation says so; callers
+// check due before each write and call rotate, so records never straddle segments
+// Rotated segments are compressed in the background, one at a time
+type rotatingFile struct {
+	path     string
+	rotation Rotation
+	file     *os.File
+	size     int64     // Bytes in the current segment
+	opened   time.Time // When the current segment was started
+	rotated  int
+
+	compressing sync.WaitGroup
+	mu          sync.Mutex
+	compressErr error // First error compressing a segment
+}
+
+// openRotatingFile creates the file and its directory, or appends to an existing
+// file if appending is set
+func openRotatingFile(path string, rotation Rotation, appending bool) (*rotatingFile, error) {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	f := &rotatingFile{path: path, rotation: rotation}
+	if err := f.open(appending); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open starts a segment at f.path
+func (f *rotatingFile) open(appending bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(f.path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), time.Now()
+	return nil
+}
+
+// Write writes to the current segment
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether the segment should be rotated before pending more bytes
+// are written (including any the caller still buffers); callers don't ask for
+// empty segments
+func (f *rotatingFile) due(pending int64) bool {
+	r := f.rotation
+	return (r.MaxBytes > 0 && f.size+pending > r.MaxBytes) ||
+		(r.Interval > 0 && time.Since(f.opened) >= r.Interval)
+}
+
+// rotate closes the current segment, moves it to <path>.1 and starts a new one
+// Callers flush their buffers first
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	// Wait for the last segment's compression, since its name is about to shift
+	f.compressing.Wait()
+	if err := f.shift(); err != nil {
+		return err
+	}
+	first := f.segment(1)
+	if err := os.Rename(f.path, first); err != nil {
+		return err
+	}
+	f.rotated++
+	if f.rotation.Gzip {
+		f.compressing.Add(1)
+		go func() {
+			defer f.compressing.Done()
+			if err := gzipFile(first); err != nil {
+				f.mu.Lock()
+				if f.compressErr == nil {
+					f.compressErr = fmt.Errorf("failed to compress %s: %w", first, err)
+				}
+				f.mu.Unlock()
+			}
+		}()
+	}
+	return f.open(false)
+}
+
+// segment returns the name of rotated segment n (uncompressed)
+func (f *rotatingFile) segment(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// shift moves the rotated segments up one number to make room for .1, deleting
+// those past Rotation.Keep
+func (f *rotatingFile) shift() error {
+	last := 1
+	for fileExists(f.segment(last)) || fileExists(f.segment(last)+".gz") {
+		last++
+	}
+	// last is the first free number; segments 1..last-1 move up
+	for n := last - 1; n >= 1; n-- {
+		for _, suffix := range []string{"", ".gz"} {
+			from := f.segment(n) + suffix
+			if !fileExists(from) {
+				continue
+			}
+			if keep := f.rotation.Keep; keep > 0 && n >= keep {
+				if err := os.Remove(from); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := os.Rename(from, f.segment(n+1)+suffix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the current segment and waits for compressions to finish
+func (f *rotatingFile) Close() error {
+	err := f.file.Close()
+	f.compressing.Wait()
+	if err == nil {
+		err = f.compressErr
+	}
+	return err
+}
+
+// fileExists reports whether a file exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// gzipFile compresses path to path.gz and removes path
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,220 @@
+package runner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rpsTraceLag is how far below the trace's rate a second of the run may fall
+// before it counts as behind
+const rpsTraceLag = 0.1
+
+// rpsTraceLayouts are the timestamp layouts accepted besides numbers and offsets
+var rpsTraceLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04"}
+
+// RPSTracePoint is one sample of a traffic trace: the rate from At until the next sample
+type RPSTracePoint struct {
+	At  time.Duration // Offset from the first sample
+	RPS float64
+}
+
+// RPSTrace is a traffic shape recorded in production, replayed as the arrival
+// rate of the run (open model)
+type RPSTrace struct {
+	File   string
+	Points []RPSTracePoint
+	Scale  float64 // Rate multiplier (1 = as recorded)
+	Speed  float64 // Time compression (2 = the trace plays in half its time)
+}
+
+// LoadRPSTrace reads a CSV of timestamp,rps rows, e.g. exported from a metrics
+// dashboard; a header line is skipped
+// Timestamps may be RFC 3339 or "2006-01-02 15:04:05" times, Unix seconds or
+// milliseconds, or offsets such as 90s or 1m30s; they must increase
+func LoadRPSTrace(path string, scale, speed float64) (*RPSTrace, error) {
+	if scale <= 0 {
+		return nil, fmt.Errorf("trace scale must be greater than 0")
+	}
+	if speed <= 0 {
+		return nil, fmt.Errorf("trace speed must be greater than 0")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RPS trace: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	trace := &RPSTrace{File: path, Scale: scale, Speed: speed}
+	var first time.Duration
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RPS trace %s: %w", path, err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("RPS trace %s line %d: expected timestamp,rps", path, line)
+		}
+		rps, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			if line == 1 {
+				continue // Header
+			}
+			return nil, fmt.Errorf("RPS trace %s line %d: invalid rate %q", path, line, record[1])
+		}
+		if rps < 0 || math.IsNaN(rps) || math.IsInf(rps, 0) {
+			return nil, fmt.Errorf("RPS trace %s line %d: invalid rate %q", path, line, record[1])
+		}
+		at, err := parseTraceTimestamp(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("RPS trace %s line %d: %w", path, line, err)
+		}
+
+		if len(trace.Points) == 0 {
+			first = at
+		}
+		at -= first
+		if n := len(trace.Points); n > 0 && at <= trace.Points[n-1].At {
+			return nil, fmt.Errorf("RPS trace %s line %d: timestamps must increase", path, line)
+		}
+		trace.Points = append(trace.Points, RPSTracePoint{At: at, RPS: rps})
+	}
+	if len(trace.Points) < 2 {
+		return nil, fmt.Errorf("RPS trace %s needs at least two samples", path)
+	}
+	if trace.Peak() == 0 {
+		return nil, fmt.Errorf("RPS trace %s has no traffic", path)
+	}
+	return trace, nil
+}
+
+// parseTraceTimestamp returns a timestamp as an offset from the Unix epoch, or
+// from the start of the trace for relative offsets
+func parseTraceTimestamp(s string) (time.Duration, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if n >= 1e11 {
+			// Unix milliseconds; seconds this large are thousands of years away
+			return time.Duration(n * float64(time.Millisecond)), nil
+		}
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	for _, layout := range rpsTraceLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Duration(t.UnixNano()), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid timestamp %q (expected RFC 3339, Unix seconds or milliseconds, or an offset such as 90s)", s)
+}
+
+// Length returns how long the trace plays: the last sample lasts as long as the
+// one before it
+func (t *RPSTrace) Length() time.Duration {
+	n := len(t.Points)
+	last := t.Points[n-1].At
+	return time.Duration(float64(last+last-t.Points[n-2].At) / t.Speed)
+}
+
+// Peak returns the highest rate of the trace, scaled
+func (t *RPSTrace) Peak() float64 {
+	peak := 0.0
+	for _, p := range t.Points {
+		peak = math.Max(peak, p.RPS)
+	}
+	return peak * t.Scale
+}
+
+// steps returns the trace as arrival rate steps of a 1 request/s base rate
+func (t *RPSTrace) steps() []rateStep {
+	steps := make([]rateStep, len(t.Points))
+	for i, p := range t.Points {
+		steps[i] = rateStep{At: time.Duration(float64(p.At) / t.Speed), Factor: p.RPS * t.Scale}
+	}
+	return steps
+}
+
+// stepRate returns the rate of the steps of a 1 request/s base rate at offset;
+// the last step holds until the end
+func stepRate(steps []rateStep, offset time.Duration) float64 {
+	rate := 1.0
+	for _, step := range steps {
+		if step.At > offset {
+			break
+		}
+		rate = step.Factor
+	}
+	return rate
+}
+
+// RPSTraceSummary reports how closely the run followed the trace
+type RPSTraceSummary struct {
+	File   string
+	Points int
+	Scale  float64
+	Speed  float64
+	Peak   float64 // Highest rate the trace asked for during the run, scaled
+	Mean   float64 // Rate the trace asked for on average during the run
+
+	Expected int64 // Requests the trace asked for during the run
+	Sent     int64 // Requests completed
+
+	Behind      int           // Seconds whose rate was more than 10% below the trace's
+	WorstAt     time.Duration // Second furthest below the trace (valid if Behind > 0)
+	WorstTarget float64       // The trace's rate in that second
+	WorstRPS    float64       // The run's rate in that second
+}
+
+// summary compares the run's timeline over duration with the rate steps it
+// followed: the trace's, changed by any chaos events
+func (t *RPSTrace) summary(steps []rateStep, timeline []TimelinePoint, duration time.Duration, sent int64) *RPSTraceSummary {
+	if t == nil {
+		return nil
+	}
+	s := &RPSTraceSummary{File: t.File, Points: len(t.Points), Scale: t.Scale, Speed: t.Speed, Sent: sent}
+
+	// The rate only changes at the steps, so integrate it between them
+	var expected float64
+	offset := time.Duration(0)
+	for _, step := range append(steps[1:], rateStep{At: duration}) {
+		end := min(step.At, duration)
+		if end <= offset {
+			continue
+		}
+		rate := stepRate(steps, offset)
+		expected += rate * (end - offset).Seconds()
+		s.Peak = math.Max(s.Peak, rate)
+		offset = end
+	}
+	s.Expected = int64(expected)
+	s.Mean = expected / duration.Seconds()
+
+	worst := 0.0
+	for _, point := range timeline {
+		target := stepRate(steps, point.Offset)
+		if target == 0 || point.RPS >= target*(1-rpsTraceLag) {
+			continue
+		}
+		s.Behind++
+		if shortfall := 1 - point.RPS/target; shortfall > worst {
+			worst = shortfall
+			s.WorstAt, s.WorstTarget, s.WorstRPS = point.Offset, target, point.RPS
+		}
+	}
+	return s
+}
@@ -0,0 +1,19 @@
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// RunIDHeader is the header carrying the run ID when WorkerOptions.RunID is set
+const RunIDHeader = "X-G0-Run-ID"
+
+// NewRunID returns an ID for a run: its UTC start time, so IDs sort by time,
+// and a random suffix that tells apart runs started in the same second
+// (e.g., 20240315-143000-9f2c4a)
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
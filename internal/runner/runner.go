@@ -3,23 +3,221 @@ package runner
 import (
 	"context"
 	"fmt"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/calummacc/g0/internal/clock"
 	"github.com/calummacc/g0/internal/httpclient"
 )
 
 // Config holds the configuration for a load test
 type Config struct {
-	URLs        []string // URLs to test (supports multiple endpoints)
+	URLs        []string    // URLs to test (supports multiple endpoints)
+	Targets     []Target    // Targets with per-target overrides (combined with URLs)
+	URLStrategy URLStrategy // How workers pick targets ("" = round-robin)
+
+	// TargetOutages take targets out of rotation for part of the run, to see how
+	// the remaining targets cope with a failover
+	TargetOutages []TargetOutage
+
+	// Chaos bursts and pauses change the load for parts of the run
+	Chaos []ChaosEvent
+
+	// RPSTrace replays a traffic shape recorded in production as the arrival rate
+	// (open model; nil = none)
+	RPSTrace *RPSTrace
+
 	Concurrency int
 	Duration    time.Duration
 	Method      string
 	Body        string
 	Headers     map[string]string
-	MaxRPS      int // Maximum requests per second (0 = no limit)
+	Protocol    string // HTTP version forced on targets without their own (httpclient.ProtocolHTTP10, ...; "" = negotiate)
+	MaxRPS      int    // Maximum requests per second (0 = no limit)
+	ArrivalRate int    // Start this many requests per second however long they take (open model; 0 = workers send back to back)
+	CacheBust   bool   // Append a unique query parameter to every request
+	Conditional bool   // Replay ETag/Last-Modified as If-None-Match/If-Modified-Since
+
+	AcceptEncoding string // Accept-Encoding to request (e.g., "gzip, br"); enables compression metrics
+	CompressBody   string // Content-Encoding applied to request bodies (e.g., "gzip"; "" = none)
+
+	BodySource httpclient.BodySource // Streams the request body with chunked encoding (overrides Body)
+	BodyRate   int64                 // Upload rate limit for streamed bodies in bytes per second (0 = unlimited)
+
+	// ExpectContinue sends request bodies with Expect: 100-continue, waiting up to
+	// this long for the interim response before sending the body anyway (0 = off)
+	ExpectContinue time.Duration
+
+	MaxBodyBytes int64 // Stop reading each response body after this many bytes (0 = read all)
+	SkipBody     bool  // Discard response bodies unread (latency covers headers only)
+
+	// MaxResponseBytes aborts reading a response body, on the wire or decoded,
+	// past this many bytes and fails the request as response_too_large (0 = no limit)
+	MaxResponseBytes int64
+
+	// ReadRate and ReadDelay make workers read response bodies like slow clients:
+	// at most ReadRate bytes per second, pausing ReadDelay before each chunk
+	ReadRate  int64
+	ReadDelay time.Duration
+
+	// RequestTimeout is a per-request deadline; when set it replaces the default
+	// client-level timeout so requests may run longer or shorter than 30s
+	RequestTimeout time.Duration
+
+	// TimeoutCandidates are client timeouts the latencies are checked against, to
+	// pick one from measured latencies (nil = DefaultTimeoutCandidates)
+	TimeoutCandidates []time.Duration
+
+	// DNSServer resolves host names through this server (host:port) instead of the
+	// system resolver, e.g. to test against a pre-production DNS view ("" = system)
+	DNSServer string
+
+	// Grace lets requests still in flight when Duration expires finish and be recorded;
+	// requests still running after the grace period are recorded as cancelled at deadline
+	Grace time.Duration
+
+	// Seed makes randomized behavior (e.g., template randInt/randString) reproducible
+	// 0 picks a random seed, which is recorded in Summary.Seed
+	Seed int64
+
+	// RunID identifies the run in its results, metrics and (with RunIDHeader) its
+	// requests, so they can be correlated with server logs ("" = NewRunID)
+	RunID       string
+	RunIDHeader bool // Send X-G0-Run-ID with the run ID on every request
+
+	Data *DataFeeder // CSV rows exposed to templates as {{.column}} (nil = none)
+
+	// HashKey is the data column whose value picks the target of each request
+	// with the hash URL strategy, so the same key always goes to the same target
+	HashKey string
+
+	// PayloadSize is the value of {{size}} in templates, in bytes; g0 sweep sets
+	// it for each stage (0 = not sweeping)
+	PayloadSize int64
+
+	// Created records the IDs of resources created by the test, then writes them to
+	// a file and/or deletes them after the test (nil = none)
+	Created      *ResourceTracker
+	WorkerHeader bool // Send X-G0-Worker with the worker ID on every request
+
+	// IdempotencyKey sends a unique Idempotency-Key header with every request, as
+	// clients of payment and other critical APIs do
+	IdempotencyKey bool
+
+	Trace    *TracePropagation // Trace context headers sent with every request (nil = none)
+	ClientIP *ClientIPSpoofer  // Synthetic client address header (nil = none)
+	Canary   *Canary           // Share of the requests sent to a canary target, compared in the summary (nil = none)
+	Mirror   *Mirror           // Target every request is duplicated to (nil = none)
+
+	// HealthCheck is polled before and during the run (nil = none); the test does
+	// not start if the first check fails
+	HealthCheck *HealthCheck
+
+	// MetricsURL is a Prometheus endpoint on the target (e.g., node_exporter) scraped
+	// every MetricsInterval for CPU and memory usage ("" = none)
+	MetricsURL      string
+	MetricsInterval time.Duration
+
+	SLOs []SLO // Objectives reported with the percentage within budget and error-budget burn
+
+	Schema *SchemaCheck // Validates a sample of successful response bodies (nil = none)
+
+	// ExpectBodySHA256 fails successful responses whose body matches none of these
+	// digests, catching truncated or corrupted responses (nil = bodies are not verified)
+	ExpectBodySHA256 BodyHashes
+
+	// Auth sends a bearer token from a token endpoint with every request and has
+	// a share of the workers re-authenticate periodically (nil = no auth)
+	Auth *AuthRefresh
+
+	// HTTPAuth answers the target's authentication challenges, e.g. Digest, NTLM
+	// or Kerberos (Negotiate) for legacy intranet services (nil = none)
+	HTTPAuth httpclient.Authenticator
+
+	// ClientCerts presents a different client certificate per worker or request
+	// to servers requiring mutual TLS (nil = none)
+	ClientCerts *ClientCertPool
+
+	// ProxyProtocol prepends a PROXY protocol header to each new connection, for
+	// targets behind PROXY-protocol-aware load balancers (nil = none)
+	ProxyProtocol *httpclient.ProxyProtocol
+
+	// SNI presents a different server name from a list with each request, against
+	// the same address, to load multi-tenant TLS termination (nil = none)
+	SNI *SNIList
+
+	// DisableTLSResumption makes every new connection do a full TLS handshake,
+	// for comparison with resumed ones
+	DisableTLSResumption bool
+
+	// RequireOCSPStaple fails the requests of connections whose server stapled no
+	// OCSP response to its certificate
+	RequireOCSPStaple bool
+
+	// BodyCardinality hashes successful response bodies and reports how many
+	// distinct ones each target returned
+	BodyCardinality bool
+
+	Ranges *RangeRequests // Share of the requests sent as random byte-range requests (nil = none)
+
+	CaptureHeaders []string // Response headers whose value distribution is reported (canonical names)
+
+	Schedule *StartSchedule // Wall-clock start the caller waited for, reported in the summary (nil = none)
+
+	// PrometheusListen serves live run statistics for Prometheus on this address ("" = off)
+	PrometheusListen string
+
+	// RecordFile receives one record per request in RecordFormat (jsonl or parquet;
+	// "" = no recording), rotated as RecordRotation says
+	RecordFile     string
+	RecordFormat   string
+	RecordRotation Rotation
+
+	// ErrorLog receives every failed request as NDJSON, rotated as
+	// ErrorLogRotation says ("" = no error log)
+	ErrorLog         string
+	ErrorLogRotation Rotation
+
+	// MaxMemory is g0's memory budget in bytes: nearing it, the per-request record
+	// is stopped and latency samples are thinned instead of the process being
+	// OOM-killed (0 = no budget)
+	MaxMemory int64
+
+	// ColdRequests reports the first N requests of each worker separately from the
+	// steady state after them (0 = off)
+	ColdRequests int
+
+	// ChurnRate opens and closes this many extra connections per second to the first
+	// target alongside the load (0 = off)
+	ChurnRate int
+
+	// Users multiplexes this many virtual users over Concurrency workers, each user
+	// pausing ThinkTime between its requests (0 = every worker sends back to back)
+	Users     int
+	ThinkTime time.Duration
+
+	// ShardWorkers splits the workers into one shard per P (GOMAXPROCS), each with
+	// its own connection pool and results collector, to cut contention at extreme rates
+	ShardWorkers bool
+
+	// Audit reconciles the request counts of the rate limiter, the workers, the stats
+	// collectors and the record file; AuditCounterHeader adds the server's running
+	// request count read from this response header ("" = none)
+	Audit              bool
+	AuditCounterHeader string
+
+	// Clock times the requests and the statistics (nil = the system's monotonic
+	// clock); a manual clock makes the measured durations exact
+	Clock clock.Clock
 }
 
+// slowClientReadBuffer is the socket receive buffer of throttled clients; the OS
+// default can absorb megabytes, hiding the slow reads from the server
+const slowClientReadBuffer = 64 << 10
+
 // RunResult contains both the stats instance (for progress monitoring) and the final summary
 type RunResult struct {
 	Stats   *Stats
@@ -43,26 +241,222 @@ func RunWithStats(config Config) (*RunResult, error) {
 
 // RunWithStatsAndChannel executes a load test and optionally sends stats instance to a channel when created
 func RunWithStatsAndChannel(config Config, statsChan chan<- *Stats) (*RunResult, error) {
+	return RunWithContext(context.Background(), config, statsChan)
+}
+
+// RunWithContext executes a load test that stops early if parent is cancelled (e.g., on Ctrl+C)
+// The partial results are still returned and the summary is marked as aborted
+func RunWithContext(parent context.Context, config Config, statsChan chan<- *Stats) (*RunResult, error) {
+	// Combine plain URLs and targets, filling overrides from the global request template
+	targets := make([]Target, 0, len(config.URLs)+len(config.Targets))
+	for _, u := range config.URLs {
+		targets = append(targets, Target{URL: u}.resolve(config.Method, config.Body, config.Protocol, config.Headers))
+	}
+	for _, t := range config.Targets {
+		targets = append(targets, t.resolve(config.Method, config.Body, config.Protocol, config.Headers))
+	}
+
 	// Validate URLs
-	if len(config.URLs) == 0 {
+	if len(targets) == 0 {
 		return nil, fmt.Errorf("at least one URL is required")
 	}
+	if config.URLStrategy == StrategyHash && (config.Data == nil || !config.Data.HasColumn(config.HashKey)) {
+		return nil, fmt.Errorf("the hash URL strategy needs a data column to hash (%q)", config.HashKey)
+	}
+	if config.RPSTrace != nil && (config.MaxRPS > 0 || config.ArrivalRate > 0 || config.Users > 0) {
+		return nil, fmt.Errorf("an RPS trace sets the arrival rate and cannot be combined with a rate limit, arrival rate or users")
+	}
+	if err := ValidateChaos(config.Chaos, config.MaxRPS > 0 || config.ArrivalRate > 0 || config.RPSTrace != nil); err != nil {
+		return nil, err
+	}
+	if config.Users > 0 && PinnedTargets(targets) {
+		return nil, fmt.Errorf("targets with their own concurrency cannot be combined with users")
+	}
+	if config.Users > 0 && config.Users < config.Concurrency {
+		return nil, fmt.Errorf("users (%d) must be at least the concurrency (%d)", config.Users, config.Concurrency)
+	}
+	if config.SNI != nil {
+		if err := config.SNI.checkTargets(targets); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compress request bodies once so workers don't pay the cost per request
+	// Templated bodies differ per request, so they can't be precompressed
+	for i := range targets {
+		targets[i].templated = targets[i].hasTemplate()
+		if targets[i].Protocol == httpclient.ProtocolHTTP2 && !strings.HasPrefix(strings.ToLower(targets[i].URL), "https://") {
+			return nil, fmt.Errorf("HTTP/2 requires an https URL (%s)", targets[i].URL)
+		}
+		if targets[i].Protocol == httpclient.ProtocolHTTP10 && (config.BodySource != nil || config.ExpectContinue > 0) {
+			return nil, fmt.Errorf("HTTP/1.0 cannot send streamed bodies or Expect: 100-continue (%s)", targets[i].URL)
+		}
+		if targets[i].Protocol == httpclient.ProtocolRaw && (config.BodySource != nil || config.ExpectContinue > 0 || config.CompressBody != "") {
+			return nil, fmt.Errorf("a raw request is sent as written, without streamed or compressed bodies or Expect: 100-continue (%s)", targets[i].URL)
+		}
+		if config.CompressBody != "" && isTemplate(targets[i].Body) {
+			return nil, fmt.Errorf("body compression cannot be combined with a templated body (%s)", targets[i].URL)
+		}
+		compressed, err := targets[i].compressBody(config.CompressBody)
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = compressed
+	}
 
 	// Create HTTP client
-	client := httpclient.New()
+	clientOptions := httpclient.DefaultOptions()
+	if config.RequestTimeout > 0 {
+		clientOptions.Timeout = 0
+	}
+	if config.ReadRate > 0 || config.ReadDelay > 0 {
+		clientOptions.ReadBuffer = slowClientReadBuffer
+	}
+	clientOptions.ExpectContinueTimeout = config.ExpectContinue
+	clientOptions.DNSServer = config.DNSServer
+	clientOptions.Clock = config.Clock
+	clientOptions.Auth = config.HTTPAuth
+	if config.ClientCerts != nil {
+		clientOptions.ClientCerts = config.ClientCerts.Certs
+	}
+	if config.SNI != nil {
+		clientOptions.ServerNames = len(config.SNI.Names)
+	}
+	clientOptions.ProxyProtocol = config.ProxyProtocol
+	clientOptions.DisableResumption = config.DisableTLSResumption
+	clientOptions.RequireOCSPStaple = config.RequireOCSPStaple
+	client := httpclient.New(clientOptions)
 
-	// Create URL rotator for round-robin distribution
-	urlRotator := NewURLRotator(config.URLs)
+	// Targets with their own concurrency get dedicated workers; the rest share the
+	// global pool. Each pool has its own URL rotator for round-robin distribution
+	pools, groups := partitionWorkers(targets, config.Concurrency)
+	rotators := make([]*URLRotator, len(groups))
+	for i, group := range groups {
+		rotator, err := NewURLRotator(group, config.URLStrategy)
+		if err != nil {
+			return nil, err
+		}
+		rotators[i] = rotator
+	}
+	outages, err := newFailover(config.TargetOutages, groups, rotators)
+	if err != nil {
+		return nil, err
+	}
+	config.Concurrency = 0
+	for _, pool := range pools {
+		config.Concurrency += pool.Workers
+	}
+
+	// Make sure the target is up before sending any load
+	var health *HealthMonitor
+	if config.HealthCheck != nil {
+		health = NewHealthMonitor(*config.HealthCheck)
+		if err := health.CheckOnce(parent); err != nil {
+			return nil, err
+		}
+	}
+
+	// Obtain the token the workers start with
+	if config.Auth != nil {
+		config.Auth.setWorkers(config.Concurrency)
+		if err := config.Auth.login(parent, client, config.RequestTimeout); err != nil {
+			return nil, err
+		}
+	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), config.Duration)
+	// Create context with timeout; no new requests are started once it expires
+	ctx, cancel := context.WithTimeout(parent, config.Duration)
 	defer cancel()
 
-	// Create results channel
-	results := make(chan Result, config.Concurrency*10)
+	// In-flight requests may keep running until the grace period ends
+	requestCtx, cancelRequests := context.WithTimeout(parent, config.Duration+config.Grace)
+	defer cancelRequests()
+
+	// Shard the workers per P so they don't all contend on one connection pool and
+	// results channel; each shard has its own client, channel and collector
+	shards := 1
+	if config.ShardWorkers {
+		shards = min(runtime.GOMAXPROCS(0), config.Concurrency)
+	}
+	shardResults := make([]chan Result, shards)
+	shardClients := make([]*httpclient.Client, shards)
+	for i := range shardResults {
+		shardResults[i] = make(chan Result, config.Concurrency*10/shards)
+		shardClients[i] = client
+		if i > 0 {
+			shardClients[i] = httpclient.New(clientOptions)
+		}
+	}
+	results := shardResults[0]
+
+	// Measure the generator's own CPU usage, which caps the load it can send
+	cpu := startCPUSampler(shards)
 
 	// Create stats collector
-	stats := NewStats()
+	stats := NewStats(config.Clock)
+	stats.setSLOs(config.SLOs)
+	stats.setCaptureHeaders(config.CaptureHeaders)
+	if config.ColdRequests > 0 {
+		stats.setColdRequests(int64(config.ColdRequests))
+	}
+	if config.Canary != nil {
+		stats.setCanary(config.Canary)
+	}
+	if config.Mirror != nil {
+		stats.setMirror(config.Mirror)
+	}
+	if config.Schema != nil {
+		stats.setSchema(config.Schema)
+	}
+	if config.Auth != nil {
+		stats.setAuth(config.Auth)
+	}
+	if config.BodyCardinality {
+		stats.setBodyCardinality()
+	}
+	if config.Ranges != nil {
+		stats.setRanges(config.Ranges)
+	}
+	if config.ExpectContinue > 0 {
+		stats.setExpectContinue(config.ExpectContinue)
+	}
+	if config.ArrivalRate > 0 {
+		stats.setArrivalRate(config.ArrivalRate)
+	}
+	if config.RPSTrace != nil {
+		stats.setArrivalRate(0)
+	}
+	if config.MaxRPS > 0 {
+		stats.setRateLimited(config.MaxRPS, config.Concurrency)
+	}
+	if config.DNSServer != "" {
+		stats.setDNSServer(config.DNSServer)
+	}
+	if config.HTTPAuth != nil {
+		stats.setHTTPAuth(config.HTTPAuth.Scheme())
+	}
+	if config.ClientCerts != nil {
+		stats.setClientCerts(config.ClientCerts)
+	}
+	if config.SNI != nil {
+		stats.setSNI(config.SNI)
+	}
+	caches := shards
+	if config.ClientCerts != nil {
+		caches *= len(config.ClientCerts.Certs)
+	}
+	if config.SNI != nil {
+		caches *= len(config.SNI.Names)
+	}
+	stats.setTLS(!config.DisableTLSResumption, caches, config.RequireOCSPStaple)
+	candidates, limit := config.TimeoutCandidates, config.RequestTimeout
+	if candidates == nil {
+		candidates = DefaultTimeoutCandidates
+	}
+	if limit == 0 {
+		limit = httpclient.DefaultTimeout
+	}
+	stats.setTimeoutCandidates(candidates, limit)
 
 	// Send stats instance to channel if provided (for progress monitoring)
 	if statsChan != nil {
@@ -73,30 +467,52 @@ func RunWithStatsAndChannel(config Config, statsChan chan<- *Stats) (*RunResult,
 		}
 	}
 
-	// Start stats collector goroutine
-	statsDone := make(chan struct{})
-	go func() {
-		defer close(statsDone)
-		for {
-			select {
-			case result, ok := <-results:
-				if !ok {
-					return
-				}
-				stats.AddResult(result)
-			case <-ctx.Done():
-				// Drain remaining results after context is done
-				for {
-					select {
-					case result := <-results:
-						stats.AddResult(result)
-					default:
-						return
-					}
-				}
-			}
+	runID := config.RunID
+	if runID == "" {
+		runID = NewRunID()
+	}
+
+	// Serve live statistics for dashboards while the test runs
+	var exporter *PrometheusExporter
+	if config.PrometheusListen != "" {
+		var err error
+		if exporter, err = StartPrometheusExporter(config.PrometheusListen, stats, config.Concurrency, runID); err != nil {
+			cancel()
+			return nil, err
 		}
-	}()
+		defer exporter.Close()
+	}
+
+	// Open the per-request record before any request is sent
+	var recorder *Recorder
+	if config.RecordFile != "" {
+		format := config.RecordFormat
+		if format == "" {
+			format = RecordFormatFor(config.RecordFile)
+		}
+		var err error
+		if recorder, err = NewRecorder(config.RecordFile, format, config.RecordRotation); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	var errorLog *ErrorLog
+	if config.ErrorLog != "" {
+		errorLog = NewErrorLog(config.ErrorLog, config.ErrorLogRotation)
+	}
+
+	// Start stats collector goroutines, one per shard
+	// They consume every result until the channel is closed after all workers stopped,
+	// so requests finishing after the deadline are still recorded
+	var collectors sync.WaitGroup
+	var received int64
+	for _, shard := range shardResults {
+		collectors.Add(1)
+		go func(results <-chan Result) {
+			defer collectors.Done()
+			atomic.AddInt64(&received, collect(results, stats, recorder, errorLog))
+		}(shard)
+	}
 
 	// Create rate limiter if MaxRPS is specified
 	var rateLimiter *RateLimiter
@@ -105,47 +521,257 @@ func RunWithStatsAndChannel(config Config, statsChan chan<- *Stats) (*RunResult,
 		defer rateLimiter.Stop()
 	}
 
+	// Randomized behavior is reproducible with the same seed
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	// Shared per-request behaviors
+	workerOptions := WorkerOptions{
+		AcceptEncoding: config.AcceptEncoding,
+		BodySource:     config.BodySource,
+		BodyRate:       config.BodyRate,
+		MaxBodyBytes:   config.MaxBodyBytes,
+		MaxResponse:    config.MaxResponseBytes,
+		SkipBody:       config.SkipBody,
+		ReadRate:       config.ReadRate,
+		ReadDelay:      config.ReadDelay,
+		RequestTimeout: config.RequestTimeout,
+		Seed:           seed,
+		Data:           config.Data,
+		HashKey:        config.HashKey,
+		PayloadSize:    config.PayloadSize,
+		WorkerHeader:   config.WorkerHeader,
+		RunIDHeader:    config.RunIDHeader,
+		RunID:          runID,
+		IdempotencyKey: config.IdempotencyKey,
+		Trace:          config.Trace,
+		ClientIP:       config.ClientIP,
+		Canary:         config.Canary,
+		Health:         health,
+		CaptureHeaders: config.CaptureHeaders,
+		ColdRequests:   int64(config.ColdRequests),
+		Created:        config.Created,
+		Schema:         config.Schema,
+		Hashes:         config.ExpectBodySHA256,
+		CountBodies:    config.BodyCardinality,
+		Auth:           config.Auth,
+		ClientCerts:    config.ClientCerts,
+		SNI:            config.SNI,
+		Ranges:         config.Ranges,
+		ExpectContinue: config.ExpectContinue > 0,
+		Clock:          config.Clock,
+		delivery:       &resultDelivery{clock: clock.Or(config.Clock)},
+	}
+	if config.AuditCounterHeader != "" {
+		workerOptions.Counter = NewServerCounter(config.AuditCounterHeader)
+	}
+	if config.Mirror != nil {
+		workerOptions.Mirror = NewMirrorSender(config.Mirror, client, results, config.Concurrency)
+	}
+	if config.CacheBust {
+		workerOptions.CacheBuster = NewCacheBuster()
+	}
+	if config.Conditional {
+		workerOptions.Validators = NewValidatorCache()
+	}
+
+	// Keep checking the target's health while the test runs
+	start := time.Now()
+	if health != nil {
+		stats.setHealth(health)
+		go health.Run(ctx, start)
+	}
+
+	// Shed bookkeeping when the memory nears its budget
+	memory := newMemoryGuard(config.MaxMemory, stats, recorder)
+	if memory != nil {
+		stats.setMemoryGuard(memory)
+		go memory.Run(ctx, start)
+	}
+
+	// Take targets out of rotation and back in on schedule
+	if outages != nil {
+		go outages.Run(ctx, stats.StartTime)
+	}
+
+	// Burst and pause the load on schedule
+	workerOptions.Chaos = NewChaosSchedule(config.Chaos, rateLimiter, config.MaxRPS)
+	if workerOptions.Chaos != nil {
+		go workerOptions.Chaos.Run(ctx, stats.StartTime)
+	}
+
+	// Scrape the target's resource usage alongside the load
+	var resources *ResourceScraper
+	if config.MetricsURL != "" {
+		resources = NewResourceScraper(config.MetricsURL, config.MetricsInterval)
+		go resources.Run(ctx, start)
+	}
+
+	// Churn connections to the target alongside the load
+	var churner *ConnectionChurner
+	churnDone := make(chan struct{})
+	if config.ChurnRate > 0 {
+		var err error
+		if churner, err = NewConnectionChurner(targets[0].URL, config.ChurnRate, httpclient.Dialer(clientOptions), config.SNI); err != nil {
+			cancel()
+			return nil, err
+		}
+		go func() {
+			defer close(churnDone)
+			churner.Run(ctx)
+		}()
+	} else {
+		close(churnDone)
+	}
+
 	// Use WaitGroup to wait for all workers to finish
 	var wg sync.WaitGroup
 
 	// Start workers
-	for i := 0; i < config.Concurrency; i++ {
-		wg.Add(1)
-		// Create base request configuration (URL will be selected dynamically)
-		baseRequest := httpclient.Request{
-			Method:  config.Method,
-			Body:    config.Body,
-			Headers: config.Headers,
+	// Request details (URL, method, headers, body) are taken from the selected target
+	// Arrivals are due from now on, so the schedule starts with the workers
+	var traceSteps []rateStep
+	if config.RPSTrace != nil {
+		// The trace's rates are factors of 1 request per second
+		traceSteps = combineSteps(config.RPSTrace.steps(), chaosSteps(config.Chaos))
+		workerOptions.Arrivals = NewArrivalScheduler(1, traceSteps, config.Clock)
+	} else {
+		workerOptions.Arrivals = NewArrivalScheduler(config.ArrivalRate, chaosSteps(config.Chaos), config.Clock)
+	}
+	workerOptions.InFlight = NewInFlightGauge()
+	go workerOptions.InFlight.Run(ctx, stats.StartTime)
+	workers := make([]*Worker, config.Concurrency)
+	var targetWorkers map[string]int
+	if config.URLStrategy == StrategySticky {
+		targetWorkers = make(map[string]int, len(targets))
+	}
+	for i, pool := 0, 0; i < len(workers); pool++ {
+		for end := i + pools[pool].Workers; i < end; i++ {
+			workers[i] = NewWorker(i, shardClients[i%shards], shardResults[i%shards], rateLimiter, rotators[pool], workerOptions)
+			if targetWorkers != nil {
+				targetWorkers[rotators[pool].pinned(i)]++
+			}
 		}
-		worker := NewWorker(client, baseRequest, results, rateLimiter, urlRotator)
+	}
+	var users *UserScheduler
+	if config.Users > 0 {
+		// Virtual users take turns on the workers
+		users = NewUserScheduler(config.Users, config.ThinkTime, workerOptions)
+		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			worker.Start(ctx)
+			users.Run(ctx, requestCtx, workers)
 		}()
+	} else {
+		for _, worker := range workers {
+			wg.Add(1)
+			go func(worker *Worker) {
+				defer wg.Done()
+				worker.Start(ctx, requestCtx)
+			}(worker)
+		}
 	}
 
-	// Wait for duration to complete
-	<-ctx.Done()
+	// Wait for duration to complete, or end early once unique data rows run out
+	select {
+	case <-ctx.Done():
+	case <-config.Data.Exhausted():
+		cancel()
+	}
+	loadEnd := clock.Or(config.Clock).Now()
 
-	// Wait for all workers to finish (they will stop when ctx.Done() is triggered)
+	// Wait for all workers to finish (they stop starting requests when ctx.Done() is
+	// triggered and return once their in-flight request completes or is cancelled)
 	wg.Wait()
+	if workerOptions.Mirror != nil {
+		// Mirrored requests send to the results channel too
+		workerOptions.Mirror.Wait()
+	}
+	cancelRequests()
 
-	// Close results channel to signal stats collector to finish
+	// Close results channels to signal stats collectors to finish
 	// This is safe now because all workers have stopped
-	close(results)
+	for _, shard := range shardResults {
+		close(shard)
+	}
 
-	// Wait for stats collector to finish processing
-	<-statsDone
+	// Wait for stats collectors to finish processing
+	collectors.Wait()
+	<-churnDone
 
 	// Finalize stats
 	stats.Finalize()
 
 	// Get summary
 	summary := stats.GetSummary()
+	summary.BodySkipped = config.SkipBody
+	summary.ReadRate = config.ReadRate
+	summary.MaxResponseBytes = config.MaxResponseBytes
+	summary.ReadDelay = config.ReadDelay
+	summary.Aborted = parent.Err() != nil
+	summary.Seed = seed
+	summary.RunID = runID
+	if summary.Arrivals != nil {
+		summary.Arrivals.Unsent = workerOptions.Arrivals.unsent(loadEnd)
+	}
+	summary.LittlesLaw = newLittlesLaw(&summary, config)
+	summary.Queueing.InFlightAvg, summary.Queueing.InFlightMax = workerOptions.InFlight.fill(summary.Timeline)
+	summary.Schedule = config.Schedule
+	summary.Data = config.Data.Usage()
+	summary.Record = recorder.Close()
+	summary.ErrorLog = errorLog.Close()
+	summary.Delivery = workerOptions.delivery.summary(summary.TotalRequests + summary.CancelledAtDeadline)
+	if config.Audit {
+		summary.Audit = auditSummary(&summary, rateLimiter, workers, received, workerOptions.Counter)
+	}
+	summary.GeneratorCPU = cpu.Summary()
+	summary.Memory = memory.Summary()
+	if health != nil {
+		summary.Health = health.Summary(time.Now())
+	}
+	if resources != nil {
+		summary.Resources = resources.Summary()
+	}
+	if churner != nil {
+		summary.Churn = churner.Summary()
+	}
+	if users != nil {
+		summary.Users = users.Summary()
+	}
+	if len(pools) > 1 {
+		summary.WorkerPools = pools
+	}
+	summary.Failover = outages.summary(summary.Timeline)
+	summary.Chaos = workerOptions.Chaos.summary(summary.Timeline)
+	summary.RPSTrace = config.RPSTrace.summary(traceSteps, summary.Timeline, summary.Duration, summary.TotalRequests)
+	summary.URLStrategy = config.URLStrategy
+	summary.HashKey = config.HashKey
+	summary.TargetWorkers = targetWorkers
+	if summary.Mirror != nil {
+		summary.Mirror.Dropped = workerOptions.Mirror.Dropped()
+	}
+	if config.Created != nil {
+		// Templated header values can't be rendered outside a worker, so they're left out
+		headers := make(map[string]string, len(config.Headers))
+		for k, v := range config.Headers {
+			if !isTemplate(v) {
+				headers[k] = v
+			}
+		}
+		summary.Cleanup = config.Created.cleanup(client, headers, config.Concurrency, config.RequestTimeout)
+	}
+	if config.Trace != nil {
+		if summary.Traces == nil {
+			summary.Traces = &TraceSummary{}
+		}
+		summary.Traces.RunID = config.Trace.RunID
+		summary.Traces.LinkTemplate = config.Trace.LinkTemplate
+	}
 
 	return &RunResult{
 		Stats:   stats,
 		Summary: &summary,
 	}, nil
 }
-
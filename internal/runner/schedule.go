@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultNTPServer is queried to detect local clock offset before a scheduled start
+const DefaultNTPServer = "pool.ntp.org"
+
+// maxClockOffset is the clock offset above which a scheduled start is flagged,
+// since generators on different machines would not start together
+const maxClockOffset = 100 * time.Millisecond
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch
+const ntpEpochOffset = 2208988800
+
+// StartSchedule describes a run that starts at a fixed wall-clock time
+type StartSchedule struct {
+	At          time.Time     // Scheduled start
+	NTPServer   string        // Server the clock was checked against ("" = not checked)
+	ClockOffset time.Duration // NTP time minus local time (valid if OffsetKnown)
+	OffsetKnown bool
+	Warnings    []string
+}
+
+// ParseStartAt parses a start time given as RFC 3339 or as a time of day
+// ("14:00" or "14:00:00", local time today); times in the past are rejected
+func ParseStartAt(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("start time %s is in the past", s)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		clock, err := time.ParseInLocation(layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("start time %s has already passed today (use RFC 3339, e.g. 2024-01-02T14:00:00Z, for another day)", s)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid start time %q (expected HH:MM[:SS] or RFC 3339)", s)
+}
+
+// CheckClock measures the local clock offset against the NTP server and records
+// a warning if it is unknown or large enough to skew a coordinated start
+func (s *StartSchedule) CheckClock(timeout time.Duration) {
+	if s.NTPServer == "" {
+		return
+	}
+	offset, err := QueryClockOffset(s.NTPServer, timeout)
+	if err != nil {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("could not check the clock against %s: %v", s.NTPServer, err))
+		return
+	}
+	s.ClockOffset = offset
+	s.OffsetKnown = true
+	if offset > maxClockOffset || offset < -maxClockOffset {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("local clock is off by %s from %s; runs on other machines may not start together", (-offset).Round(time.Millisecond), s.NTPServer))
+	}
+}
+
+// QueryClockOffset returns the offset of the local clock from an NTP server
+// (server time minus local time) using a single SNTP request
+func QueryClockOffset(server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// Client request: leap indicator 0, version 3, mode 3 (client)
+	packet := make([]byte, 48)
+	packet[0] = 0x1B
+	sent := time.Now()
+	if _, err := conn.Write(packet); err != nil {
+		return 0, err
+	}
+	n, err := conn.Read(packet)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+	if n < 48 {
+		return 0, fmt.Errorf("short NTP response (%d bytes)", n)
+	}
+
+	serverReceived := ntpTime(packet[32:40])
+	serverSent := ntpTime(packet[40:48])
+	if serverSent.IsZero() {
+		return 0, fmt.Errorf("NTP server returned no time")
+	}
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil
+}
+
+// ntpTime decodes a 64-bit NTP timestamp
+func ntpTime(b []byte) time.Time {
+	seconds := binary.BigEndian.Uint32(b[:4])
+	fraction := binary.BigEndian.Uint32(b[4:])
+	if seconds == 0 && fraction == 0 {
+		return time.Time{}
+	}
+	nanos := (int64(fraction) * 1e9) >> 32
+	return time.Unix(int64(seconds)-ntpEpochOffset, nanos)
+}
@@ -0,0 +1,427 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// maxSchemaViolationKinds bounds the distinct violation messages that are counted;
+// further ones are counted as "other"
+const maxSchemaViolationKinds = 50
+
+// SchemaCheck validates a sample of successful JSON response bodies against a
+// JSON Schema; violations are counted apart from failed requests
+// The common validation keywords are supported: type, enum, const, properties,
+// required, additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, allOf, anyOf,
+// oneOf, not and local $ref (#/definitions/... or #/$defs/...); others are ignored
+type SchemaCheck struct {
+	Path   string  // Schema file
+	Sample float64 // Percentage of requests whose response is validated if successful (0-100]
+
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+	counter  int64 // Atomic count of responses considered for sampling
+}
+
+// LoadSchemaCheck reads a JSON Schema and validates sample percent of responses against it
+func LoadSchemaCheck(path string, sample float64) (*SchemaCheck, error) {
+	if sample <= 0 || sample > 100 {
+		return nil, fmt.Errorf("schema sample rate must be greater than 0 and at most 100")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	c := &SchemaCheck{Path: path, Sample: sample, patterns: make(map[string]*regexp.Regexp)}
+	if err := json.Unmarshal(data, &c.root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema file %s: %w", path, err)
+	}
+	if err := c.compile(c.root); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// compile checks the schema's structure and compiles its patterns
+func (c *SchemaCheck) compile(node interface{}) error {
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		if _, ok := node.(bool); ok {
+			return nil
+		}
+		return fmt.Errorf("a schema must be an object or a boolean")
+	}
+	if p, ok := n["pattern"].(string); ok {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", p, err)
+		}
+		c.patterns[p] = re
+	}
+	if ref, ok := n["$ref"].(string); ok {
+		if _, err := c.resolve(ref); err != nil {
+			return err
+		}
+	}
+
+	// Only keywords holding subschemas are walked; enum and const hold plain values
+	var subschemas []interface{}
+	for _, key := range []string{"properties", "definitions", "$defs"} {
+		if m, ok := n[key].(map[string]interface{}); ok {
+			for _, sub := range m {
+				subschemas = append(subschemas, sub)
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := n[key]; ok {
+			subschemas = append(subschemas, sub)
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if list, ok := n[key].([]interface{}); ok {
+			subschemas = append(subschemas, list...)
+		}
+	}
+	for _, sub := range subschemas {
+		if err := c.compile(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sample reports whether the next request's response is validated (if successful);
+// samples are spread evenly over the run
+func (c *SchemaCheck) sample() bool {
+	if c == nil {
+		return false
+	}
+	n := atomic.AddInt64(&c.counter, 1)
+	return math.Floor(float64(n)*c.Sample/100) != math.Floor(float64(n-1)*c.Sample/100)
+}
+
+// check validates a response body and returns the first violation ("" if it conforms)
+func (c *SchemaCheck) check(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "$: response is not valid JSON"
+	}
+	if err := c.validate(c.root, v, "$"); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// resolve finds the schema a local $ref points to
+func (c *SchemaCheck) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local $ref is supported: %s", ref)
+	}
+	node := c.root
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+	}
+	return node, nil
+}
+
+// schemaViolation is a value that doesn't conform to the schema
+type schemaViolation struct {
+	path    string
+	message string
+}
+
+func (v *schemaViolation) Error() string {
+	return v.path + ": " + v.message
+}
+
+func violation(path, format string, args ...interface{}) error {
+	return &schemaViolation{path: path, message: fmt.Sprintf(format, args...)}
+}
+
+// validate checks value against schema; path locates the value in the response
+func (c *SchemaCheck) validate(schema, value interface{}, path string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		if schema == false {
+			return violation(path, "no value is allowed")
+		}
+		return nil
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := c.resolve(ref)
+		if err != nil {
+			return violation(path, "%v", err)
+		}
+		if err := c.validate(target, value, path); err != nil {
+			return err
+		}
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		return violation(path, "expected %s, got %s", describeType(t), jsonType(value))
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return violation(path, "value is not one of the allowed values")
+		}
+	}
+	if constant, ok := s["const"]; ok && !jsonEqual(constant, value) {
+		return violation(path, "value does not match const")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if err := c.validateObject(s, v, path); err != nil {
+			return err
+		}
+	case []interface{}:
+		if n, ok := s["minItems"].(float64); ok && float64(len(v)) < n {
+			return violation(path, "expected at least %g items", n)
+		}
+		if n, ok := s["maxItems"].(float64); ok && float64(len(v)) > n {
+			return violation(path, "expected at most %g items", n)
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				if err := c.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := s["minLength"].(float64); ok && length < n {
+			return violation(path, "expected at least %g characters", n)
+		}
+		if n, ok := s["maxLength"].(float64); ok && length > n {
+			return violation(path, "expected at most %g characters", n)
+		}
+		if p, ok := s["pattern"].(string); ok && !c.patterns[p].MatchString(v) {
+			return violation(path, "does not match pattern %q", p)
+		}
+	case float64:
+		if n, ok := s["minimum"].(float64); ok && v < n {
+			return violation(path, "less than the minimum %g", n)
+		}
+		if n, ok := s["maximum"].(float64); ok && v > n {
+			return violation(path, "greater than the maximum %g", n)
+		}
+		if n, ok := s["exclusiveMinimum"].(float64); ok && v <= n {
+			return violation(path, "not greater than %g", n)
+		}
+		if n, ok := s["exclusiveMaximum"].(float64); ok && v >= n {
+			return violation(path, "not less than %g", n)
+		}
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if err := c.validate(sub, value, path); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if c.validate(sub, value, path) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return violation(path, "value matches none of anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range oneOf {
+			if c.validate(sub, value, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return violation(path, "value matches %d of oneOf, expected exactly 1", matched)
+		}
+	}
+	if not, ok := s["not"]; ok && c.validate(not, value, path) == nil {
+		return violation(path, "value matches not")
+	}
+	return nil
+}
+
+// validateObject checks the properties of an object
+func (c *SchemaCheck) validateObject(s map[string]interface{}, v map[string]interface{}, path string) error {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := v[name]; !present {
+					return violation(path, "missing required property %q", name)
+				}
+			}
+		}
+	}
+	properties, _ := s["properties"].(map[string]interface{})
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if sub, ok := properties[name]; ok {
+			if err := c.validate(sub, v[name], path+"."+name); err != nil {
+				return err
+			}
+			continue
+		}
+		if additional, ok := s["additionalProperties"]; ok {
+			if additional == false {
+				return violation(path, "unexpected property %q", name)
+			}
+			if err := c.validate(additional, v[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value has the schema type t (a name or a list of names)
+func matchesType(t, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		actual := jsonType(value)
+		return actual == t || (t == "number" && actual == "integer")
+	case []interface{}:
+		for _, name := range t {
+			if matchesType(name, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// describeType formats a schema type for violation messages
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// jsonType returns the JSON Schema type name of a decoded value
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonEqual compares two decoded JSON values
+func jsonEqual(a, b interface{}) bool {
+	ja, err1 := json.Marshal(a)
+	jb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ja) == string(jb)
+}
+
+// arrayIndex matches array indexes in violation paths, so violations at different
+// positions of an array are counted together
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+// schemaStats counts validated responses and violations by kind
+type schemaStats struct {
+	check      *SchemaCheck
+	validated  int64
+	violations int64
+	kinds      map[string]int64
+}
+
+// add accounts a result; a nil schemaStats (no schema check) ignores it
+func (s *schemaStats) add(result Result) {
+	if s == nil || !result.SchemaChecked {
+		return
+	}
+	s.validated++
+	if result.SchemaViolation == "" {
+		return
+	}
+	s.violations++
+	kind := arrayIndex.ReplaceAllString(result.SchemaViolation, "[*]")
+	if _, ok := s.kinds[kind]; !ok && len(s.kinds) >= maxSchemaViolationKinds {
+		kind = "other"
+	}
+	s.kinds[kind]++
+}
+
+// SchemaSummary contains the outcome of the schema check
+type SchemaSummary struct {
+	Path       string
+	Sample     float64          // Percentage of successful responses validated
+	Validated  int64            // Responses validated
+	Violations int64            // Responses that did not conform
+	Kinds      map[string]int64 // Violations by message (array indexes replaced with [*])
+}
+
+// ViolationRate returns the fraction of validated responses that did not conform
+func (s *SchemaSummary) ViolationRate() float64 {
+	if s.Validated == 0 {
+		return 0
+	}
+	return float64(s.Violations) / float64(s.Validated)
+}
+
+// summary returns the schema check outcome (nil without a schema check)
+func (s *schemaStats) summary() *SchemaSummary {
+	if s == nil {
+		return nil
+	}
+	return &SchemaSummary{Path: s.check.Path, Sample: s.check.Sample, Validated: s.validated, Violations: s.violations, Kinds: maps.Clone(s.kinds)}
+}
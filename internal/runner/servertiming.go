@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serverTimingTotal is the conventional name of a Server-Timing entry covering the whole request
+const serverTimingTotal = "total"
+
+// ServerTimingMetric is one entry of a Server-Timing response header
+type ServerTimingMetric struct {
+	Name     string
+	Duration time.Duration
+}
+
+// parseServerTiming parses Server-Timing header values such as
+// `db;dur=53.2, cache;desc="Cache Read";dur=23.2`; entries without a duration are skipped
+func parseServerTiming(values []string) []ServerTimingMetric {
+	var metrics []ServerTimingMetric
+	for _, value := range values {
+		for _, entry := range splitServerTiming(value, ',') {
+			params := splitServerTiming(entry, ';')
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+			for _, param := range params[1:] {
+				key, raw, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "dur") {
+					continue
+				}
+				ms, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(raw), `"`), 64)
+				if err != nil || ms < 0 {
+					break
+				}
+				metrics = append(metrics, ServerTimingMetric{Name: name, Duration: time.Duration(ms * float64(time.Millisecond))})
+				break
+			}
+		}
+	}
+	return metrics
+}
+
+// splitServerTiming splits s at sep outside of quoted strings
+func splitServerTiming(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// serverTime returns the time the server reported for a response: the "total"
+// entry if present, otherwise the sum of all entries
+func serverTime(metrics []ServerTimingMetric) time.Duration {
+	var sum time.Duration
+	for _, m := range metrics {
+		if strings.EqualFold(m.Name, serverTimingTotal) {
+			return m.Duration
+		}
+		sum += m.Duration
+	}
+	return sum
+}
+
+// serverTimingStats aggregates Server-Timing entries across responses
+type serverTimingStats struct {
+	responses   int64
+	phaseCounts map[string]int64
+	phases      map[string][]time.Duration
+	server      []time.Duration // Server-reported time per sampled response
+	remainder   []time.Duration // TTFB minus server time per sampled response (network, queueing, proxies)
+}
+
+// add records the Server-Timing entries of a response with the given time to
+// first byte, keeping their times if sampled
+func (s *serverTimingStats) add(metrics []ServerTimingMetric, ttfb time.Duration, sampled bool) {
+	if s.phases == nil {
+		s.phaseCounts = make(map[string]int64)
+		s.phases = make(map[string][]time.Duration)
+	}
+	s.responses++
+	for _, m := range metrics {
+		s.phaseCounts[m.Name]++
+	}
+	if !sampled {
+		return
+	}
+	for _, m := range metrics {
+		s.phases[m.Name] = append(s.phases[m.Name], m.Duration)
+	}
+	server := serverTime(metrics)
+	remainder := ttfb - server
+	if remainder < 0 {
+		remainder = 0
+	}
+	s.server = append(s.server, server)
+	s.remainder = append(s.remainder, remainder)
+}
+
+// thin drops every other timing sample, as Stats.downsample does
+func (s *serverTimingStats) thin() {
+	for name, durations := range s.phases {
+		s.phases[name] = thinned(durations)
+	}
+	s.server = thinned(s.server)
+	s.remainder = thinned(s.remainder)
+}
+
+// summary returns the aggregated timings, or nil if no response carried Server-Timing
+func (s *serverTimingStats) summary() *ServerTimingSummary {
+	if s.responses == 0 {
+		return nil
+	}
+	summary := &ServerTimingSummary{
+		Responses: s.responses,
+		Server:    NewDurationStats(s.server),
+		Remainder: NewDurationStats(s.remainder),
+	}
+	for name, count := range s.phaseCounts {
+		summary.Phases = append(summary.Phases, ServerTimingPhase{
+			Name:     name,
+			Count:    count,
+			Duration: NewDurationStats(s.phases[name]),
+		})
+	}
+	// Slowest phases first
+	sort.Slice(summary.Phases, func(i, j int) bool {
+		if summary.Phases[i].Duration.Avg != summary.Phases[j].Duration.Avg {
+			return summary.Phases[i].Duration.Avg > summary.Phases[j].Duration.Avg
+		}
+		return summary.Phases[i].Name < summary.Phases[j].Name
+	})
+	return summary
+}
+
+// ServerTimingPhase is the distribution of one Server-Timing entry (e.g., db, cache)
+type ServerTimingPhase struct {
+	Name     string
+	Count    int64 // Responses that reported the phase
+	Duration DurationStats
+}
+
+// ServerTimingSummary compares server-reported time with the latency seen by the client
+type ServerTimingSummary struct {
+	Responses int64               // Responses that carried a Server-Timing header
+	Phases    []ServerTimingPhase // Per-phase durations, slowest first
+	Server    DurationStats       // Server time per response ("total" entry or the sum of entries)
+	Remainder DurationStats       // TTFB not covered by server time (network, queueing, proxies)
+}
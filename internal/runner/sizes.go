@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// sizeBuckets splits requests by body size into power-of-two buckets: bucket 0
+// holds empty bodies and bucket b sizes from 2^(b-1) up to 2^b-1 bytes
+// Each bucket keeps a latency histogram, so memory doesn't grow with the request count
+type sizeBuckets struct {
+	buckets map[int]*sizeBucketStats
+
+	// Running sums for the Pearson correlation between size and latency
+	n, sumX, sumY, sumXY, sumX2, sumY2 float64
+}
+
+// sizeBucketStats aggregates the latencies of one size bucket
+type sizeBucketStats struct {
+	requests int64
+	latency  time.Duration // Sum of latencies
+	counts   [histogramBuckets]uint32
+}
+
+// add records the latency of a request with the given body size
+func (s *sizeBuckets) add(size int64, latency time.Duration) {
+	if s.buckets == nil {
+		s.buckets = make(map[int]*sizeBucketStats)
+	}
+	b := bits.Len64(uint64(size))
+	stats := s.buckets[b]
+	if stats == nil {
+		stats = &sizeBucketStats{}
+		s.buckets[b] = stats
+	}
+	stats.requests++
+	stats.latency += latency
+	stats.counts[latencyBucket(latency)]++
+
+	x, y := float64(size), latency.Seconds()
+	s.n++
+	s.sumX += x
+	s.sumY += y
+	s.sumXY += x * y
+	s.sumX2 += x * x
+	s.sumY2 += y * y
+}
+
+// SizeBucket contains the latencies of requests whose size is within [MinBytes, MaxBytes]
+type SizeBucket struct {
+	MinBytes int64
+	MaxBytes int64
+	Requests int64
+	Avg      time.Duration
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+}
+
+// SizeCorrelation relates latency to request or response body size
+type SizeCorrelation struct {
+	Buckets []SizeBucket // Non-empty buckets, smallest first
+
+	// Correlation is the Pearson coefficient between size and latency, from -1 to 1
+	// (near 1 = larger bodies are slower, near 0 = no linear relation)
+	Correlation float64
+}
+
+// summary returns the per-bucket latencies, or nil if all sizes fell into one bucket
+// and there is nothing to compare
+func (s *sizeBuckets) summary() *SizeCorrelation {
+	if len(s.buckets) < 2 {
+		return nil
+	}
+
+	keys := make([]int, 0, len(s.buckets))
+	for b := range s.buckets {
+		keys = append(keys, b)
+	}
+	sort.Ints(keys)
+
+	result := &SizeCorrelation{}
+	for _, b := range keys {
+		stats := s.buckets[b]
+		bucket := SizeBucket{Requests: stats.requests, Avg: stats.latency / time.Duration(stats.requests)}
+		if b > 0 {
+			bucket.MinBytes = 1 << (b - 1)
+			bucket.MaxBytes = 1<<b - 1
+			if b == 64 {
+				bucket.MaxBytes = math.MaxInt64
+			}
+		}
+		bucket.P50 = histogramPercentile(stats.counts[:], stats.requests, 50)
+		bucket.P95 = histogramPercentile(stats.counts[:], stats.requests, 95)
+		bucket.P99 = histogramPercentile(stats.counts[:], stats.requests, 99)
+		result.Buckets = append(result.Buckets, bucket)
+	}
+
+	varX := s.n*s.sumX2 - s.sumX*s.sumX
+	varY := s.n*s.sumY2 - s.sumY*s.sumY
+	if varX > 0 && varY > 0 {
+		result.Correlation = (s.n*s.sumXY - s.sumX*s.sumY) / math.Sqrt(varX*varY)
+	}
+	return result
+}
+
+// histogramPercentile returns a percentile from latency histogram counts holding total samples
+func histogramPercentile(counts []uint32, total int64, p float64) time.Duration {
+	rank := int64(math.Ceil(float64(total) * p / 100.0))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen int64
+	for b, c := range counts {
+		seen += int64(c)
+		if seen >= rank {
+			return bucketValue(b)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SLO is a service level objective evaluated over all requests of the run,
+// e.g. "99% of requests succeed within 300ms"
+type SLO struct {
+	Name      string        `yaml:"name"`
+	Objective float64       `yaml:"objective"` // Percentage of requests that must be good (e.g., 99.9)
+	Latency   time.Duration `yaml:"latency"`   // Good requests must also be at least this fast (0 = success only)
+}
+
+// sloFile is the layout of an SLO definition file
+//
+// Example:
+//
+//	slos:
+//	  - name: checkout-latency
+//	    objective: 99
+//	    latency: 300ms
+//	  - name: availability
+//	    objective: 99.9
+type sloFile struct {
+	SLOs []SLO `yaml:"slos"`
+}
+
+// LoadSLOs reads SLO definitions from a YAML file
+func LoadSLOs(path string) ([]SLO, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO file: %w", err)
+	}
+
+	var file sloFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse SLO file %s: %w", path, err)
+	}
+	if len(file.SLOs) == 0 {
+		return nil, fmt.Errorf("SLO file %s defines no slos", path)
+	}
+
+	for i, slo := range file.SLOs {
+		if slo.Name == "" {
+			file.SLOs[i].Name = fmt.Sprintf("slo-%d", i+1)
+		}
+		if slo.Objective <= 0 || slo.Objective >= 100 {
+			return nil, fmt.Errorf("SLO %q in %s: objective must be between 0 and 100 (exclusive)", file.SLOs[i].Name, path)
+		}
+		if slo.Latency < 0 {
+			return nil, fmt.Errorf("SLO %q in %s: latency must not be negative", file.SLOs[i].Name, path)
+		}
+	}
+	return file.SLOs, nil
+}
+
+// good reports whether a request meets the SLO
+func (s SLO) good(result Result) bool {
+	if result.failed() {
+		return false
+	}
+	return s.Latency == 0 || result.Latency <= s.Latency
+}
+
+// SLOResult is the outcome of an SLO over the run
+type SLOResult struct {
+	SLO
+	Total  int64
+	Good   int64
+	Within float64 // Percentage of good requests
+	Burn   float64 // Percentage of the error budget consumed (over 100 = budget exhausted)
+	Passed bool
+}
+
+// newSLOResult computes the outcome of an SLO from request counts
+func newSLOResult(slo SLO, total, good int64) SLOResult {
+	r := SLOResult{SLO: slo, Total: total, Good: good, Within: 100, Passed: true}
+	if total == 0 {
+		return r
+	}
+	r.Within = float64(good) / float64(total) * 100
+	budget := (100 - slo.Objective) / 100 * float64(total)
+	r.Burn = float64(total-good) / budget * 100
+	r.Passed = r.Within >= slo.Objective
+	return r
+}
@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// maxSNIFailures is how many server names with failed requests the summary lists
+const maxSNIFailures = 5
+
+// SNIList presents the server names of a list in turn, one per request, against
+// the same target address, as the tenants of a multi-tenant TLS terminator see
+// it: each name gets TLS connections and sessions of its own, with the
+// certificate the terminator picks for it
+type SNIList struct {
+	File  string
+	Names []string
+
+	next atomic.Uint64
+}
+
+// LoadSNIList reads a file of server names, one per line; blank lines and
+// lines starting with # are skipped, as are repeated names
+func LoadSNIList(path string) (*SNIList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SNI list: %w", err)
+	}
+	defer f.Close()
+
+	list := &SNIList{File: path}
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		name := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if name == "" || strings.HasPrefix(name, "#") || seen[name] {
+			continue
+		}
+		if strings.ContainsAny(name, " \t/:@") {
+			return nil, fmt.Errorf("SNI list %s line %d: %q is not a host name", path, line, name)
+		}
+		seen[name] = true
+		list.Names = append(list.Names, name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read SNI list %s: %w", path, err)
+	}
+	if len(list.Names) == 0 {
+		return nil, fmt.Errorf("SNI list %s has no server names", path)
+	}
+	return list, nil
+}
+
+// checkTargets rejects targets the server names can't be presented to: all
+// must be https URLs of one host, as connections are pooled by server name
+func (l *SNIList) checkTargets(targets []Target) error {
+	host := ""
+	for _, target := range targets {
+		u, err := url.Parse(target.URL)
+		if err != nil || u.Scheme != "https" {
+			return fmt.Errorf("an SNI list requires https URLs (%s)", target.URL)
+		}
+		if host != "" && u.Host != host {
+			return fmt.Errorf("an SNI list requires all URLs on one host (%s and %s)", host, u.Host)
+		}
+		host = u.Host
+	}
+	return nil
+}
+
+// pick returns the index of the server name of the next request
+func (l *SNIList) pick() int {
+	return int((l.next.Add(1) - 1) % uint64(len(l.Names)))
+}
+
+// sniStats counts the requests and failures of each server name
+type sniStats struct {
+	list     *SNIList
+	requests []int64
+	failed   []int64
+}
+
+// add accounts a result; a nil sniStats (no SNI list) ignores it
+func (s *sniStats) add(result Result, failed bool) {
+	if s == nil || result.ServerName < 0 || result.ServerName >= len(s.requests) {
+		return
+	}
+	s.requests[result.ServerName]++
+	if failed {
+		s.failed[result.ServerName]++
+	}
+}
+
+// SNIFailures is a server name with failed requests
+type SNIFailures struct {
+	Name     string
+	Requests int64
+	Failed   int64
+}
+
+// SNISummary reports how the requests spread over the server names and which
+// names saw failures, e.g. ones the terminator has no certificate for
+type SNISummary struct {
+	File        string
+	Names       int           // Server names in the list
+	Used        int           // Names presented by at least one request
+	MinRequests int64         // Fewest requests of a used name
+	MaxRequests int64         // Most requests of a name
+	Failing     int           // Names with failed requests
+	Failures    []SNIFailures // Names with the most failed requests, most first
+}
+
+// summary returns the requests per server name (nil without an SNI list)
+func (s *sniStats) summary() *SNISummary {
+	if s == nil {
+		return nil
+	}
+	summary := &SNISummary{File: s.list.File, Names: len(s.list.Names)}
+	for i, requests := range s.requests {
+		if requests == 0 {
+			continue
+		}
+		if summary.Used == 0 || requests < summary.MinRequests {
+			summary.MinRequests = requests
+		}
+		summary.MaxRequests = max(summary.MaxRequests, requests)
+		summary.Used++
+		if s.failed[i] > 0 {
+			summary.Failing++
+			summary.Failures = append(summary.Failures, SNIFailures{Name: s.list.Names[i], Requests: requests, Failed: s.failed[i]})
+		}
+	}
+	sort.Slice(summary.Failures, func(i, j int) bool {
+		if summary.Failures[i].Failed != summary.Failures[j].Failed {
+			return summary.Failures[i].Failed > summary.Failures[j].Failed
+		}
+		return summary.Failures[i].Name < summary.Failures[j].Name
+	})
+	if len(summary.Failures) > maxSNIFailures {
+		summary.Failures = summary.Failures[:maxSNIFailures]
+	}
+	return summary
+}
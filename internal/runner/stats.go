@@ -1,36 +1,159 @@
 package runner
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/calummacc/g0/internal/clock"
 )
 
 // Result represents a single request result
 type Result struct {
-	Latency    time.Duration
-	StatusCode int
-	Error      error
+	Method      string    // HTTP method of the request
+	URL         string    // Request URL after template rendering
+	Target      string    // URL of the target as configured, before patterns and templates
+	SentAt      time.Time // When the request was sent
+	Latency     time.Duration
+	TTFB        time.Duration // Time to first response byte
+	Download    time.Duration // Time spent receiving the response body
+	Truncated   bool          // Body reading stopped at the byte limit
+	StatusCode  int
+	Error       error
+	ErrorClass  string   // Coarse classification of Error (timeout, dns, ...)
+	Conditional bool     // Request carried If-None-Match/If-Modified-Since
+	TraceID     string   // Trace ID sent with the request ("" if trace propagation is off)
+	Headers     []string // Values of the captured response headers, in capture order ("" = absent)
+	Cold        bool     // Among the first requests of its worker (cold-start reporting)
+	Connection  string   // Whether the request used a new or reused connection (ConnectionNew, ...)
+	Canary      bool     // Sent to the canary target instead of the baseline
+	Mirror      bool     // Duplicate sent to the mirror target; kept out of the overall statistics
+	Auth        bool     // Token request of an auth refresh; kept out of the overall statistics
+	AuthToken   string   // Token the request carried (AuthTokenRefreshed, ...; "" = none)
+	Range       bool     // Sent as a byte-range request
+
+	QueueDelay  time.Duration // Time from when the request was due until it was sent (arrival rate only; included in Latency)
+	LimiterWait time.Duration // Time the worker waited for the rate limiter before sending (not included in Latency)
+
+	Continue     string        // Expect: 100-continue outcome (httpclient.ContinueReceived, ...; "" if not sent)
+	ContinueWait time.Duration // Time until 100 Continue arrived
+	Protocol     string        // Protocol of the response, e.g. "HTTP/1.1" ("" without a response)
+	DNSLookup    time.Duration // Time spent resolving the host name (0 if no lookup was needed)
+
+	AuthChallenges int // HTTP authentication challenges (Digest, NTLM, Negotiate) answered before the response
+	ClientCert     int // Index of the client certificate presented (with a ClientCertPool)
+	ServerName     int // Index of the server name presented (with an SNIList)
+
+	TLSHandshake time.Duration // TLS handshake of the request's new connection (0 = none)
+	TLSResumed   bool          // The handshake resumed an earlier TLS session
+	CertVerify   time.Duration // Certificate chain verification within the handshake (0 = none)
+	OCSPStapled  bool          // The server stapled an OCSP response to its certificate
+
+	SchemaChecked   bool   // The response body was validated against the schema
+	BodyVerified    bool   // The response body hash was checked (a mismatch sets ErrorClassBodyMismatch)
+	SchemaViolation string // First schema violation found ("" = the body conforms)
+	BodyHash        uint64 // Shortened SHA-256 of a successful response body (0 = not hashed)
+
+	ServerTiming []ServerTimingMetric // Entries of the Server-Timing response header (nil if absent)
+
+	BytesSent       int64         // Request body bytes sent
+	BytesRead       int64         // Response body bytes received on the wire
+	DecodedBytes    int64         // Response body bytes after decompression
+	ContentEncoding string        // Content-Encoding of the response
+	DecompressTime  time.Duration // Time spent decompressing the body
+}
+
+// failed reports whether a request failed: a network error, an HTTP error status
+// or a successful response with an unexpected body or byte range
+func (r Result) failed() bool {
+	if r.Error != nil || r.StatusCode >= 400 {
+		return true
+	}
+	switch r.ErrorClass {
+	case ErrorClassBodyMismatch, ErrorClassRangeIgnored, ErrorClassRangeMismatch:
+		return true
+	}
+	return false
 }
 
 // Stats aggregates statistics from all requests
 type Stats struct {
 	mu sync.RWMutex
 
-	TotalRequests    int64
-	SuccessRequests  int64
-	FailedRequests   int64
-	StatusCodeCounts map[int]int64
-	Latencies        []time.Duration
-	StartTime        time.Time
-	EndTime          time.Time
+	TotalRequests       int64
+	SuccessRequests     int64
+	FailedRequests      int64
+	StatusCodeCounts    map[int]int64
+	ErrorClasses        map[string]int64         // Failed requests (without an HTTP status, or with an unexpected body) by error class
+	methods             map[string]*methodStats  // Requests by HTTP method
+	protocols           map[string]*latencyGroup // Requests by response protocol
+	targets             map[string]*latencyGroup // Requests by target URL as configured
+	Latencies           []time.Duration
+	TTFBs               []time.Duration
+	Downloads           []time.Duration
+	TruncatedResponses  int64 // Responses cut off at --max-body-bytes
+	BodyVerified        int64 // Successful response bodies checked against the expected hashes
+	BodyMismatches      int64 // Verified bodies matching none of the expected hashes
+	ConditionalRequests int64 // Requests sent with cache validators
+	NotModified         int64 // Conditional requests answered with 304
+	BytesSent           int64 // Request body bytes sent
+	BytesReceived       int64 // Response body bytes received on the wire
+	Compression         CompressionSummary
+	CancelledAtDeadline int64             // In-flight requests cancelled when the test ended
+	recent              slidingHistogram  // Latencies from the last few seconds (for live percentiles)
+	recentErrors        slidingCounter    // Requests and failures from the last few seconds (for the live error rate)
+	timeline            timeline          // Per-second buckets for the over-time charts
+	durations           durationHistogram // Latency histogram served to Prometheus
+	requestSizes        sizeBuckets       // Latency by request body size
+	responseSizes       sizeBuckets       // Latency by response body size
+	coldStart           *coldStartStats   // First requests per worker vs. steady state (nil = off)
+	canary              *canaryStats      // Baseline vs. canary requests (nil = no canary)
+	mirror              *mirrorStats      // Primary vs. mirrored requests (nil = no mirror)
+	auth                *authStats        // Token requests and business requests by token (nil = no auth refresh)
+	schema              *schemaStats      // Response schema violations (nil = no schema check)
+	bodies              *bodyStats        // Distinct response bodies per target (nil = not counted)
+	ranges              *rangeStats       // Range vs. full requests (nil = no range requests)
+	expect              *continueStats    // Expect: 100-continue outcomes (nil = header not sent)
+	arrivals            *arrivalStats     // Lateness of scheduled requests (nil = no arrival rate)
+	limiter             *limiterStats     // Waits for the rate limiter (nil = no rate limit)
+	dns                 *dnsStats         // Lookups through a custom DNS server (nil = system resolver)
+	httpAuth            *httpAuthStats    // Requests with and without an auth challenge (nil = no HTTP auth)
+	certs               *certStats        // Requests per client certificate (nil = no client certificates)
+	sni                 *sniStats         // Requests per server name (nil = no SNI list)
+	tls                 tlsStats          // Full and resumed TLS handshakes
+	timeoutCandidates   []time.Duration   // Client timeouts to check the latencies against (nil = no analysis)
+	timeoutLimit        time.Duration     // Timeout the requests ran with
+	tail                tailStats         // Attributes of each request for the tail analysis
+	traces              traceSamples      // Slowest and failed traced requests
+	health              *HealthMonitor    // Reports target outages on the progress line (nil = none)
+	memory              *memoryGuard      // Sheds bookkeeping to stay within --max-memory (nil = no budget)
+	stride              int               // Latencies are kept for one in stride requests (0 or 1 = all)
+	mirrored            int64             // Mirrored results, sampled like requests
+	slos                []SLO             // Objectives counted as results arrive
+	sloGood             []int64           // Good requests per SLO
+	headerNames         []string          // Captured response headers
+	headerCounts        []headerCounter   // Value counts per captured header
+	clock               clock.Clock       // Times the results as they arrive
+	serverTiming        serverTimingStats
+	StartTime           time.Time
+	EndTime             time.Time
 }
 
-// NewStats creates a new Stats instance
-func NewStats() *Stats {
+// NewStats creates a new Stats instance timed by c (nil = the system clock)
+func NewStats(c clock.Clock) *Stats {
+	c = clock.Or(c)
 	return &Stats{
 		StatusCodeCounts: make(map[int]int64),
+		ErrorClasses:     make(map[string]int64),
+		methods:          make(map[string]*methodStats),
+		protocols:        make(map[string]*latencyGroup),
+		targets:          make(map[string]*latencyGroup),
+		Compression:      CompressionSummary{Encodings: make(map[string]int64)},
 		Latencies:        make([]time.Duration, 0),
-		StartTime:        time.Now(),
+		StartTime:        c.Now(),
+		clock:            c,
 	}
 }
 
@@ -38,16 +161,121 @@ func NewStats() *Stats {
 func (s *Stats) AddResult(result Result) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.addResult(result)
+}
+
+// AddResults adds several results under one lock
+func (s *Stats) AddResults(results []Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, result := range results {
+		s.addResult(result)
+	}
+}
+
+// addResult adds a result; the caller holds s.mu
+func (s *Stats) addResult(result Result) {
+	// Mirrored requests are only compared with the primary ones
+	if result.Mirror {
+		s.mirrored++
+		s.mirror.add(result, result.failed(), s.sampled(s.mirrored))
+		return
+	}
+
+	// Token requests of an auth refresh are only reported on their own
+	if result.Auth {
+		s.auth.add(result)
+		return
+	}
+
+	// Requests cut off by the end of the test never completed, so they are
+	// counted on their own and kept out of request and latency statistics
+	if result.ErrorClass == ErrorClassCancelledAtDeadline {
+		s.CancelledAtDeadline++
+		return
+	}
+
+	failed := result.failed()
 
 	s.TotalRequests++
-	s.Latencies = append(s.Latencies, result.Latency)
+	now := s.clock.Now()
+	sampled := s.sampled(s.TotalRequests)
+	s.timeline.add(now.Sub(s.StartTime), len(s.Latencies), failed, result.LimiterWait)
+	if sampled {
+		s.Latencies = append(s.Latencies, result.Latency)
+		s.tail.add(result)
+	}
+	s.recent.record(result.Latency, now)
+	s.recentErrors.record(failed, now)
+	s.durations.add(result.Latency)
+	s.requestSizes.add(result.BytesSent, result.Latency)
+	if result.StatusCode > 0 {
+		s.responseSizes.add(result.BytesRead, result.Latency)
+		if sampled {
+			s.TTFBs = append(s.TTFBs, result.TTFB)
+			s.Downloads = append(s.Downloads, result.Download)
+		}
+	}
+	if result.Truncated {
+		s.TruncatedResponses++
+	}
+	if result.BodyVerified {
+		s.BodyVerified++
+		if result.ErrorClass == ErrorClassBodyMismatch {
+			s.BodyMismatches++
+		}
+	}
 
-	if result.Error != nil || result.StatusCode >= 400 {
+	if failed {
 		s.FailedRequests++
 	} else {
 		s.SuccessRequests++
 	}
 
+	m := s.methods[result.Method]
+	if m == nil {
+		m = &methodStats{}
+		s.methods[result.Method] = m
+	}
+	m.requests++
+	if failed {
+		m.failed++
+	}
+	if sampled {
+		m.latencies = append(m.latencies, result.Latency)
+	}
+	if result.Target != "" {
+		t := s.targets[result.Target]
+		if t == nil {
+			t = &latencyGroup{}
+			s.targets[result.Target] = t
+		}
+		t.record(result.Latency, failed, sampled)
+	}
+	if result.Protocol != "" {
+		p := s.protocols[result.Protocol]
+		if p == nil {
+			p = &latencyGroup{}
+			s.protocols[result.Protocol] = p
+		}
+		p.record(result.Latency, failed, sampled)
+	}
+	s.coldStart.add(result, failed, sampled)
+	s.canary.add(result, failed, sampled)
+	s.mirror.add(result, failed, sampled)
+	s.auth.addBusiness(result, failed, sampled)
+	s.schema.add(result)
+	s.bodies.add(result)
+	s.ranges.add(result, failed, sampled)
+	s.expect.add(result, sampled)
+	s.arrivals.add(result, sampled)
+	s.limiter.add(result, sampled)
+	s.dns.add(result, sampled)
+	s.httpAuth.add(result, failed, sampled)
+	s.certs.add(result, failed)
+	s.sni.add(result, failed)
+	s.tls.add(result, sampled)
+
 	// Record status code, including 0 for network errors
 	// StatusCode 0 indicates network/connection errors (not HTTP status codes)
 	if result.Error != nil && result.StatusCode == 0 {
@@ -58,29 +286,122 @@ func (s *Stats) AddResult(result Result) {
 		s.StatusCodeCounts[result.StatusCode]++
 	}
 	// Note: If StatusCode is 0 and Error is nil, it shouldn't happen in normal flow
+
+	for i, slo := range s.slos {
+		if slo.good(result) {
+			s.sloGood[i]++
+		}
+	}
+
+	if len(result.ServerTiming) > 0 {
+		s.serverTiming.add(result.ServerTiming, result.TTFB, sampled)
+	}
+
+	for i, value := range result.Headers {
+		s.headerCounts[i].add(value)
+	}
+
+	if result.TraceID != "" {
+		s.traces.add(result)
+	}
+
+	if result.ErrorClass != "" {
+		s.ErrorClasses[result.ErrorClass]++
+	}
+
+	if result.Conditional {
+		s.ConditionalRequests++
+		if result.StatusCode == 304 {
+			s.NotModified++
+		}
+	}
+
+	s.BytesSent += result.BytesSent
+	s.BytesReceived += result.BytesRead
+	if result.ContentEncoding != "" && result.ContentEncoding != "identity" {
+		s.Compression.Responses++
+		s.Compression.WireBytes += result.BytesRead
+		s.Compression.DecodedBytes += result.DecodedBytes
+		s.Compression.DecompressTime += result.DecompressTime
+		s.Compression.Encodings[result.ContentEncoding]++
+	}
 }
 
 // Finalize marks the end of the test
 func (s *Stats) Finalize() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.EndTime = time.Now()
+	s.EndTime = s.clock.Now()
 }
 
 // GetSummary returns a summary of the statistics
 func (s *Stats) GetSummary() Summary {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	summary, samples := s.summary(s.EndTime)
+	s.mu.RUnlock()
+	samples.summarize(&summary)
+	return summary
+}
 
-	if len(s.Latencies) == 0 {
-		return Summary{
-			TotalRequests:   s.TotalRequests,
-			SuccessRequests: s.SuccessRequests,
-			FailedRequests:  s.FailedRequests,
-			StatusCodeCounts: s.StatusCodeCounts,
+// SummarySoFar returns a summary of the results so far while the test is still
+// running, e.g. to checkpoint it
+func (s *Stats) SummarySoFar() Summary {
+	s.mu.RLock()
+	summary, samples := s.summary(s.clock.Now())
+	s.mu.RUnlock()
+	samples.summarize(&summary)
+	return summary
+}
+
+// summary summarizes the statistics of the test up to end, apart from the
+// latency distributions; callers hold s.mu, then complete the summary from the
+// returned copy of the samples once they released it, since sorting every
+// sample while results wait for the lock would stall the run
+func (s *Stats) summary(end time.Time) (Summary, *latencySamples) {
+	summary := Summary{
+		TotalRequests:       s.TotalRequests,
+		SuccessRequests:     s.SuccessRequests,
+		FailedRequests:      s.FailedRequests,
+		StatusCodeCounts:    maps.Clone(s.StatusCodeCounts),
+		ErrorClasses:        maps.Clone(s.ErrorClasses),
+		ConditionalRequests: s.ConditionalRequests,
+		NotModified:         s.NotModified,
+		BytesSent:           s.BytesSent,
+		BytesReceived:       s.BytesReceived,
+		Compression:         s.Compression,
+		TruncatedResponses:  s.TruncatedResponses,
+		BodyVerified:        s.BodyVerified,
+		BodyMismatches:      s.BodyMismatches,
+		CancelledAtDeadline: s.CancelledAtDeadline,
+	}
+	summary.Compression.Encodings = maps.Clone(s.Compression.Encodings)
+	samples := s.copySamples()
+	for i, slo := range s.slos {
+		summary.SLOs = append(summary.SLOs, newSLOResult(slo, s.TotalRequests, s.sloGood[i]))
+	}
+	summary.ServerTiming = s.serverTiming.summary()
+	for i, name := range s.headerNames {
+		summary.Headers = append(summary.Headers, newHeaderSummary(name, &s.headerCounts[i]))
+	}
+	if len(s.traces.slowest) > 0 {
+		summary.Traces = &TraceSummary{
+			Slowest: append([]TraceSample(nil), s.traces.slowest...),
+			Failed:  append([]TraceSample(nil), s.traces.failed...),
 		}
 	}
 
+	var downloadTime time.Duration
+	for _, d := range s.Downloads {
+		downloadTime += d
+	}
+	if downloadTime > 0 {
+		summary.DownloadThroughput = float64(s.BytesReceived) / downloadTime.Seconds()
+	}
+
+	if len(s.Latencies) == 0 {
+		return summary, samples
+	}
+
 	// Calculate latency statistics
 	var min, max, sum time.Duration
 	min = s.Latencies[0]
@@ -96,33 +417,141 @@ func (s *Stats) GetSummary() Summary {
 		sum += lat
 	}
 
-	avg := sum / time.Duration(len(s.Latencies))
-
-	// Calculate percentiles
-	p90 := Percentile(s.Latencies, 90)
-	p95 := Percentile(s.Latencies, 95)
-	p99 := Percentile(s.Latencies, 99)
+	summary.MinLatency = min
+	summary.MaxLatency = max
+	summary.AvgLatency = sum / time.Duration(len(s.Latencies))
+	summary.LatencyHistogram = NewLatencyHistogram(s.Latencies)
 
 	// Calculate RPS
-	duration := s.EndTime.Sub(s.StartTime)
-	var rps float64
-	if duration > 0 {
-		rps = float64(s.TotalRequests) / duration.Seconds()
+	summary.StartTime = s.StartTime
+	summary.EndTime = end
+	summary.Duration = end.Sub(s.StartTime)
+	if summary.Duration > 0 {
+		summary.RPS = float64(s.TotalRequests) / summary.Duration.Seconds()
 	}
+	summary.RequestSizes = s.requestSizes.summary()
+	summary.ResponseSizes = s.responseSizes.summary()
+	summary.ColdStart = s.coldStart.summary()
+	summary.Canary = s.canary.summary()
+	summary.Mirror = s.mirror.summary()
+	summary.Schema = s.schema.summary()
+	summary.Auth = s.auth.summary()
+	summary.Bodies = s.bodies.summary()
+	summary.Ranges = s.ranges.summary()
+	summary.ExpectContinue = s.expect.summary()
+	summary.Arrivals = s.arrivals.summary()
+	summary.Queueing = &QueueingSummary{}
+	s.limiter.summary(summary.Queueing)
+	summary.RateTarget = s.limiter.rateTarget(&summary, s.stride)
+	summary.DNS = s.dns.summary()
+	summary.HTTPAuth = s.httpAuth.summary()
+	summary.ClientCerts = s.certs.summary()
+	summary.SNI = s.sni.summary()
+	summary.TLS = s.tls.summary()
+
+	return summary, samples
+}
+
+// latencySamples is a copy of the samples a summary sorts, taken under s.mu
+type latencySamples struct {
+	latencies         []time.Duration // In completion order, as the timeline and tail expect
+	ttfbs             []time.Duration
+	downloads         []time.Duration
+	methods           map[string]methodStats
+	protocols         map[string]latencyGroup
+	targets           map[string]latencyGroup // Only with several targets
+	timeline          timeline
+	tail              tailStats
+	timeoutCandidates []time.Duration
+	timeoutLimit      time.Duration
+}
 
-	return Summary{
-		TotalRequests:    s.TotalRequests,
-		SuccessRequests:  s.SuccessRequests,
-		FailedRequests:   s.FailedRequests,
-		StatusCodeCounts: s.StatusCodeCounts,
-		MinLatency:       min,
-		MaxLatency:       max,
-		AvgLatency:       avg,
-		P90Latency:       p90,
-		P95Latency:       p95,
-		P99Latency:       p99,
-		RPS:              rps,
-		Duration:         duration,
+// copySamples copies the samples of the summary; callers hold s.mu
+func (s *Stats) copySamples() *latencySamples {
+	c := &latencySamples{
+		latencies: slices.Clone(s.Latencies),
+		ttfbs:     slices.Clone(s.TTFBs),
+		downloads: slices.Clone(s.Downloads),
+		timeline: timeline{
+			starts:   slices.Clone(s.timeline.starts),
+			requests: slices.Clone(s.timeline.requests),
+			failed:   slices.Clone(s.timeline.failed),
+			waits:    slices.Clone(s.timeline.waits),
+		},
+		tail:              tailStats{keys: slices.Clone(s.tail.keys), combos: slices.Clone(s.tail.combos)},
+		timeoutCandidates: s.timeoutCandidates,
+		timeoutLimit:      s.timeoutLimit,
+	}
+	if len(s.methods) > 0 {
+		c.methods = make(map[string]methodStats, len(s.methods))
+		for method, m := range s.methods {
+			c.methods[method] = methodStats{requests: m.requests, failed: m.failed, latencies: slices.Clone(m.latencies)}
+		}
+	}
+	c.protocols = copyLatencyGroups(s.protocols)
+	if len(s.targets) > 1 {
+		c.targets = copyLatencyGroups(s.targets)
+	}
+	return c
+}
+
+// copyLatencyGroups copies groups and their latencies (nil without groups)
+func copyLatencyGroups(groups map[string]*latencyGroup) map[string]latencyGroup {
+	if len(groups) == 0 {
+		return nil
+	}
+	c := make(map[string]latencyGroup, len(groups))
+	for key, g := range groups {
+		c[key] = latencyGroup{requests: g.requests, failed: g.failed, latencies: slices.Clone(g.latencies)}
+	}
+	return c
+}
+
+// summarize adds the latency distributions of the samples to summary, without
+// holding any lock
+func (c *latencySamples) summarize(summary *Summary) {
+	if len(c.methods) > 0 {
+		summary.Methods = make(map[string]MethodSummary, len(c.methods))
+		for method, m := range c.methods {
+			summary.Methods[method] = MethodSummary{
+				Requests: m.requests,
+				Failed:   m.failed,
+				Latency:  NewDurationStats(m.latencies),
+			}
+		}
+	}
+	if len(c.protocols) > 0 {
+		summary.Protocols = make(map[string]LatencyGroup, len(c.protocols))
+		for protocol, p := range c.protocols {
+			summary.Protocols[protocol] = p.summary()
+		}
+	}
+	if len(c.targets) > 0 {
+		summary.Targets = make(map[string]LatencyGroup, len(c.targets))
+		for target, t := range c.targets {
+			summary.Targets[target] = t.summary()
+		}
+	}
+
+	// Split latency into waiting for the server and receiving the body
+	summary.TTFB = NewDurationStats(c.ttfbs)
+	summary.Download = NewDurationStats(c.downloads)
+
+	if len(c.latencies) == 0 {
+		return
+	}
+
+	// Calculate percentiles from one sorted copy
+	sorted := slices.Clone(c.latencies)
+	slices.Sort(sorted)
+	summary.P90Latency = sortedPercentile(sorted, 90)
+	summary.P95Latency = sortedPercentile(sorted, 95)
+	summary.P99Latency = sortedPercentile(sorted, 99)
+
+	summary.Timeline = c.timeline.points(c.latencies, summary.Duration)
+	summary.Tail = c.tail.summary(c.latencies, &c.timeline)
+	if c.timeoutCandidates != nil {
+		summary.Timeouts = newTimeoutAnalysis(c.latencies, c.timeoutCandidates, c.timeoutLimit)
 	}
 }
 
@@ -131,6 +560,19 @@ type ProgressStats struct {
 	TotalRequests   int64
 	SuccessRequests int64
 	FailedRequests  int64
+
+	// Rolling latency percentiles over the last few seconds (0 if no recent requests)
+	RecentP50 time.Duration
+	RecentP95 time.Duration
+	RecentP99 time.Duration
+
+	// Requests and error rate (percent) over the last ErrorWindowSeconds seconds
+	RecentRequests  int64
+	RecentErrorRate float64
+
+	TargetDown bool // The last health check failed
+
+	MemoryEvents []MemoryEvent // What was shed to stay within --max-memory so far
 }
 
 // GetProgressStats returns current progress statistics without locking for long operations
@@ -138,26 +580,435 @@ func (s *Stats) GetProgressStats() ProgressStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	now := s.clock.Now()
+	recent := s.recent.percentiles(now, 50, 95, 99)
+	recentTotal, recentFailed := s.recentErrors.counts(now)
+	var recentErrorRate float64
+	if recentTotal > 0 {
+		recentErrorRate = float64(recentFailed) / float64(recentTotal) * 100
+	}
 	return ProgressStats{
 		TotalRequests:   s.TotalRequests,
 		SuccessRequests: s.SuccessRequests,
 		FailedRequests:  s.FailedRequests,
+		RecentP50:       recent[0],
+		RecentP95:       recent[1],
+		RecentP99:       recent[2],
+		RecentRequests:  recentTotal,
+		RecentErrorRate: recentErrorRate,
+		TargetDown:      !s.health.Healthy(),
+		MemoryEvents:    s.memory.eventList(),
+	}
+}
+
+// setSLOs sets the objectives to count; it must be called before results are added
+func (s *Stats) setSLOs(slos []SLO) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slos = slos
+	s.sloGood = make([]int64, len(slos))
+}
+
+// setCaptureHeaders sets the response headers to count; it must be called before results are added
+func (s *Stats) setCaptureHeaders(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headerNames = names
+	s.headerCounts = make([]headerCounter, len(names))
+	for i := range s.headerCounts {
+		s.headerCounts[i].values = make(map[string]int64)
+	}
+}
+
+// setColdRequests turns on cold-start reporting for the first n requests of each
+// worker; it must be called before results are added
+func (s *Stats) setColdRequests(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coldStart = &coldStartStats{requests: n}
+}
+
+// setCanary splits the results into baseline and canary; it must be called before results are added
+func (s *Stats) setCanary(c *Canary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.canary = &canaryStats{canary: c}
+}
+
+// setSchema counts schema violations; it must be called before results are added
+func (s *Stats) setSchema(c *SchemaCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schema = &schemaStats{check: c, kinds: make(map[string]int64)}
+}
+
+// setBodyCardinality counts the distinct response bodies per target; it must be
+// called before results are added
+func (s *Stats) setBodyCardinality() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = &bodyStats{targets: make(map[string]*bodyCounter)}
+}
+
+// setRanges splits the results into range and full requests; it must be called before results are added
+func (s *Stats) setRanges(r *RangeRequests) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ranges = &rangeStats{ranges: r}
+}
+
+// setExpectContinue counts Expect: 100-continue outcomes; it must be called before results are added
+func (s *Stats) setExpectContinue(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expect = &continueStats{timeout: timeout}
+}
+
+// setArrivalRate measures the lateness of scheduled requests; it must be called before results are added
+func (s *Stats) setArrivalRate(rate int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.arrivals = &arrivalStats{rate: rate}
+}
+
+// setRateLimited measures the waits for a rate limiter of target requests per
+// second shared by workers; it must be called before results are added
+func (s *Stats) setRateLimited(target, workers int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limiter = &limiterStats{target: target, workers: workers}
+}
+
+// setDNSServer reports lookups through server; it must be called before results are added
+func (s *Stats) setDNSServer(server string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dns = &dnsStats{server: server}
+}
+
+// setHTTPAuth splits the requests by auth challenge; it must be called before
+// results are added
+func (s *Stats) setHTTPAuth(scheme string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.httpAuth = &httpAuthStats{scheme: scheme}
+}
+
+// setClientCerts counts the requests of each certificate of pool; it must be
+// called before results are added
+func (s *Stats) setClientCerts(pool *ClientCertPool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certs = &certStats{pool: pool, requests: make([]int64, len(pool.Certs)), failed: make([]int64, len(pool.Certs))}
+}
+
+// setSNI counts the requests of each server name of list; it must be called
+// before results are added
+func (s *Stats) setSNI(list *SNIList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sni = &sniStats{list: list, requests: make([]int64, len(list.Names)), failed: make([]int64, len(list.Names))}
+}
+
+// setTLS records whether TLS sessions were offered for resumption, from how
+// many session caches, and whether OCSP staples were required; it must be
+// called before results are added
+func (s *Stats) setTLS(resumption bool, caches int, requireStaple bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tls.resumption, s.tls.caches, s.tls.requireStaple = resumption, caches, requireStaple
+}
+
+// setTimeoutCandidates checks the latencies against candidate client timeouts;
+// limit is the timeout the requests ran with
+func (s *Stats) setTimeoutCandidates(candidates []time.Duration, limit time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeoutCandidates = candidates
+	s.timeoutLimit = limit
+}
+
+// setMirror splits the results into primary and mirrored; it must be called before results are added
+func (s *Stats) setMirror(m *Mirror) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mirror = &mirrorStats{mirror: m}
+}
+
+// setAuth reports the token requests of a on their own; it must be called
+// before results are added
+func (s *Stats) setAuth(a *AuthRefresh) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = &authStats{auth: a, errors: make(map[string]int64), business: make(map[string]*authBusiness)}
+}
+
+// setHealth attaches a health monitor whose state is shown in progress stats
+func (s *Stats) setHealth(h *HealthMonitor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health = h
+}
+
+// setMemoryGuard attaches the guard whose events are shown in progress stats
+func (s *Stats) setMemoryGuard(g *memoryGuard) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memory = g
+}
+
+// downsample halves the latency samples to save memory: every other sample kept
+// so far is dropped, and from now on one in twice as many requests is sampled
+// It returns the new stride, or 0 if the samples can't be thinned any further
+func (s *Stats) downsample() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stride := max(s.stride, 1) * 2
+	if stride > maxSampleStride {
+		return 0
+	}
+	s.stride = stride
+	s.Latencies = thinned(s.Latencies)
+	s.TTFBs = thinned(s.TTFBs)
+	s.Downloads = thinned(s.Downloads)
+	s.tail.thin()
+	s.timeline.thin()
+	s.auth.thin()
+	s.httpAuth.thin()
+	s.coldStart.thin()
+	s.ranges.thin()
+	s.canary.thin()
+	s.mirror.thin()
+	s.expect.thin()
+	s.arrivals.thin()
+	s.limiter.thin()
+	s.dns.thin()
+	s.tls.thin()
+	s.serverTiming.thin()
+	for _, m := range s.methods {
+		m.latencies = thinned(m.latencies)
+	}
+	for _, groups := range []map[string]*latencyGroup{s.targets, s.protocols} {
+		for _, g := range groups {
+			g.thin()
+		}
 	}
+	return stride
+}
+
+// sampled reports whether the n-th request (counting from 1) keeps its
+// latencies and other per-request samples; the caller holds s.mu
+func (s *Stats) sampled(n int64) bool {
+	return s.stride <= 1 || n%int64(s.stride) == 0
+}
+
+// sampleStride returns one in how many requests keeps its latencies
+func (s *Stats) sampleStride() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return max(s.stride, 1)
 }
 
 // Summary contains aggregated statistics
 type Summary struct {
-	TotalRequests    int64
-	SuccessRequests  int64
-	FailedRequests   int64
-	StatusCodeCounts map[int]int64
-	MinLatency       time.Duration
-	MaxLatency       time.Duration
-	AvgLatency       time.Duration
-	P90Latency       time.Duration
-	P95Latency       time.Duration
-	P99Latency       time.Duration
-	RPS              float64
-	Duration         time.Duration
+	TotalRequests       int64
+	SuccessRequests     int64
+	FailedRequests      int64
+	StatusCodeCounts    map[int]int64
+	ErrorClasses        map[string]int64         // Failed requests (without an HTTP status, or with an unexpected body) by error class
+	Methods             map[string]MethodSummary // Requests by HTTP method
+	Protocols           map[string]LatencyGroup  // Requests by response protocol (e.g., "HTTP/2.0")
+	Targets             map[string]LatencyGroup  // Requests by target URL as configured (only with several targets)
+	URLStrategy         URLStrategy              // How workers picked their targets
+	HashKey             string                   // Data column hashed to pick the target (hash strategy only)
+	TargetWorkers       map[string]int           // Workers pinned to each target URL (sticky strategy only)
+	Failover            *FailoverSummary         // Targets taken out of rotation and their impact (nil without outages)
+	Chaos               *ChaosSummary            // Bursts and pauses of the load and their impact (nil without chaos events)
+	RPSTrace            *RPSTraceSummary         // How closely the run followed a recorded traffic shape (nil without one)
+	Timeouts            *TimeoutAnalysis         // Share of the requests candidate client timeouts would have cut off
+	Tail                *TailAnalysis            // Where the slowest 1% of the requests came from (nil with too few requests)
+	MinLatency          time.Duration
+	MaxLatency          time.Duration
+	AvgLatency          time.Duration
+	P90Latency          time.Duration
+	P95Latency          time.Duration
+	P99Latency          time.Duration
+	LatencyHistogram    []HistogramBucket // Non-empty latency buckets in ascending order
+	RPS                 float64
+	Duration            time.Duration
+	StartTime           time.Time
+	EndTime             time.Time
+	ConditionalRequests int64 // Requests sent with cache validators
+	NotModified         int64 // Conditional requests answered with 304
+	BytesSent           int64 // Request body bytes sent
+	BytesReceived       int64 // Response body bytes received on the wire
+	Compression         CompressionSummary
+
+	TTFB               DurationStats // Time to first response byte
+	Download           DurationStats // Time spent receiving response bodies
+	DownloadThroughput float64       // Sustained body download rate in bytes per second
+	TruncatedResponses int64         // Responses cut off at --max-body-bytes
+	BodySkipped        bool          // Bodies were discarded unread, so download metrics are absent
+	BodyVerified       int64         // Successful response bodies checked against the expected hashes
+	BodyMismatches     int64         // Verified bodies matching none of the expected hashes (counted as failed)
+
+	// Responses larger than this were aborted as response_too_large (0 = no limit)
+	MaxResponseBytes int64
+
+	// Slow client simulation; download times include the throttling
+	ReadRate  int64         // Response bodies were read at most this fast in bytes per second (0 = unlimited)
+	ReadDelay time.Duration // Pause before each chunk of a response body
+
+	CancelledAtDeadline int64 // In-flight requests cancelled when the test (and grace period) ended
+
+	Delivery DeliverySummary // Results that waited for or never reached the statistics
+	Audit    *AuditSummary   // Request counts of each stage (nil without --audit)
+
+	Aborted    bool              // The run was interrupted before the configured duration
+	Thresholds []ThresholdResult // Pass/fail outcome of configured thresholds
+
+	Seed  int64      // Seed used for randomized behavior; pass it to --seed to reproduce the run
+	RunID string     // ID of the run in its results, metrics and requests
+	Data  *DataUsage // Rows used of a unique data feed (nil unless rows are unique)
+
+	Timeline []TimelinePoint // Requests, rate and latency per second of the run
+
+	WorkerPools []WorkerPool // Workers dedicated to each target (nil unless targets set their own concurrency)
+
+	// Latency by body size (nil unless sizes varied enough to span several buckets)
+	RequestSizes  *SizeCorrelation
+	ResponseSizes *SizeCorrelation
+
+	ColdStart *ColdStartSummary // First requests of each worker vs. steady state (nil unless requested)
+	Canary    *CanarySummary    // Baseline vs. canary target (nil without a canary)
+	Mirror    *MirrorSummary    // Primary vs. mirror target (nil without a mirror)
+	Auth      *AuthSummary      // Token requests vs. business requests (nil without auth refresh)
+	Schema    *SchemaSummary    // Response bodies validated against a JSON Schema (nil without a schema)
+	Bodies    []BodyCardinality // Distinct response bodies per target (nil unless counted)
+	Ranges    *RangeSummary     // Range requests vs. full requests (nil without range requests)
+
+	ExpectContinue *ExpectContinueSummary // Handling of Expect: 100-continue (nil if the header wasn't sent)
+	Arrivals       *ArrivalSummary        // Lateness of requests at a constant arrival rate or an RPS trace (nil without one)
+	LittlesLaw     *LittlesLawSummary     // Requests in flight derived from throughput and latency (nil without requests)
+	Queueing       *QueueingSummary       // Sampled requests in flight and waits for the rate limiter
+	RateTarget     *RateTargetSummary     // --max-rps vs. the rate achieved (nil without a rate limit)
+	DNS            *DNSSummary            // Lookups through a custom DNS server (nil with the system resolver)
+	HTTPAuth       *HTTPAuthSummary       // Requests with and without an auth challenge (nil without HTTP auth)
+	ClientCerts    *ClientCertSummary     // Requests per client certificate (nil without client certificates)
+	SNI            *SNISummary            // Requests per server name of --sni-list (nil without one)
+	TLS            *TLSSummary            // Full vs. resumed TLS handshakes (nil if no connection made one)
+
+	Traces *TraceSummary // Trace IDs of notable requests (nil if trace propagation is off)
+
+	Health    *HealthSummary   // Health check results (nil if no health URL was given)
+	Resources *ResourceSummary // Target CPU/memory series (nil if no metrics URL was given)
+	Churn     *ChurnSummary    // Extra connections opened and closed during the run (nil if churn was off)
+	Users     *UsersSummary    // Virtual users multiplexed over the workers (nil without --users)
+	Cleanup   *CleanupSummary  // Created resources and their removal (nil unless tracked)
+
+	GeneratorCPU *GeneratorCPUSummary // CPU usage of the machine running g0 (nil where not measurable)
+	Memory       *MemorySummary       // g0's memory use against --max-memory (nil without a budget)
+
+	SLOs []SLOResult // Outcome of each SLO over the run
+
+	Headers []HeaderSummary // Value distribution of each captured response header
+
+	ServerTiming *ServerTimingSummary // Server-reported phase durations (nil if no response had Server-Timing)
+
+	Schedule *StartSchedule // Scheduled start and clock check (nil if the run started immediately)
+
+	Record *RecordSummary // Per-request record file (nil if requests were not recorded)
+
+	ErrorLog *ErrorLogSummary // NDJSON log of the failed requests (nil if none failed or there is no log)
+}
+
+// FailedSLOs returns the number of SLOs that were not met
+func (s *Summary) FailedSLOs() int {
+	failed := 0
+	for _, r := range s.SLOs {
+		if !r.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// StatusClassNetworkError is the status class of requests that got no HTTP response
+const StatusClassNetworkError = "error"
+
+// StatusClasses rolls the status codes up into classes ("2xx", "5xx", ...);
+// requests without a response are counted as StatusClassNetworkError
+func (s *Summary) StatusClasses() map[string]int64 {
+	classes := make(map[string]int64)
+	for code, count := range s.StatusCodeCounts {
+		if code <= 0 {
+			classes[StatusClassNetworkError] += count
+			continue
+		}
+		classes[fmt.Sprintf("%dxx", code/100)] += count
+	}
+	return classes
+}
+
+// ErrorRate returns the fraction of requests that failed
+func (s *Summary) ErrorRate() float64 {
+	if s.TotalRequests == 0 {
+		return 0
+	}
+	return float64(s.FailedRequests) / float64(s.TotalRequests)
+}
+
+// methodStats aggregates the requests sent with one HTTP method
+type methodStats struct {
+	requests  int64
+	failed    int64
+	latencies []time.Duration
 }
 
+// MethodSummary contains the statistics for one HTTP method
+type MethodSummary struct {
+	Requests int64
+	Failed   int64
+	Latency  DurationStats
+}
+
+// ErrorRate returns the fraction of the method's requests that failed
+func (m MethodSummary) ErrorRate() float64 {
+	if m.Requests == 0 {
+		return 0
+	}
+	return float64(m.Failed) / float64(m.Requests)
+}
+
+// CompressionSummary describes responses that arrived with a Content-Encoding
+type CompressionSummary struct {
+	Responses      int64            // Number of compressed responses
+	WireBytes      int64            // Compressed body bytes received
+	DecodedBytes   int64            // Body bytes after decompression
+	DecompressTime time.Duration    // Total time spent decompressing
+	Encodings      map[string]int64 // Response count per Content-Encoding
+}
+
+// Ratio returns the compression ratio (decoded size / wire size)
+func (c CompressionSummary) Ratio() float64 {
+	if c.WireBytes == 0 {
+		return 0
+	}
+	return float64(c.DecodedBytes) / float64(c.WireBytes)
+}
+
+// AvgDecompressTime returns the mean decompression time per compressed response
+func (c CompressionSummary) AvgDecompressTime() time.Duration {
+	if c.Responses == 0 {
+		return 0
+	}
+	return c.DecompressTime / time.Duration(c.Responses)
+}
+
+// NotModifiedRatio returns the fraction of conditional requests answered with 304
+func (s *Summary) NotModifiedRatio() float64 {
+	if s.ConditionalRequests == 0 {
+		return 0
+	}
+	return float64(s.NotModified) / float64(s.ConditionalRequests)
+}
@@ -0,0 +1,268 @@
+package runner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// latencyResults returns results with latencies of 1ms to n ms
+func latencyResults(n int) []Result {
+	results := make([]Result, n)
+	for i := range results {
+		results[i] = Result{Method: "GET", StatusCode: 200, Latency: time.Duration(i+1) * time.Millisecond}
+	}
+	return results
+}
+
+func TestStatsSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    []Result
+		step       time.Duration // Clock advance before each result
+		downsample int           // Thin the samples after this many results (0 = never)
+
+		total, failed int64
+		rps           float64
+		min, max, avg time.Duration
+		p90, p95, p99 time.Duration
+		classes       map[string]int64
+		samples       int
+	}{
+		{
+			name:    "percentiles interpolate between samples",
+			results: latencyResults(100),
+			step:    100 * time.Millisecond,
+			total:   100,
+			rps:     10,
+			min:     time.Millisecond,
+			max:     100 * time.Millisecond,
+			avg:     50500 * time.Microsecond,
+			p90:     90100 * time.Microsecond,
+			p95:     95050 * time.Microsecond,
+			p99:     99010 * time.Microsecond,
+			classes: map[string]int64{"2xx": 100},
+			samples: 100,
+		},
+		{
+			name: "status classes and failures",
+			results: []Result{
+				{StatusCode: 200, Latency: 10 * time.Millisecond},
+				{StatusCode: 204, Latency: 10 * time.Millisecond},
+				{StatusCode: 304, Latency: 10 * time.Millisecond},
+				{StatusCode: 404, Latency: 10 * time.Millisecond},
+				{StatusCode: 500, Latency: 10 * time.Millisecond},
+				{StatusCode: 503, Latency: 10 * time.Millisecond},
+				{Error: errors.New("connection refused"), ErrorClass: "connection_refused", Latency: 10 * time.Millisecond},
+				{StatusCode: 200, ErrorClass: ErrorClassBodyMismatch, Latency: 10 * time.Millisecond},
+			},
+			step:    250 * time.Millisecond,
+			total:   8,
+			failed:  5,
+			rps:     4,
+			min:     10 * time.Millisecond,
+			max:     10 * time.Millisecond,
+			avg:     10 * time.Millisecond,
+			p90:     10 * time.Millisecond,
+			p95:     10 * time.Millisecond,
+			p99:     10 * time.Millisecond,
+			classes: map[string]int64{"2xx": 3, "3xx": 1, "4xx": 1, "5xx": 2, StatusClassNetworkError: 1},
+			samples: 8,
+		},
+		{
+			// Every other sample of 1..1000 ms is kept; RPS and the request
+			// count come from the counters and stay exact
+			name:       "downsampled samples keep exact counts",
+			results:    latencyResults(1000),
+			step:       10 * time.Millisecond,
+			downsample: 500,
+			total:      1000,
+			rps:        100,
+			min:        time.Millisecond,
+			max:        1000 * time.Millisecond,
+			avg:        500500 * time.Microsecond,
+			p90:        900200 * time.Microsecond,
+			p95:        950100 * time.Microsecond,
+			p99:        990020 * time.Microsecond,
+			classes:    map[string]int64{"2xx": 1000},
+			samples:    500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+			s := NewStats(c)
+			for i, result := range tt.results {
+				if tt.downsample > 0 && i == tt.downsample {
+					s.downsample()
+				}
+				c.Advance(tt.step)
+				s.AddResult(result)
+			}
+			s.Finalize()
+			summary := s.GetSummary()
+
+			if summary.TotalRequests != tt.total || summary.FailedRequests != tt.failed {
+				t.Errorf("requests = %d (%d failed), want %d (%d failed)", summary.TotalRequests, summary.FailedRequests, tt.total, tt.failed)
+			}
+			if summary.RPS != tt.rps {
+				t.Errorf("RPS = %v, want %v", summary.RPS, tt.rps)
+			}
+			got := []time.Duration{summary.MinLatency, summary.MaxLatency, summary.AvgLatency, summary.P90Latency, summary.P95Latency, summary.P99Latency}
+			want := []time.Duration{tt.min, tt.max, tt.avg, tt.p90, tt.p95, tt.p99}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("min, max, avg, p90, p95, p99 = %v, want %v", got, want)
+			}
+			if classes := summary.StatusClasses(); !reflect.DeepEqual(classes, tt.classes) {
+				t.Errorf("status classes = %v, want %v", classes, tt.classes)
+			}
+			if len(s.Latencies) != tt.samples {
+				t.Errorf("%d latency samples, want %d", len(s.Latencies), tt.samples)
+			}
+		})
+	}
+}
+
+// retainedSamples returns the lengths of the per-request sample slices of s
+func retainedSamples(s *Stats) map[string]int {
+	n := map[string]int{
+		"latencies": len(s.Latencies), "ttfbs": len(s.TTFBs), "downloads": len(s.Downloads), "tail": len(s.tail.keys),
+		"expect": len(s.expect.waits), "arrivals": len(s.arrivals.delays),
+		"limiter waits": len(s.limiter.waits), "limiter sent": len(s.limiter.sent),
+		"dns": len(s.dns.lookups), "tls full": len(s.tls.full), "tls resumed": len(s.tls.resumed), "tls verify": len(s.tls.verify),
+		"server timing": len(s.serverTiming.server), "server timing remainder": len(s.serverTiming.remainder),
+	}
+	for method, m := range s.methods {
+		n["method "+method] = len(m.latencies)
+	}
+	for name, groups := range map[string]map[string]*latencyGroup{"target": s.targets, "protocol": s.protocols} {
+		for key, g := range groups {
+			n[name+" "+key] = len(g.latencies)
+		}
+	}
+	for name, g := range map[string]*latencyGroup{
+		"cold": &s.coldStart.cold, "steady": &s.coldStart.steady, "new conn": &s.coldStart.newConn, "reused conn": &s.coldStart.reusedConn,
+		"canary baseline": &s.canary.baseline, "canary": &s.canary.target, "mirror primary": &s.mirror.primary, "mirror": &s.mirror.shadow,
+		"ranged": &s.ranges.ranged, "full": &s.ranges.full,
+	} {
+		n[name] = len(g.latencies)
+	}
+	for phase, durations := range s.serverTiming.phases {
+		n["phase "+phase] = len(durations)
+	}
+	return n
+}
+
+// totalSamples sums the lengths of retainedSamples
+func totalSamples(s *Stats) int {
+	total := 0
+	for _, n := range retainedSamples(s) {
+		total += n
+	}
+	return total
+}
+
+func TestDownsampleBoundsRetainedSamples(t *testing.T) {
+	const (
+		requests = 200000
+		limit    = 20000
+	)
+	s := NewStats(nil)
+	s.coldStart = &coldStartStats{requests: 1}
+	s.canary = &canaryStats{canary: &Canary{}}
+	s.mirror = &mirrorStats{mirror: &Mirror{}}
+	s.ranges = &rangeStats{ranges: &RangeRequests{}}
+	s.expect = &continueStats{}
+	s.arrivals = &arrivalStats{}
+	s.limiter = &limiterStats{target: 1000, workers: 10}
+	s.dns = &dnsStats{}
+
+	sent := time.Now()
+	for i := 0; i < requests; i++ {
+		latency := time.Duration(1+i%100) * time.Millisecond
+		connection := ConnectionReused
+		if i%50 == 0 {
+			connection = ConnectionNew
+		}
+		s.AddResult(Result{
+			Method:       []string{"GET", "POST"}[i%2],
+			URL:          "http://example.com/",
+			Target:       []string{"http://a.example.com/", "http://b.example.com/"}[i%2],
+			StatusCode:   200,
+			Protocol:     "HTTP/1.1",
+			Latency:      latency,
+			TTFB:         latency / 2,
+			Download:     latency / 2,
+			Cold:         i < 10,
+			Connection:   connection,
+			Canary:       i%10 == 0,
+			Range:        i%2 == 0,
+			Continue:     httpclient.ContinueReceived,
+			ContinueWait: time.Millisecond,
+			QueueDelay:   time.Millisecond,
+			LimiterWait:  time.Millisecond,
+			SentAt:       sent.Add(time.Duration(i) * time.Millisecond),
+			DNSLookup:    time.Millisecond,
+			TLSHandshake: 5 * time.Millisecond,
+			TLSResumed:   i%2 == 0,
+			CertVerify:   time.Millisecond,
+			ServerTiming: []ServerTimingMetric{{Name: "db", Duration: latency / 4}},
+		})
+		if i%10 == 0 {
+			s.AddResult(Result{Mirror: true, StatusCode: 200, Latency: latency})
+		}
+
+		// As the memory guard does when nearing its budget
+		for totalSamples(s) > limit {
+			if s.downsample() == 0 {
+				t.Fatalf("after %d requests: %d samples retained at the largest stride, limit %d", i+1, totalSamples(s), limit)
+			}
+		}
+	}
+
+	// Thinning keeps the counts exact
+	summary := s.GetSummary()
+	if summary.TotalRequests != requests {
+		t.Errorf("TotalRequests = %d, want %d", summary.TotalRequests, requests)
+	}
+	if got := summary.Methods["GET"].Requests + summary.Methods["POST"].Requests; got != requests {
+		t.Errorf("requests by method = %d, want %d", got, requests)
+	}
+	if got := summary.TLS.Full + summary.TLS.Resumed; got != requests {
+		t.Errorf("TLS handshakes = %d, want %d", got, requests)
+	}
+	if summary.TLS.Verified != requests {
+		t.Errorf("TLS verified = %d, want %d", summary.TLS.Verified, requests)
+	}
+	if summary.DNS.Lookups != requests {
+		t.Errorf("DNS lookups = %d, want %d", summary.DNS.Lookups, requests)
+	}
+	if summary.Arrivals.Requests != requests {
+		t.Errorf("arrival requests = %d, want %d", summary.Arrivals.Requests, requests)
+	}
+	if summary.ServerTiming.Responses != requests || summary.ServerTiming.Phases[0].Count != requests {
+		t.Errorf("Server-Timing responses = %d, db phase = %d, want %d", summary.ServerTiming.Responses, summary.ServerTiming.Phases[0].Count, requests)
+	}
+	if got := summary.Canary.Baseline.Requests + summary.Canary.Canary.Requests; got != requests {
+		t.Errorf("canary requests = %d, want %d", got, requests)
+	}
+	if summary.Mirror.Mirror.Requests != requests/10 {
+		t.Errorf("mirrored requests = %d, want %d", summary.Mirror.Mirror.Requests, requests/10)
+	}
+
+	// No slice keeps more than one sample per sampled request; one thinned
+	// less often than the others would hold about twice as many
+	stride := s.sampleStride()
+	if stride == 1 {
+		t.Fatalf("samples were never thinned")
+	}
+	for name, n := range retainedSamples(s) {
+		if max := requests/stride*11/10 + 1; n > max {
+			t.Errorf("%s: %d samples retained at stride %d, want at most %d", name, n, stride, max)
+		}
+	}
+}
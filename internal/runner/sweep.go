@@ -0,0 +1,52 @@
+package runner
+
+import "time"
+
+// SweepStage is one stage of a payload size sweep: a run at one value of {{size}}
+type SweepStage struct {
+	Label   string // Size as given, e.g. "10KB"
+	Size    int64  // Payload size in bytes
+	Summary *Summary
+}
+
+// Throughput returns the body bytes sent and received per second of the stage
+func (s SweepStage) Throughput() float64 {
+	if s.Summary.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Summary.BytesSent+s.Summary.BytesReceived) / s.Summary.Duration.Seconds()
+}
+
+// SweepFit is a least-squares line through the average latency of each stage
+// by its payload size
+type SweepFit struct {
+	Base   time.Duration // Latency extrapolated to an empty payload
+	PerMiB time.Duration // Latency added by each MiB of payload
+}
+
+// FitSweep fits the average latency of the stages against their payload sizes;
+// ok is false with fewer than two distinct sizes
+func FitSweep(stages []SweepStage) (fit SweepFit, ok bool) {
+	var n, sumX, sumY, sumXX, sumXY float64
+	for _, s := range stages {
+		if s.Summary.TotalRequests == 0 {
+			continue
+		}
+		x := float64(s.Size) / (1 << 20)
+		y := float64(s.Summary.AvgLatency)
+		n++
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	denominator := n*sumXX - sumX*sumX
+	if n < 2 || denominator == 0 {
+		return SweepFit{}, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return SweepFit{
+		Base:   time.Duration((sumY - slope*sumX) / n),
+		PerMiB: time.Duration(slope),
+	}, true
+}
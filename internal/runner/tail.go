@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// tailPercent is the share of the slowest requests the tail analysis looks at
+const tailPercent = 1.0
+
+// minTailRequests is how many requests a run needs for the tail to mean anything
+const minTailRequests = 100
+
+// maxTailShares is how many values of each attribute the tail analysis lists
+const maxTailShares = 5
+
+// maxTailKeys caps the distinct attribute combinations tracked; further ones
+// share tailOverflow
+const maxTailKeys = 1 << 16
+
+// tailOverflow stands for attribute combinations past maxTailKeys
+var tailOverflow = tailKey{target: "other", status: "other", connection: "other"}
+
+// tailKey is the combination of attributes the tail analysis breaks requests down by
+type tailKey struct {
+	target     string
+	status     string // Status code, or error class of requests without a response
+	connection string
+}
+
+// tailStats records the attributes of each request alongside Stats.Latencies,
+// as a small index into the combinations seen so far
+type tailStats struct {
+	keys   []uint16 // Combination of each request, in the order of Stats.Latencies
+	index  map[tailKey]uint16
+	combos []tailKey
+}
+
+// add records the attributes of a result just appended to Stats.Latencies
+func (t *tailStats) add(result Result) {
+	key := tailKey{target: result.Target, status: strconv.Itoa(result.StatusCode), connection: result.Connection}
+	if result.StatusCode == 0 {
+		key.status = result.ErrorClass
+		if key.status == "" {
+			key.status = "error"
+		}
+	}
+	if key.connection == ConnectionNone {
+		key.connection = "none"
+	}
+
+	i, ok := t.index[key]
+	if !ok && len(t.combos) >= maxTailKeys-1 {
+		key = tailOverflow
+		i, ok = t.index[key]
+	}
+	if !ok {
+		if t.index == nil {
+			t.index = make(map[tailKey]uint16)
+		}
+		i = uint16(len(t.combos))
+		t.index[key] = i
+		t.combos = append(t.combos, key)
+	}
+	t.keys = append(t.keys, i)
+}
+
+// thin drops the attributes of every other request, as Stats.downsample does
+// with their latencies
+func (t *tailStats) thin() {
+	t.keys = thinned(t.keys)
+}
+
+// TailShare is one value of an attribute with its share of the tail and of all requests
+type TailShare struct {
+	Value    string
+	Tail     int64 // Tail requests with the value
+	Requests int64 // Requests with the value
+}
+
+// TailSecond is a second of the run with its tail requests
+type TailSecond struct {
+	Offset   time.Duration
+	Tail     int64
+	Requests int64
+}
+
+// TailAnalysis characterizes the slowest 1% of the requests: where they went,
+// what they got back, whether they opened a connection and when they completed
+// Each attribute lists the values with the most tail requests first
+type TailAnalysis struct {
+	Percent   float64       // Share of the slowest requests analyzed
+	Threshold time.Duration // Latency of the fastest request in the tail
+	Requests  int64         // Requests in the tail
+	Total     int64         // Requests of the run
+
+	Targets     []TailShare // Only with several targets
+	Statuses    []TailShare
+	Connections []TailShare
+	Seconds     []TailSecond // Seconds with the most tail requests
+	SecondsHit  int          // Seconds with at least one tail request
+	SecondCount int          // Seconds of the run
+}
+
+// summary analyzes the slowest requests of latencies, bucketed into seconds by
+// the timeline (nil with too few requests)
+func (t *tailStats) summary(latencies []time.Duration, timeline *timeline) *TailAnalysis {
+	if len(latencies) < minTailRequests || len(t.keys) != len(latencies) {
+		return nil
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	threshold := sorted[n-max(1, int(float64(n)*tailPercent/100))]
+
+	a := &TailAnalysis{Percent: tailPercent, Threshold: threshold, Total: int64(n), SecondCount: len(timeline.starts)}
+	tail := make([]int64, len(t.combos))
+	all := make([]int64, len(t.combos))
+	seconds := make([]TailSecond, len(timeline.starts))
+	bucket := 0
+	for i, latency := range latencies {
+		for bucket+1 < len(timeline.starts) && timeline.starts[bucket+1] <= i {
+			bucket++
+		}
+		all[t.keys[i]]++
+		seconds[bucket].Requests++
+		if latency >= threshold {
+			tail[t.keys[i]]++
+			seconds[bucket].Tail++
+			a.Requests++
+		}
+	}
+
+	// Sum the combinations per attribute
+	targets, statuses, connections := map[string]*TailShare{}, map[string]*TailShare{}, map[string]*TailShare{}
+	for i, key := range t.combos {
+		for _, group := range []struct {
+			shares map[string]*TailShare
+			value  string
+		}{{targets, key.target}, {statuses, key.status}, {connections, key.connection}} {
+			share := group.shares[group.value]
+			if share == nil {
+				share = &TailShare{Value: group.value}
+				group.shares[group.value] = share
+			}
+			share.Tail += tail[i]
+			share.Requests += all[i]
+		}
+	}
+	if len(targets) > 1 {
+		a.Targets = topTailShares(targets)
+	}
+	a.Statuses = topTailShares(statuses)
+	a.Connections = topTailShares(connections)
+
+	for i := range seconds {
+		seconds[i].Offset = time.Duration(i) * timelineInterval
+		if seconds[i].Tail > 0 {
+			a.SecondsHit++
+		}
+	}
+	sort.SliceStable(seconds, func(i, j int) bool { return seconds[i].Tail > seconds[j].Tail })
+	for _, second := range seconds {
+		if second.Tail == 0 || len(a.Seconds) == maxTailShares {
+			break
+		}
+		a.Seconds = append(a.Seconds, second)
+	}
+	return a
+}
+
+// topTailShares returns the values with the most tail requests, most first
+func topTailShares(shares map[string]*TailShare) []TailShare {
+	var top []TailShare
+	for _, share := range shares {
+		if share.Tail > 0 {
+			top = append(top, *share)
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Tail != top[j].Tail {
+			return top[i].Tail > top[j].Tail
+		}
+		return top[i].Value < top[j].Value
+	})
+	if len(top) > maxTailShares {
+		top = top[:maxTailShares]
+	}
+	return top
+}
@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// Target describes a single endpoint under test
+// Method, Headers and Body are optional overrides; when empty the global
+// request template (Config.Method, Config.Headers, Config.Body) is used, except
+// for a body a targets file sets to "", which sends an empty body
+type Target struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"` // Merged on top of the global headers
+	Body    string            `json:"body,omitempty"`
+
+	// Protocol forces the HTTP version: "1.0", "1.1" or "2" (HTTP/2 over https;
+	// "" = negotiate as usual)
+	Protocol string `json:"protocol,omitempty"`
+
+	// Concurrency dedicates this many workers to the target instead of sharing
+	// the global pool with the other targets (0 = share the pool)
+	Concurrency int `json:"concurrency,omitempty"`
+
+	templated bool   // URL, headers or body contain {{...}} actions rendered per request
+	origin    string // URL as configured, before pattern expansion and rendering
+	bodySet   bool   // The targets file sets the body, even to "" ("body": null does not)
+}
+
+// UnmarshalJSON decodes a target, noting whether it sets a body
+func (t *Target) UnmarshalJSON(data []byte) error {
+	type target Target // Without this method
+	var body struct {
+		Body *string `json:"body"`
+	}
+	if err := json.Unmarshal(data, (*target)(t)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	t.bodySet = body.Body != nil
+	return nil
+}
+
+// LoadTargets reads a JSON targets file containing an array of targets
+//
+// Example:
+//
+//	[
+//	  {"url": "https://api.example.com/users"},
+//	  {"url": "https://api.example.com/orders", "method": "POST", "body": "{}",
+//	   "headers": {"Content-Type": "application/json"}},
+//	  {"url": "https://api.example.com/ping", "method": "POST", "body": ""},
+//	  {"url": "https://legacy.example.com/status", "protocol": "1.0"},
+//	  {"url": "https://static.example.com/app.js", "concurrency": 20}
+//	]
+func LoadTargets(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets file: %w", err)
+	}
+
+	var targets []Target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("failed to parse targets file: %w", err)
+	}
+
+	for i, t := range targets {
+		if t.URL == "" {
+			return nil, fmt.Errorf("target %d in %s has no url", i+1, path)
+		}
+		if err := httpclient.ValidateProtocol(t.Protocol); err != nil {
+			return nil, fmt.Errorf("target %d in %s: %w", i+1, path, err)
+		}
+		if t.Concurrency < 0 {
+			return nil, fmt.Errorf("target %d in %s has a negative concurrency", i+1, path)
+		}
+	}
+
+	return targets, nil
+}
+
+// resolve returns a copy of the target with every empty field filled in from the
+// global request template, so workers don't have to merge on every request
+func (t Target) resolve(method, body, protocol string, headers map[string]string) Target {
+	resolved := Target{
+		URL:         t.URL,
+		Method:      t.Method,
+		Body:        t.Body,
+		Protocol:    t.Protocol,
+		Concurrency: t.Concurrency,
+	}
+	if resolved.Method == "" {
+		resolved.Method = method
+	}
+	if resolved.Body == "" && !t.bodySet {
+		resolved.Body = body
+	}
+	if resolved.Protocol == "" {
+		resolved.Protocol = protocol
+	}
+
+	// Share the global header map when there is nothing to merge
+	if len(t.Headers) == 0 {
+		resolved.Headers = headers
+		return resolved
+	}
+
+	resolved.Headers = make(map[string]string, len(headers)+len(t.Headers))
+	for k, v := range headers {
+		resolved.Headers[k] = v
+	}
+	for k, v := range t.Headers {
+		resolved.Headers[k] = v
+	}
+	return resolved
+}
+
+// compressBody returns a copy of a resolved target with its body compressed once up
+// front and Content-Encoding set, so workers send the same precomputed payload
+func (t Target) compressBody(encoding string) (Target, error) {
+	if encoding == "" || t.Body == "" {
+		return t, nil
+	}
+
+	compressed, err := httpclient.EncodeBody(encoding, []byte(t.Body))
+	if err != nil {
+		return Target{}, fmt.Errorf("failed to compress body for %s: %w", t.URL, err)
+	}
+
+	headers := make(map[string]string, len(t.Headers)+1)
+	for k, v := range t.Headers {
+		headers[k] = v
+	}
+	headers["Content-Encoding"] = encoding
+
+	t.Body = string(compressed)
+	t.Headers = headers
+	return t, nil
+}
+
+// TargetURLs returns the URL of each target (for display and reporting)
+func TargetURLs(targets []Target) []string {
+	urls := make([]string, len(targets))
+	for i, t := range targets {
+		urls[i] = t.URL
+	}
+	return urls
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTargetBodyOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.json")
+	data := `[
+		{"url": "http://example.com/inherit"},
+		{"url": "http://example.com/null", "body": null},
+		{"url": "http://example.com/empty", "method": "POST", "body": ""},
+		{"url": "http://example.com/own", "body": "own"}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := LoadTargets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"global", "global", "", "own"}
+	for i, target := range targets {
+		if body := target.resolve("GET", "global", "", nil).Body; body != want[i] {
+			t.Errorf("%s: body %q, want %q", target.URL, body, want[i])
+		}
+	}
+	if method := targets[2].resolve("GET", "global", "", nil).Method; method != "POST" {
+		t.Errorf("method %q, want POST", method)
+	}
+	if body := (Target{URL: "http://example.com/"}).resolve("GET", "global", "", nil).Body; body != "global" {
+		t.Errorf("target without a file: body %q, want the global one", body)
+	}
+}
+
+func TestLoadTargetsInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"no url":               `[{"method": "GET"}]`,
+		"body of another type": `[{"url": "http://example.com/", "body": 42}]`,
+		"negative concurrency": `[{"url": "http://example.com/", "concurrency": -1}]`,
+	} {
+		path := filepath.Join(t.TempDir(), "targets.json")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTargets(path); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
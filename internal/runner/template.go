@@ -0,0 +1,200 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ErrorClassTemplate marks requests that were not sent because a template failed to render
+const ErrorClassTemplate = "template"
+
+// maxCachedTemplates bounds the per-worker template cache (URL patterns can
+// produce many distinct templated URLs)
+const maxCachedTemplates = 1024
+
+// randStringAlphabet is the character set used by randString
+const randStringAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// isTemplate reports whether s contains template actions
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// hasTemplate reports whether any field of the target is templated
+func (t Target) hasTemplate() bool {
+	if isTemplate(t.URL) || isTemplate(t.Body) {
+		return true
+	}
+	for _, v := range t.Headers {
+		if isTemplate(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTemplate checks that s is a valid request template by rendering it once
+// Argument errors (e.g., {{randInt 1}}) and unknown variables only show up when
+// rendering, so parsing alone is not enough; strings without {{...}} are always valid
+// data provides the variables (nil = no data file) and size the value of {{size}}
+func ValidateTemplate(s string, data *DataFeeder, size int64) error {
+	if !isTemplate(s) {
+		return nil
+	}
+	r := newTemplateRenderer(rand.New(rand.NewSource(1)), 0, nil)
+	r.size = size
+	r.row = data.sample()
+	if _, err := r.render(s); err != nil {
+		return fmt.Errorf("invalid template %q: %w", s, err)
+	}
+	return nil
+}
+
+// templateData holds the variables available as {{.name}}; unknown names are an error
+type templateData map[string]string
+
+// templateRenderer renders request templates for a single worker
+// Parsed templates are cached; it is not safe for concurrent use
+type templateRenderer struct {
+	rng       *rand.Rand
+	workerID  int
+	iteration int64
+	size      int64        // Payload size of the sweep stage, returned by {{size}}
+	data      *DataFeeder  // Source of per-request variables (nil = none)
+	row       templateData // Variables for the request being rendered
+
+	funcs template.FuncMap
+	cache map[string]*template.Template
+	buf   bytes.Buffer
+}
+
+// newTemplateRenderer creates a renderer for a worker using the given random source
+func newTemplateRenderer(rng *rand.Rand, workerID int, data *DataFeeder) *templateRenderer {
+	r := &templateRenderer{
+		rng:      rng,
+		workerID: workerID,
+		data:     data,
+		row:      templateData{},
+		cache:    make(map[string]*template.Template),
+	}
+	r.funcs = template.FuncMap{
+		// randInt returns a random integer between min and max (inclusive)
+		"randInt": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("randInt: max (%d) is less than min (%d)", max, min)
+			}
+			return min + r.rng.Intn(max-min+1), nil
+		},
+		// randString returns n random letters and digits
+		"randString": func(n int) (string, error) {
+			if n < 0 || n > 1<<20 {
+				return "", fmt.Errorf("randString: length must be between 0 and %d", 1<<20)
+			}
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = randStringAlphabet[r.rng.Intn(len(randStringAlphabet))]
+			}
+			return string(b), nil
+		},
+		// workerID returns the 0-based index of the worker sending the request
+		"workerID": func() int { return r.workerID },
+		// iteration returns the 0-based number of the request within its worker
+		"iteration": func() int64 { return r.iteration },
+		// size returns the payload size in bytes of the current g0 sweep stage (0 outside a sweep)
+		"size": func() int { return int(r.size) },
+	}
+	return r
+}
+
+// render executes s as a template; strings without {{...}} are returned as-is
+func (r *templateRenderer) render(s string) (string, error) {
+	if !isTemplate(s) {
+		return s, nil
+	}
+
+	tmpl, ok := r.cache[s]
+	if !ok {
+		var err error
+		tmpl, err = template.New("request").Funcs(r.funcs).Option("missingkey=error").Parse(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid template %q: %w", s, err)
+		}
+		if len(r.cache) < maxCachedTemplates {
+			r.cache[s] = tmpl
+		}
+	}
+
+	r.buf.Reset()
+	if err := tmpl.Execute(&r.buf, r.row); err != nil {
+		return "", err
+	}
+	return r.buf.String(), nil
+}
+
+// next starts the given iteration, taking its data row from the feeder
+func (r *templateRenderer) next(iteration int64) error {
+	r.iteration = iteration
+	if r.data != nil {
+		row, err := r.data.row(r.workerID, r.rng)
+		if err != nil {
+			return err
+		}
+		r.row = row
+	}
+	return nil
+}
+
+// value returns a column of the current data row ("" if absent)
+func (r *templateRenderer) value(column string) string {
+	return r.row[column]
+}
+
+// renderTarget returns a copy of the target with its URL, header values and body
+// rendered for the current iteration; all fields share one data row
+func (r *templateRenderer) renderTarget(t Target) (Target, error) {
+	var err error
+	if t.URL, err = r.render(t.URL); err != nil {
+		return Target{}, err
+	}
+	if t.Body, err = r.render(t.Body); err != nil {
+		return Target{}, err
+	}
+
+	// Only copy the header map if a value changes; render in key order so the
+	// random sequence doesn't depend on map iteration order
+	var keys []string
+	for k, v := range t.Headers {
+		if isTemplate(v) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return t, nil
+	}
+	sort.Strings(keys)
+
+	headers := make(map[string]string, len(t.Headers))
+	for k, v := range t.Headers {
+		headers[k] = v
+	}
+	for _, k := range keys {
+		if headers[k], err = r.render(t.Headers[k]); err != nil {
+			return Target{}, err
+		}
+	}
+	t.Headers = headers
+	return t, nil
+}
+
+// workerSeed derives an independent, reproducible seed for each worker from the run seed
+func workerSeed(seed int64, workerID int) int64 {
+	// splitmix64 finalizer, so neighbouring seeds and workers don't share sequences
+	z := uint64(seed) + uint64(workerID+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return int64(z ^ (z >> 31))
+}
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// thresholdMetrics lists the metrics thresholds can be defined on
+// Latency metrics are compared in milliseconds, rates in percent
+var thresholdMetrics = map[string]func(s *Summary) float64{
+	"min":          func(s *Summary) float64 { return durationMs(s.MinLatency) },
+	"avg":          func(s *Summary) float64 { return durationMs(s.AvgLatency) },
+	"max":          func(s *Summary) float64 { return durationMs(s.MaxLatency) },
+	"p90":          func(s *Summary) float64 { return durationMs(s.P90Latency) },
+	"p95":          func(s *Summary) float64 { return durationMs(s.P95Latency) },
+	"p99":          func(s *Summary) float64 { return durationMs(s.P99Latency) },
+	"rps":          func(s *Summary) float64 { return s.RPS },
+	"error_rate":   func(s *Summary) float64 { return s.ErrorRate() * 100 },
+	"success_rate": func(s *Summary) float64 { return (1 - s.ErrorRate()) * 100 },
+}
+
+// Threshold is a pass/fail condition on a summary metric, e.g. "p95<300ms"
+type Threshold struct {
+	Expression string  // Original expression
+	Metric     string  // Metric name (see thresholdMetrics)
+	Operator   string  // <, <=, >, >=
+	Value      float64 // Limit in the metric's unit (ms for latency, % for rates)
+}
+
+// ThresholdResult is the outcome of evaluating a threshold
+type ThresholdResult struct {
+	Threshold
+	Actual float64 // Measured value in the metric's unit
+	Passed bool
+}
+
+// ParseThreshold parses an expression like "p95<300ms", "error_rate<=1%" or "rps>=500"
+func ParseThreshold(expr string) (Threshold, error) {
+	compact := strings.ReplaceAll(expr, " ", "")
+
+	// Check two-character operators first so "<=" isn't read as "<"
+	for _, op := range []string{"<=", ">=", "<", ">"} {
+		i := strings.Index(compact, op)
+		if i < 0 {
+			continue
+		}
+		metric := strings.ToLower(compact[:i])
+		raw := compact[i+len(op):]
+		if _, ok := thresholdMetrics[metric]; !ok {
+			return Threshold{}, fmt.Errorf("invalid threshold %q: unknown metric %q (available: %s)", expr, metric, strings.Join(ThresholdMetricNames(), ", "))
+		}
+		value, err := parseThresholdValue(metric, raw)
+		if err != nil {
+			return Threshold{}, fmt.Errorf("invalid threshold %q: %w", expr, err)
+		}
+		return Threshold{Expression: expr, Metric: metric, Operator: op, Value: value}, nil
+	}
+
+	return Threshold{}, fmt.Errorf("invalid threshold %q (expected e.g. p95<300ms, error_rate<1%%, rps>=500)", expr)
+}
+
+// parseThresholdValue converts a limit into the metric's unit
+func parseThresholdValue(metric, raw string) (float64, error) {
+	switch metric {
+	case "error_rate", "success_rate":
+		return strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	case "rps":
+		return strconv.ParseFloat(raw, 64)
+	default:
+		// Latency: a duration ("300ms", "1.5s") or a bare number of milliseconds
+		if d, err := time.ParseDuration(raw); err == nil {
+			return durationMs(d), nil
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration", raw)
+		}
+		return value, nil
+	}
+}
+
+// ThresholdMetricNames returns the metric names thresholds may use
+func ThresholdMetricNames() []string {
+	return []string{"min", "avg", "max", "p90", "p95", "p99", "rps", "error_rate", "success_rate"}
+}
+
+// Evaluate checks the threshold against a summary
+func (t Threshold) Evaluate(s *Summary) ThresholdResult {
+	actual := thresholdMetrics[t.Metric](s)
+	var passed bool
+	switch t.Operator {
+	case "<":
+		passed = actual < t.Value
+	case "<=":
+		passed = actual <= t.Value
+	case ">":
+		passed = actual > t.Value
+	case ">=":
+		passed = actual >= t.Value
+	}
+	return ThresholdResult{Threshold: t, Actual: actual, Passed: passed}
+}
+
+// EvaluateThresholds evaluates all thresholds, stores the results on the summary
+// and returns the number that failed
+func EvaluateThresholds(s *Summary, thresholds []Threshold) int {
+	failed := 0
+	s.Thresholds = make([]ThresholdResult, 0, len(thresholds))
+	for _, t := range thresholds {
+		result := t.Evaluate(s)
+		if !result.Passed {
+			failed++
+		}
+		s.Thresholds = append(s.Thresholds, result)
+	}
+	return failed
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1000000.0
+}
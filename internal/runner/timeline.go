@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"sort"
+	"time"
+)
+
+// timelineInterval is the width of one timeline bucket
+const timelineInterval = time.Second
+
+// timeline splits the run into per-second buckets by completion time
+// Latencies are appended to Stats.Latencies in completion order, so each bucket
+// only needs the index of its first latency rather than a copy of the samples
+// Once the latencies are downsampled a bucket holds fewer latencies than requests
+type timeline struct {
+	starts   []int           // Index into Stats.Latencies of each bucket's first latency
+	requests []int64         // Requests per bucket
+	failed   []int64         // Failed requests per bucket
+	waits    []time.Duration // Time spent waiting for the rate limiter per bucket
+}
+
+// add accounts a result completed at offset from the start; index is the position
+// its latency is about to take in Stats.Latencies and wait its rate limiter wait
+func (t *timeline) add(offset time.Duration, index int, failed bool, wait time.Duration) {
+	if offset < 0 {
+		offset = 0
+	}
+	bucket := int(offset / timelineInterval)
+	for len(t.starts) <= bucket {
+		t.starts = append(t.starts, index)
+		t.requests = append(t.requests, 0)
+		t.failed = append(t.failed, 0)
+		t.waits = append(t.waits, 0)
+	}
+	t.requests[bucket]++
+	if failed {
+		t.failed[bucket]++
+	}
+	t.waits[bucket] += wait
+}
+
+// thin maps the bucket starts onto latencies of which every other one was dropped
+func (t *timeline) thin() {
+	for i, start := range t.starts {
+		t.starts[i] = (start + 1) / 2
+	}
+}
+
+// TimelinePoint summarizes the requests completed in one second of the run
+type TimelinePoint struct {
+	Offset   time.Duration // Start of the bucket, relative to the test start
+	Requests int64
+	Failed   int64
+	RPS      float64
+	Min      time.Duration
+	Max      time.Duration
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+
+	InFlightAvg float64       // Requests in flight on average, sampled every 100ms
+	InFlightMax int64         // Most requests in flight in a sample
+	LimiterWait time.Duration // Average time the requests waited for the rate limiter
+}
+
+// points computes the per-bucket statistics; a trailing bucket shorter than half
+// an interval is dropped, since its rate would be mostly noise
+func (t *timeline) points(latencies []time.Duration, duration time.Duration) []TimelinePoint {
+	points := make([]TimelinePoint, 0, len(t.starts))
+	for i, start := range t.starts {
+		offset := time.Duration(i) * timelineInterval
+		width := timelineInterval
+		if remaining := duration - offset; remaining < width {
+			width = remaining
+		}
+		if width < timelineInterval/2 {
+			break
+		}
+
+		end := len(latencies)
+		if i+1 < len(t.starts) {
+			end = t.starts[i+1]
+		}
+		point := TimelinePoint{
+			Offset:   offset,
+			Requests: t.requests[i],
+			Failed:   t.failed[i],
+			RPS:      float64(t.requests[i]) / width.Seconds(),
+		}
+		if end > start {
+			sorted := make([]time.Duration, end-start)
+			copy(sorted, latencies[start:end])
+			sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+			point.Min = sorted[0]
+			point.Max = sorted[len(sorted)-1]
+			point.P50 = sortedPercentile(sorted, 50)
+			point.P95 = sortedPercentile(sorted, 95)
+			point.P99 = sortedPercentile(sorted, 99)
+			point.LimiterWait = t.waits[i] / time.Duration(t.requests[i])
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// WorstSecond returns the second with the slowest single request (nil without a timeline)
+// A brief stall such as a long GC pause stands out here even when it barely moves
+// the percentiles of the whole run
+func (s *Summary) WorstSecond() *TimelinePoint {
+	var worst *TimelinePoint
+	for i := range s.Timeline {
+		if worst == nil || s.Timeline[i].Max > worst.Max {
+			worst = &s.Timeline[i]
+		}
+	}
+	return worst
+}
+
+// TimelineWindow describes the requests of part of the run, e.g. before or after an event
+type TimelineWindow struct {
+	Requests int64
+	Failed   int64
+	RPS      float64
+	P95      time.Duration // Per-second p95, averaged over the window's requests
+}
+
+// ErrorRate returns the fraction of the window's requests that failed
+func (w TimelineWindow) ErrorRate() float64 {
+	if w.Requests == 0 {
+		return 0
+	}
+	return float64(w.Failed) / float64(w.Requests)
+}
+
+// timelineWindow sums the timeline seconds starting in [from, to)
+func timelineWindow(timeline []TimelinePoint, from, to time.Duration) TimelineWindow {
+	var window TimelineWindow
+	var p95 float64
+	seconds := 0
+	for _, point := range timeline {
+		if point.Offset < from || point.Offset >= to {
+			continue
+		}
+		window.Requests += point.Requests
+		window.Failed += point.Failed
+		window.RPS += point.RPS
+		p95 += float64(point.P95) * float64(point.Requests)
+		seconds++
+	}
+	if seconds > 0 {
+		window.RPS /= float64(seconds)
+	}
+	if window.Requests > 0 {
+		window.P95 = time.Duration(p95 / float64(window.Requests))
+	}
+	return window
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultTimeoutCandidates are the client timeouts the latencies are checked against
+var DefaultTimeoutCandidates = []time.Duration{
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+}
+
+// timeoutBudget is the share of requests a suggested timeout may cut off
+const timeoutBudget = 0.001
+
+// TimeoutCandidate is a client timeout with the requests that would have exceeded it
+type TimeoutCandidate struct {
+	Timeout  time.Duration
+	Breached int64   // Requests that took longer
+	Rate     float64 // Share of the requests that took longer
+	Censored bool    // Not below the run's own timeout, which cut slower requests off, so they can't be counted
+}
+
+// TimeoutAnalysis reports what share of the requests each candidate client
+// timeout would have cut off, to pick timeouts from measured latencies
+type TimeoutAnalysis struct {
+	Requests   int64
+	Limit      time.Duration // Timeout of the run's own requests
+	Candidates []TimeoutCandidate
+	Suggested  time.Duration // Smallest candidate cutting off at most 0.1% of the requests (0 = none does)
+}
+
+// newTimeoutAnalysis checks the latencies against the candidates in ascending
+// order; limit is the timeout the requests ran with (nil without requests)
+func newTimeoutAnalysis(latencies, candidates []time.Duration, limit time.Duration) *TimeoutAnalysis {
+	if len(latencies) == 0 {
+		return nil
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	candidates = append([]time.Duration(nil), candidates...)
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+
+	a := &TimeoutAnalysis{Requests: int64(len(sorted)), Limit: limit}
+	for _, timeout := range candidates {
+		c := TimeoutCandidate{Timeout: timeout, Censored: limit > 0 && timeout >= limit}
+		if !c.Censored {
+			faster := sort.Search(len(sorted), func(i int) bool { return sorted[i] > timeout })
+			c.Breached = int64(len(sorted) - faster)
+			c.Rate = float64(c.Breached) / float64(len(sorted))
+			if a.Suggested == 0 && c.Rate <= timeoutBudget {
+				a.Suggested = timeout
+			}
+		}
+		a.Candidates = append(a.Candidates, c)
+	}
+	return a
+}
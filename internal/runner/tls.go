@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"errors"
+	"time"
+
+	"github.com/calummacc/g0/internal/httpclient"
+)
+
+// tlsStats collects the TLS handshakes of new connections, split by whether
+// they resumed an earlier session, with their certificate checks
+type tlsStats struct {
+	resumption    bool
+	caches        int
+	requireStaple bool
+	full          []time.Duration
+	resumed       []time.Duration
+	verify        []time.Duration
+	fullCount     int64
+	resumedCount  int64
+	verified      int64
+	stapled       int64
+	unstapled     int64
+}
+
+// add accounts a result's handshake, if its connection made one, keeping its
+// times if sampled
+func (t *tlsStats) add(result Result, sampled bool) {
+	if errors.Is(result.Error, httpclient.ErrNoOCSPStaple) {
+		t.unstapled++
+		return
+	}
+	if result.TLSHandshake <= 0 {
+		return
+	}
+	if result.TLSResumed {
+		t.resumedCount++
+		if sampled {
+			t.resumed = append(t.resumed, result.TLSHandshake)
+		}
+	} else {
+		t.fullCount++
+		if sampled {
+			t.full = append(t.full, result.TLSHandshake)
+		}
+	}
+	if result.CertVerify > 0 {
+		t.verified++
+		if sampled {
+			t.verify = append(t.verify, result.CertVerify)
+		}
+	}
+	if result.OCSPStapled {
+		t.stapled++
+	}
+}
+
+// thin drops every other handshake sample, as Stats.downsample does
+func (t *tlsStats) thin() {
+	t.full = thinned(t.full)
+	t.resumed = thinned(t.resumed)
+	t.verify = thinned(t.verify)
+}
+
+// TLSSummary reports how well a TLS terminator resumes sessions under load
+// Handshakes only happen when a connection is opened, so kept-alive
+// connections make few; a resumed handshake skips the certificate exchange and
+// the expensive key operations of a full one
+type TLSSummary struct {
+	Resumption     bool  // Sessions were offered for resumption (false with --no-tls-resumption)
+	SessionCaches  int   // One per client certificate, server name and worker shard; each starts with a full handshake
+	Full           int64 // Full handshakes
+	Resumed        int64 // Handshakes that resumed an earlier session
+	FullLatency    DurationStats
+	ResumedLatency DurationStats
+
+	// Verified counts the certificate chains verified (one per full handshake),
+	// taking Verification of the handshake time
+	Verified     int64
+	Verification DurationStats
+
+	RequireStaple bool  // Connections without an OCSP staple failed (--require-ocsp-staple)
+	Stapled       int64 // Handshakes whose server stapled an OCSP response
+	Unstapled     int64 // Requests failed for a missing staple with RequireStaple
+}
+
+// ResumedPercent returns the share of handshakes that resumed a session
+func (s *TLSSummary) ResumedPercent() float64 {
+	if total := s.Full + s.Resumed; total > 0 {
+		return float64(s.Resumed) / float64(total) * 100
+	}
+	return 0
+}
+
+// summary returns the TLS handshakes (nil if no connection made one)
+func (t *tlsStats) summary() *TLSSummary {
+	if t.fullCount+t.resumedCount == 0 && t.unstapled == 0 {
+		return nil
+	}
+	return &TLSSummary{
+		Resumption:     t.resumption,
+		SessionCaches:  t.caches,
+		Full:           t.fullCount,
+		Resumed:        t.resumedCount,
+		FullLatency:    NewDurationStats(t.full),
+		ResumedLatency: NewDurationStats(t.resumed),
+		Verified:       t.verified,
+		Verification:   NewDurationStats(t.verify),
+		RequireStaple:  t.requireStaple,
+		Stapled:        t.stapled,
+		Unstapled:      t.unstapled,
+	}
+}
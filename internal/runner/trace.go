@@ -0,0 +1,155 @@
+package runner
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Trace context formats
+const (
+	TraceW3C = "w3c" // traceparent/tracestate (W3C Trace Context)
+	TraceB3  = "b3"  // Single b3 header (Zipkin B3)
+)
+
+// TraceFormats lists the supported trace propagation formats
+var TraceFormats = []string{TraceW3C, TraceB3}
+
+// traceStateKey is the tracestate vendor key carrying the run ID
+const traceStateKey = "g0"
+
+// maxTraceSamples is how many slowest and failed traced requests are kept for the report
+const maxTraceSamples = 5
+
+// TracePropagation adds trace context headers to every request so server-side
+// traces can be filtered to load-test traffic and linked from the report
+type TracePropagation struct {
+	W3C   bool
+	B3    bool
+	RunID string // Identifies the run in tracestate (g0=<run ID>)
+
+	// LinkTemplate turns a trace ID into a URL for the report, e.g.
+	// "https://tracing.example.com/trace/{trace_id}" ("" = print IDs only)
+	LinkTemplate string
+}
+
+// NewTracePropagation creates trace propagation for the given formats (e.g., "w3c,b3")
+// with a random run ID
+func NewTracePropagation(formats string) (*TracePropagation, error) {
+	t := &TracePropagation{RunID: randomHex(8)}
+	for _, f := range strings.Split(formats, ",") {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case TraceW3C:
+			t.W3C = true
+		case TraceB3:
+			t.B3 = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown trace propagation format %q (supported: %s)", f, strings.Join(TraceFormats, ", "))
+		}
+	}
+	if !t.W3C && !t.B3 {
+		return nil, fmt.Errorf("no trace propagation format given (supported: %s)", strings.Join(TraceFormats, ", "))
+	}
+	return t, nil
+}
+
+// newTraceSource returns a random source for trace IDs
+// It is seeded independently of --seed so trace IDs never repeat across runs
+func newTraceSource() *rand.Rand {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(b[:]))))
+}
+
+// apply adds the trace headers for a new sampled trace to headers and returns the trace ID
+func (t *TracePropagation) apply(rng *rand.Rand, headers map[string]string) string {
+	var ids [24]byte
+	rng.Read(ids[:])
+	traceID := hex.EncodeToString(ids[:16])
+	spanID := hex.EncodeToString(ids[16:])
+
+	if t.W3C {
+		headers["traceparent"] = "00-" + traceID + "-" + spanID + "-01"
+		headers["tracestate"] = traceStateKey + "=" + t.RunID
+	}
+	if t.B3 {
+		headers["b3"] = traceID + "-" + spanID + "-1"
+	}
+	return traceID
+}
+
+// randomHex returns n random bytes as a hex string
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		rand.Read(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+// TraceSample identifies a traced request worth looking up in the tracing backend
+type TraceSample struct {
+	TraceID    string
+	Latency    time.Duration
+	StatusCode int    // 0 for network errors
+	ErrorClass string // Set for failed requests without a status
+}
+
+// traceSamples keeps the slowest and the first failed traced requests
+type traceSamples struct {
+	slowest []TraceSample // Sorted by latency, slowest first
+	failed  []TraceSample
+}
+
+// add records a traced request
+func (t *traceSamples) add(result Result) {
+	sample := TraceSample{
+		TraceID:    result.TraceID,
+		Latency:    result.Latency,
+		StatusCode: result.StatusCode,
+		ErrorClass: result.ErrorClass,
+	}
+
+	if result.failed() && len(t.failed) < maxTraceSamples {
+		t.failed = append(t.failed, sample)
+	}
+
+	if len(t.slowest) == maxTraceSamples && sample.Latency <= t.slowest[len(t.slowest)-1].Latency {
+		return
+	}
+	i := sort.Search(len(t.slowest), func(i int) bool { return t.slowest[i].Latency < sample.Latency })
+	t.slowest = append(t.slowest, TraceSample{})
+	copy(t.slowest[i+1:], t.slowest[i:])
+	t.slowest[i] = sample
+	if len(t.slowest) > maxTraceSamples {
+		t.slowest = t.slowest[:maxTraceSamples]
+	}
+}
+
+// TraceSummary links the run to the tracing backend
+type TraceSummary struct {
+	RunID   string        // Value of the g0 tracestate entry on every request
+	Slowest []TraceSample // Slowest traced requests, slowest first
+	Failed  []TraceSample // First failed traced requests
+
+	LinkTemplate string // See TracePropagation.LinkTemplate
+}
+
+// traceIDPlaceholder is replaced with the trace ID in link templates
+const traceIDPlaceholder = "{trace_id}"
+
+// Link returns the tracing backend URL for a trace, or the trace ID if no link template is set
+func (t *TraceSummary) Link(traceID string) string {
+	if t.LinkTemplate == "" {
+		return traceID
+	}
+	return strings.ReplaceAll(t.LinkTemplate, traceIDPlaceholder, traceID)
+}
@@ -0,0 +1,188 @@
+package runner
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxPatternSize caps the number of URLs a single pattern may expand to
+const maxPatternSize = 1 << 40
+
+// urlPattern is a URL containing {start..end} ranges and/or {a,b,c} lists
+// URLs are never materialized; the i-th expansion is computed on demand
+type urlPattern struct {
+	parts []patternPart
+	size  int64 // Total number of concrete URLs
+}
+
+// patternPart is either a literal string or a set of alternatives
+type patternPart struct {
+	literal string
+	values  []string // List alternatives ({a,b,c})
+	start   int64    // Range start ({1..10})
+	count   int64    // Number of alternatives (0 for literals)
+	step    int64    // +1 or -1 for ranges
+	width   int      // Zero-padding width for ranges like {001..100}
+}
+
+// value returns the i-th alternative of the part
+func (p patternPart) value(i int64) string {
+	if p.values != nil {
+		return p.values[i]
+	}
+	n := p.start + i*p.step
+	s := strconv.FormatInt(n, 10)
+	if p.width > 0 && len(s) < p.width {
+		if n < 0 {
+			return "-" + strings.Repeat("0", p.width-len(s)) + s[1:]
+		}
+		return strings.Repeat("0", p.width-len(s)) + s
+	}
+	return s
+}
+
+// parseURLPattern parses a URL with expansion expressions
+// It returns nil (and no error) if the URL contains no patterns
+// Double braces ({{...}}) are left untouched
+func parseURLPattern(raw string) (*urlPattern, error) {
+	pattern := &urlPattern{size: 1}
+	var literal strings.Builder
+	hasExpansion := false
+
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+
+		// Keep {{...}} verbatim
+		if i+1 < len(raw) && raw[i+1] == '{' {
+			end := strings.Index(raw[i:], "}}")
+			if end < 0 {
+				literal.WriteString(raw[i:])
+				break
+			}
+			literal.WriteString(raw[i : i+end+2])
+			i += end + 1
+			continue
+		}
+
+		end := strings.IndexByte(raw[i:], '}')
+		if end < 0 {
+			literal.WriteString(raw[i:])
+			break
+		}
+		expr := raw[i+1 : i+end]
+
+		part, ok, err := parsePatternExpr(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", raw, err)
+		}
+		if !ok {
+			// Not an expansion expression, keep braces as-is
+			literal.WriteString(raw[i : i+end+1])
+			i += end
+			continue
+		}
+
+		if literal.Len() > 0 {
+			pattern.parts = append(pattern.parts, patternPart{literal: literal.String()})
+			literal.Reset()
+		}
+		pattern.parts = append(pattern.parts, part)
+		if pattern.size > maxPatternSize/part.count {
+			return nil, fmt.Errorf("invalid URL pattern %q: expands to more than %d URLs", raw, int64(maxPatternSize))
+		}
+		pattern.size *= part.count
+		hasExpansion = true
+		i += end
+	}
+
+	if !hasExpansion {
+		return nil, nil
+	}
+	if literal.Len() > 0 {
+		pattern.parts = append(pattern.parts, patternPart{literal: literal.String()})
+	}
+	return pattern, nil
+}
+
+// parsePatternExpr parses the contents of a single {...} expression
+// ok is false if the expression is not a range or list
+func parsePatternExpr(expr string) (part patternPart, ok bool, err error) {
+	if from, to, found := strings.Cut(expr, ".."); found {
+		start, err1 := strconv.ParseInt(from, 10, 64)
+		end, err2 := strconv.ParseInt(to, 10, 64)
+		if err1 != nil || err2 != nil {
+			return patternPart{}, false, fmt.Errorf("range {%s} must use integers", expr)
+		}
+		part = patternPart{start: start, step: 1}
+		low, high := start, end
+		if end < start {
+			part.step = -1
+			low, high = end, start
+		}
+		// The distance between the bounds can exceed int64, but not uint64
+		span := uint64(high) - uint64(low)
+		if span >= maxPatternSize {
+			return patternPart{}, false, fmt.Errorf("range {%s} expands to more than %d URLs", expr, int64(maxPatternSize))
+		}
+		part.count = int64(span) + 1
+		// Leading zeros on either bound request zero-padding
+		if (len(from) > 1 && strings.TrimLeft(from, "-")[0] == '0') || (len(to) > 1 && strings.TrimLeft(to, "-")[0] == '0') {
+			part.width = len(strings.TrimLeft(from, "-"))
+			if w := len(strings.TrimLeft(to, "-")); w > part.width {
+				part.width = w
+			}
+		}
+		return part, true, nil
+	}
+
+	if strings.Contains(expr, ",") {
+		values := strings.Split(expr, ",")
+		return patternPart{values: values, count: int64(len(values))}, true, nil
+	}
+
+	return patternPart{}, false, nil
+}
+
+// at returns the i-th concrete URL; the rightmost expression varies fastest
+func (p *urlPattern) at(i int64) string {
+	i %= p.size
+	values := make([]string, len(p.parts))
+	for j := len(p.parts) - 1; j >= 0; j-- {
+		part := p.parts[j]
+		if part.count == 0 {
+			values[j] = part.literal
+			continue
+		}
+		values[j] = part.value(i % part.count)
+		i /= part.count
+	}
+	return strings.Join(values, "")
+}
+
+// ValidateURL checks that a URL, or the first expansion of a URL pattern, is an
+// absolute http or https URL
+func ValidateURL(raw string) error {
+	expanded := raw
+	pattern, err := parseURLPattern(raw)
+	if err != nil {
+		return err
+	}
+	if pattern != nil {
+		expanded = pattern.at(0)
+	}
+
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: must be an absolute http or https URL", raw)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseURLPatternRanges(t *testing.T) {
+	tests := []struct {
+		url   string
+		size  int64
+		first string
+		last  string
+	}{
+		{"http://example.com/{1..3}", 3, "http://example.com/1", "http://example.com/3"},
+		{"http://example.com/{3..1}", 3, "http://example.com/3", "http://example.com/1"},
+		{"http://example.com/{-2..2}", 5, "http://example.com/-2", "http://example.com/2"},
+		{"http://example.com/{001..100}", 100, "http://example.com/001", "http://example.com/100"},
+		{"http://example.com/{a,b}/{1..2}", 4, "http://example.com/a/1", "http://example.com/b/2"},
+		// Ranges at the ends of int64
+		{"http://example.com/{9223372036854775806..9223372036854775807}", 2, "http://example.com/9223372036854775806", "http://example.com/9223372036854775807"},
+		{"http://example.com/{-9223372036854775808..-9223372036854775807}", 2, "http://example.com/-9223372036854775808", "http://example.com/-9223372036854775807"},
+		{"http://example.com/{9223372036854775807..9223372036854775807}", 1, "http://example.com/9223372036854775807", "http://example.com/9223372036854775807"},
+	}
+	for _, tt := range tests {
+		p, err := parseURLPattern(tt.url)
+		if err != nil {
+			t.Errorf("%s: %v", tt.url, err)
+			continue
+		}
+		if p.size != tt.size {
+			t.Errorf("%s: %d URLs, want %d", tt.url, p.size, tt.size)
+		}
+		if first, last := p.at(0), p.at(p.size-1); first != tt.first || last != tt.last {
+			t.Errorf("%s: expands from %s to %s, want %s to %s", tt.url, first, last, tt.first, tt.last)
+		}
+	}
+}
+
+func TestParseURLPatternTooLarge(t *testing.T) {
+	for _, url := range []string{
+		// The distance between the bounds overflows int64
+		"http://example.com/{9223372036854775807..-9223372036854775808}",
+		"http://example.com/{-9223372036854775808..9223372036854775807}",
+		"http://example.com/{0..9223372036854775807}",
+		// One more than the cap, alone and multiplied
+		"http://example.com/{1.." + strconv.FormatInt(maxPatternSize+1, 10) + "}",
+		"http://example.com/{1..1048576}/{0..1048576}",
+	} {
+		if p, err := parseURLPattern(url); err == nil {
+			t.Errorf("%s: accepted with %d URLs", url, p.size)
+		}
+	}
+
+	// The cap itself is allowed
+	if p, err := parseURLPattern("http://example.com/{1.." + strconv.FormatInt(maxPatternSize, 10) + "}"); err != nil || p.size != maxPatternSize {
+		t.Errorf("pattern of %d URLs: %v", int64(maxPatternSize), err)
+	}
+}
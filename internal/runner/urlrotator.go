@@ -1,38 +1,149 @@
 package runner
 
 import (
+	"fmt"
+	"math/rand"
 	"sync/atomic"
 )
 
-// URLRotator provides round-robin URL rotation for load testing multiple endpoints
+// URLStrategy selects how workers pick the target of each request
+type URLStrategy string
+
+const (
+	StrategyRoundRobin URLStrategy = "round-robin" // Targets in turn across all workers
+	StrategySticky     URLStrategy = "sticky"      // Each worker keeps one target for the whole run (target = worker ID)
+	StrategyRandom     URLStrategy = "random"      // A random target per request (reproducible with --seed)
+	StrategyHash       URLStrategy = "hash"        // The target owning a data column value on a consistent hash ring
+)
+
+// ParseURLStrategy validates a --url-strategy value
+func ParseURLStrategy(s string) (URLStrategy, error) {
+	switch strategy := URLStrategy(s); strategy {
+	case StrategyRoundRobin, StrategySticky, StrategyRandom, StrategyHash:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown URL strategy %q (supported: round-robin, sticky, random, hash)", s)
+	}
+}
+
+// URLRotator provides target rotation for load testing multiple endpoints
+// Targets whose URL contains a pattern ({1..100}, {a,b,c}) are expanded lazily and
+// cycle through their concrete URLs each time they are selected
 type URLRotator struct {
-	urls []string
-	idx  int64 // Atomic counter for round-robin selection
+	targets  []Target
+	patterns []*urlPattern // Parsed pattern per target (nil for plain URLs)
+	counters []int64       // Atomic per-target expansion counters
+	idx      int64         // Atomic counter for round-robin selection
+	strategy URLStrategy
+	ring     *hashRing // Targets by key (hash strategy only)
+	down     []int32   // Atomic per-target flags: 1 = out of rotation (target outages)
 }
 
-// NewURLRotator creates a new URL rotator with the given URLs
-func NewURLRotator(urls []string) *URLRotator {
-	if len(urls) == 0 {
-		return nil
+// NewURLRotator creates a new URL rotator with the given targets
+// It returns an error if a target URL contains an invalid pattern
+func NewURLRotator(targets []Target, strategy URLStrategy) (*URLRotator, error) {
+	if len(targets) == 0 {
+		return nil, nil
 	}
-	return &URLRotator{
-		urls: urls,
-		idx:  0,
+
+	r := &URLRotator{
+		targets:  make([]Target, len(targets)),
+		patterns: make([]*urlPattern, len(targets)),
+		counters: make([]int64, len(targets)),
+		down:     make([]int32, len(targets)),
+		idx:      0,
+		strategy: strategy,
 	}
+	for i, t := range targets {
+		pattern, err := parseURLPattern(t.URL)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns[i] = pattern
+
+		// Results are reported by the target as configured, before any expansion
+		t.origin = t.URL
+		r.targets[i] = t
+	}
+	if strategy == StrategyHash {
+		r.ring = newHashRing(TargetURLs(targets))
+	}
+	return r, nil
 }
 
-// Next returns the next URL in round-robin fashion
-// Thread-safe using atomic operations
-func (r *URLRotator) Next() string {
-	if r == nil || len(r.urls) == 0 {
-		return ""
+// hashed reports whether targets are picked by a key, which Next then needs
+func (r *URLRotator) hashed() bool {
+	return r != nil && r.ring != nil
+}
+
+// setDown takes the targets with the given URL out of rotation or puts them back
+func (r *URLRotator) setDown(url string, down bool) {
+	var flag int32
+	if down {
+		flag = 1
 	}
-	if len(r.urls) == 1 {
-		return r.urls[0]
+	for i, t := range r.targets {
+		if t.URL == url {
+			atomic.StoreInt32(&r.down[i], flag)
+		}
 	}
-	
-	// Atomic increment and modulo for thread-safe round-robin
-	idx := atomic.AddInt64(&r.idx, 1) - 1
-	return r.urls[int(idx)%len(r.urls)]
 }
 
+// isDown reports whether target i is out of rotation
+func (r *URLRotator) isDown(i int) bool {
+	return atomic.LoadInt32(&r.down[i]) == 1
+}
+
+// pinned returns the target a worker keeps with the sticky strategy
+func (r *URLRotator) pinned(worker int) string {
+	return r.targets[worker%len(r.targets)].URL
+}
+
+// Next returns the next target for a worker, with any URL pattern expanded
+// The random strategy draws from the worker's own seeded source and the hash
+// strategy looks up key, the value of the hashed data column
+// Thread-safe using atomic operations
+func (r *URLRotator) Next(worker int, rng *rand.Rand, key string) (Target, bool) {
+	if r == nil || len(r.targets) == 0 {
+		return Target{}, false
+	}
+
+	i := 0
+	switch {
+	case len(r.targets) == 1:
+	case r.strategy == StrategySticky:
+		i = worker % len(r.targets)
+	case r.ring != nil:
+		i = r.ring.lookup(key, r.isDown)
+	case r.strategy == StrategyRandom:
+		// Independent draws keep workers out of lockstep, which round-robin
+		// produces when many workers start together
+		i = rng.Intn(len(r.targets))
+	default:
+		// Atomic increment and modulo for thread-safe round-robin
+		i = int((atomic.AddInt64(&r.idx, 1) - 1) % int64(len(r.targets)))
+	}
+
+	// A target out of rotation hands its requests to the next one that is up,
+	// as a client or load balancer failing over would
+	if r.isDown(i) {
+		for next := 1; next < len(r.targets); next++ {
+			if j := (i + next) % len(r.targets); !r.isDown(j) {
+				i = j
+				break
+			}
+		}
+	}
+
+	target := r.targets[i]
+	if pattern := r.patterns[i]; pattern != nil {
+		var n int64
+		if r.strategy == StrategyRandom {
+			n = rng.Int63n(pattern.size)
+		} else {
+			n = atomic.AddInt64(&r.counters[i], 1) - 1
+		}
+		target.URL = pattern.at(n)
+	}
+	return target, true
+}
@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/calummacc/g0/internal/clock"
+)
+
+// virtualUser is the state a user keeps between its requests; it is only
+// touched by the pool worker currently acting as the user
+type virtualUser struct {
+	iteration int64     // Requests started by the user
+	due       time.Time // When the user's next request is due
+	clientIP  string    // Fixed client address for per-worker spoofing
+}
+
+// UserScheduler multiplexes many virtual users over a bounded pool of workers
+// Each user sends a request, thinks, and sends the next one; an idle user costs
+// a few bytes and a timer instead of a goroutine, so memory stays flat at tens
+// of thousands of users
+type UserScheduler struct {
+	users []virtualUser
+	think time.Duration
+	ready chan int // Users whose next request is due
+
+	workers int // Size of the worker pool
+	clock   clock.Clock
+
+	wakeups  int64 // Requests started by users (atomic)
+	delaySum int64 // Total time users waited for a free worker, in ns (atomic)
+	delayMax int64 // Longest wait for a free worker, in ns (atomic)
+}
+
+// NewUserScheduler creates count users pausing think between their requests
+// Per-worker client addresses (options.ClientIP) are picked for each user
+func NewUserScheduler(count int, think time.Duration, options WorkerOptions) *UserScheduler {
+	s := &UserScheduler{
+		users: make([]virtualUser, count),
+		think: think,
+		ready: make(chan int, count),
+		clock: clock.Or(options.Clock),
+	}
+	if options.ClientIP != nil && options.ClientIP.PerWorker {
+		rng := rand.New(rand.NewSource(workerSeed(options.Seed, -1)))
+		for i := range s.users {
+			s.users[i].clientIP = options.ClientIP.pick(rng)
+		}
+	}
+	return s
+}
+
+// Run starts the users, staggered over one think time so they don't all send at
+// once, and drives them with the workers until ctx is cancelled
+func (s *UserScheduler) Run(ctx, requestCtx context.Context, workers []*Worker) {
+	s.workers = len(workers)
+	start := s.clock.Now()
+	for i := range s.users {
+		offset := s.think * time.Duration(i) / time.Duration(len(s.users))
+		s.users[i].due = start.Add(offset)
+		s.schedule(i, offset)
+	}
+
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker *Worker) {
+			defer wg.Done()
+			s.serve(ctx, requestCtx, worker)
+		}(worker)
+	}
+	wg.Wait()
+}
+
+// schedule queues user i once delay has passed
+func (s *UserScheduler) schedule(i int, delay time.Duration) {
+	if delay <= 0 {
+		s.ready <- i
+		return
+	}
+	time.AfterFunc(delay, func() { s.ready <- i })
+}
+
+// serve lets worker act as each due user in turn until ctx is cancelled
+func (s *UserScheduler) serve(ctx, requestCtx context.Context, worker *Worker) {
+	defer func() {
+		// Same safety net as Worker.Start
+		recover()
+	}()
+
+	for {
+		var i int
+		select {
+		case <-ctx.Done():
+			return
+		case i = <-s.ready:
+		}
+
+		user := &s.users[i]
+		delay := s.clock.Since(user.due)
+		worker.actAs(i, user.iteration, user.clientIP)
+		more := worker.send(ctx, requestCtx)
+		user.iteration = worker.iteration
+		if !more {
+			return
+		}
+		s.recordWakeup(delay)
+		user.due = s.clock.Now().Add(s.think)
+		s.schedule(i, s.think)
+	}
+}
+
+// recordWakeup accounts how long a due user waited for a free worker
+func (s *UserScheduler) recordWakeup(delay time.Duration) {
+	if delay < 0 {
+		delay = 0
+	}
+	atomic.AddInt64(&s.wakeups, 1)
+	atomic.AddInt64(&s.delaySum, int64(delay))
+	for {
+		max := atomic.LoadInt64(&s.delayMax)
+		if int64(delay) <= max || atomic.CompareAndSwapInt64(&s.delayMax, max, int64(delay)) {
+			return
+		}
+	}
+}
+
+// UsersSummary reports the virtual users and how well the worker pool kept up with them
+type UsersSummary struct {
+	Users     int
+	ThinkTime time.Duration
+	Workers   int   // Size of the worker pool the users were multiplexed over
+	Wakeups   int64 // Requests started by users
+
+	// Time from a user's request being due to a worker picking it up; a large
+	// delay means the pool is too small for the users and think time
+	AvgDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// Summary returns the virtual user results; call it after Run returned
+func (s *UserScheduler) Summary() *UsersSummary {
+	summary := &UsersSummary{
+		Users:     len(s.users),
+		ThinkTime: s.think,
+		Workers:   s.workers,
+		Wakeups:   atomic.LoadInt64(&s.wakeups),
+		MaxDelay:  time.Duration(atomic.LoadInt64(&s.delayMax)),
+	}
+	if summary.Wakeups > 0 {
+		summary.AvgDelay = time.Duration(atomic.LoadInt64(&s.delaySum) / summary.Wakeups)
+	}
+	return summary
+}
+
+// actAs makes the worker send its next request as virtual user id
+func (w *Worker) actAs(id int, iteration int64, clientIP string) {
+	w.idHeader = strconv.Itoa(id)
+	w.templates.workerID = id
+	w.iteration = iteration
+	if clientIP != "" {
+		w.clientIP = clientIP
+	}
+}
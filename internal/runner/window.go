@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// latencyWindowSeconds is how far back the rolling latency percentiles look
+	latencyWindowSeconds = 5
+
+	// ErrorWindowSeconds is how far back the rolling error rate looks
+	ErrorWindowSeconds = 10
+
+	// Histogram buckets grow geometrically, giving about ±2.5% precision
+	// from 1µs up to roughly 20 minutes
+	histogramGrowth   = 1.05
+	histogramMinNanos = 1000
+	histogramBuckets  = 430
+)
+
+var logHistogramGrowth = math.Log(histogramGrowth)
+
+// latencyBucket returns the histogram bucket for a latency
+func latencyBucket(d time.Duration) int {
+	if d <= histogramMinNanos {
+		return 0
+	}
+	b := int(math.Log(float64(d)/histogramMinNanos)/logHistogramGrowth) + 1
+	if b >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return b
+}
+
+// bucketValue returns a representative latency for a bucket (its geometric midpoint)
+func bucketValue(b int) time.Duration {
+	if b == 0 {
+		return histogramMinNanos
+	}
+	low := histogramMinNanos * math.Pow(histogramGrowth, float64(b-1))
+	return time.Duration(low * math.Sqrt(histogramGrowth))
+}
+
+// bucketUpperBound returns the exclusive upper bound of a bucket
+func bucketUpperBound(b int) time.Duration {
+	return time.Duration(histogramMinNanos * math.Pow(histogramGrowth, float64(b)))
+}
+
+// slidingHistogram keeps one latency histogram per second for the last
+// latencyWindowSeconds seconds, so recent percentiles can be computed cheaply
+// It is not safe for concurrent use; Stats guards it with its mutex
+type slidingHistogram struct {
+	seconds [latencyWindowSeconds]int64 // Unix second each slot currently holds
+	counts  [latencyWindowSeconds][histogramBuckets]uint32
+}
+
+// record adds a latency observed at the given time
+func (h *slidingHistogram) record(d time.Duration, now time.Time) {
+	sec := now.Unix()
+	slot := int(sec % latencyWindowSeconds)
+	if h.seconds[slot] != sec {
+		h.seconds[slot] = sec
+		h.counts[slot] = [histogramBuckets]uint32{}
+	}
+	h.counts[slot][latencyBucket(d)]++
+}
+
+// percentiles returns the requested percentiles over the window ending at now
+// Missing values (no samples in the window) are returned as 0
+func (h *slidingHistogram) percentiles(now time.Time, ps ...float64) []time.Duration {
+	var merged [histogramBuckets]uint64
+	var total uint64
+	sec := now.Unix()
+	for slot := range h.seconds {
+		if age := sec - h.seconds[slot]; age < 0 || age >= latencyWindowSeconds {
+			continue
+		}
+		for b, c := range h.counts[slot] {
+			merged[b] += uint64(c)
+			total += uint64(c)
+		}
+	}
+
+	result := make([]time.Duration, len(ps))
+	if total == 0 {
+		return result
+	}
+	for i, p := range ps {
+		rank := uint64(math.Ceil(float64(total) * p / 100.0))
+		if rank == 0 {
+			rank = 1
+		}
+		var seen uint64
+		for b, c := range merged {
+			seen += c
+			if seen >= rank {
+				result[i] = bucketValue(b)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// slidingCounter counts requests and failures per second for the last
+// ErrorWindowSeconds seconds, for the rolling error rate
+// It is not safe for concurrent use; Stats guards it with its mutex
+type slidingCounter struct {
+	seconds [ErrorWindowSeconds]int64 // Unix second each slot currently holds
+	total   [ErrorWindowSeconds]int64
+	failed  [ErrorWindowSeconds]int64
+}
+
+// record adds a request completed at the given time
+func (c *slidingCounter) record(failed bool, now time.Time) {
+	sec := now.Unix()
+	slot := int(sec % ErrorWindowSeconds)
+	if c.seconds[slot] != sec {
+		c.seconds[slot] = sec
+		c.total[slot] = 0
+		c.failed[slot] = 0
+	}
+	c.total[slot]++
+	if failed {
+		c.failed[slot]++
+	}
+}
+
+// counts returns the requests and failures over the window ending at now
+func (c *slidingCounter) counts(now time.Time) (total, failed int64) {
+	sec := now.Unix()
+	for slot := range c.seconds {
+		if age := sec - c.seconds[slot]; age < 0 || age >= ErrorWindowSeconds {
+			continue
+		}
+		total += c.total[slot]
+		failed += c.failed[slot]
+	}
+	return total, failed
+}
@@ -2,79 +2,473 @@ package runner
 
 import (
 	"context"
+	"math/rand"
+	"strconv"
+	"time"
 
+	"github.com/calummacc/g0/internal/clock"
 	"github.com/calummacc/g0/internal/httpclient"
 )
 
+// WorkerHeader is the header carrying the worker ID when WorkerOptions.WorkerHeader is set
+const WorkerHeader = "X-G0-Worker"
+
+// ErrorClassCancelledAtDeadline marks requests cancelled because the test ended
+const ErrorClassCancelledAtDeadline = "cancelled_at_deadline"
+
+// WorkerOptions holds optional per-request behaviors shared by all workers
+type WorkerOptions struct {
+	CacheBuster *CacheBuster    // Appends a unique query parameter to every URL (nil = disabled)
+	Validators  *ValidatorCache // Replays ETag/Last-Modified as conditional headers (nil = disabled)
+
+	AcceptEncoding string // Requested response encodings, decoded and measured by the client
+
+	BodySource httpclient.BodySource // Streamed request body shared by all targets (nil = use target body)
+	BodyRate   int64                 // Upload rate for streamed bodies in bytes per second
+
+	MaxBodyBytes int64 // Stop reading response bodies after this many bytes (0 = read all)
+	SkipBody     bool  // Discard response bodies unread
+	MaxResponse  int64 // Fail responses with bodies larger than this many bytes (0 = no limit)
+
+	ReadRate  int64         // Read response bodies at most this fast in bytes per second (0 = unlimited)
+	ReadDelay time.Duration // Pause before each chunk of a response body is read
+
+	RequestTimeout time.Duration // Per-request deadline (0 = client default)
+
+	Seed        int64       // Run seed; each worker derives its own random source from it
+	Data        *DataFeeder // Rows of template variables ({{.column}}; nil = none)
+	HashKey     string      // Data column whose value picks the target (hash strategy)
+	PayloadSize int64       // Value of {{size}} in templates
+
+	WorkerHeader   bool              // Add an X-G0-Worker header with the worker ID to every request
+	RunID          string            // ID of the run
+	RunIDHeader    bool              // Add an X-G0-Run-ID header with RunID to every request
+	IdempotencyKey bool              // Add an Idempotency-Key header with a unique key to every request
+	Trace          *TracePropagation // Add trace context headers to every request (nil = disabled)
+	ClientIP       *ClientIPSpoofer  // Send a synthetic client address header (nil = disabled)
+	Canary         *Canary           // Send a share of the requests to a canary target (nil = disabled)
+	Mirror         *MirrorSender     // Duplicate every request to a mirror target (nil = disabled)
+
+	Health *HealthMonitor // Pauses load while the target is down (nil = no health checks)
+	Chaos  *ChaosSchedule // Bursts and pauses of the load (nil = steady load)
+
+	Arrivals *ArrivalScheduler // Sends each request when it is due at a constant arrival rate (nil = back to back)
+	InFlight *InFlightGauge    // Counts the requests in flight (nil = not counted)
+
+	CaptureHeaders []string // Response headers whose values are recorded (canonical names)
+
+	ColdRequests int64 // The first requests of each worker are marked cold (0 = none)
+
+	Created *ResourceTracker // Records the IDs of created resources (nil = disabled)
+	Schema  *SchemaCheck     // Validates a sample of successful response bodies (nil = disabled)
+	Hashes  BodyHashes       // Expected SHA-256 digests of successful response bodies (nil = not verified)
+	Ranges  *RangeRequests   // Sends a share of the requests as byte-range requests (nil = disabled)
+
+	CountBodies bool // Hash successful response bodies to count the distinct ones
+
+	Auth *AuthRefresh // Sends a bearer token and re-authenticates periodically (nil = disabled)
+
+	ClientCerts *ClientCertPool // Presents a client certificate per worker or request (nil = none)
+	SNI         *SNIList        // Presents the next server name of the list with every request (nil = the URL's host)
+
+	ExpectContinue bool // Send request bodies with Expect: 100-continue
+
+	Counter *ServerCounter // Reads the server's request count from the responses (nil = disabled)
+
+	Clock clock.Clock // Timestamps the requests (nil = the system clock)
+
+	delivery *resultDelivery // Accounts the results owed to the stats collectors (nil = not accounted)
+}
+
 // Worker sends HTTP requests in a loop until the context is cancelled
 type Worker struct {
+	id          int
 	client      *httpclient.Client
-	request     httpclient.Request // Base request config (URL will be selected dynamically)
 	results     chan<- Result
 	rateLimiter *RateLimiter
-	urlRotator  *URLRotator // For selecting URL in round-robin fashion
+	urlRotator  *URLRotator // For selecting the target in round-robin fashion
+	options     WorkerOptions
+	templates   *templateRenderer // Renders {{...}} actions with the worker's random source
+	iteration   int64             // Number of requests started by this worker
+	produced    int64             // Results sent to the stats collector, for the audit
+	idHeader    string            // Value of the X-G0-Worker header
+	traceRand   *rand.Rand        // Source of trace and span IDs and idempotency keys
+	rng         *rand.Rand        // Seeded source for templates and client addresses
+	clientIP    string            // Fixed client address for per-worker spoofing
+	clock       clock.Clock
+
+	// Auth refresh: a refreshing worker sends the token it obtained last
+	refreshing bool
+	nextAuth   time.Time // When the worker re-authenticates next
+	token      string    // Token obtained by the worker ("" = use the cached one)
 }
 
 // NewWorker creates a new worker
-func NewWorker(client *httpclient.Client, request httpclient.Request, results chan<- Result, rateLimiter *RateLimiter, urlRotator *URLRotator) *Worker {
-	return &Worker{
+// id identifies the worker within the run and selects its random sequence
+func NewWorker(id int, client *httpclient.Client, results chan<- Result, rateLimiter *RateLimiter, urlRotator *URLRotator, options WorkerOptions) *Worker {
+	rng := rand.New(rand.NewSource(workerSeed(options.Seed, id)))
+	w := &Worker{
+		id:          id,
 		client:      client,
-		request:     request,
 		results:     results,
 		rateLimiter: rateLimiter,
 		urlRotator:  urlRotator,
+		options:     options,
+		templates:   newTemplateRenderer(rng, id, options.Data),
+		idHeader:    strconv.Itoa(id),
+		traceRand:   newTraceSource(),
+		rng:         rng,
+		clock:       clock.Or(options.Clock),
 	}
+	w.templates.size = options.PayloadSize
+	if options.ClientIP != nil && options.ClientIP.PerWorker {
+		w.clientIP = options.ClientIP.pick(rng)
+	}
+	w.nextAuth, w.refreshing = options.Auth.refreshes(id, w.clock.Now())
+	return w
 }
 
 // Start begins the worker loop, sending requests until ctx is cancelled
-func (w *Worker) Start(ctx context.Context) {
+// Requests run under requestCtx, which may outlive ctx by a grace period so
+// in-flight requests can complete and be recorded
+func (w *Worker) Start(ctx, requestCtx context.Context) {
 	defer func() {
 		// Recover from any panic (e.g., sending on closed channel)
 		// This should not happen with proper synchronization, but provides safety
 		recover()
 	}()
 
-	for {
-		// Check if context is done before starting a new request
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
+	for w.send(ctx, requestCtx) {
+	}
+}
+
+// send sends one request and records its result
+// It returns false once the worker should stop (the test ended or its data ran out)
+func (w *Worker) send(ctx, requestCtx context.Context) bool {
+	// Check if context is done before starting a new request
+	select {
+	case <-ctx.Done():
+		return false
+	default:
+	}
+
+	// Hold off while the target is down if load is paused on failed health checks
+	if !w.options.Health.Wait(ctx) {
+		return false
+	}
+
+	// Hold off during chaos pauses
+	if !w.options.Chaos.Wait(ctx) {
+		return false
+	}
 
-		// Wait for rate limiter token if rate limiting is enabled
+	// Re-authenticate when due, outside the rate limit
+	if w.refreshing && !w.clock.Now().Before(w.nextAuth) {
+		w.refresh(requestCtx)
+	}
+
+	// Wait for rate limiter token if rate limiting is enabled; the wait is
+	// client-side queueing, kept apart from the latency
+	var limiterWait time.Duration
+	if w.rateLimiter != nil {
+		waitStart := w.clock.Now()
 		if !w.rateLimiter.Wait(ctx) {
 			// Context cancelled or rate limiter stopped
-			return
+			return false
+		}
+		limiterWait = w.clock.Since(waitStart)
+	}
+
+	// Take the next scheduled arrival; an overdue one is sent at once
+	due, ok := w.options.Arrivals.Wait(ctx)
+	if !ok {
+		return false
+	}
+
+	iteration := w.iteration
+	w.iteration++
+
+	// With the hash strategy the data row picks the target, so it is taken first;
+	// templates then render with the same row
+	key := ""
+	hashed := w.urlRotator.hashed()
+	if hashed {
+		if err := w.templates.next(iteration); err != nil {
+			// Every unique row was used; the run ends without this request
+			return false
+		}
+		key = w.templates.value(w.options.HashKey)
+	}
+
+	// Select target from rotator (round-robin, random, by key or the worker's own target)
+	target, ok := w.urlRotator.Next(w.id, w.rng, key)
+	if !ok {
+		// No target available, skip
+		return true
+	}
+
+	// Render per-request values such as {{randInt 1 100}} or {{.column}}
+	if target.templated {
+		var err error
+		if !hashed {
+			err = w.templates.next(iteration)
+		}
+		if err == errDataExhausted {
+			// Every unique row was used; the run ends without this request
+			return false
+		}
+		rendered := target
+		if err == nil {
+			rendered, err = w.templates.renderTarget(target)
+		}
+		if err != nil {
+			w.options.delivery.owe()
+			w.produced++
+			w.options.delivery.send(w.results, Result{Method: target.Method, URL: target.URL, Target: target.origin, SentAt: w.clock.Now(), Error: err, ErrorClass: ErrorClassTemplate})
+			return true
+		}
+		if target.Protocol == httpclient.ProtocolRaw {
+			rendered.Body = fitContentLength(target.Body, rendered.Body)
+		}
+		target = rendered
+	}
+
+	// Route a share of the traffic to the canary
+	canary := w.options.Canary != nil && w.options.Canary.pick(w.rng)
+	if canary {
+		target.URL = w.options.Canary.rewrite(target.URL)
+	}
+
+	// Ask for a random part of the object
+	part, ranged := w.options.Ranges.pick(target.URL, w.rng)
+
+	// Authenticate with the worker's own token once it has one
+	token, tokenKind := "", ""
+	if w.options.Auth != nil {
+		token, tokenKind = w.token, AuthTokenRefreshed
+		if token == "" {
+			token, tokenKind = w.options.Auth.cached(), AuthTokenCached
 		}
+	}
 
-		// Select URL from rotator (round-robin)
-		selectedURL := w.urlRotator.Next()
-		if selectedURL == "" {
-			// No URL available, skip
-			continue
+	// Identify the worker and the trace so server-side logs and traces can be
+	// correlated with the load test
+	traceID := ""
+	if token != "" || ranged || w.options.WorkerHeader || w.options.RunIDHeader || w.options.IdempotencyKey || w.options.Trace != nil || w.options.ClientIP != nil {
+		headers := make(map[string]string, len(target.Headers)+5)
+		for k, v := range target.Headers {
+			headers[k] = v
+		}
+		if token != "" {
+			headers["Authorization"] = "Bearer " + token
+		}
+		if ranged {
+			headers["Range"] = part.header()
 		}
+		if w.options.WorkerHeader {
+			headers[WorkerHeader] = w.idHeader
+		}
+		if w.options.RunIDHeader {
+			headers[RunIDHeader] = w.options.RunID
+		}
+		if w.options.IdempotencyKey {
+			// One key per logical request; the mirrored copy carries the same key
+			headers[IdempotencyKeyHeader] = newIdempotencyKey(w.traceRand)
+		}
+		if w.options.Trace != nil {
+			traceID = w.options.Trace.apply(w.traceRand, headers)
+		}
+		if spoofer := w.options.ClientIP; spoofer != nil {
+			if spoofer.PerWorker {
+				headers[spoofer.Header] = w.clientIP
+			} else {
+				headers[spoofer.Header] = spoofer.pick(w.rng)
+			}
+		}
+		target.Headers = headers
+	}
+
+	// Create request from the selected target with context for cancellation
+	request := httpclient.Request{
+		Method:  target.Method,
+		URL:     target.URL,
+		Body:    target.Body,
+		Headers: target.Headers,
+		Context: requestCtx, // Pass context to enable request cancellation
 
-		// Create request with selected URL and context for cancellation
-		request := w.request
-		request.URL = selectedURL
-		request.Context = ctx // Pass context to enable request cancellation
+		AcceptEncoding: w.options.AcceptEncoding,
+		BodySource:     w.options.BodySource,
+		BodyRate:       w.options.BodyRate,
+		MaxBodyBytes:   w.options.MaxBodyBytes,
+		SkipBody:       w.options.SkipBody,
+		ReadRate:       w.options.ReadRate,
+		ReadDelay:      w.options.ReadDelay,
+		Timeout:        w.options.RequestTimeout,
+		KeepBody:       w.options.Created.needsBody(),
+		HashBody:       w.options.Hashes != nil || w.options.CountBodies,
+		ExpectContinue: w.options.ExpectContinue,
+		Protocol:       target.Protocol,
 
-		// Send request
-		resp := w.client.Do(request)
+		MaxResponseBytes: w.options.MaxResponse,
+	}
+	if w.options.ClientCerts != nil {
+		request.ClientCert = w.options.ClientCerts.pick(w.id)
+	}
+	serverName := 0
+	if w.options.SNI != nil {
+		serverName = w.options.SNI.pick()
+		request.ServerName = w.options.SNI.Names[serverName]
+	}
+	validate := w.options.Schema.sample()
+	if validate {
+		request.KeepBody = true
+	}
+
+	// Replay cache validators captured from earlier responses
+	conditional := false
+	if w.options.Validators != nil {
+		conditional = w.options.Validators.Apply(target.URL, &request)
+	}
+
+	// Make the URL unique so caches can't serve the response
+	if w.options.CacheBuster != nil {
+		request.URL = w.options.CacheBuster.Bust(request.URL)
+	}
+
+	// Duplicate the request to the mirror exactly as sent to the target
+	if w.options.Mirror != nil {
+		w.options.Mirror.send(request)
+	}
+
+	// Send request; from here on its result is owed to the stats collector
+	w.options.delivery.owe()
+	sentAt := w.clock.Now()
+	w.options.InFlight.add(1)
+	resp := w.client.Do(request)
+	w.options.InFlight.add(-1)
+
+	if w.options.Validators != nil {
+		w.options.Validators.Store(target.URL, resp.StatusCode, resp.Header)
+	}
+	w.options.Created.track(request.URL, resp)
+	w.options.Counter.observe(resp.Header)
 
-		// Check context again before sending result (request might have taken time)
-		select {
-		case <-ctx.Done():
-			// Context cancelled, don't send result
-			return
-		case w.results <- Result{
-			Latency:    resp.Latency,
-			StatusCode: resp.StatusCode,
-			Error:      resp.Error,
-		}:
-			// Successfully sent result, continue loop
+	result := Result{
+		Method:      target.Method,
+		URL:         target.URL,
+		Target:      target.origin,
+		SentAt:      sentAt,
+		Latency:     resp.Latency,
+		TTFB:        resp.TTFB,
+		Download:    resp.Download,
+		Truncated:   resp.Truncated,
+		StatusCode:  resp.StatusCode,
+		Error:       resp.Error,
+		ErrorClass:  httpclient.ClassifyError(resp.Error),
+		Conditional: conditional,
+		TraceID:     traceID,
+		Cold:        iteration < w.options.ColdRequests,
+		Canary:      canary,
+		Range:       ranged,
+		LimiterWait: limiterWait,
+		AuthToken:   tokenKind,
+
+		Continue:     resp.Continue,
+		ContinueWait: resp.ContinueWait,
+		Protocol:     resp.Protocol,
+		DNSLookup:    resp.DNSLookup,
+
+		AuthChallenges: resp.AuthChallenges,
+		ClientCert:     request.ClientCert,
+		ServerName:     serverName,
+
+		TLSHandshake: resp.TLSHandshake,
+		TLSResumed:   resp.TLSResumed,
+		CertVerify:   resp.CertVerify,
+		OCSPStapled:  resp.OCSPStapled,
+
+		BytesSent:       resp.BytesSent,
+		BytesRead:       resp.BytesRead,
+		DecodedBytes:    resp.DecodedBytes,
+		ContentEncoding: resp.ContentEncoding,
+		DecompressTime:  resp.DecompressTime,
+	}
+
+	if !due.IsZero() {
+		// The request was due earlier; an open stream of clients would have
+		// waited all along, so the wait is part of its latency
+		if delay := sentAt.Sub(due); delay > 0 {
+			result.QueueDelay = delay
+			result.Latency += delay
+		}
+	}
+
+	if validate && resp.Error == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 && !resp.Truncated {
+		result.SchemaChecked = true
+		result.SchemaViolation = w.options.Schema.check(resp.Body)
+	}
+	if ranged {
+		if class := w.options.Ranges.verify(target.URL, part, resp, !request.SkipBody && !resp.Truncated); class != "" {
+			result.ErrorClass = class
+		}
+	}
+	if w.options.Hashes != nil && resp.Error == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		result.BodyVerified = true
+		if !w.options.Hashes.match(resp.BodySHA256) {
+			result.ErrorClass = ErrorClassBodyMismatch
+		}
+	}
+	if w.options.CountBodies && resp.Error == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 && !resp.Truncated && !ranged {
+		result.BodyHash = bodyKey(resp.BodySHA256)
+	}
+	if resp.Connected {
+		result.Connection = ConnectionNew
+		if resp.ConnReused {
+			result.Connection = ConnectionReused
 		}
 	}
+	if timing := resp.Header.Values("Server-Timing"); len(timing) > 0 {
+		result.ServerTiming = parseServerTiming(timing)
+	}
+	if len(w.options.CaptureHeaders) > 0 && resp.StatusCode > 0 {
+		result.Headers = captureHeaders(w.options.CaptureHeaders, resp.Header)
+	}
+
+	// A request cut off because the run (and its grace period) ended is not a
+	// server failure; record it separately instead of dropping it silently
+	if resp.Error != nil && requestEnded(requestCtx) {
+		result.ErrorClass = ErrorClassCancelledAtDeadline
+	}
+
+	// Always record the result; the channel is only closed after all workers stopped
+	w.produced++
+	w.options.delivery.send(w.results, result)
+	return true
 }
 
+// refresh requests a new token for the worker and shares it with the workers
+// using the cached one; after a failure the worker keeps its token until the
+// next refresh
+func (w *Worker) refresh(ctx context.Context) {
+	now := w.clock.Now()
+	for !w.nextAuth.After(now) {
+		w.nextAuth = w.nextAuth.Add(w.options.Auth.Interval)
+	}
+	result, token := w.options.Auth.authenticate(ctx, w.client, w.options.RequestTimeout)
+	if token != "" {
+		w.token = token
+		w.options.Auth.store(token)
+	}
+	// Token requests are not owed to the audit, like mirrored ones
+	w.results <- result
+}
+
+// requestEnded reports whether ctx is done or past its deadline; a dial fails as
+// soon as the deadline passes, which may be before the context itself is cancelled
+func requestEnded(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}